@@ -0,0 +1,50 @@
+// dlqreplay 将 <topic>.dlq 中的消息重新投递回原始 topic
+//
+//	go run ./cmd/dlqreplay --config=config/config.yaml --topic=ink-view --topic=comment-reply
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/KNICEX/InkFlow/ioc"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func main() {
+	configFile := pflag.String("config", "config/config.yaml", "specify config file")
+	topics := pflag.StringSlice("topic", nil, "origin topics whose dlq will be replayed")
+	pflag.Parse()
+	if len(*topics) == 0 {
+		fmt.Println("at least one --topic is required")
+		os.Exit(1)
+	}
+
+	viper.SetConfigFile(*configFile)
+	if err := viper.ReadInConfig(); err != nil {
+		panic(err)
+	}
+
+	l := ioc.InitLogger()
+	cli := ioc.InitKafka()
+	defer cli.Close()
+	producer := ioc.InitSyncProducer(cli)
+	defer producer.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	replayer := saramax.NewReplayer(cli, producer, l)
+	for _, topic := range *topics {
+		cnt, err := replayer.Replay(ctx, topic)
+		fmt.Printf("%s: replayed %d messages\n", saramax.DLQTopic(topic), cnt)
+		if err != nil {
+			fmt.Printf("%s: replay stopped: %v\n", saramax.DLQTopic(topic), err)
+			os.Exit(1)
+		}
+	}
+}
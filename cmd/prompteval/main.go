@@ -0,0 +1,180 @@
+// prompteval 用人工标注过的内容离线评估审核提示词, 输出每个版本的结论与标注的一致率
+//
+//	go run ./cmd/prompteval --config=config/config.yaml --data=review.jsonl --version=builtin --version=v2
+//
+// 数据集每行一个 json, passed 为人工标注的结论:
+//
+//	{"id": 1, "title": "标题", "content": "正文", "images": ["https://..."], "passed": true}
+//
+// 待评估的版本先通过 /moderation/prompts 以权重 0 创建, 不会影响线上流量
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+
+	"github.com/KNICEX/InkFlow/internal/ai"
+	"github.com/KNICEX/InkFlow/internal/prompt"
+	"github.com/KNICEX/InkFlow/internal/review"
+	"github.com/KNICEX/InkFlow/ioc"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+type sample struct {
+	Id      int64    `json:"id"`
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Images  []string `json:"images"`
+	Passed  bool     `json:"passed"`
+}
+
+// report 一个版本的评估结果, 一致率只统计给出了明确结论的样本
+type report struct {
+	version     string
+	total       int
+	agree       int
+	falsePass   int
+	falseReject int
+	needsHuman  int
+	errors      int
+}
+
+func (r report) decided() int {
+	return r.total - r.needsHuman - r.errors
+}
+
+func (r report) agreement() float64 {
+	if r.decided() == 0 {
+		return 0
+	}
+	return float64(r.agree) / float64(r.decided())
+}
+
+func main() {
+	configFile := pflag.String("config", "config/config.yaml", "specify config file")
+	dataFile := pflag.String("data", "", "labelled samples in json lines")
+	purpose := pflag.String("purpose", review.PromptInkReview, "prompt purpose, review.ink or review.comment")
+	versions := pflag.StringSlice("version", []string{prompt.BuiltinVersion}, "prompt versions to evaluate")
+	verbose := pflag.Bool("verbose", false, "print samples that disagree with the label")
+	pflag.Parse()
+	if *dataFile == "" {
+		fmt.Println("--data is required")
+		os.Exit(1)
+	}
+	if *purpose != review.PromptInkReview && *purpose != review.PromptCommentReview {
+		fmt.Printf("unsupported purpose %s\n", *purpose)
+		os.Exit(1)
+	}
+
+	viper.SetConfigFile(*configFile)
+	if err := viper.ReadInConfig(); err != nil {
+		panic(err)
+	}
+	samples, err := loadSamples(*dataFile)
+	if err != nil {
+		fmt.Printf("load samples error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	l := ioc.InitLogger()
+	db := ioc.InitDB(l)
+	cmd := ioc.InitRedisCmdable(ioc.InitRedisUniversalClient())
+	llmSvc := ai.InitLLMService(ioc.InitGeminiClient(), cmd, l)
+	prompts := prompt.InitService(db, l)
+	svc := review.InitService(llmSvc, prompts, l)
+	if err = prompts.Reload(ctx); err != nil {
+		fmt.Printf("load prompts error: %v\n", err)
+		os.Exit(1)
+	}
+
+	reports := make([]report, 0, len(*versions))
+	for _, version := range *versions {
+		r := report{version: version}
+		versionCtx := prompt.WithVersion(ctx, *purpose, version)
+		for _, s := range samples {
+			if ctx.Err() != nil {
+				break
+			}
+			r.total++
+			res, er := reviewSample(versionCtx, svc, *purpose, s)
+			switch {
+			case er != nil:
+				r.errors++
+				fmt.Printf("[%s] sample %d review error: %v\n", version, s.Id, er)
+			case res.NeedsHuman:
+				r.needsHuman++
+			case res.Passed == s.Passed:
+				r.agree++
+			default:
+				if res.Passed {
+					r.falsePass++
+				} else {
+					r.falseReject++
+				}
+				if *verbose {
+					fmt.Printf("[%s] sample %d: label passed=%t, got passed=%t, reason: %s\n",
+						version, s.Id, s.Passed, res.Passed, res.Reason)
+				}
+			}
+		}
+		reports = append(reports, r)
+	}
+	printReports(reports)
+}
+
+func loadSamples(file string) ([]sample, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var samples []sample
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var s sample
+		if err = json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		samples = append(samples, s)
+	}
+	return samples, scanner.Err()
+}
+
+func reviewSample(ctx context.Context, svc review.Service, purpose string, s sample) (review.Result, error) {
+	if purpose == review.PromptCommentReview {
+		return svc.ReviewComment(ctx, review.Comment{
+			Id:      s.Id,
+			Content: s.Content,
+			Images:  s.Images,
+		})
+	}
+	return svc.ReviewInk(ctx, review.Ink{
+		Id:      s.Id,
+		Title:   s.Title,
+		Content: s.Content,
+		Images:  s.Images,
+	})
+}
+
+func printReports(reports []report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSAMPLES\tAGREEMENT\tFALSE PASS\tFALSE REJECT\tNEEDS HUMAN\tERRORS")
+	for _, r := range reports {
+		fmt.Fprintf(w, "%s\t%d\t%.2f%% (%d/%d)\t%d\t%d\t%d\t%d\n", r.version, r.total,
+			r.agreement()*100, r.agree, r.decided(), r.falsePass, r.falseReject, r.needsHuman, r.errors)
+	}
+	w.Flush()
+}
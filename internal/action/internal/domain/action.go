@@ -13,8 +13,22 @@ type Action struct {
 
 type ActionType string
 
+const (
+	ActionTypeView    ActionType = "view"
+	ActionTypeLike    ActionType = "like"
+	ActionTypeComment ActionType = "comment"
+	ActionTypeFollow  ActionType = "follow"
+	ActionTypePublish ActionType = "publish"
+)
+
 type TargetType string
 
+const (
+	TargetTypeInk     TargetType = "ink"
+	TargetTypeComment TargetType = "comment"
+	TargetTypeUser    TargetType = "user"
+)
+
 type Statistics struct {
 	TargetType TargetType
 	ActionCnt  int64
@@ -0,0 +1,93 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/internal/action/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/action/internal/service"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
+	"time"
+)
+
+const (
+	actionGroup = "action-log-group"
+
+	topicInkView      = "ink-view"
+	topicInkLike      = "ink-like"
+	topicCommentReply = "comment-reply"
+	topicFollow       = "user-follow"
+
+	actionBatchSize = 100
+	actionMaxWait   = time.Second
+)
+
+type ActionConsumer struct {
+	cli      sarama.Client
+	svc      service.ActionService
+	handlers map[string]Handler
+	retry    *saramax.RetryHandler
+	l        logx.Logger
+}
+
+func NewActionConsumer(cli sarama.Client, svc service.ActionService, retry *saramax.RetryHandler, l logx.Logger) *ActionConsumer {
+	return &ActionConsumer{
+		cli:      cli,
+		svc:      svc,
+		handlers: make(map[string]Handler),
+		retry:    retry.WithGroup(actionGroup),
+		l:        l,
+	}
+}
+
+func (c *ActionConsumer) RegisterHandler(handlers ...Handler) error {
+	for _, handler := range handlers {
+		if _, ok := c.handlers[handler.Topic()]; ok {
+			return fmt.Errorf("%s handler already exists", handler.Topic())
+		}
+		c.handlers[handler.Topic()] = handler
+	}
+	return nil
+}
+
+func (c *ActionConsumer) Start() error {
+	cg, err := sarama.NewConsumerGroupFromClient(actionGroup, c.cli)
+	if err != nil {
+		return err
+	}
+	go func() {
+		err = cg.Consume(context.Background(),
+			saramax.WithRetryTopics(topicInkView, topicInkLike, topicCommentReply, topicFollow),
+			saramax.NewBatchHandler[json.RawMessage](c.l, c,
+				saramax.WithBatchSize[json.RawMessage](actionBatchSize),
+				saramax.WithMaxWait[json.RawMessage](actionMaxWait),
+				saramax.WithHandlerOptions[json.RawMessage](saramax.WithRetryHandler(c.retry))))
+		if err != nil {
+			c.l.Warn("action consumer quit...", logx.Error(err))
+		}
+	}()
+	return nil
+}
+
+// Consume 一批消息在同一个事务中写入, 格式错误的消息重试也无用, 记录日志后跳过
+func (c *ActionConsumer) Consume(msgs []*sarama.ConsumerMessage, _ []json.RawMessage) error {
+	actions := make([]domain.Action, 0, len(msgs))
+	for _, msg := range msgs {
+		handler, ok := c.handlers[msg.Topic]
+		if !ok {
+			c.l.Error("no handler found for topic", logx.String("topic", msg.Topic))
+			continue
+		}
+		action, err := handler.Action(msg)
+		if err != nil {
+			c.l.Error("decode action event error", logx.Error(err), logx.String("topic", msg.Topic))
+			continue
+		}
+		actions = append(actions, action)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	return c.svc.CreateActions(ctx, actions)
+}
@@ -0,0 +1,56 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/internal/action/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/action/internal/service"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeActionService struct {
+	service.ActionService
+	calls   int
+	actions []domain.Action
+}
+
+func (f *fakeActionService) CreateActions(ctx context.Context, actions []domain.Action) error {
+	f.calls++
+	f.actions = append(f.actions, actions...)
+	return nil
+}
+
+func message(t *testing.T, topic string, evt any) *sarama.ConsumerMessage {
+	bs, err := json.Marshal(evt)
+	require.NoError(t, err)
+	return &sarama.ConsumerMessage{Topic: topic, Value: bs}
+}
+
+func TestActionConsumer_Consume(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	svc := &fakeActionService{}
+	c := &ActionConsumer{svc: svc, handlers: map[string]Handler{}, l: logx.NewNopLogger()}
+	require.NoError(t, c.RegisterHandler(NewInkViewHandler(), NewInkLikeHandler(), NewCommentReplyHandler(), NewFollowHandler()))
+
+	msgs := []*sarama.ConsumerMessage{
+		message(t, topicInkView, InkViewEvent{InkId: 1, UserId: 10, CreatedAt: now}),
+		message(t, topicCommentReply, CommentReplyEvent{CommentId: 100, Biz: "ink", BizId: 2, CommentatorId: 11, CreatedAt: now}),
+		message(t, topicFollow, FollowEvent{FollowerId: 12, FolloweeId: 13, CreatedAt: now}),
+		// 格式错误的消息跳过, 不影响同一批的其他消息
+		{Topic: topicInkLike, Value: []byte("{")},
+	}
+	require.NoError(t, c.Consume(msgs, nil))
+
+	assert.Equal(t, 1, svc.calls)
+	assert.Equal(t, []domain.Action{
+		{UserId: 10, TargetType: domain.TargetTypeInk, TargetId: 1, ActionType: domain.ActionTypeView, CreatedAt: now},
+		{UserId: 11, TargetType: domain.TargetTypeInk, TargetId: 2, ActionType: domain.ActionTypeComment, CreatedAt: now},
+		{UserId: 12, TargetType: domain.TargetTypeUser, TargetId: 13, ActionType: domain.ActionTypeFollow, CreatedAt: now},
+	}, svc.actions)
+}
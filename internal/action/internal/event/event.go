@@ -0,0 +1,29 @@
+package event
+
+import "time"
+
+type InkViewEvent struct {
+	InkId     int64     `json:"inkId"`
+	UserId    int64     `json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type InkLikeEvent struct {
+	InkId     int64     `json:"inkId"`
+	UserId    int64     `json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CommentReplyEvent struct {
+	CommentId     int64     `json:"commentId"`
+	Biz           string    `json:"biz"`
+	BizId         int64     `json:"bizId"`
+	CommentatorId int64     `json:"commentatorId"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type FollowEvent struct {
+	FollowerId int64     `json:"followerId"`
+	FolloweeId int64     `json:"followeeId"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
@@ -0,0 +1,110 @@
+package event
+
+import (
+	"encoding/json"
+	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/internal/action/internal/domain"
+)
+
+// Handler 把对应 topic 的消息转换成用户行为, 由 ActionConsumer 批量写入
+type Handler interface {
+	Topic() string
+	Action(msg *sarama.ConsumerMessage) (domain.Action, error)
+}
+
+type InkViewHandler struct{}
+
+func NewInkViewHandler() Handler {
+	return &InkViewHandler{}
+}
+
+func (h *InkViewHandler) Topic() string {
+	return topicInkView
+}
+
+func (h *InkViewHandler) Action(msg *sarama.ConsumerMessage) (domain.Action, error) {
+	var evt InkViewEvent
+	if err := json.Unmarshal(msg.Value, &evt); err != nil {
+		return domain.Action{}, err
+	}
+	return domain.Action{
+		UserId:     evt.UserId,
+		TargetType: domain.TargetTypeInk,
+		TargetId:   evt.InkId,
+		ActionType: domain.ActionTypeView,
+		CreatedAt:  evt.CreatedAt,
+	}, nil
+}
+
+type InkLikeHandler struct{}
+
+func NewInkLikeHandler() Handler {
+	return &InkLikeHandler{}
+}
+
+func (h *InkLikeHandler) Topic() string {
+	return topicInkLike
+}
+
+func (h *InkLikeHandler) Action(msg *sarama.ConsumerMessage) (domain.Action, error) {
+	var evt InkLikeEvent
+	if err := json.Unmarshal(msg.Value, &evt); err != nil {
+		return domain.Action{}, err
+	}
+	return domain.Action{
+		UserId:     evt.UserId,
+		TargetType: domain.TargetTypeInk,
+		TargetId:   evt.InkId,
+		ActionType: domain.ActionTypeLike,
+		CreatedAt:  evt.CreatedAt,
+	}, nil
+}
+
+type CommentReplyHandler struct{}
+
+func NewCommentReplyHandler() Handler {
+	return &CommentReplyHandler{}
+}
+
+func (h *CommentReplyHandler) Topic() string {
+	return topicCommentReply
+}
+
+// Action 评论行为记录在被评论的内容上, 而不是评论本身
+func (h *CommentReplyHandler) Action(msg *sarama.ConsumerMessage) (domain.Action, error) {
+	var evt CommentReplyEvent
+	if err := json.Unmarshal(msg.Value, &evt); err != nil {
+		return domain.Action{}, err
+	}
+	return domain.Action{
+		UserId:     evt.CommentatorId,
+		TargetType: domain.TargetType(evt.Biz),
+		TargetId:   evt.BizId,
+		ActionType: domain.ActionTypeComment,
+		CreatedAt:  evt.CreatedAt,
+	}, nil
+}
+
+type FollowHandler struct{}
+
+func NewFollowHandler() Handler {
+	return &FollowHandler{}
+}
+
+func (h *FollowHandler) Topic() string {
+	return topicFollow
+}
+
+func (h *FollowHandler) Action(msg *sarama.ConsumerMessage) (domain.Action, error) {
+	var evt FollowEvent
+	if err := json.Unmarshal(msg.Value, &evt); err != nil {
+		return domain.Action{}, err
+	}
+	return domain.Action{
+		UserId:     evt.FollowerId,
+		TargetType: domain.TargetTypeUser,
+		TargetId:   evt.FolloweeId,
+		ActionType: domain.ActionTypeFollow,
+		CreatedAt:  evt.CreatedAt,
+	}, nil
+}
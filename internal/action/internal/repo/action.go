@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"context"
+	"github.com/KNICEX/InkFlow/internal/action/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/action/internal/repo/dao"
+	"github.com/samber/lo"
+	"time"
+)
+
+type ActionRepo interface {
+	CreateActions(ctx context.Context, actions []domain.Action) error
+	FindActiveUser(ctx context.Context, uids []int64, since time.Time) ([]domain.User, error)
+	FindStatistics(ctx context.Context, uid int64, start, end time.Time) ([]domain.Statistics, error)
+}
+
+type NoCacheActionRepo struct {
+	dao dao.ActionDAO
+}
+
+func NewNoCacheActionRepo(dao dao.ActionDAO) ActionRepo {
+	return &NoCacheActionRepo{
+		dao: dao,
+	}
+}
+
+func (repo *NoCacheActionRepo) CreateActions(ctx context.Context, actions []domain.Action) error {
+	return repo.dao.BatchInsert(ctx, lo.Map(actions, func(action domain.Action, index int) dao.UserAction {
+		return dao.UserAction{
+			UserId:     action.UserId,
+			TargetType: string(action.TargetType),
+			TargetId:   action.TargetId,
+			ActionType: string(action.ActionType),
+			CreatedAt:  action.CreatedAt,
+		}
+	}))
+}
+
+func (repo *NoCacheActionRepo) FindActiveUser(ctx context.Context, uids []int64, since time.Time) ([]domain.User, error) {
+	actives, err := repo.dao.FindActiveUsers(ctx, uids, since)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(actives, func(item dao.UserActive, index int) domain.User {
+		return domain.User{
+			Id:           item.UserId,
+			LastActionAt: item.LastActionAt,
+		}
+	}), nil
+}
+
+func (repo *NoCacheActionRepo) FindStatistics(ctx context.Context, uid int64, start, end time.Time) ([]domain.Statistics, error) {
+	counts, err := repo.dao.CountByTargetType(ctx, uid, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(counts, func(item dao.TargetCount, index int) domain.Statistics {
+		return domain.Statistics{
+			TargetType: domain.TargetType(item.TargetType),
+			ActionCnt:  item.Cnt,
+			StartTime:  start,
+			EndTime:    end,
+		}
+	}), nil
+}
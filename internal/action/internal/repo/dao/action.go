@@ -0,0 +1,112 @@
+package dao
+
+import (
+	"context"
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// UserAction 用户行为流水
+type UserAction struct {
+	Id         int64
+	UserId     int64  `gorm:"index:user_created"`
+	TargetType string `gorm:"type:varchar(32)"`
+	TargetId   int64
+	ActionType string    `gorm:"type:varchar(32)"`
+	CreatedAt  time.Time `gorm:"index:user_created"`
+}
+
+// UserActive 用户最后一次行为时间, 用于快速筛选活跃用户
+type UserActive struct {
+	Id           int64
+	UserId       int64     `gorm:"uniqueIndex"`
+	LastActionAt time.Time `gorm:"index"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type TargetCount struct {
+	TargetType string
+	Cnt        int64
+}
+
+type ActionDAO interface {
+	// BatchInsert 在同一个事务中写入行为流水并更新用户最后行为时间
+	BatchInsert(ctx context.Context, actions []UserAction) error
+	FindActiveUsers(ctx context.Context, uids []int64, since time.Time) ([]UserActive, error)
+	CountByTargetType(ctx context.Context, uid int64, start, end time.Time) ([]TargetCount, error)
+}
+
+type GormActionDAO struct {
+	db   *gorm.DB
+	node snowflakex.Node
+}
+
+func NewGormActionDAO(db *gorm.DB, node snowflakex.Node) ActionDAO {
+	return &GormActionDAO{
+		db:   db,
+		node: node,
+	}
+}
+
+func (dao *GormActionDAO) BatchInsert(ctx context.Context, actions []UserAction) error {
+	if len(actions) == 0 {
+		return nil
+	}
+	now := time.Now()
+	// 同一条 upsert 语句不能多次更新同一行, 每个用户只保留最后的行为时间
+	lastActions := make(map[int64]time.Time, len(actions))
+	for i := range actions {
+		actions[i].Id = dao.node.NextID()
+		if actions[i].CreatedAt.IsZero() {
+			actions[i].CreatedAt = now
+		}
+		if last, ok := lastActions[actions[i].UserId]; !ok || actions[i].CreatedAt.After(last) {
+			lastActions[actions[i].UserId] = actions[i].CreatedAt
+		}
+	}
+	actives := make([]UserActive, 0, len(lastActions))
+	for uid, last := range lastActions {
+		actives = append(actives, UserActive{
+			Id:           dao.node.NextID(),
+			UserId:       uid,
+			LastActionAt: last,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+	}
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&actions).Error; err != nil {
+			return err
+		}
+		// 消息可能乱序到达, 只向后更新最后行为时间
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"last_action_at": gorm.Expr("GREATEST(user_actives.last_action_at, EXCLUDED.last_action_at)"),
+				"updated_at":     now,
+			}),
+		}).Create(&actives).Error
+	})
+}
+
+func (dao *GormActionDAO) FindActiveUsers(ctx context.Context, uids []int64, since time.Time) ([]UserActive, error) {
+	var res []UserActive
+	if len(uids) == 0 {
+		return res, nil
+	}
+	err := dao.db.WithContext(ctx).Where("user_id IN ? AND last_action_at >= ?", uids, since).Find(&res).Error
+	return res, err
+}
+
+func (dao *GormActionDAO) CountByTargetType(ctx context.Context, uid int64, start, end time.Time) ([]TargetCount, error) {
+	var res []TargetCount
+	err := dao.db.WithContext(ctx).Model(&UserAction{}).
+		Select("target_type, COUNT(*) AS cnt").
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", uid, start, end).
+		Group("target_type").
+		Scan(&res).Error
+	return res, err
+}
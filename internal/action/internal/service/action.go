@@ -3,12 +3,60 @@ package service
 import (
 	"context"
 	"github.com/KNICEX/InkFlow/internal/action/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/action/internal/repo"
 	"time"
 )
 
 type ActionService interface {
 	CreateAction(ctx context.Context, action domain.Action) error
+	// CreateActions 批量记录行为, 匿名用户的行为会被忽略
+	CreateActions(ctx context.Context, actions []domain.Action) error
 	FindActiveUser(ctx context.Context, uids []int64, lastAction time.Time) ([]domain.User, error)
+	// FindStatistics 按 TargetType 统计用户在 [start, end) 内的行为次数
+	FindStatistics(ctx context.Context, uid int64, start, end time.Time) ([]domain.Statistics, error)
+}
+
+type actionService struct {
+	repo repo.ActionRepo
+}
+
+func NewActionService(repo repo.ActionRepo) ActionService {
+	return &actionService{
+		repo: repo,
+	}
+}
+
+func (s *actionService) CreateAction(ctx context.Context, action domain.Action) error {
+	return s.CreateActions(ctx, []domain.Action{action})
+}
+
+func (s *actionService) CreateActions(ctx context.Context, actions []domain.Action) error {
+	now := time.Now()
+	res := make([]domain.Action, 0, len(actions))
+	for _, action := range actions {
+		if action.UserId == 0 {
+			continue
+		}
+		if action.CreatedAt.IsZero() {
+			action.CreatedAt = now
+		}
+		res = append(res, action)
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return s.repo.CreateActions(ctx, res)
+}
+
+func (s *actionService) FindActiveUser(ctx context.Context, uids []int64, lastAction time.Time) ([]domain.User, error) {
+	if len(uids) == 0 {
+		return []domain.User{}, nil
+	}
+	return s.repo.FindActiveUser(ctx, uids, lastAction)
+}
+
+func (s *actionService) FindStatistics(ctx context.Context, uid int64, start, end time.Time) ([]domain.Statistics, error) {
+	return s.repo.FindStatistics(ctx, uid, start, end)
 }
 
 type DoNothingActionService struct{}
@@ -17,6 +65,10 @@ func (s *DoNothingActionService) CreateAction(ctx context.Context, action domain
 	return nil
 }
 
+func (s *DoNothingActionService) CreateActions(ctx context.Context, actions []domain.Action) error {
+	return nil
+}
+
 func (s *DoNothingActionService) FindActiveUser(ctx context.Context, uids []int64, lastAction time.Time) ([]domain.User, error) {
 	res := make([]domain.User, 0, len(uids))
 	for _, uid := range uids {
@@ -27,3 +79,7 @@ func (s *DoNothingActionService) FindActiveUser(ctx context.Context, uids []int6
 	}
 	return res, nil
 }
+
+func (s *DoNothingActionService) FindStatistics(ctx context.Context, uid int64, start, end time.Time) ([]domain.Statistics, error) {
+	return []domain.Statistics{}, nil
+}
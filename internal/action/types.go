@@ -1,5 +1,29 @@
 package action
 
-import "github.com/KNICEX/InkFlow/internal/action/internal/service"
+import (
+	"github.com/KNICEX/InkFlow/internal/action/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/action/internal/event"
+	"github.com/KNICEX/InkFlow/internal/action/internal/service"
+)
 
 type Service = service.ActionService
+
+type Action = domain.Action
+type Statistics = domain.Statistics
+type ActionType = domain.ActionType
+type TargetType = domain.TargetType
+type User = domain.User
+
+type Consumer = event.ActionConsumer
+
+const (
+	ActionTypeView    = domain.ActionTypeView
+	ActionTypeLike    = domain.ActionTypeLike
+	ActionTypeComment = domain.ActionTypeComment
+	ActionTypeFollow  = domain.ActionTypeFollow
+	ActionTypePublish = domain.ActionTypePublish
+
+	TargetTypeInk     = domain.TargetTypeInk
+	TargetTypeComment = domain.TargetTypeComment
+	TargetTypeUser    = domain.TargetTypeUser
+)
@@ -1,7 +1,39 @@
 package action
 
-import "github.com/KNICEX/InkFlow/internal/action/internal/service"
+import (
+	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/internal/action/internal/event"
+	"github.com/KNICEX/InkFlow/internal/action/internal/repo"
+	"github.com/KNICEX/InkFlow/internal/action/internal/repo/dao"
+	"github.com/KNICEX/InkFlow/internal/action/internal/service"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"gorm.io/gorm"
+)
 
-func InitService() Service {
+func InitService(db *gorm.DB) Service {
+	if err := dao.InitTables(db); err != nil {
+		panic(err)
+	}
+	node := snowflakex.NewNode(snowflakex.DefaultStartTime, 0)
+	return service.NewActionService(repo.NewNoCacheActionRepo(dao.NewGormActionDAO(db, node)))
+}
+
+func InitDoNothingService() Service {
 	return &service.DoNothingActionService{}
 }
+
+func InitActionConsumer(cli sarama.Client, svc Service, retry *saramax.RetryHandler, l logx.Logger) *Consumer {
+	consumer := event.NewActionConsumer(cli, svc, retry, l)
+	err := consumer.RegisterHandler(
+		event.NewInkViewHandler(),
+		event.NewInkLikeHandler(),
+		event.NewCommentReplyHandler(),
+		event.NewFollowHandler(),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return consumer
+}
@@ -1,6 +1,63 @@
 package domain
 
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/KNICEX/InkFlow/pkg/jsonschema"
+)
+
 type Resp struct {
 	Content string
 	Token   int64
+	// ToolCalls 生成回答的过程中模型调用过的工具
+	ToolCalls []ToolCall
+}
+
+// Image 多模态请求中的图片, Data 为空时由实现方根据 URL 下载
+type Image struct {
+	URL      string
+	MimeType string
+	Data     []byte
+}
+
+type Role string
+
+const (
+	RoleSystem Role = "system"
+	RoleUser   Role = "user"
+	RoleModel  Role = "model"
+)
+
+// Message 多轮对话中的一条消息, 用于恢复持久化的会话
+type Message struct {
+	Role    Role
+	Content string
+}
+
+type Request struct {
+	Question string
+	Images   []Image
+	// Schema 不为空时要求模型只输出符合 schema 的 json
+	Schema *jsonschema.Schema
+	// Tools 模型可以调用的工具, 由实现方执行后把结果交还给模型, 直到模型给出回答
+	Tools []Tool
+}
+
+// Tool 提供给模型调用的函数
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters 参数必须是 object, 可以由 jsonschema.For 生成
+	Parameters *jsonschema.Schema
+	// Call args 为模型给出的 json 参数, 返回值序列化为 json 后交给模型;
+	// 返回错误时错误信息同样交给模型, 由模型决定如何继续
+	Call func(ctx context.Context, args json.RawMessage) (any, error)
+}
+
+type ToolCall struct {
+	Name   string
+	Args   json.RawMessage
+	Result any
+	Err    string
 }
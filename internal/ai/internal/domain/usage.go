@@ -0,0 +1,32 @@
+package domain
+
+// Purpose 调用方的用途, 用于按用途统计 token 和限制额度
+type Purpose string
+
+const (
+	PurposeUnknown Purpose = "unknown"
+	PurposeReview  Purpose = "review"
+	PurposeTagging Purpose = "tagging"
+	PurposeSummary Purpose = "summary"
+	PurposeChat    Purpose = "chat"
+)
+
+// Budget token 额度, 0 表示不限制
+type Budget struct {
+	Daily   int64
+	Monthly int64
+	// UserDaily 单个用户每天的额度
+	UserDaily int64
+}
+
+type BudgetConfig struct {
+	// Total 所有用途合计的额度
+	Total    Budget
+	Purposes map[Purpose]Budget
+}
+
+// Usage 已使用的 token 数
+type Usage struct {
+	Daily   int64
+	Monthly int64
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	dailyExpiration   = time.Hour * 48
+	monthlyExpiration = time.Hour * 24 * 62
+)
+
+// UsageCache 按天和按月累计 token, key 自带日期, 过期后自然清零
+type UsageCache interface {
+	Incr(ctx context.Context, purpose domain.Purpose, uid int64, token int64, now time.Time) error
+	// Usage purpose 为空时返回所有用途的合计
+	Usage(ctx context.Context, purpose domain.Purpose, now time.Time) (domain.Usage, error)
+	UserDaily(ctx context.Context, purpose domain.Purpose, uid int64, now time.Time) (int64, error)
+}
+
+type RedisUsageCache struct {
+	cmd redis.Cmdable
+}
+
+func NewRedisUsageCache(cmd redis.Cmdable) UsageCache {
+	return &RedisUsageCache{
+		cmd: cmd,
+	}
+}
+
+func (cache *RedisUsageCache) Incr(ctx context.Context, purpose domain.Purpose, uid int64, token int64, now time.Time) error {
+	pipe := cache.cmd.TxPipeline()
+	for _, p := range []domain.Purpose{"", purpose} {
+		pipe.IncrBy(ctx, cache.dailyKey(p, now), token)
+		pipe.Expire(ctx, cache.dailyKey(p, now), dailyExpiration)
+		pipe.IncrBy(ctx, cache.monthlyKey(p, now), token)
+		pipe.Expire(ctx, cache.monthlyKey(p, now), monthlyExpiration)
+	}
+	if uid > 0 {
+		pipe.IncrBy(ctx, cache.userKey(purpose, uid, now), token)
+		pipe.Expire(ctx, cache.userKey(purpose, uid, now), dailyExpiration)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (cache *RedisUsageCache) Usage(ctx context.Context, purpose domain.Purpose, now time.Time) (domain.Usage, error) {
+	vals, err := cache.cmd.MGet(ctx, cache.dailyKey(purpose, now), cache.monthlyKey(purpose, now)).Result()
+	if err != nil {
+		return domain.Usage{}, err
+	}
+	daily, err := parseInt(vals[0])
+	if err != nil {
+		return domain.Usage{}, err
+	}
+	monthly, err := parseInt(vals[1])
+	if err != nil {
+		return domain.Usage{}, err
+	}
+	return domain.Usage{
+		Daily:   daily,
+		Monthly: monthly,
+	}, nil
+}
+
+func (cache *RedisUsageCache) UserDaily(ctx context.Context, purpose domain.Purpose, uid int64, now time.Time) (int64, error) {
+	v, err := cache.cmd.Get(ctx, cache.userKey(purpose, uid, now)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return v, err
+}
+
+func (cache *RedisUsageCache) dailyKey(purpose domain.Purpose, now time.Time) string {
+	return fmt.Sprintf("ai:usage:%s:%s", cache.purposeKey(purpose), now.Format(time.DateOnly))
+}
+
+func (cache *RedisUsageCache) monthlyKey(purpose domain.Purpose, now time.Time) string {
+	return fmt.Sprintf("ai:usage:%s:%s", cache.purposeKey(purpose), now.Format("2006-01"))
+}
+
+func (cache *RedisUsageCache) userKey(purpose domain.Purpose, uid int64, now time.Time) string {
+	return fmt.Sprintf("ai:usage:%s:user:%d:%s", cache.purposeKey(purpose), uid, now.Format(time.DateOnly))
+}
+
+func (cache *RedisUsageCache) purposeKey(purpose domain.Purpose) string {
+	if purpose == "" {
+		return "total"
+	}
+	return string(purpose)
+}
+
+func parseInt(v any) (int64, error) {
+	if v == nil {
+		return 0, nil
+	}
+	return strconv.ParseInt(v.(string), 10, 64)
+}
@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/ai/internal/repo/cache"
+)
+
+type UsageRepo interface {
+	Record(ctx context.Context, purpose domain.Purpose, uid int64, token int64, now time.Time) error
+	// Usage purpose 为空时返回所有用途的合计
+	Usage(ctx context.Context, purpose domain.Purpose, now time.Time) (domain.Usage, error)
+	UserDaily(ctx context.Context, purpose domain.Purpose, uid int64, now time.Time) (int64, error)
+}
+
+type CachedUsageRepo struct {
+	cache cache.UsageCache
+}
+
+func NewCachedUsageRepo(cache cache.UsageCache) UsageRepo {
+	return &CachedUsageRepo{
+		cache: cache,
+	}
+}
+
+func (repo *CachedUsageRepo) Record(ctx context.Context, purpose domain.Purpose, uid int64, token int64, now time.Time) error {
+	return repo.cache.Incr(ctx, purpose, uid, token, now)
+}
+
+func (repo *CachedUsageRepo) Usage(ctx context.Context, purpose domain.Purpose, now time.Time) (domain.Usage, error) {
+	return repo.cache.Usage(ctx, purpose, now)
+}
+
+func (repo *CachedUsageRepo) UserDaily(ctx context.Context, purpose domain.Purpose, uid int64, now time.Time) (int64, error) {
+	return repo.cache.UserDaily(ctx, purpose, uid, now)
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/ai/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+)
+
+// ErrBudgetExhausted token 额度已用完, 调用方应当降级(稍后重试或者跳过), 而不是当作错误处理
+var ErrBudgetExhausted = errors.New("llm token budget exhausted")
+
+// BudgetLLMService 调用前检查额度, 调用后按用途和用户累计 token.
+// 额度检查只看已经使用的量, 单次调用可能超出少量额度
+type BudgetLLMService struct {
+	svc     LLMService
+	repo    repo.UsageRepo
+	cfg     domain.BudgetConfig
+	metrics *Metrics
+	now     func() time.Time
+	l       logx.Logger
+}
+
+func NewBudgetService(svc LLMService, repo repo.UsageRepo, cfg domain.BudgetConfig, metrics *Metrics, l logx.Logger) *BudgetLLMService {
+	return &BudgetLLMService{
+		svc:     svc,
+		repo:    repo,
+		cfg:     cfg,
+		metrics: metrics,
+		now:     time.Now,
+		l:       l,
+	}
+}
+
+func (s *BudgetLLMService) AskOnce(ctx context.Context, question string) (domain.Resp, error) {
+	return s.call(ctx, func() (domain.Resp, error) {
+		return s.svc.AskOnce(ctx, question)
+	})
+}
+
+func (s *BudgetLLMService) AskWithImages(ctx context.Context, question string, images []domain.Image) (domain.Resp, error) {
+	return s.call(ctx, func() (domain.Resp, error) {
+		return s.svc.AskWithImages(ctx, question, images)
+	})
+}
+
+func (s *BudgetLLMService) Generate(ctx context.Context, req domain.Request) (domain.Resp, error) {
+	return s.call(ctx, func() (domain.Resp, error) {
+		return s.svc.Generate(ctx, req)
+	})
+}
+
+func (s *BudgetLLMService) BeginChat(ctx context.Context, history ...domain.Message) (LLMSession, error) {
+	if err := s.check(ctx); err != nil {
+		return nil, err
+	}
+	session, err := s.svc.BeginChat(ctx, history...)
+	if err != nil {
+		return nil, err
+	}
+	return &budgetSession{
+		session: session,
+		svc:     s,
+	}, nil
+}
+
+func (s *BudgetLLMService) call(ctx context.Context, fn func() (domain.Resp, error)) (domain.Resp, error) {
+	if err := s.check(ctx); err != nil {
+		return domain.Resp{}, err
+	}
+	resp, err := fn()
+	s.record(ctx, resp.Token)
+	return resp, err
+}
+
+// check 读取用量失败时放行, 不能因为统计不可用影响业务
+func (s *BudgetLLMService) check(ctx context.Context) error {
+	purpose := PurposeFrom(ctx)
+	now := s.now()
+	err := s.checkUsage(ctx, "", s.cfg.Total, now)
+	if err == nil {
+		err = s.checkUsage(ctx, purpose, s.cfg.Purposes[purpose], now)
+	}
+	if uid := UserFrom(ctx); err == nil && uid > 0 && s.cfg.Purposes[purpose].UserDaily > 0 {
+		var used int64
+		used, err = s.repo.UserDaily(ctx, purpose, uid, now)
+		if err == nil && used >= s.cfg.Purposes[purpose].UserDaily {
+			err = fmt.Errorf("%w: %s user %d daily", ErrBudgetExhausted, purpose, uid)
+		}
+	}
+	if err == nil || errors.Is(err, ErrBudgetExhausted) {
+		if err != nil {
+			s.metrics.exhausted.WithLabelValues(string(purpose)).Inc()
+		}
+		return err
+	}
+	s.l.WithCtx(ctx).Warn("load llm usage error", logx.String("purpose", string(purpose)), logx.Error(err))
+	return nil
+}
+
+func (s *BudgetLLMService) checkUsage(ctx context.Context, purpose domain.Purpose, budget domain.Budget, now time.Time) error {
+	if budget.Daily <= 0 && budget.Monthly <= 0 {
+		return nil
+	}
+	usage, err := s.repo.Usage(ctx, purpose, now)
+	if err != nil {
+		return err
+	}
+	scope := string(purpose)
+	if scope == "" {
+		scope = "total"
+	}
+	if budget.Daily > 0 && usage.Daily >= budget.Daily {
+		return fmt.Errorf("%w: %s daily", ErrBudgetExhausted, scope)
+	}
+	if budget.Monthly > 0 && usage.Monthly >= budget.Monthly {
+		return fmt.Errorf("%w: %s monthly", ErrBudgetExhausted, scope)
+	}
+	return nil
+}
+
+func (s *BudgetLLMService) record(ctx context.Context, token int64) {
+	if token <= 0 {
+		return
+	}
+	purpose := PurposeFrom(ctx)
+	err := s.repo.Record(ctx, purpose, UserFrom(ctx), token, s.now())
+	if err != nil {
+		s.l.WithCtx(ctx).Warn("record llm usage error", logx.String("purpose", string(purpose)),
+			logx.Int64("token", token), logx.Error(err))
+	}
+}
+
+type budgetSession struct {
+	session LLMSession
+	svc     *BudgetLLMService
+}
+
+func (s *budgetSession) Ask(ctx context.Context, question string) (domain.Resp, error) {
+	return s.svc.call(ctx, func() (domain.Resp, error) {
+		return s.session.Ask(ctx, question)
+	})
+}
+
+func (s *budgetSession) AskStream(ctx context.Context, question string, onChunk func(chunk string) error) (domain.Resp, error) {
+	return s.svc.call(ctx, func() (domain.Resp, error) {
+		return s.session.AskStream(ctx, question, onChunk)
+	})
+}
+
+func (s *budgetSession) Close() error {
+	return s.session.Close()
+}
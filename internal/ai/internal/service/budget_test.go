@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/ai/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memUsageRepo struct {
+	repo.UsageRepo
+	usage map[domain.Purpose]int64
+	users map[int64]int64
+}
+
+func (r *memUsageRepo) Record(ctx context.Context, purpose domain.Purpose, uid int64, token int64, now time.Time) error {
+	r.usage[""] += token
+	r.usage[purpose] += token
+	r.users[uid] += token
+	return nil
+}
+
+func (r *memUsageRepo) Usage(ctx context.Context, purpose domain.Purpose, now time.Time) (domain.Usage, error) {
+	return domain.Usage{Daily: r.usage[purpose], Monthly: r.usage[purpose]}, nil
+}
+
+func (r *memUsageRepo) UserDaily(ctx context.Context, purpose domain.Purpose, uid int64, now time.Time) (int64, error) {
+	return r.users[uid], nil
+}
+
+type tokenLLMService struct {
+	LLMService
+}
+
+func (s tokenLLMService) AskOnce(ctx context.Context, question string) (domain.Resp, error) {
+	return domain.Resp{Content: question, Token: 60}, nil
+}
+
+func TestBudgetLLMService(t *testing.T) {
+	usage := &memUsageRepo{usage: map[domain.Purpose]int64{}, users: map[int64]int64{}}
+	svc := NewBudgetService(tokenLLMService{}, usage, domain.BudgetConfig{
+		Total: domain.Budget{Monthly: 1000},
+		Purposes: map[domain.Purpose]domain.Budget{
+			domain.PurposeTagging: {Daily: 100},
+			domain.PurposeChat:    {UserDaily: 50},
+		},
+	}, NewMetrics(), logx.NewNopLogger())
+
+	tagging := WithPurpose(context.Background(), domain.PurposeTagging)
+	for range 2 {
+		_, err := svc.AskOnce(tagging, "tag")
+		require.NoError(t, err)
+	}
+	// 已使用 120, 超出每日额度
+	_, err := svc.AskOnce(tagging, "tag")
+	assert.ErrorIs(t, err, ErrBudgetExhausted)
+	assert.Equal(t, int64(120), usage.usage[domain.PurposeTagging])
+
+	// 其他用途不受影响
+	_, err = svc.AskOnce(WithPurpose(context.Background(), domain.PurposeReview), "review")
+	require.NoError(t, err)
+
+	chat := WithUser(WithPurpose(context.Background(), domain.PurposeChat), 1)
+	_, err = svc.AskOnce(chat, "hi")
+	require.NoError(t, err)
+	_, err = svc.AskOnce(chat, "hi")
+	assert.ErrorIs(t, err, ErrBudgetExhausted)
+	_, err = svc.AskOnce(WithUser(chat, 2), "hi")
+	require.NoError(t, err)
+
+	usage.usage[""] = 1000
+	_, err = svc.AskOnce(context.Background(), "hi")
+	assert.ErrorIs(t, err, ErrBudgetExhausted)
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
+)
+
+type purposeKey struct{}
+
+type userKey struct{}
+
+// WithPurpose 标记调用方的用途, 所有调用都应该带上, 否则记为 unknown
+func WithPurpose(ctx context.Context, purpose domain.Purpose) context.Context {
+	return context.WithValue(ctx, purposeKey{}, purpose)
+}
+
+func PurposeFrom(ctx context.Context) domain.Purpose {
+	if purpose, ok := ctx.Value(purposeKey{}).(domain.Purpose); ok {
+		return purpose
+	}
+	return domain.PurposeUnknown
+}
+
+// WithUser 标记为哪个用户调用, 用于按用户统计和限制额度
+func WithUser(ctx context.Context, uid int64) context.Context {
+	return context.WithValue(ctx, userKey{}, uid)
+}
+
+func UserFrom(ctx context.Context) int64 {
+	uid, _ := ctx.Value(userKey{}).(int64)
+	return uid
+}
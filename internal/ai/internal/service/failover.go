@@ -2,74 +2,206 @@ package service
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"time"
+
 	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
 	"github.com/KNICEX/InkFlow/pkg/backoff"
-	"sync/atomic"
+	"github.com/KNICEX/InkFlow/pkg/logx"
 )
 
+// ErrNoAvailableProvider 所有 provider 都处于熔断中
+var ErrNoAvailableProvider = errors.New("no available llm provider")
+
+type Provider struct {
+	Name string
+	Svc  LLMService
+}
+
+// FailoverLLMService 按健康状况在 provider 之间分配请求, 失败时换下一个重试.
+// 每个 provider 单独统计错误率和耗时, 错误率过高时熔断, 冷却后放行一个探测请求, 成功后恢复.
+// 没有熔断的 provider 中轮询取两个, 交给平均每次成功耗时更小的一个, 既偏向健康的 provider 也不会把流量全压到一个上
 type FailoverLLMService struct {
-	svcs   []LLMService
-	idx    *atomic.Int32
-	policy backoff.Policy
+	providers []Provider
+	health    []*providerHealth
+	idx       *atomic.Int32
+	policy    backoff.Policy
+	breaker   BreakerConfig
+	now       func() time.Time
+	l         logx.Logger
 }
 
 type Option func(*FailoverLLMService)
 
-func NewFailoverService(services []LLMService, policy ...backoff.Policy) LLMService {
+func WithPolicy(policy backoff.Policy) Option {
+	return func(f *FailoverLLMService) {
+		f.policy = policy
+	}
+}
+
+func WithBreaker(cfg BreakerConfig) Option {
+	return func(f *FailoverLLMService) {
+		f.breaker = cfg
+	}
+}
+
+func WithLogger(l logx.Logger) Option {
+	return func(f *FailoverLLMService) {
+		f.l = l
+	}
+}
+
+func NewFailoverService(providers []Provider, opts ...Option) *FailoverLLMService {
 	svc := &FailoverLLMService{
-		svcs: services,
-		idx:  new(atomic.Int32),
+		providers: providers,
+		idx:       new(atomic.Int32),
+		policy:    backoff.DefaultPolicy,
+		breaker:   DefaultBreakerConfig,
+		now:       time.Now,
+		l:         logx.NewNopLogger(),
+	}
+	for _, opt := range opts {
+		opt(svc)
 	}
-	if len(policy) > 0 {
-		svc.policy = policy[0]
-	} else {
-		svc.policy = backoff.DefaultPolicy
+	svc.breaker = svc.breaker.withDefaults()
+	svc.health = make([]*providerHealth, 0, len(providers))
+	for _, p := range providers {
+		svc.health = append(svc.health, newProviderHealth(p.Name, svc.breaker))
 	}
 	return svc
 }
 
 func (f *FailoverLLMService) AskOnce(ctx context.Context, question string) (domain.Resp, error) {
-	svc := f.svcs[f.idx.Load()%int32(len(f.svcs))]
-	f.idx.Add(1)
+	return f.ask(ctx, func(svc LLMService) (domain.Resp, error) {
+		return svc.AskOnce(ctx, question)
+	})
+}
 
-	var resp domain.Resp
-	var err error
+func (f *FailoverLLMService) AskWithImages(ctx context.Context, question string, images []domain.Image) (domain.Resp, error) {
+	return f.ask(ctx, func(svc LLMService) (domain.Resp, error) {
+		return svc.AskWithImages(ctx, question, images)
+	})
+}
+
+func (f *FailoverLLMService) Generate(ctx context.Context, req domain.Request) (domain.Resp, error) {
+	return f.ask(ctx, func(svc LLMService) (domain.Resp, error) {
+		return svc.Generate(ctx, req)
+	})
+}
 
-	copyPolicy := f.policy
-	copyPolicy.OnRetry = func(i int, err error) {
+func (f *FailoverLLMService) ask(ctx context.Context, askFn func(svc LLMService) (domain.Resp, error)) (domain.Resp, error) {
+	var resp domain.Resp
+	var permanent error
+	tried := make([]bool, len(f.providers))
+	fn := backoff.Wrap(func() error {
 		// 每次重试都换svc
-		svc = f.svcs[f.idx.Load()%int32(len(f.svcs))]
-		f.idx.Add(1)
-		if f.policy.OnRetry != nil {
-			f.policy.OnRetry(i, err)
+		i, ok := f.pick(tried)
+		if !ok {
+			return ErrNoAvailableProvider
+		}
+		var err error
+		start := f.now()
+		resp, err = askFn(f.providers[i].Svc)
+		if errors.Is(err, ErrImageUnavailable) {
+			// 不是 provider 的问题, 不计入错误率, 也不再重试
+			f.health[i].release()
+			permanent = err
+			return nil
 		}
+		f.record(ctx, i, start, err)
+		return err
+	}, f.policy)
+
+	if err := fn(); err != nil {
+		return resp, err
 	}
+	return resp, permanent
+}
 
-	fn := backoff.Wrap(func() error {
-		resp, err = svc.AskOnce(ctx, question)
-		if err != nil {
-			return err
+// pick 从下一个 provider 开始找两个没有熔断且本次请求没有试过的, 选择 score 更小的一个.
+// 遇到可以探测的 provider 时直接使用, 保证熔断的 provider 有机会恢复. 全部试过之后重新开始
+func (f *FailoverLLMService) pick(tried []bool) (int, bool) {
+	if !slices.Contains(tried, false) {
+		clear(tried)
+	}
+	start := int(f.idx.Add(1) - 1)
+	now := f.now()
+	best := -1
+	for j := range len(f.providers) {
+		i := (start + j) % len(f.providers)
+		if tried[i] {
+			continue
+		}
+		ok, probe := f.health[i].allow(now)
+		if !ok {
+			continue
+		}
+		if probe {
+			best = i
+			break
+		}
+		if best < 0 {
+			best = i
+			continue
 		}
-		return nil
-	}, copyPolicy)
+		if f.health[i].score() < f.health[best].score() {
+			best = i
+		}
+		break
+	}
+	if best < 0 {
+		return 0, false
+	}
+	tried[best] = true
+	return best, true
+}
 
-	err = fn()
-	return resp, err
+func (f *FailoverLLMService) record(ctx context.Context, i int, start time.Time, err error) {
+	if err != nil && ctx.Err() != nil {
+		// 调用方取消不算 provider 的错误, 但半开状态下需要归还探测机会
+		f.health[i].release()
+		return
+	}
+	now := f.now()
+	state, changed := f.health[i].record(now, now.Sub(start), err)
+	if !changed {
+		return
+	}
+	name := f.providers[i].Name
+	if state == CircuitOpen {
+		f.l.WithCtx(ctx).Warn("llm provider circuit open", logx.String("provider", name), logx.Error(err))
+	} else if state == CircuitClosed {
+		f.l.WithCtx(ctx).Info("llm provider recovered", logx.String("provider", name))
+	}
 }
 
-func (f *FailoverLLMService) BeginChat(ctx context.Context) (LLMSession, error) {
-	i := int(f.idx.Load())
-	f.idx.Add(1)
-	var session LLMSession
-	var err error
+// Health 所有 provider 的健康状况
+func (f *FailoverLLMService) Health() []ProviderHealth {
+	res := make([]ProviderHealth, 0, len(f.health))
+	for _, h := range f.health {
+		res = append(res, h.snapshot())
+	}
+	return res
+}
 
-	for range len(f.svcs) {
+func (f *FailoverLLMService) BeginChat(ctx context.Context, history ...domain.Message) (LLMSession, error) {
+	var err error = ErrNoAvailableProvider
+	tried := make([]bool, len(f.providers))
+	for range len(f.providers) {
 		// 最多遍历一遍所有svc
-		session, err = f.svcs[i].BeginChat(ctx)
+		i, ok := f.pick(tried)
+		if !ok {
+			break
+		}
+		var session LLMSession
+		start := f.now()
+		session, err = f.providers[i].Svc.BeginChat(ctx, history...)
+		f.record(ctx, i, start, err)
 		if err == nil {
 			return session, nil
 		}
-		i++
 	}
-	return session, err
+	return nil, err
 }
@@ -2,9 +2,15 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
 	"testing"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
+	"github.com/KNICEX/InkFlow/pkg/backoff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mockLLMService struct {
@@ -16,7 +22,15 @@ func (m mockLLMService) AskOnce(ctx context.Context, question string) (domain.Re
 	return domain.Resp{}, fmt.Errorf("mock err %s", m.name)
 }
 
-func (m mockLLMService) BeginChat(ctx context.Context) (LLMSession, error) {
+func (m mockLLMService) AskWithImages(ctx context.Context, question string, images []domain.Image) (domain.Resp, error) {
+	return m.AskOnce(ctx, question)
+}
+
+func (m mockLLMService) Generate(ctx context.Context, req domain.Request) (domain.Resp, error) {
+	return m.AskOnce(ctx, req.Question)
+}
+
+func (m mockLLMService) BeginChat(ctx context.Context, history ...domain.Message) (LLMSession, error) {
 	//TODO implement me
 	panic("implement me")
 }
@@ -27,8 +41,118 @@ func TestFailoverLLMService_AskOnce(t *testing.T) {
 	m3 := mockLLMService{name: "m3"}
 	m4 := mockLLMService{name: "m4"}
 
-	svc := NewFailoverService([]LLMService{m1, m2, m3, m4})
+	svc := NewFailoverService([]Provider{{"m1", m1}, {"m2", m2}, {"m3", m3}, {"m4", m4}})
 
 	_, _ = svc.AskOnce(context.Background(), "test")
 
 }
+
+type fakeProvider struct {
+	LLMService
+	err   error
+	calls int
+}
+
+func (p *fakeProvider) AskOnce(ctx context.Context, question string) (domain.Resp, error) {
+	p.calls++
+	return domain.Resp{Content: question}, p.err
+}
+
+func TestFailoverLLMService_Circuit(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	bad := &fakeProvider{err: errors.New("unavailable")}
+	good := &fakeProvider{}
+	svc := NewFailoverService([]Provider{{"bad", bad}, {"good", good}},
+		WithPolicy(backoff.Policy{MaxRetries: 2, InitialInterval: time.Millisecond}),
+		WithBreaker(BreakerConfig{Window: 4, MinRequests: 1, ErrorRate: 0.5, OpenDuration: time.Minute}),
+	)
+	svc.now = func() time.Time { return now }
+
+	for range 4 {
+		resp, err := svc.AskOnce(ctx, "hi")
+		require.NoError(t, err)
+		assert.Equal(t, "hi", resp.Content)
+	}
+	// 失败后熔断, 之后的请求全部交给 good
+	assert.Equal(t, 1, bad.calls)
+	assert.Equal(t, CircuitOpen, svc.Health()[0].State)
+	assert.Equal(t, 1.0, svc.Health()[0].ErrorRate)
+
+	// 冷却结束后放行一个探测请求, 失败则继续熔断
+	now = now.Add(time.Minute)
+	_, err := svc.AskOnce(ctx, "hi")
+	require.NoError(t, err)
+	_, err = svc.AskOnce(ctx, "hi")
+	require.NoError(t, err)
+	assert.Equal(t, 2, bad.calls)
+	assert.Equal(t, CircuitOpen, svc.Health()[0].State)
+
+	// 探测成功后恢复
+	now = now.Add(time.Minute)
+	bad.err = nil
+	_, err = svc.AskOnce(ctx, "hi")
+	require.NoError(t, err)
+	assert.Equal(t, 3, bad.calls)
+	assert.Equal(t, CircuitClosed, svc.Health()[0].State)
+
+	// 全部熔断
+	bad.err = errors.New("unavailable")
+	good.err = errors.New("unavailable")
+	for range 4 {
+		_, _ = svc.AskOnce(ctx, "hi")
+	}
+	_, err = svc.AskOnce(ctx, "hi")
+	assert.ErrorIs(t, err, ErrNoAvailableProvider)
+}
+
+func TestFailoverLLMService_PreferHealthy(t *testing.T) {
+	slow := &fakeProvider{}
+	fast := &fakeProvider{}
+	flaky := &fakeProvider{}
+	svc := NewFailoverService([]Provider{{"slow", slow}, {"fast", fast}, {"flaky", flaky}})
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+	svc.health[0].latency = time.Second * 2
+	svc.health[1].latency = time.Second
+	svc.health[2].latency = time.Millisecond * 600
+	// 一半请求失败, 平均每次成功耗时 1.2s
+	svc.health[2].results = []bool{true, false}
+	svc.health[2].failures = 1
+
+	// 轮询取相邻的两个: slow 和 fast, fast 和 flaky, flaky 和 slow
+	for range 3 {
+		_, err := svc.AskOnce(context.Background(), "hi")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 0, slow.calls)
+	assert.Equal(t, 2, fast.calls)
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestBreakerConfig_WithDefaults(t *testing.T) {
+	cfg := BreakerConfig{MinRequests: 50}.withDefaults()
+	assert.Equal(t, DefaultBreakerConfig.Window, cfg.Window)
+	assert.Equal(t, DefaultBreakerConfig.Window, cfg.MinRequests)
+	assert.Equal(t, DefaultBreakerConfig.ErrorRate, cfg.ErrorRate)
+	assert.Equal(t, DefaultBreakerConfig.OpenDuration, cfg.OpenDuration)
+
+	// Window 为 0 时不会 panic
+	svc := NewFailoverService([]Provider{{"p", &fakeProvider{}}}, WithBreaker(BreakerConfig{}))
+	_, err := svc.AskOnce(context.Background(), "hi")
+	assert.NoError(t, err)
+}
+
+func TestFailoverLLMService_ImageUnavailable(t *testing.T) {
+	p := &fakeProvider{err: fmt.Errorf("%w: 404", ErrImageUnavailable)}
+	svc := NewFailoverService([]Provider{{"p1", p}, {"p2", p}},
+		WithPolicy(backoff.Policy{MaxRetries: 3, InitialInterval: time.Millisecond}),
+		WithBreaker(BreakerConfig{Window: 4, MinRequests: 1, ErrorRate: 0.5, OpenDuration: time.Minute}),
+	)
+	_, err := svc.AskOnce(context.Background(), "hi")
+	assert.ErrorIs(t, err, ErrImageUnavailable)
+	// 图片的问题不重试, 也不影响 provider 的健康状态
+	assert.Equal(t, 1, p.calls)
+	assert.Equal(t, CircuitClosed, svc.Health()[0].State)
+	assert.Equal(t, 0.0, svc.Health()[0].ErrorRate)
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type CircuitState string
+
+const (
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen 熔断中, 冷却时间内不再请求
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen 冷却结束, 只放行一个探测请求
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+type BreakerConfig struct {
+	// Window 统计错误率的最近请求数
+	Window int
+	// MinRequests 窗口内请求数达到该值后才按错误率熔断
+	MinRequests int
+	ErrorRate   float64
+	// OpenDuration 熔断后等待多久再探测
+	OpenDuration time.Duration
+}
+
+var DefaultBreakerConfig = BreakerConfig{
+	Window:       20,
+	MinRequests:  5,
+	ErrorRate:    0.5,
+	OpenDuration: time.Second * 30,
+}
+
+// withDefaults 非法的配置项使用默认值, MinRequests 不超过 Window
+func (cfg BreakerConfig) withDefaults() BreakerConfig {
+	def := DefaultBreakerConfig
+	if cfg.Window <= 0 {
+		cfg.Window = def.Window
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = def.MinRequests
+	}
+	cfg.MinRequests = min(cfg.MinRequests, cfg.Window)
+	if cfg.ErrorRate <= 0 || cfg.ErrorRate > 1 {
+		cfg.ErrorRate = def.ErrorRate
+	}
+	if cfg.OpenDuration <= 0 {
+		cfg.OpenDuration = def.OpenDuration
+	}
+	return cfg
+}
+
+// ProviderHealth provider 当前的健康状况
+type ProviderHealth struct {
+	Name      string
+	State     CircuitState
+	ErrorRate float64
+	// Latency 成功请求耗时的滑动平均
+	Latency  time.Duration
+	OpenedAt time.Time
+}
+
+// providerHealth 单个 provider 的熔断器, 基于最近 Window 次请求的错误率
+type providerHealth struct {
+	cfg BreakerConfig
+
+	mu       sync.Mutex
+	name     string
+	results  []bool
+	next     int
+	failures int
+	latency  time.Duration
+	state    CircuitState
+	openedAt time.Time
+	probing  bool
+}
+
+func newProviderHealth(name string, cfg BreakerConfig) *providerHealth {
+	return &providerHealth{
+		cfg:     cfg,
+		name:    name,
+		results: make([]bool, 0, cfg.Window),
+		state:   CircuitClosed,
+	}
+}
+
+// allow 是否可以请求该 provider, 冷却结束后只有第一个调用方拿到探测机会, 此时 probe 为 true,
+// 拿到探测机会的调用方必须发起请求或者 release
+func (h *providerHealth) allow(now time.Time) (ok bool, probe bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch h.state {
+	case CircuitClosed:
+		return true, false
+	case CircuitOpen:
+		if now.Sub(h.openedAt) < h.cfg.OpenDuration {
+			return false, false
+		}
+		h.state = CircuitHalfOpen
+		h.probing = true
+		return true, true
+	default:
+		if h.probing {
+			return false, false
+		}
+		h.probing = true
+		return true, true
+	}
+}
+
+// score 平均每次成功请求的耗时, 越小越好. 还没有成功请求时为 0, 优先分配以便收集数据
+func (h *providerHealth) score() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rate := h.errorRate()
+	if rate >= 1 {
+		return math.MaxFloat64
+	}
+	return float64(h.latency) / (1 - rate)
+}
+
+// release 放弃本次请求的结果
+func (h *providerHealth) release() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.probing = false
+}
+
+// record 记录一次请求结果, 返回熔断状态是否发生变化
+func (h *providerHealth) record(now time.Time, latency time.Duration, err error) (CircuitState, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	old := h.state
+	if h.state == CircuitHalfOpen {
+		h.probing = false
+		if err != nil {
+			h.open(now)
+		} else {
+			// 探测成功后重新开始统计
+			h.state = CircuitClosed
+			h.results = h.results[:0]
+			h.next = 0
+			h.failures = 0
+		}
+	}
+	if err == nil {
+		if h.latency == 0 {
+			h.latency = latency
+		} else {
+			h.latency = (h.latency*4 + latency) / 5
+		}
+	}
+	if h.state == CircuitClosed {
+		h.push(err != nil)
+		if len(h.results) >= h.cfg.MinRequests && h.errorRate() >= h.cfg.ErrorRate {
+			h.open(now)
+		}
+	}
+	return h.state, h.state != old
+}
+
+func (h *providerHealth) push(failed bool) {
+	if len(h.results) < h.cfg.Window {
+		h.results = append(h.results, failed)
+	} else {
+		if h.results[h.next] {
+			h.failures--
+		}
+		h.results[h.next] = failed
+		h.next = (h.next + 1) % h.cfg.Window
+	}
+	if failed {
+		h.failures++
+	}
+}
+
+func (h *providerHealth) open(now time.Time) {
+	h.state = CircuitOpen
+	h.openedAt = now
+}
+
+func (h *providerHealth) errorRate() float64 {
+	if len(h.results) == 0 {
+		return 0
+	}
+	return float64(h.failures) / float64(len(h.results))
+}
+
+func (h *providerHealth) snapshot() ProviderHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return ProviderHealth{
+		Name:      h.name,
+		State:     h.state,
+		ErrorRate: h.errorRate(),
+		Latency:   h.latency,
+		OpenedAt:  h.openedAt,
+	}
+}
@@ -2,18 +2,30 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
 	"github.com/KNICEX/InkFlow/internal/ai/internal/service"
+	"github.com/KNICEX/InkFlow/pkg/jsonschema"
 	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
 )
 
+// maxToolRounds 单次请求最多的工具调用轮数, 防止模型反复调用
+const maxToolRounds = 5
+
+var ErrTooManyToolRounds = errors.New("too many tool call rounds")
+
 type Service struct {
-	key    string
-	client *genai.Client
-	model  *genai.GenerativeModel
+	key     string
+	client  *genai.Client
+	model   *genai.GenerativeModel
+	httpCli *http.Client
 }
 
 type Session struct {
@@ -32,6 +44,35 @@ func (c *Session) Ask(ctx context.Context, question string) (domain.Resp, error)
 	}, nil
 }
 
+func (c *Session) AskStream(ctx context.Context, question string, onChunk func(chunk string) error) (domain.Resp, error) {
+	iter := c.session.SendMessageStream(ctx, genai.Text(question))
+	var res domain.Resp
+	var content strings.Builder
+	for {
+		resp, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return res, err
+		}
+		// 用量只在最后几个分片中给出, 且为累计值
+		if token := tokenCount(resp); token > 0 {
+			res.Token = token
+		}
+		chunk, _ := parseResponse(resp)
+		if chunk == "" {
+			continue
+		}
+		content.WriteString(chunk)
+		if err = onChunk(chunk); err != nil {
+			return res, err
+		}
+	}
+	res.Content = content.String()
+	return res, nil
+}
+
 func (c *Session) Close() error {
 	return nil
 }
@@ -40,8 +81,9 @@ type Option func(*Service)
 
 func NewGeminiService(client *genai.Client, opts ...Option) service.LLMService {
 	svc := &Service{
-		client: client,
-		model:  client.GenerativeModel("gemini-2.5-flash-lite"),
+		client:  client,
+		model:   client.GenerativeModel("gemini-2.5-flash-lite"),
+		httpCli: newImageClient(time.Second * 10),
 	}
 
 	for _, opt := range opts {
@@ -92,17 +134,166 @@ func (svc *Service) AskOnce(ctx context.Context, msg string) (domain.Resp, error
 	}, nil
 }
 
-func (svc *Service) BeginChat(ctx context.Context) (service.LLMSession, error) {
-	session := svc.model.StartChat()
+func (svc *Service) AskWithImages(ctx context.Context, msg string, images []domain.Image) (domain.Resp, error) {
+	parts, err := svc.buildParts(ctx, msg, images)
+	if err != nil {
+		return domain.Resp{}, err
+	}
+	resp, err := svc.model.GenerateContent(ctx, parts...)
+	if err != nil {
+		return domain.Resp{}, err
+	}
+	res, token := parseResponse(resp)
+	return domain.Resp{
+		Content: res,
+		Token:   token,
+	}, nil
+}
+
+func (svc *Service) Generate(ctx context.Context, req domain.Request) (domain.Resp, error) {
+	parts, err := svc.buildParts(ctx, req.Question, req.Images)
+	if err != nil {
+		return domain.Resp{}, err
+	}
+	// 复制一份模型配置, 避免并发请求互相影响
+	model := *svc.model
+	if req.Schema != nil && len(req.Tools) == 0 {
+		// gemini 不支持同时使用函数调用和结构化输出, 有工具时只依靠调用方校验
+		model.ResponseMIMEType = "application/json"
+		model.ResponseSchema = toGenaiSchema(req.Schema)
+	}
+	if len(req.Tools) == 0 {
+		resp, err := model.GenerateContent(ctx, parts...)
+		if err != nil {
+			return domain.Resp{}, err
+		}
+		res, token := parseResponse(resp)
+		return domain.Resp{
+			Content: res,
+			Token:   token,
+		}, nil
+	}
+
+	decls := make([]*genai.FunctionDeclaration, 0, len(req.Tools))
+	tools := make(map[string]domain.Tool, len(req.Tools))
+	for _, tool := range req.Tools {
+		decls = append(decls, &genai.FunctionDeclaration{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  toGenaiSchema(tool.Parameters),
+		})
+		tools[tool.Name] = tool
+	}
+	model.Tools = []*genai.Tool{{FunctionDeclarations: decls}}
+
+	session := model.StartChat()
+	var res domain.Resp
+	resp, err := session.SendMessage(ctx, parts...)
+	for round := 0; ; round++ {
+		if err != nil {
+			return res, err
+		}
+		res.Token += tokenCount(resp)
+		var calls []genai.FunctionCall
+		if len(resp.Candidates) > 0 {
+			calls = resp.Candidates[0].FunctionCalls()
+		}
+		if len(calls) == 0 {
+			res.Content, _ = parseResponse(resp)
+			return res, nil
+		}
+		if round >= maxToolRounds {
+			return res, ErrTooManyToolRounds
+		}
+		replies := make([]genai.Part, 0, len(calls))
+		for _, call := range calls {
+			toolCall, reply := svc.callTool(ctx, tools, call)
+			res.ToolCalls = append(res.ToolCalls, toolCall)
+			replies = append(replies, reply)
+		}
+		resp, err = session.SendMessage(ctx, replies...)
+	}
+}
+
+// callTool 执行模型请求的工具, 工具不存在或者执行失败时把错误交给模型
+func (svc *Service) callTool(ctx context.Context, tools map[string]domain.Tool, call genai.FunctionCall) (domain.ToolCall, genai.FunctionResponse) {
+	args, _ := json.Marshal(call.Args)
+	toolCall := domain.ToolCall{
+		Name: call.Name,
+		Args: args,
+	}
+	reply := genai.FunctionResponse{Name: call.Name}
+
+	tool, ok := tools[call.Name]
+	if !ok {
+		toolCall.Err = fmt.Sprintf("unknown tool %s", call.Name)
+		reply.Response = map[string]any{"error": toolCall.Err}
+		return toolCall, reply
+	}
+	result, err := tool.Call(ctx, args)
+	if err == nil {
+		// 转换为 json 对象, FunctionResponse 只接受 map
+		var data []byte
+		data, err = json.Marshal(result)
+		if err == nil {
+			var v any
+			err = json.Unmarshal(data, &v)
+			toolCall.Result = v
+		}
+	}
+	if err != nil {
+		toolCall.Err = err.Error()
+		reply.Response = map[string]any{"error": toolCall.Err}
+		return toolCall, reply
+	}
+	reply.Response = map[string]any{"result": toolCall.Result}
+	return toolCall, reply
+}
+
+func (svc *Service) buildParts(ctx context.Context, msg string, images []domain.Image) ([]genai.Part, error) {
+	parts := make([]genai.Part, 0, len(images)+1)
+	for _, img := range images {
+		if len(img.Data) == 0 {
+			var err error
+			img, err = fetchImage(ctx, svc.httpCli, img.URL)
+			if err != nil {
+				return nil, err
+			}
+		}
+		parts = append(parts, genai.Blob{
+			MIMEType: img.MimeType,
+			Data:     img.Data,
+		})
+	}
+	return append(parts, genai.Text(msg)), nil
+}
+
+func (svc *Service) BeginChat(ctx context.Context, history ...domain.Message) (service.LLMSession, error) {
+	model := svc.model
+	var contents []*genai.Content
+	for _, msg := range history {
+		if msg.Role == domain.RoleSystem {
+			// 复制一份模型配置, 系统指令只对当前会话生效
+			m := *model
+			m.SystemInstruction = &genai.Content{Parts: []genai.Part{genai.Text(msg.Content)}}
+			model = &m
+			continue
+		}
+		contents = append(contents, &genai.Content{
+			Role:  string(msg.Role),
+			Parts: []genai.Part{genai.Text(msg.Content)},
+		})
+	}
+	session := model.StartChat()
+	session.History = contents
 	return &Session{
 		session: session,
 	}, nil
-
 }
 
 func parseResponse(resp *genai.GenerateContentResponse) (string, int64) {
 	var resStr strings.Builder
-	if resp.Candidates != nil && len(resp.Candidates) > 0 {
+	if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
 		for i, part := range resp.Candidates[0].Content.Parts {
 			if part == nil {
 				continue
@@ -117,5 +308,57 @@ func parseResponse(resp *genai.GenerateContentResponse) (string, int64) {
 			}
 		}
 	}
-	return resStr.String(), int64(resp.UsageMetadata.TotalTokenCount)
+	return resStr.String(), tokenCount(resp)
+}
+
+func tokenCount(resp *genai.GenerateContentResponse) int64 {
+	if resp.UsageMetadata == nil {
+		return 0
+	}
+	return int64(resp.UsageMetadata.TotalTokenCount)
+}
+
+var genaiTypes = map[jsonschema.Type]genai.Type{
+	jsonschema.TypeObject:  genai.TypeObject,
+	jsonschema.TypeArray:   genai.TypeArray,
+	jsonschema.TypeString:  genai.TypeString,
+	jsonschema.TypeInteger: genai.TypeInteger,
+	jsonschema.TypeNumber:  genai.TypeNumber,
+	jsonschema.TypeBoolean: genai.TypeBoolean,
+}
+
+// toGenaiSchema gemini 不支持 minimum/maximum, 范围写进描述里
+func toGenaiSchema(s *jsonschema.Schema) *genai.Schema {
+	if s == nil {
+		return nil
+	}
+	desc := s.Description
+	if s.Minimum != nil || s.Maximum != nil {
+		var bounds []string
+		if s.Minimum != nil {
+			bounds = append(bounds, fmt.Sprintf(">= %v", *s.Minimum))
+		}
+		if s.Maximum != nil {
+			bounds = append(bounds, fmt.Sprintf("<= %v", *s.Maximum))
+		}
+		desc = strings.TrimSpace(fmt.Sprintf("%s (%s)", desc, strings.Join(bounds, ", ")))
+	}
+	res := &genai.Schema{
+		Type:        genaiTypes[s.Type],
+		Description: desc,
+		Nullable:    s.Nullable,
+		Enum:        s.Enum,
+		Items:       toGenaiSchema(s.Items),
+		Required:    s.Required,
+	}
+	if len(s.Enum) > 0 {
+		res.Format = "enum"
+	}
+	if len(s.Properties) > 0 {
+		res.Properties = make(map[string]*genai.Schema, len(s.Properties))
+		for name, prop := range s.Properties {
+			res.Properties[name] = toGenaiSchema(prop)
+		}
+	}
+	return res
 }
@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/ai/internal/service"
+)
+
+// maxImageSize 单张图片的最大字节数, 超过后不再下载
+const maxImageSize = 10 << 20
+
+var (
+	ErrImageTooLarge = errors.New("image too large")
+	// ErrPrivateAddress 图片地址指向内网, 防止通过图片链接探测内部服务
+	ErrPrivateAddress = errors.New("image address is not public")
+)
+
+// newImageClient 下载图片用的 client, 只允许连接公网地址.
+// 在建立连接时检查解析后的 ip, 重定向和 dns rebinding 同样会被拦截
+func newImageClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrPrivateAddress, address)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// fetchImage 下载图片, gemini 只接受 inline 数据或者自家文件服务的链接, 本地模型也无法访问外网.
+// 图片本身的问题(链接无效、下载失败、过大)都会包装为 service.ErrImageUnavailable
+func fetchImage(ctx context.Context, cli *http.Client, rawURL string) (domain.Image, error) {
+	img, err := doFetchImage(ctx, cli, rawURL)
+	if err != nil && ctx.Err() == nil {
+		return domain.Image{}, fmt.Errorf("%w: %w", service.ErrImageUnavailable, err)
+	}
+	return img, err
+}
+
+func doFetchImage(ctx context.Context, cli *http.Client, rawURL string) (domain.Image, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return domain.Image{}, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return domain.Image{}, fmt.Errorf("fetch image %s: unsupported scheme", rawURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return domain.Image{}, err
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return domain.Image{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return domain.Image{}, fmt.Errorf("fetch image %s: unexpected status %d", rawURL, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return domain.Image{}, err
+	}
+	if len(data) > maxImageSize {
+		return domain.Image{}, fmt.Errorf("%w: %s", ErrImageTooLarge, rawURL)
+	}
+	mimeType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(data)
+	}
+	return domain.Image{
+		URL:      rawURL,
+		MimeType: mimeType,
+		Data:     data,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchImage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{
+			name:    "内网地址",
+			url:     server.URL,
+			wantErr: ErrPrivateAddress,
+		},
+		{
+			name:    "不支持的协议",
+			url:     "file:///etc/passwd",
+			wantErr: service.ErrImageUnavailable,
+		},
+	}
+	cli := newImageClient(time.Second)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := fetchImage(context.Background(), cli, tc.url)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.ErrorIs(t, err, service.ErrImageUnavailable)
+		})
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for ip, want := range map[string]bool{
+		"8.8.8.8":         true,
+		"127.0.0.1":       false,
+		"10.0.0.1":        false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"::1":             false,
+		"fd00::1":         false,
+	} {
+		assert.Equal(t, want, isPublicIP(net.ParseIP(ip)), ip)
+	}
+}
@@ -0,0 +1,421 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/ai/internal/service"
+)
+
+// OpenAIConfig 兼容 openai chat completions 接口的服务, 如 vLLM、Ollama 和各家云厂商
+type OpenAIConfig struct {
+	BaseURL string
+	Key     string
+	Model   string
+	// Temperature 为 0 时使用服务端默认值
+	Temperature float32
+	// InlineImages 先下载图片再以 base64 发送, 用于无法访问外网的本地模型
+	InlineImages bool
+	Timeout      time.Duration
+}
+
+type OpenAIService struct {
+	cfg     OpenAIConfig
+	preset  string
+	httpCli *http.Client
+	// imageCli 下载图片, 与请求模型的 client 分开, 模型部署在内网时也不放开图片的内网访问
+	imageCli *http.Client
+}
+
+type OpenAIOption func(*OpenAIService)
+
+func NewOpenAIService(cfg OpenAIConfig, opts ...OpenAIOption) service.LLMService {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Minute
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	svc := &OpenAIService{
+		cfg:      cfg,
+		httpCli:  &http.Client{Timeout: cfg.Timeout},
+		imageCli: newImageClient(time.Second * 10),
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
+}
+
+func WithOpenAIPreset(prompt string) OpenAIOption {
+	return func(s *OpenAIService) {
+		s.preset = prompt
+	}
+}
+
+type chatMessage struct {
+	Role string `json:"role"`
+	// Content string 或者 []chatPart
+	Content    any            `json:"content,omitempty"`
+	ToolCalls  []chatToolCall `json:"tool_calls,omitempty"`
+	ToolCallId string         `json:"tool_call_id,omitempty"`
+}
+
+type chatPart struct {
+	Type     string        `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	ImageURL *chatImageURL `json:"image_url,omitempty"`
+}
+
+type chatImageURL struct {
+	URL string `json:"url"`
+}
+
+type chatToolCall struct {
+	Id       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+		// Arguments json 字符串
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type chatTool struct {
+	Type     string       `json:"type"`
+	Function chatFunction `json:"function"`
+}
+
+type chatFunction struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+type chatResponseFormat struct {
+	Type       string `json:"type"`
+	JSONSchema struct {
+		Name   string `json:"name"`
+		Schema any    `json:"schema"`
+	} `json:"json_schema"`
+}
+
+type chatRequest struct {
+	Model          string              `json:"model"`
+	Messages       []chatMessage       `json:"messages"`
+	Temperature    float32             `json:"temperature,omitempty"`
+	ResponseFormat *chatResponseFormat `json:"response_format,omitempty"`
+	Tools          []chatTool          `json:"tools,omitempty"`
+	Stream         bool                `json:"stream,omitempty"`
+	StreamOptions  *chatStreamOptions  `json:"stream_options,omitempty"`
+}
+
+type chatStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// chatChunk 流式响应的分片, 开启 include_usage 后最后一个分片只有用量没有 choices
+type chatChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		TotalTokens int64 `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content   string         `json:"content"`
+			ToolCalls []chatToolCall `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		TotalTokens int64 `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+func (svc *OpenAIService) AskOnce(ctx context.Context, question string) (domain.Resp, error) {
+	return svc.Generate(ctx, domain.Request{Question: question})
+}
+
+func (svc *OpenAIService) AskWithImages(ctx context.Context, question string, images []domain.Image) (domain.Resp, error) {
+	return svc.Generate(ctx, domain.Request{Question: question, Images: images})
+}
+
+func (svc *OpenAIService) Generate(ctx context.Context, req domain.Request) (domain.Resp, error) {
+	msg, err := svc.userMessage(ctx, req.Question, req.Images)
+	if err != nil {
+		return domain.Resp{}, err
+	}
+	body := chatRequest{
+		Model:       svc.cfg.Model,
+		Messages:    append(svc.presetMessages(), msg),
+		Temperature: svc.cfg.Temperature,
+	}
+	if req.Schema != nil {
+		body.ResponseFormat = &chatResponseFormat{Type: "json_schema"}
+		body.ResponseFormat.JSONSchema.Name = "output"
+		body.ResponseFormat.JSONSchema.Schema = req.Schema
+	}
+	tools := make(map[string]domain.Tool, len(req.Tools))
+	for _, tool := range req.Tools {
+		body.Tools = append(body.Tools, chatTool{
+			Type: "function",
+			Function: chatFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+		tools[tool.Name] = tool
+	}
+
+	var res domain.Resp
+	for round := 0; ; round++ {
+		resp, err := svc.complete(ctx, body)
+		if err != nil {
+			return res, err
+		}
+		res.Token += resp.Usage.TotalTokens
+		reply := resp.Choices[0].Message
+		if len(reply.ToolCalls) == 0 {
+			res.Content = reply.Content
+			return res, nil
+		}
+		if round >= maxToolRounds {
+			return res, ErrTooManyToolRounds
+		}
+		body.Messages = append(body.Messages, chatMessage{
+			Role:      "assistant",
+			Content:   reply.Content,
+			ToolCalls: reply.ToolCalls,
+		})
+		for _, call := range reply.ToolCalls {
+			toolCall, content := svc.callTool(ctx, tools, call)
+			res.ToolCalls = append(res.ToolCalls, toolCall)
+			body.Messages = append(body.Messages, chatMessage{
+				Role:       "tool",
+				Content:    content,
+				ToolCallId: call.Id,
+			})
+		}
+	}
+}
+
+// callTool 执行模型请求的工具, 返回交给模型的 json 内容
+func (svc *OpenAIService) callTool(ctx context.Context, tools map[string]domain.Tool, call chatToolCall) (domain.ToolCall, string) {
+	args := json.RawMessage(call.Function.Arguments)
+	if !json.Valid(args) {
+		args = json.RawMessage("{}")
+	}
+	toolCall := domain.ToolCall{
+		Name: call.Function.Name,
+		Args: args,
+	}
+	tool, ok := tools[call.Function.Name]
+	if !ok {
+		toolCall.Err = fmt.Sprintf("unknown tool %s", call.Function.Name)
+	} else if result, err := tool.Call(ctx, args); err != nil {
+		toolCall.Err = err.Error()
+	} else {
+		toolCall.Result = result
+	}
+
+	var content []byte
+	if toolCall.Err != "" {
+		content, _ = json.Marshal(map[string]any{"error": toolCall.Err})
+	} else {
+		var err error
+		content, err = json.Marshal(map[string]any{"result": toolCall.Result})
+		if err != nil {
+			toolCall.Err = err.Error()
+			content, _ = json.Marshal(map[string]any{"error": toolCall.Err})
+		}
+	}
+	return toolCall, string(content)
+}
+
+func (svc *OpenAIService) presetMessages() []chatMessage {
+	if svc.preset == "" {
+		return nil
+	}
+	return []chatMessage{{Role: "system", Content: svc.preset}}
+}
+
+func (svc *OpenAIService) userMessage(ctx context.Context, question string, images []domain.Image) (chatMessage, error) {
+	if len(images) == 0 {
+		return chatMessage{Role: "user", Content: question}, nil
+	}
+	parts := make([]chatPart, 0, len(images)+1)
+	for _, img := range images {
+		if len(img.Data) == 0 && svc.cfg.InlineImages {
+			var err error
+			img, err = fetchImage(ctx, svc.imageCli, img.URL)
+			if err != nil {
+				return chatMessage{}, err
+			}
+		}
+		url := img.URL
+		if len(img.Data) > 0 {
+			url = fmt.Sprintf("data:%s;base64,%s", img.MimeType, base64.StdEncoding.EncodeToString(img.Data))
+		}
+		parts = append(parts, chatPart{Type: "image_url", ImageURL: &chatImageURL{URL: url}})
+	}
+	parts = append(parts, chatPart{Type: "text", Text: question})
+	return chatMessage{Role: "user", Content: parts}, nil
+}
+
+func (svc *OpenAIService) complete(ctx context.Context, body chatRequest) (chatResponse, error) {
+	resp, err := svc.post(ctx, body)
+	if err != nil {
+		return chatResponse{}, err
+	}
+	defer resp.Body.Close()
+	var res chatResponse
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return chatResponse{}, err
+	}
+	if len(res.Choices) == 0 {
+		return chatResponse{}, fmt.Errorf("chat completions: no choices")
+	}
+	return res, nil
+}
+
+// stream 以 SSE 方式读取回答, 每个分片调用一次 onChunk
+func (svc *OpenAIService) stream(ctx context.Context, body chatRequest, onChunk func(chunk string) error) (domain.Resp, error) {
+	body.Stream = true
+	body.StreamOptions = &chatStreamOptions{IncludeUsage: true}
+	resp, err := svc.post(ctx, body)
+	if err != nil {
+		return domain.Resp{}, err
+	}
+	defer resp.Body.Close()
+
+	var res domain.Resp
+	var content strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk chatChunk
+		if err = json.Unmarshal([]byte(data), &chunk); err != nil {
+			return res, err
+		}
+		if chunk.Usage != nil {
+			res.Token = chunk.Usage.TotalTokens
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		delta := chunk.Choices[0].Delta.Content
+		content.WriteString(delta)
+		if err = onChunk(delta); err != nil {
+			return res, err
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return res, err
+	}
+	res.Content = content.String()
+	return res, nil
+}
+
+func (svc *OpenAIService) post(ctx context.Context, body chatRequest) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, svc.cfg.BaseURL+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if svc.cfg.Key != "" {
+		req.Header.Set("Authorization", "Bearer "+svc.cfg.Key)
+	}
+	resp, err := svc.httpCli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("chat completions: unexpected status %d: %s", resp.StatusCode, msg)
+	}
+	return resp, nil
+}
+
+func (svc *OpenAIService) BeginChat(ctx context.Context, history ...domain.Message) (service.LLMSession, error) {
+	messages := svc.presetMessages()
+	for _, msg := range history {
+		role := string(msg.Role)
+		if msg.Role == domain.RoleModel {
+			role = "assistant"
+		}
+		messages = append(messages, chatMessage{Role: role, Content: msg.Content})
+	}
+	return &OpenAISession{
+		svc:      svc,
+		messages: messages,
+	}, nil
+}
+
+// OpenAISession 接口本身无状态, 由客户端保存历史消息
+type OpenAISession struct {
+	svc      *OpenAIService
+	messages []chatMessage
+}
+
+func (s *OpenAISession) Ask(ctx context.Context, question string) (domain.Resp, error) {
+	messages := append(s.messages, chatMessage{Role: "user", Content: question})
+	resp, err := s.svc.complete(ctx, chatRequest{
+		Model:       s.svc.cfg.Model,
+		Messages:    messages,
+		Temperature: s.svc.cfg.Temperature,
+	})
+	if err != nil {
+		return domain.Resp{}, err
+	}
+	content := resp.Choices[0].Message.Content
+	s.messages = append(messages, chatMessage{Role: "assistant", Content: content})
+	return domain.Resp{
+		Content: content,
+		Token:   resp.Usage.TotalTokens,
+	}, nil
+}
+
+func (s *OpenAISession) AskStream(ctx context.Context, question string, onChunk func(chunk string) error) (domain.Resp, error) {
+	messages := append(s.messages, chatMessage{Role: "user", Content: question})
+	resp, err := s.svc.stream(ctx, chatRequest{
+		Model:       s.svc.cfg.Model,
+		Messages:    messages,
+		Temperature: s.svc.cfg.Temperature,
+	}, onChunk)
+	if err != nil {
+		return resp, err
+	}
+	s.messages = append(messages, chatMessage{Role: "assistant", Content: resp.Content})
+	return resp, nil
+}
+
+func (s *OpenAISession) Close() error {
+	return nil
+}
@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
+	"github.com/KNICEX/InkFlow/pkg/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAIService_Generate(t *testing.T) {
+	var reqs []chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		var req chatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		reqs = append(reqs, req)
+
+		// 第一轮要求调用工具, 第二轮根据工具结果回答
+		if len(reqs) == 1 {
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"tool_calls":[{"id":"call_1","type":"function",
+				"function":{"name":"get_weather","arguments":"{\"city\":\"杭州\"}"}}]}}],"usage":{"total_tokens":10}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{\"weather\":\"晴\"}"}}],"usage":{"total_tokens":20}}`))
+	}))
+	defer server.Close()
+
+	svc := NewOpenAIService(OpenAIConfig{BaseURL: server.URL + "/v1/", Key: "sk-test", Model: "qwen"})
+	resp, err := svc.Generate(context.Background(), domain.Request{
+		Question: "杭州天气怎么样",
+		Schema:   jsonschema.For[struct{ Weather string }](),
+		Tools: []domain.Tool{{
+			Name: "get_weather",
+			Call: func(ctx context.Context, args json.RawMessage) (any, error) {
+				return "晴", nil
+			},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"weather":"晴"}`, resp.Content)
+	assert.Equal(t, int64(30), resp.Token)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.JSONEq(t, `{"city":"杭州"}`, string(resp.ToolCalls[0].Args))
+
+	require.Len(t, reqs, 2)
+	assert.Equal(t, "qwen", reqs[0].Model)
+	assert.Equal(t, "json_schema", reqs[0].ResponseFormat.Type)
+	require.Len(t, reqs[1].Messages, 3)
+	assert.Equal(t, "tool", reqs[1].Messages[2].Role)
+	assert.Equal(t, "call_1", reqs[1].Messages[2].ToolCallId)
+	assert.Equal(t, `{"result":"晴"}`, reqs[1].Messages[2].Content)
+}
+
+func TestOpenAISession_AskStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(t, req.Stream)
+		// 恢复的历史消息
+		require.Len(t, req.Messages, 3)
+		assert.Equal(t, "assistant", req.Messages[1].Role)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"你\"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"好\"}}]}\n\n" +
+			"data: {\"choices\":[],\"usage\":{\"total_tokens\":8}}\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	svc := NewOpenAIService(OpenAIConfig{BaseURL: server.URL})
+	session, err := svc.BeginChat(context.Background(),
+		domain.Message{Role: domain.RoleUser, Content: "在吗"},
+		domain.Message{Role: domain.RoleModel, Content: "在"},
+	)
+	require.NoError(t, err)
+	var chunks []string
+	resp, err := session.AskStream(context.Background(), "打个招呼", func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"你", "好"}, chunks)
+	assert.Equal(t, domain.Resp{Content: "你好", Token: 8}, resp)
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics llm 调用的 prometheus 指标, 按用途和 provider 区分.
+// 用户维度基数太高, 只记录在 UsageRepo 中
+type Metrics struct {
+	tokens    *prometheus.CounterVec
+	requests  *prometheus.CounterVec
+	duration  *prometheus.HistogramVec
+	exhausted *prometheus.CounterVec
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		tokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ink_flow",
+			Subsystem: "llm",
+			Name:      "tokens_total",
+			Help:      "llm token usage",
+		}, []string{"purpose", "provider"}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ink_flow",
+			Subsystem: "llm",
+			Name:      "requests_total",
+			Help:      "llm requests",
+		}, []string{"purpose", "provider", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "ink_flow",
+			Subsystem: "llm",
+			Name:      "request_seconds",
+			Help:      "llm request duration",
+			Buckets:   []float64{0.5, 1, 2, 5, 10, 20, 40, 60},
+		}, []string{"purpose", "provider"}),
+		exhausted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ink_flow",
+			Subsystem: "llm",
+			Name:      "budget_exhausted_total",
+			Help:      "llm requests rejected by budget",
+		}, []string{"purpose"}),
+	}
+}
+
+func (m *Metrics) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.tokens, m.requests, m.duration, m.exhausted}
+}
+
+func (m *Metrics) observe(ctx context.Context, provider string, start time.Time, resp domain.Resp, err error) {
+	purpose := string(PurposeFrom(ctx))
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	m.requests.WithLabelValues(purpose, provider, status).Inc()
+	m.duration.WithLabelValues(purpose, provider).Observe(time.Since(start).Seconds())
+	if resp.Token > 0 {
+		m.tokens.WithLabelValues(purpose, provider).Add(float64(resp.Token))
+	}
+}
+
+// Wrap 记录单个 provider 的调用指标
+func (m *Metrics) Wrap(provider string, svc LLMService) LLMService {
+	return &metricsLLMService{
+		svc:      svc,
+		provider: provider,
+		m:        m,
+	}
+}
+
+type metricsLLMService struct {
+	svc      LLMService
+	provider string
+	m        *Metrics
+}
+
+func (s *metricsLLMService) AskOnce(ctx context.Context, question string) (domain.Resp, error) {
+	start := time.Now()
+	resp, err := s.svc.AskOnce(ctx, question)
+	s.m.observe(ctx, s.provider, start, resp, err)
+	return resp, err
+}
+
+func (s *metricsLLMService) AskWithImages(ctx context.Context, question string, images []domain.Image) (domain.Resp, error) {
+	start := time.Now()
+	resp, err := s.svc.AskWithImages(ctx, question, images)
+	s.m.observe(ctx, s.provider, start, resp, err)
+	return resp, err
+}
+
+func (s *metricsLLMService) Generate(ctx context.Context, req domain.Request) (domain.Resp, error) {
+	start := time.Now()
+	resp, err := s.svc.Generate(ctx, req)
+	s.m.observe(ctx, s.provider, start, resp, err)
+	return resp, err
+}
+
+func (s *metricsLLMService) BeginChat(ctx context.Context, history ...domain.Message) (LLMSession, error) {
+	session, err := s.svc.BeginChat(ctx, history...)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsSession{
+		session:  session,
+		provider: s.provider,
+		m:        s.m,
+	}, nil
+}
+
+type metricsSession struct {
+	session  LLMSession
+	provider string
+	m        *Metrics
+}
+
+func (s *metricsSession) Ask(ctx context.Context, question string) (domain.Resp, error) {
+	start := time.Now()
+	resp, err := s.session.Ask(ctx, question)
+	s.m.observe(ctx, s.provider, start, resp, err)
+	return resp, err
+}
+
+func (s *metricsSession) AskStream(ctx context.Context, question string, onChunk func(chunk string) error) (domain.Resp, error) {
+	start := time.Now()
+	resp, err := s.session.AskStream(ctx, question, onChunk)
+	s.m.observe(ctx, s.provider, start, resp, err)
+	return resp, err
+}
+
+func (s *metricsSession) Close() error {
+	return s.session.Close()
+}
+
+// healthCollector 采集时读取 provider 的熔断状态、错误率和耗时
+type healthCollector struct {
+	f         *FailoverLLMService
+	state     *prometheus.Desc
+	errorRate *prometheus.Desc
+	latency   *prometheus.Desc
+}
+
+// NewHealthCollector 暴露 FailoverLLMService 中每个 provider 的健康状况
+func NewHealthCollector(f *FailoverLLMService) prometheus.Collector {
+	return &healthCollector{
+		f: f,
+		state: prometheus.NewDesc("ink_flow_llm_provider_circuit_state",
+			"llm provider circuit state, 1 for the current state", []string{"provider", "state"}, nil),
+		errorRate: prometheus.NewDesc("ink_flow_llm_provider_error_rate",
+			"llm provider error rate in the breaker window", []string{"provider"}, nil),
+		latency: prometheus.NewDesc("ink_flow_llm_provider_latency_seconds",
+			"moving average of llm provider successful request latency", []string{"provider"}, nil),
+	}
+}
+
+func (c *healthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.state
+	ch <- c.errorRate
+	ch <- c.latency
+}
+
+func (c *healthCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, h := range c.f.Health() {
+		for _, state := range []CircuitState{CircuitClosed, CircuitOpen, CircuitHalfOpen} {
+			v := 0.0
+			if h.State == state {
+				v = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.state, prometheus.GaugeValue, v, h.Name, string(state))
+		}
+		ch <- prometheus.MustNewConstMetric(c.errorRate, prometheus.GaugeValue, h.ErrorRate, h.Name)
+		ch <- prometheus.MustNewConstMetric(c.latency, prometheus.GaugeValue, h.Latency.Seconds(), h.Name)
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
+	"github.com/KNICEX/InkFlow/pkg/jsonschema"
+)
+
+// ErrSchemaMismatch 重试后模型的输出仍然不符合 schema
+var ErrSchemaMismatch = errors.New("llm output does not match schema")
+
+// maxSchemaRetries 输出不符合 schema 时带上错误重新提问的次数
+const maxSchemaRetries = 2
+
+// Structured 要求模型按 T 的 schema 输出, 校验通过后解析为 T.
+// req.Schema 为空时由 T 反射生成; 校验失败时把错误告诉模型重新生成, Resp.Token 为所有请求的总和
+func Structured[T any](ctx context.Context, svc LLMService, req domain.Request) (T, domain.Resp, error) {
+	var res T
+	if req.Schema == nil {
+		req.Schema = jsonschema.For[T]()
+	}
+	question := req.Question
+	var total domain.Resp
+	for i := 0; ; i++ {
+		resp, err := svc.Generate(ctx, req)
+		if err != nil {
+			return res, total, err
+		}
+		total.Token += resp.Token
+		total.ToolCalls = append(total.ToolCalls, resp.ToolCalls...)
+		total.Content = trimCodeFence(resp.Content)
+
+		err = req.Schema.Validate([]byte(total.Content))
+		if err == nil {
+			err = json.Unmarshal([]byte(total.Content), &res)
+		}
+		if err == nil {
+			return res, total, nil
+		}
+		if i >= maxSchemaRetries {
+			return res, total, fmt.Errorf("%w: %w", ErrSchemaMismatch, err)
+		}
+		req.Question = fmt.Sprintf("%s\n\n你上一次的输出不符合要求: %s\n请只输出符合 schema 的 JSON。", question, err)
+	}
+}
+
+// trimCodeFence 去掉模型输出中包裹 json 的 markdown 代码块
+func trimCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	lines = lines[1:]
+	if len(lines) > 0 && strings.HasPrefix(strings.TrimSpace(lines[len(lines)-1]), "```") {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type scriptedLLMService struct {
+	LLMService
+	outputs   []string
+	questions []string
+}
+
+func (s *scriptedLLMService) Generate(ctx context.Context, req domain.Request) (domain.Resp, error) {
+	s.questions = append(s.questions, req.Question)
+	out := s.outputs[0]
+	if len(s.outputs) > 1 {
+		s.outputs = s.outputs[1:]
+	}
+	return domain.Resp{Content: out, Token: 10}, nil
+}
+
+type reviewOutput struct {
+	Passed     bool   `json:"passed"`
+	Reason     string `json:"reason,omitempty"`
+	Confidence int64  `json:"confidence" minimum:"0" maximum:"100"`
+}
+
+func TestStructured(t *testing.T) {
+	ctx := context.Background()
+
+	svc := &scriptedLLMService{outputs: []string{
+		`{"passed": true}`,
+		"```json\n{\"passed\": true, \"confidence\": 90}\n```",
+	}}
+	out, resp, err := Structured[reviewOutput](ctx, svc, domain.Request{Question: "审核"})
+	require.NoError(t, err)
+	assert.Equal(t, reviewOutput{Passed: true, Confidence: 90}, out)
+	assert.Equal(t, int64(20), resp.Token)
+	require.Len(t, svc.questions, 2)
+	assert.Contains(t, svc.questions[1], `missing required property "confidence"`)
+
+	svc = &scriptedLLMService{outputs: []string{`{"passed": true, "confidence": 120}`}}
+	_, resp, err = Structured[reviewOutput](ctx, svc, domain.Request{Question: "审核"})
+	assert.ErrorIs(t, err, ErrSchemaMismatch)
+	assert.Len(t, svc.questions, maxSchemaRetries+1)
+	assert.Equal(t, int64(10*(maxSchemaRetries+1)), resp.Token)
+}
@@ -2,15 +2,27 @@ package service
 
 import (
 	"context"
+	"errors"
 	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
 )
 
+// ErrImageUnavailable 图片无法下载或不符合要求, 是请求本身的问题, 换 provider 重试也没有意义
+var ErrImageUnavailable = errors.New("image unavailable")
+
 type LLMSession interface {
 	Ask(ctx context.Context, question string) (domain.Resp, error)
+	// AskStream 流式回答, 每收到一段内容调用一次 onChunk, onChunk 返回错误时中止.
+	// 返回的 Resp 包含完整的回答
+	AskStream(ctx context.Context, question string, onChunk func(chunk string) error) (domain.Resp, error)
 	Close() error
 }
 
 type LLMService interface {
 	AskOnce(ctx context.Context, question string) (domain.Resp, error)
-	BeginChat(ctx context.Context) (LLMSession, error)
+	// AskWithImages 携带图片提问, 用于图片审核等多模态场景
+	AskWithImages(ctx context.Context, question string, images []domain.Image) (domain.Resp, error)
+	// Generate 支持结构化输出和工具调用, 结构化输出需要调用方校验, 一般使用 Structured
+	Generate(ctx context.Context, req domain.Request) (domain.Resp, error)
+	// BeginChat history 为之前的对话, system 消息作为系统指令
+	BeginChat(ctx context.Context, history ...domain.Message) (LLMSession, error)
 }
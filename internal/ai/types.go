@@ -1,7 +1,56 @@
 package ai
 
 import (
+	"context"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
 	"github.com/KNICEX/InkFlow/internal/ai/internal/service"
 )
 
 type LLMService = service.LLMService
+type LLMSession = service.LLMSession
+
+type Resp = domain.Resp
+type Image = domain.Image
+type Request = domain.Request
+type Tool = domain.Tool
+type ToolCall = domain.ToolCall
+type Message = domain.Message
+type Role = domain.Role
+
+const (
+	RoleSystem = domain.RoleSystem
+	RoleUser   = domain.RoleUser
+	RoleModel  = domain.RoleModel
+)
+
+type Purpose = domain.Purpose
+
+const (
+	PurposeReview  = domain.PurposeReview
+	PurposeTagging = domain.PurposeTagging
+	PurposeSummary = domain.PurposeSummary
+	PurposeChat    = domain.PurposeChat
+)
+
+var (
+	ErrSchemaMismatch  = service.ErrSchemaMismatch
+	ErrBudgetExhausted = service.ErrBudgetExhausted
+	// ErrImageUnavailable 图片无法下载, 调用方可以跳过图片或转人工
+	ErrImageUnavailable = service.ErrImageUnavailable
+)
+
+// WithPurpose 见 service.WithPurpose
+func WithPurpose(ctx context.Context, purpose Purpose) context.Context {
+	return service.WithPurpose(ctx, purpose)
+}
+
+// WithUser 见 service.WithUser
+func WithUser(ctx context.Context, uid int64) context.Context {
+	return service.WithUser(ctx, uid)
+}
+
+// Structured 见 service.Structured
+func Structured[T any](ctx context.Context, svc LLMService, req Request) (T, Resp, error) {
+	return service.Structured[T](ctx, svc, req)
+}
@@ -1,15 +1,136 @@
 package ai
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/ai/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/ai/internal/repo"
+	"github.com/KNICEX/InkFlow/internal/ai/internal/repo/cache"
 	"github.com/KNICEX/InkFlow/internal/ai/internal/service"
 	"github.com/KNICEX/InkFlow/internal/ai/internal/service/llm"
+	"github.com/KNICEX/InkFlow/pkg/logx"
 	"github.com/google/generative-ai-go/genai"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
 )
 
-func InitLLMService(cli []*genai.Client) LLMService {
-	svcs := make([]LLMService, 0, len(cli))
-	for _, c := range cli {
-		svcs = append(svcs, llm.NewGeminiService(c))
+// initOpenAIProviders 读取 llm.openai 配置, 兼容 openai 接口的服务, 如 vLLM、Ollama
+func initOpenAIProviders() []service.Provider {
+	type Config struct {
+		Name         string        `mapstructure:"name"`
+		BaseURL      string        `mapstructure:"base_url"`
+		Key          string        `mapstructure:"key"`
+		Model        string        `mapstructure:"model"`
+		Temperature  float32       `mapstructure:"temperature"`
+		InlineImages bool          `mapstructure:"inline_images"`
+		Timeout      time.Duration `mapstructure:"timeout"`
+	}
+	var cfgs []Config
+	if err := viper.UnmarshalKey("llm.openai", &cfgs); err != nil {
+		panic(err)
+	}
+	providers := make([]service.Provider, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		if cfg.Name == "" {
+			cfg.Name = fmt.Sprintf("openai-%d", i)
+		}
+		providers = append(providers, service.Provider{
+			Name: cfg.Name,
+			Svc: llm.NewOpenAIService(llm.OpenAIConfig{
+				BaseURL:      cfg.BaseURL,
+				Key:          cfg.Key,
+				Model:        cfg.Model,
+				Temperature:  cfg.Temperature,
+				InlineImages: cfg.InlineImages,
+				Timeout:      cfg.Timeout,
+			}),
+		})
+	}
+	return providers
+}
+
+// initBreakerConfig 读取 llm.breaker 配置, 未配置或非法的项使用默认值
+func initBreakerConfig() service.BreakerConfig {
+	type Config struct {
+		Window       int           `mapstructure:"window"`
+		MinRequests  int           `mapstructure:"min_requests"`
+		ErrorRate    float64       `mapstructure:"error_rate"`
+		OpenDuration time.Duration `mapstructure:"open_duration"`
+	}
+	def := service.DefaultBreakerConfig
+	cfg := Config{
+		Window:       def.Window,
+		MinRequests:  def.MinRequests,
+		ErrorRate:    def.ErrorRate,
+		OpenDuration: def.OpenDuration,
+	}
+	if err := viper.UnmarshalKey("llm.breaker", &cfg); err != nil {
+		panic(err)
+	}
+	return service.BreakerConfig{
+		Window:       cfg.Window,
+		MinRequests:  cfg.MinRequests,
+		ErrorRate:    cfg.ErrorRate,
+		OpenDuration: cfg.OpenDuration,
+	}
+}
+
+// initBudgetConfig 读取 llm.budget 配置, 0 表示不限制
+func initBudgetConfig() domain.BudgetConfig {
+	type Budget struct {
+		Daily     int64 `mapstructure:"daily"`
+		Monthly   int64 `mapstructure:"monthly"`
+		UserDaily int64 `mapstructure:"user_daily"`
+	}
+	type Config struct {
+		Daily    int64             `mapstructure:"daily"`
+		Monthly  int64             `mapstructure:"monthly"`
+		Purposes map[string]Budget `mapstructure:"purposes"`
+	}
+	var cfg Config
+	if err := viper.UnmarshalKey("llm.budget", &cfg); err != nil {
+		panic(err)
 	}
-	return service.NewFailoverService(svcs)
+	res := domain.BudgetConfig{
+		Total: domain.Budget{
+			Daily:   cfg.Daily,
+			Monthly: cfg.Monthly,
+		},
+		Purposes: make(map[domain.Purpose]domain.Budget, len(cfg.Purposes)),
+	}
+	for purpose, b := range cfg.Purposes {
+		res.Purposes[domain.Purpose(purpose)] = domain.Budget{
+			Daily:     b.Daily,
+			Monthly:   b.Monthly,
+			UserDaily: b.UserDaily,
+		}
+	}
+	return res
+}
+
+func InitLLMService(cli []*genai.Client, cmd redis.Cmdable, l logx.Logger) LLMService {
+	metrics := service.NewMetrics()
+	prometheus.MustRegister(metrics.Collectors()...)
+
+	providers := make([]service.Provider, 0, len(cli))
+	for i, c := range cli {
+		providers = append(providers, service.Provider{
+			Name: fmt.Sprintf("gemini-%d", i),
+			Svc:  llm.NewGeminiService(c),
+		})
+	}
+	providers = append(providers, initOpenAIProviders()...)
+	for i, p := range providers {
+		providers[i].Svc = metrics.Wrap(p.Name, p.Svc)
+	}
+
+	failover := service.NewFailoverService(providers,
+		service.WithBreaker(initBreakerConfig()),
+		service.WithLogger(l),
+	)
+	prometheus.MustRegister(service.NewHealthCollector(failover))
+	usageRepo := repo.NewCachedUsageRepo(cache.NewRedisUsageCache(cmd))
+	return service.NewBudgetService(failover, usageRepo, initBudgetConfig(), metrics, l)
 }
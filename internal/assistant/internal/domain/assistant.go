@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// Session 写作助手会话, 绑定到一篇草稿
+type Session struct {
+	Id    int64
+	Uid   int64
+	InkId int64
+	Title string
+	// Summary 超出上下文长度后, 较早的对话被压缩为摘要
+	Summary string
+	// SummaryUntil 已经压缩进摘要的最后一条消息 id
+	SummaryUntil int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+type Message struct {
+	Id        int64
+	SessionId int64
+	Role      Role
+	Action    Action
+	// Content 展示给用户的内容
+	Content string
+	// Prompt 实际发送给模型的内容, 为空时与 Content 相同
+	Prompt    string
+	Token     int64
+	CreatedAt time.Time
+}
+
+// Action 内置的写作动作, 没有指定内容时作用于整篇草稿
+type Action string
+
+const (
+	ActionChat      Action = "chat"
+	ActionContinue  Action = "continue"
+	ActionPolish    Action = "polish"
+	ActionTranslate Action = "translate"
+	ActionTitle     Action = "title"
+	ActionSummary   Action = "summary"
+)
+
+type ChatReq struct {
+	Action  Action
+	Content string
+	// Lang 翻译的目标语言
+	Lang string
+}
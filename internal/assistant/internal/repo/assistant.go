@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/KNICEX/InkFlow/internal/assistant/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/assistant/internal/repo/dao"
+	"github.com/samber/lo"
+)
+
+var ErrSessionNotFound = dao.ErrRecordNotFound
+
+type AssistantRepo interface {
+	CreateSession(ctx context.Context, session domain.Session) (int64, error)
+	FindSession(ctx context.Context, id int64) (domain.Session, error)
+	FindSessions(ctx context.Context, uid, inkId int64, offset, limit int) ([]domain.Session, error)
+	UpdateSummary(ctx context.Context, id int64, summary string, until int64) error
+	DelSession(ctx context.Context, id int64) error
+
+	CreateMessages(ctx context.Context, msgs []domain.Message) error
+	FindMessages(ctx context.Context, sessionId, maxId int64, limit int) ([]domain.Message, error)
+	FindMessagesAfter(ctx context.Context, sessionId, minId int64) ([]domain.Message, error)
+}
+
+type assistantRepo struct {
+	dao dao.AssistantDAO
+}
+
+func NewAssistantRepo(dao dao.AssistantDAO) AssistantRepo {
+	return &assistantRepo{
+		dao: dao,
+	}
+}
+
+func (r *assistantRepo) CreateSession(ctx context.Context, session domain.Session) (int64, error) {
+	return r.dao.InsertSession(ctx, r.sessionToEntity(session))
+}
+
+func (r *assistantRepo) FindSession(ctx context.Context, id int64) (domain.Session, error) {
+	session, err := r.dao.FindSession(ctx, id)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	return r.sessionToDomain(session), nil
+}
+
+func (r *assistantRepo) FindSessions(ctx context.Context, uid, inkId int64, offset, limit int) ([]domain.Session, error) {
+	sessions, err := r.dao.FindSessions(ctx, uid, inkId, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(sessions, func(item dao.AssistantSession, index int) domain.Session {
+		return r.sessionToDomain(item)
+	}), nil
+}
+
+func (r *assistantRepo) UpdateSummary(ctx context.Context, id int64, summary string, until int64) error {
+	return r.dao.UpdateSummary(ctx, id, summary, until)
+}
+
+func (r *assistantRepo) DelSession(ctx context.Context, id int64) error {
+	return r.dao.DeleteSession(ctx, id)
+}
+
+func (r *assistantRepo) CreateMessages(ctx context.Context, msgs []domain.Message) error {
+	return r.dao.InsertMessages(ctx, lo.Map(msgs, func(item domain.Message, index int) dao.AssistantMessage {
+		return r.messageToEntity(item)
+	}))
+}
+
+func (r *assistantRepo) FindMessages(ctx context.Context, sessionId, maxId int64, limit int) ([]domain.Message, error) {
+	msgs, err := r.dao.FindMessages(ctx, sessionId, maxId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(msgs, func(item dao.AssistantMessage, index int) domain.Message {
+		return r.messageToDomain(item)
+	}), nil
+}
+
+func (r *assistantRepo) FindMessagesAfter(ctx context.Context, sessionId, minId int64) ([]domain.Message, error) {
+	msgs, err := r.dao.FindMessagesAfter(ctx, sessionId, minId)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(msgs, func(item dao.AssistantMessage, index int) domain.Message {
+		return r.messageToDomain(item)
+	}), nil
+}
+
+func (r *assistantRepo) sessionToEntity(session domain.Session) dao.AssistantSession {
+	return dao.AssistantSession{
+		Id:           session.Id,
+		Uid:          session.Uid,
+		InkId:        session.InkId,
+		Title:        session.Title,
+		Summary:      session.Summary,
+		SummaryUntil: session.SummaryUntil,
+		CreatedAt:    session.CreatedAt,
+		UpdatedAt:    session.UpdatedAt,
+	}
+}
+
+func (r *assistantRepo) sessionToDomain(session dao.AssistantSession) domain.Session {
+	return domain.Session{
+		Id:           session.Id,
+		Uid:          session.Uid,
+		InkId:        session.InkId,
+		Title:        session.Title,
+		Summary:      session.Summary,
+		SummaryUntil: session.SummaryUntil,
+		CreatedAt:    session.CreatedAt,
+		UpdatedAt:    session.UpdatedAt,
+	}
+}
+
+func (r *assistantRepo) messageToEntity(msg domain.Message) dao.AssistantMessage {
+	return dao.AssistantMessage{
+		Id:        msg.Id,
+		SessionId: msg.SessionId,
+		Role:      string(msg.Role),
+		Action:    string(msg.Action),
+		Content:   msg.Content,
+		Prompt:    msg.Prompt,
+		Token:     msg.Token,
+		CreatedAt: msg.CreatedAt,
+	}
+}
+
+func (r *assistantRepo) messageToDomain(msg dao.AssistantMessage) domain.Message {
+	return domain.Message{
+		Id:        msg.Id,
+		SessionId: msg.SessionId,
+		Role:      domain.Role(msg.Role),
+		Action:    domain.Action(msg.Action),
+		Content:   msg.Content,
+		Prompt:    msg.Prompt,
+		Token:     msg.Token,
+		CreatedAt: msg.CreatedAt,
+	}
+}
@@ -0,0 +1,140 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"gorm.io/gorm"
+)
+
+var ErrRecordNotFound = gorm.ErrRecordNotFound
+
+type AssistantSession struct {
+	Id           int64
+	Uid          int64 `gorm:"index:uid_ink"`
+	InkId        int64 `gorm:"index:uid_ink"`
+	Title        string
+	Summary      string
+	SummaryUntil int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type AssistantMessage struct {
+	Id        int64
+	SessionId int64 `gorm:"index"`
+	Role      string
+	Action    string
+	Content   string
+	Prompt    string
+	Token     int64
+	CreatedAt time.Time
+}
+
+type AssistantDAO interface {
+	InsertSession(ctx context.Context, session AssistantSession) (int64, error)
+	FindSession(ctx context.Context, id int64) (AssistantSession, error)
+	// FindSessions inkId 为 0 时查找用户所有的会话
+	FindSessions(ctx context.Context, uid, inkId int64, offset, limit int) ([]AssistantSession, error)
+	UpdateSummary(ctx context.Context, id int64, summary string, until int64) error
+	// DeleteSession 同时删除会话中的消息
+	DeleteSession(ctx context.Context, id int64) error
+
+	// InsertMessages 同一轮对话的提问和回答一起保存
+	InsertMessages(ctx context.Context, msgs []AssistantMessage) error
+	// FindMessages 按 id 倒序, maxId 为 0 时从最新的开始
+	FindMessages(ctx context.Context, sessionId, maxId int64, limit int) ([]AssistantMessage, error)
+	// FindMessagesAfter 按 id 正序查找 minId 之后的所有消息
+	FindMessagesAfter(ctx context.Context, sessionId, minId int64) ([]AssistantMessage, error)
+}
+
+type GormAssistantDAO struct {
+	db   *gorm.DB
+	node snowflakex.Node
+}
+
+func NewGormAssistantDAO(db *gorm.DB, node snowflakex.Node) AssistantDAO {
+	return &GormAssistantDAO{
+		db:   db,
+		node: node,
+	}
+}
+
+func (dao *GormAssistantDAO) InsertSession(ctx context.Context, session AssistantSession) (int64, error) {
+	now := time.Now()
+	session.Id = dao.node.NextID()
+	session.CreatedAt = now
+	session.UpdatedAt = now
+	err := dao.db.WithContext(ctx).Create(&session).Error
+	return session.Id, err
+}
+
+func (dao *GormAssistantDAO) FindSession(ctx context.Context, id int64) (AssistantSession, error) {
+	var session AssistantSession
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&session).Error
+	return session, err
+}
+
+func (dao *GormAssistantDAO) FindSessions(ctx context.Context, uid, inkId int64, offset, limit int) ([]AssistantSession, error) {
+	var sessions []AssistantSession
+	tx := dao.db.WithContext(ctx).Where("uid = ?", uid)
+	if inkId > 0 {
+		tx = tx.Where("ink_id = ?", inkId)
+	}
+	err := tx.Order("updated_at desc").Offset(offset).Limit(limit).Find(&sessions).Error
+	return sessions, err
+}
+
+func (dao *GormAssistantDAO) UpdateSummary(ctx context.Context, id int64, summary string, until int64) error {
+	return dao.db.WithContext(ctx).Model(&AssistantSession{}).Where("id = ?", id).Updates(map[string]any{
+		"summary":       summary,
+		"summary_until": until,
+		"updated_at":    time.Now(),
+	}).Error
+}
+
+func (dao *GormAssistantDAO) DeleteSession(ctx context.Context, id int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", id).Delete(&AssistantMessage{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&AssistantSession{}).Error
+	})
+}
+
+func (dao *GormAssistantDAO) InsertMessages(ctx context.Context, msgs []AssistantMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for i := range msgs {
+		msgs[i].Id = dao.node.NextID()
+		msgs[i].CreatedAt = now
+	}
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&msgs).Error; err != nil {
+			return err
+		}
+		// 最近有对话的会话排在前面
+		return tx.Model(&AssistantSession{}).Where("id = ?", msgs[0].SessionId).
+			Update("updated_at", now).Error
+	})
+}
+
+func (dao *GormAssistantDAO) FindMessages(ctx context.Context, sessionId, maxId int64, limit int) ([]AssistantMessage, error) {
+	var msgs []AssistantMessage
+	tx := dao.db.WithContext(ctx).Where("session_id = ?", sessionId)
+	if maxId > 0 {
+		tx = tx.Where("id < ?", maxId)
+	}
+	err := tx.Order("id desc").Limit(limit).Find(&msgs).Error
+	return msgs, err
+}
+
+func (dao *GormAssistantDAO) FindMessagesAfter(ctx context.Context, sessionId, minId int64) ([]AssistantMessage, error) {
+	var msgs []AssistantMessage
+	err := dao.db.WithContext(ctx).Where("session_id = ? AND id > ?", sessionId, minId).
+		Order("id asc").Find(&msgs).Error
+	return msgs, err
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/KNICEX/InkFlow/internal/ai"
+	"github.com/KNICEX/InkFlow/internal/assistant/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/assistant/internal/repo"
+	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+)
+
+var (
+	ErrSessionNotFound = errors.New("assistant session not found")
+	ErrInvalidAction   = errors.New("invalid assistant action")
+	ErrEmptyContent    = errors.New("empty content")
+)
+
+const systemPrompt = `你是一个中文写作助手，帮助作者完成文章的构思、续写、润色和翻译。
+回答直接给出结果，不要重复作者的要求，不要添加与写作无关的内容。`
+
+const summaryPrompt = `请把以下写作助手与作者的对话压缩为一段摘要，保留作者的写作意图、已经确定的内容和风格要求，不超过 500 字。
+{{if .summary}}之前的摘要：{{.summary}}
+{{end}}
+对话：
+{{range .messages}}{{.Role}}：{{.Content}}
+{{end}}`
+
+// actionPrompts 内置动作的提示词, content 为选中的内容或者整篇草稿
+var actionPrompts = map[domain.Action]string{
+	domain.ActionContinue:  "请接着以下内容续写，保持文风和人称一致，只输出续写的部分：\n{{.content}}",
+	domain.ActionPolish:    "请润色以下内容，修正语病，让表达更流畅，不要改变原意，只输出润色后的内容：\n{{.content}}",
+	domain.ActionTranslate: "请把以下内容翻译为{{.lang}}，只输出译文：\n{{.content}}",
+	domain.ActionTitle:     "请为以下文章拟 5 个标题，每行一个，不要编号：\n{{.content}}",
+	domain.ActionSummary:   "请为以下文章写一段 100 字以内的摘要：\n{{.content}}",
+}
+
+// Config 上下文长度按字符数估算, 中文约一个字一个 token, 英文会偏保守
+type Config struct {
+	// ContextLimit 摘要加历史消息的最大长度, 超过后把较早的消息压缩为摘要
+	ContextLimit int
+	// KeepRecent 压缩时保留的最近消息数
+	KeepRecent int
+	// MaxInput 单条消息的最大长度, 超出部分截断
+	MaxInput int
+}
+
+type AssistantService interface {
+	// CreateSession 只能为自己的草稿创建会话
+	CreateSession(ctx context.Context, uid, inkId int64) (domain.Session, error)
+	// ListSessions inkId 为 0 时列出所有草稿的会话
+	ListSessions(ctx context.Context, uid, inkId int64, offset, limit int) ([]domain.Session, error)
+	DelSession(ctx context.Context, uid, id int64) error
+	// History 按时间倒序, maxId 为 0 时从最新的开始
+	History(ctx context.Context, uid, id, maxId int64, limit int) ([]domain.Message, error)
+	// Chat 流式回答, 每收到一段内容调用一次 onChunk, 回答完成后保存本轮对话并返回回答
+	Chat(ctx context.Context, uid, id int64, req domain.ChatReq, onChunk func(chunk string) error) (domain.Message, error)
+}
+
+type assistantService struct {
+	repo      repo.AssistantRepo
+	inkSvc    ink.Service
+	llm       ai.LLMService
+	cfg       Config
+	actions   map[domain.Action]*template.Template
+	summaryTp *template.Template
+	l         logx.Logger
+}
+
+func NewAssistantService(repo repo.AssistantRepo, inkSvc ink.Service, llm ai.LLMService, cfg Config, l logx.Logger) AssistantService {
+	actions := make(map[domain.Action]*template.Template, len(actionPrompts))
+	for action, prompt := range actionPrompts {
+		actions[action] = template.Must(template.New(string(action)).Parse(prompt))
+	}
+	return &assistantService{
+		repo:      repo,
+		inkSvc:    inkSvc,
+		llm:       llm,
+		cfg:       cfg,
+		actions:   actions,
+		summaryTp: template.Must(template.New("summary").Parse(summaryPrompt)),
+		l:         l,
+	}
+}
+
+func (svc *assistantService) CreateSession(ctx context.Context, uid, inkId int64) (domain.Session, error) {
+	draft, err := svc.inkSvc.FindDraftInk(ctx, inkId, uid)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	session := domain.Session{
+		Uid:   uid,
+		InkId: inkId,
+		Title: draft.Title,
+	}
+	session.Id, err = svc.repo.CreateSession(ctx, session)
+	return session, err
+}
+
+func (svc *assistantService) ListSessions(ctx context.Context, uid, inkId int64, offset, limit int) ([]domain.Session, error) {
+	return svc.repo.FindSessions(ctx, uid, inkId, offset, limit)
+}
+
+func (svc *assistantService) DelSession(ctx context.Context, uid, id int64) error {
+	if _, err := svc.findSession(ctx, uid, id); err != nil {
+		return err
+	}
+	return svc.repo.DelSession(ctx, id)
+}
+
+func (svc *assistantService) History(ctx context.Context, uid, id, maxId int64, limit int) ([]domain.Message, error) {
+	if _, err := svc.findSession(ctx, uid, id); err != nil {
+		return nil, err
+	}
+	return svc.repo.FindMessages(ctx, id, maxId, limit)
+}
+
+func (svc *assistantService) Chat(ctx context.Context, uid, id int64, req domain.ChatReq, onChunk func(chunk string) error) (domain.Message, error) {
+	session, err := svc.findSession(ctx, uid, id)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	prompt, err := svc.buildPrompt(ctx, session, req)
+	if err != nil {
+		return domain.Message{}, err
+	}
+
+	ctx = ai.WithUser(ai.WithPurpose(ctx, ai.PurposeChat), uid)
+	history, err := svc.history(ctx, &session, prompt)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	llmSession, err := svc.llm.BeginChat(ctx, history...)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	defer llmSession.Close()
+	resp, err := llmSession.AskStream(ctx, prompt, onChunk)
+	if err != nil {
+		return domain.Message{}, err
+	}
+
+	question := domain.Message{
+		SessionId: id,
+		Role:      domain.RoleUser,
+		Action:    req.Action,
+		Content:   svc.truncate(req.Content),
+	}
+	if prompt != question.Content {
+		question.Prompt = prompt
+	}
+	answer := domain.Message{
+		SessionId: id,
+		Role:      domain.RoleAssistant,
+		Action:    req.Action,
+		Content:   resp.Content,
+		Token:     resp.Token,
+	}
+	msgs := []domain.Message{question, answer}
+	if err = svc.repo.CreateMessages(ctx, msgs); err != nil {
+		return domain.Message{}, err
+	}
+	return msgs[1], nil
+}
+
+func (svc *assistantService) findSession(ctx context.Context, uid, id int64) (domain.Session, error) {
+	session, err := svc.repo.FindSession(ctx, id)
+	if errors.Is(err, repo.ErrSessionNotFound) || (err == nil && session.Uid != uid) {
+		return domain.Session{}, ErrSessionNotFound
+	}
+	return session, err
+}
+
+// buildPrompt 内置动作没有指定内容时使用整篇草稿
+func (svc *assistantService) buildPrompt(ctx context.Context, session domain.Session, req domain.ChatReq) (string, error) {
+	content := strings.TrimSpace(req.Content)
+	if req.Action == "" || req.Action == domain.ActionChat {
+		if content == "" {
+			return "", ErrEmptyContent
+		}
+		return svc.truncate(content), nil
+	}
+	tpl, ok := svc.actions[req.Action]
+	if !ok {
+		return "", ErrInvalidAction
+	}
+	if content == "" {
+		draft, err := svc.inkSvc.FindDraftInk(ctx, session.InkId, session.Uid)
+		if err != nil {
+			return "", err
+		}
+		content = strings.TrimSpace(draft.Title + "\n" + draft.PlainText())
+		if content == "" {
+			return "", ErrEmptyContent
+		}
+	}
+	lang := req.Lang
+	if lang == "" {
+		lang = "英文"
+	}
+	var bs bytes.Buffer
+	err := tpl.Execute(&bs, map[string]any{
+		"content": svc.truncate(content),
+		"lang":    lang,
+	})
+	return bs.String(), err
+}
+
+// history 恢复会话的上下文, 超出长度时把较早的消息压缩为摘要, 压缩失败时直接丢弃较早的消息
+func (svc *assistantService) history(ctx context.Context, session *domain.Session, prompt string) ([]ai.Message, error) {
+	msgs, err := svc.repo.FindMessagesAfter(ctx, session.Id, session.SummaryUntil)
+	if err != nil {
+		return nil, err
+	}
+	size := func() int {
+		n := utf8.RuneCountInString(session.Summary) + utf8.RuneCountInString(prompt)
+		for _, msg := range msgs {
+			n += utf8.RuneCountInString(svc.promptOf(msg))
+		}
+		return n
+	}
+	if size() > svc.cfg.ContextLimit && len(msgs) > svc.cfg.KeepRecent {
+		old := msgs[:len(msgs)-svc.cfg.KeepRecent]
+		summary, err := svc.summarize(ctx, session.Summary, old)
+		if err == nil {
+			until := old[len(old)-1].Id
+			err = svc.repo.UpdateSummary(ctx, session.Id, summary, until)
+			session.Summary, session.SummaryUntil = summary, until
+		}
+		if err != nil {
+			svc.l.WithCtx(ctx).Warn("summarize assistant session error",
+				logx.Int64("sessionId", session.Id), logx.Error(err))
+		}
+		msgs = msgs[len(old):]
+	}
+	// 保留的消息仍然太长时, 成对丢弃最早的消息, 保证以提问开头
+	for size() > svc.cfg.ContextLimit && len(msgs) >= 2 {
+		msgs = msgs[2:]
+	}
+
+	system := systemPrompt
+	if session.Title != "" {
+		system += "\n当前文章标题：" + session.Title
+	}
+	if session.Summary != "" {
+		system += "\n之前对话的摘要：" + session.Summary
+	}
+	history := make([]ai.Message, 0, len(msgs)+1)
+	history = append(history, ai.Message{Role: ai.RoleSystem, Content: system})
+	for _, msg := range msgs {
+		role := ai.RoleUser
+		if msg.Role == domain.RoleAssistant {
+			role = ai.RoleModel
+		}
+		history = append(history, ai.Message{Role: role, Content: svc.promptOf(msg)})
+	}
+	return history, nil
+}
+
+func (svc *assistantService) summarize(ctx context.Context, summary string, msgs []domain.Message) (string, error) {
+	type item struct {
+		Role    string
+		Content string
+	}
+	items := make([]item, 0, len(msgs))
+	for _, msg := range msgs {
+		role := "作者"
+		if msg.Role == domain.RoleAssistant {
+			role = "助手"
+		}
+		items = append(items, item{Role: role, Content: svc.promptOf(msg)})
+	}
+	var bs bytes.Buffer
+	if err := svc.summaryTp.Execute(&bs, map[string]any{
+		"summary":  summary,
+		"messages": items,
+	}); err != nil {
+		return "", err
+	}
+	resp, err := svc.llm.AskOnce(ctx, bs.String())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
+
+func (svc *assistantService) promptOf(msg domain.Message) string {
+	if msg.Prompt != "" {
+		return msg.Prompt
+	}
+	return msg.Content
+}
+
+func (svc *assistantService) truncate(s string) string {
+	if utf8.RuneCountInString(s) <= svc.cfg.MaxInput {
+		return s
+	}
+	return string([]rune(s)[:svc.cfg.MaxInput])
+}
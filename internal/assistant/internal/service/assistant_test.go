@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/KNICEX/InkFlow/internal/ai"
+	"github.com/KNICEX/InkFlow/internal/assistant/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/assistant/internal/repo"
+	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memAssistantRepo struct {
+	repo.AssistantRepo
+	session domain.Session
+	msgs    []domain.Message
+}
+
+func (r *memAssistantRepo) FindSession(ctx context.Context, id int64) (domain.Session, error) {
+	if id != r.session.Id {
+		return domain.Session{}, repo.ErrSessionNotFound
+	}
+	return r.session, nil
+}
+
+func (r *memAssistantRepo) UpdateSummary(ctx context.Context, id int64, summary string, until int64) error {
+	r.session.Summary, r.session.SummaryUntil = summary, until
+	return nil
+}
+
+func (r *memAssistantRepo) CreateMessages(ctx context.Context, msgs []domain.Message) error {
+	for _, msg := range msgs {
+		msg.Id = int64(len(r.msgs) + 1)
+		r.msgs = append(r.msgs, msg)
+	}
+	return nil
+}
+
+func (r *memAssistantRepo) FindMessagesAfter(ctx context.Context, sessionId, minId int64) ([]domain.Message, error) {
+	var res []domain.Message
+	for _, msg := range r.msgs {
+		if msg.Id > minId {
+			res = append(res, msg)
+		}
+	}
+	return res, nil
+}
+
+type fakeInkService struct {
+	ink.Service
+}
+
+func (s fakeInkService) FindDraftInk(ctx context.Context, id, authorId int64) (ink.Ink, error) {
+	return ink.Ink{Id: id, Title: "春天", ContentHtml: "<p>花开了</p>"}, nil
+}
+
+type fakeLLM struct {
+	ai.LLMService
+	history   []ai.Message
+	questions []string
+}
+
+func (l *fakeLLM) AskOnce(ctx context.Context, question string) (ai.Resp, error) {
+	return ai.Resp{Content: "摘要"}, nil
+}
+
+func (l *fakeLLM) BeginChat(ctx context.Context, history ...ai.Message) (ai.LLMSession, error) {
+	l.history = history
+	return &fakeSession{llm: l}, nil
+}
+
+type fakeSession struct {
+	ai.LLMSession
+	llm *fakeLLM
+}
+
+func (s *fakeSession) AskStream(ctx context.Context, question string, onChunk func(chunk string) error) (ai.Resp, error) {
+	s.llm.questions = append(s.llm.questions, question)
+	for _, chunk := range []string{"好", "的"} {
+		if err := onChunk(chunk); err != nil {
+			return ai.Resp{}, err
+		}
+	}
+	return ai.Resp{Content: "好的", Token: 10}, nil
+}
+
+func (s *fakeSession) Close() error {
+	return nil
+}
+
+func TestAssistantService_Chat(t *testing.T) {
+	ctx := context.Background()
+	r := &memAssistantRepo{session: domain.Session{Id: 1, Uid: 100, InkId: 10}}
+	llm := &fakeLLM{}
+	svc := NewAssistantService(r, fakeInkService{}, llm, Config{
+		ContextLimit: 40,
+		KeepRecent:   2,
+		MaxInput:     20,
+	}, logx.NewNopLogger())
+
+	_, err := svc.Chat(ctx, 200, 1, domain.ChatReq{Content: "hi"}, nil)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	_, err = svc.Chat(ctx, 100, 1, domain.ChatReq{Action: "unknown"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidAction)
+
+	var chunks []string
+	answer, err := svc.Chat(ctx, 100, 1, domain.ChatReq{Action: domain.ActionPolish}, func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"好", "的"}, chunks)
+	assert.Equal(t, "好的", answer.Content)
+	// 没有指定内容时使用整篇草稿
+	assert.Contains(t, llm.questions[0], "春天\n花开了")
+	require.Len(t, r.msgs, 2)
+	assert.Equal(t, domain.ActionPolish, r.msgs[0].Action)
+	assert.Equal(t, llm.questions[0], r.msgs[0].Prompt)
+
+	// 超出上下文长度后压缩较早的消息, 只保留最近两条
+	for range 2 {
+		_, err = svc.Chat(ctx, 100, 1, domain.ChatReq{Content: strings.Repeat("长", 30)}, func(chunk string) error {
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "摘要", r.session.Summary)
+	assert.Equal(t, int64(2), r.session.SummaryUntil)
+	require.NotEmpty(t, llm.history)
+	assert.Equal(t, ai.RoleSystem, llm.history[0].Role)
+	assert.Contains(t, llm.history[0].Content, "摘要")
+	// 单条消息被截断
+	assert.Equal(t, strings.Repeat("长", 20), r.msgs[4].Content)
+}
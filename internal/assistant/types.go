@@ -0,0 +1,32 @@
+package assistant
+
+import (
+	"github.com/KNICEX/InkFlow/internal/assistant/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/assistant/internal/service"
+)
+
+type Service = service.AssistantService
+
+type Session = domain.Session
+type Message = domain.Message
+type Role = domain.Role
+type Action = domain.Action
+type ChatReq = domain.ChatReq
+
+const (
+	RoleUser      = domain.RoleUser
+	RoleAssistant = domain.RoleAssistant
+
+	ActionChat      = domain.ActionChat
+	ActionContinue  = domain.ActionContinue
+	ActionPolish    = domain.ActionPolish
+	ActionTranslate = domain.ActionTranslate
+	ActionTitle     = domain.ActionTitle
+	ActionSummary   = domain.ActionSummary
+)
+
+var (
+	ErrSessionNotFound = service.ErrSessionNotFound
+	ErrInvalidAction   = service.ErrInvalidAction
+	ErrEmptyContent    = service.ErrEmptyContent
+)
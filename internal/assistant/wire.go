@@ -0,0 +1,60 @@
+//go:build wireinject
+
+package assistant
+
+import (
+	"github.com/KNICEX/InkFlow/internal/ai"
+	"github.com/KNICEX/InkFlow/internal/assistant/internal/repo"
+	"github.com/KNICEX/InkFlow/internal/assistant/internal/repo/dao"
+	"github.com/KNICEX/InkFlow/internal/assistant/internal/service"
+	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"github.com/google/wire"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+func initSnowflakeNode() snowflakex.Node {
+	return snowflakex.NewNode(snowflakex.DefaultStartTime, 0)
+}
+
+func initDAO(db *gorm.DB, node snowflakex.Node) dao.AssistantDAO {
+	if err := dao.InitTables(db); err != nil {
+		panic(err)
+	}
+	return dao.NewGormAssistantDAO(db, node)
+}
+
+// initConfig 读取 assistant 配置, 长度按字符数计算
+func initConfig() service.Config {
+	type Config struct {
+		ContextLimit int `mapstructure:"context_limit"`
+		KeepRecent   int `mapstructure:"keep_recent"`
+		MaxInput     int `mapstructure:"max_input"`
+	}
+	cfg := Config{
+		ContextLimit: 16000,
+		KeepRecent:   6,
+		MaxInput:     8000,
+	}
+	if err := viper.UnmarshalKey("assistant", &cfg); err != nil {
+		panic(err)
+	}
+	return service.Config{
+		ContextLimit: cfg.ContextLimit,
+		KeepRecent:   cfg.KeepRecent,
+		MaxInput:     cfg.MaxInput,
+	}
+}
+
+func InitService(db *gorm.DB, inkSvc ink.Service, llmSvc ai.LLMService, l logx.Logger) Service {
+	wire.Build(
+		initSnowflakeNode,
+		initDAO,
+		repo.NewAssistantRepo,
+		initConfig,
+		service.NewAssistantService,
+	)
+	return nil
+}
@@ -2,10 +2,122 @@ package bff
 
 import (
 	"fmt"
+	"github.com/KNICEX/InkFlow/internal/bff/internal/service"
+	"github.com/KNICEX/InkFlow/internal/bff/internal/web"
+	"github.com/KNICEX/InkFlow/internal/prompt"
+	"github.com/KNICEX/InkFlow/internal/review"
+	"github.com/KNICEX/InkFlow/internal/search"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
+	"github.com/KNICEX/InkFlow/pkg/ginx/middleware"
+	"github.com/KNICEX/InkFlow/pkg/logx"
 	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/redis/go-redis/v9"
 	"github.com/spf13/viper"
+	"go.temporal.io/sdk/client"
+	"time"
 )
 
+const (
+	fileProviderCloudinary = "cloudinary"
+	fileProviderLocal      = "local"
+	fileProviderS3         = "s3"
+)
+
+// initFileService 根据 file.provider 选择存储后端, 默认使用 cloudinary
+func initFileService() service.FileService {
+	provider := viper.GetString("file.provider")
+	switch provider {
+	case "", fileProviderCloudinary:
+		return service.NewCloudinaryFileService(initCloudinary())
+	case fileProviderLocal:
+		type Config struct {
+			Dir     string `mapstructure:"dir"`
+			BaseUrl string `mapstructure:"base_url"`
+		}
+		cfg := Config{
+			Dir:     "./data/files",
+			BaseUrl: "/api/v1/files",
+		}
+		if err := viper.UnmarshalKey("file.local", &cfg); err != nil {
+			panic(err)
+		}
+		return service.NewLocalFileService(cfg.Dir, cfg.BaseUrl)
+	case fileProviderS3:
+		type Config struct {
+			Endpoint  string `mapstructure:"endpoint"`
+			Region    string `mapstructure:"region"`
+			Bucket    string `mapstructure:"bucket"`
+			AccessKey string `mapstructure:"access_key"`
+			SecretKey string `mapstructure:"secret_key"`
+			PathStyle bool   `mapstructure:"path_style"`
+			PublicUrl string `mapstructure:"public_url"`
+		}
+		var cfg Config
+		if err := viper.UnmarshalKey("file.s3", &cfg); err != nil {
+			panic(err)
+		}
+		return service.NewS3FileService(service.S3Config{
+			Endpoint:  cfg.Endpoint,
+			Region:    cfg.Region,
+			Bucket:    cfg.Bucket,
+			AccessKey: cfg.AccessKey,
+			SecretKey: cfg.SecretKey,
+			PathStyle: cfg.PathStyle,
+			PublicUrl: cfg.PublicUrl,
+		})
+	default:
+		panic(fmt.Sprintf("unknown file provider: %s", provider))
+	}
+}
+
+// initUploadGuard 读取 file.quota 配置, 未配置时使用默认配额
+func initUploadGuard(cmd redis.Cmdable) service.UploadGuard {
+	type Quota struct {
+		MaxFiles int64 `mapstructure:"max_files"`
+		MaxBytes int64 `mapstructure:"max_bytes"`
+	}
+	type Config struct {
+		Avatar          Quota         `mapstructure:"avatar"`
+		Cover           Quota         `mapstructure:"cover"`
+		Image           Quota         `mapstructure:"image"`
+		MaxViolations   int64         `mapstructure:"max_violations"`
+		ViolationWindow time.Duration `mapstructure:"violation_window"`
+		BanDuration     time.Duration `mapstructure:"ban_duration"`
+	}
+	cfg := Config{
+		Avatar:          Quota{MaxFiles: 20, MaxBytes: 50 << 20},
+		Cover:           Quota{MaxFiles: 50, MaxBytes: 200 << 20},
+		Image:           Quota{MaxFiles: 200, MaxBytes: 1 << 30},
+		MaxViolations:   5,
+		ViolationWindow: time.Hour,
+		BanDuration:     time.Hour * 24,
+	}
+	if err := viper.UnmarshalKey("file.quota", &cfg); err != nil {
+		panic(err)
+	}
+	return service.NewRedisUploadGuard(cmd, service.UploadGuardConfig{
+		Quotas: map[string]service.UploadQuota{
+			service.FolderAvatar: {MaxFiles: cfg.Avatar.MaxFiles, MaxBytes: cfg.Avatar.MaxBytes},
+			service.FolderCover:  {MaxFiles: cfg.Cover.MaxFiles, MaxBytes: cfg.Cover.MaxBytes},
+			service.FolderImage:  {MaxFiles: cfg.Image.MaxFiles, MaxBytes: cfg.Image.MaxBytes},
+		},
+		MaxViolations:   cfg.MaxViolations,
+		ViolationWindow: cfg.ViolationWindow,
+		BanDuration:     cfg.BanDuration,
+	})
+}
+
+// initModerationHandler 审核员由 review.moderators 配置, 为空时没有人可以处理人工审核
+func initModerationHandler(svc review.ModerationService, sensitiveSvc sensitive.Service, promptSvc prompt.Service,
+	analyticsSvc search.AnalyticsService, workflowCli client.Client, auth middleware.Authentication, l logx.Logger) *web.ModerationHandler {
+	moderators := viper.GetIntSlice("review.moderators")
+	ids := make([]int64, 0, len(moderators))
+	for _, id := range moderators {
+		ids = append(ids, int64(id))
+	}
+	return web.NewModerationHandler(svc, sensitiveSvc, promptSvc, analyticsSvc, workflowCli, ids, auth, l)
+}
+
 func initCloudinary() *cloudinary.Cloudinary {
 	type Config struct {
 		Key       string `mapstructure:"key"`
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+type CloudinaryFileService struct {
+	client *cloudinary.Cloudinary
+}
+
+func NewCloudinaryFileService(client *cloudinary.Cloudinary) FileService {
+	return &CloudinaryFileService{
+		client: client,
+	}
+}
+
+func (c *CloudinaryFileService) Upload(ctx context.Context, reader io.Reader, params UploadParams) (string, error) {
+	key, err := objectKey(params)
+	if err != nil {
+		return "", err
+	}
+	// cloudinary 的 public id 不带扩展名
+	publicId := strings.TrimSuffix(key, path.Ext(key))
+	res, err := c.client.Upload.Upload(ctx, reader, uploader.UploadParams{
+		PublicID: publicId,
+	})
+	if err != nil {
+		return "", err
+	}
+	return res.SecureURL, nil
+}
@@ -2,30 +2,68 @@ package service
 
 import (
 	"context"
-	"github.com/cloudinary/cloudinary-go/v2"
-	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"errors"
 	"io"
+	"mime"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FolderAvatar = "avatar"
+	FolderCover  = "cover"
+	FolderImage  = "image"
 )
 
+var ErrInvalidFolder = errors.New("invalid folder")
+
 type FileService interface {
-	Upload(ctx context.Context, reader io.Reader) (string, error)
+	// Upload 上传文件, 返回可直接访问的稳定 URL
+	Upload(ctx context.Context, reader io.Reader, params UploadParams) (string, error)
 }
 
-type CloudinaryFileService struct {
-	client *cloudinary.Cloudinary
+type UploadParams struct {
+	// Folder 文件用途对应的目录, 如 avatar, cover, image
+	Folder string
+	// Name 文件在 Folder 下的相对路径, 为空时自动生成
+	Name string
+	// Filename 原始文件名, 仅用于推断扩展名
+	Filename    string
+	ContentType string
 }
 
-type UploadParams struct {
-	// Path example: "/folder/subfolder/file.jpg"
-	Path string
-	// Folder example: "/folder/subfolder"
-	Folder string // if path is not empty, folder is ignored
+// objectKey 生成对象存储的 key, 未指定 Name 时格式为 folder/yyyymmdd/uuid.ext
+func objectKey(params UploadParams) (string, error) {
+	folder := strings.Trim(params.Folder, "/")
+	if folder == "" || strings.Contains(folder, "..") {
+		return "", ErrInvalidFolder
+	}
+	if params.Name != "" {
+		name := strings.Trim(params.Name, "/")
+		if name == "" || strings.Contains(name, "..") {
+			return "", ErrInvalidFolder
+		}
+		return path.Join(folder, name), nil
+	}
+	return path.Join(folder, NewObjectName(extension(params))), nil
 }
 
-func (c *CloudinaryFileService) Upload(ctx context.Context, reader io.Reader) (string, error) {
-	res, err := c.client.Upload.Upload(ctx, reader, uploader.UploadParams{})
-	if err != nil {
-		return "", err
+// NewObjectName 生成 yyyymmdd/uuid.ext 形式的文件名
+func NewObjectName(ext string) string {
+	return path.Join(time.Now().Format("20060102"), uuid.NewString()+ext)
+}
+
+func extension(params UploadParams) string {
+	if ext := strings.ToLower(path.Ext(params.Filename)); ext != "" {
+		return ext
+	}
+	if params.ContentType != "" {
+		if exts, err := mime.ExtensionsByType(params.ContentType); err == nil && len(exts) > 0 {
+			return exts[0]
+		}
 	}
-	return res.URL, nil
+	return ""
 }
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalFileService_Upload(t *testing.T) {
+	dir := t.TempDir()
+	svc := NewLocalFileService(dir, "http://localhost:8080/api/v1/files/")
+
+	url, err := svc.Upload(context.Background(), strings.NewReader("img"), UploadParams{
+		Folder:   FolderAvatar,
+		Filename: "a.PNG",
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(url, "http://localhost:8080/api/v1/files/avatar/"))
+	assert.True(t, strings.HasSuffix(url, ".png"))
+
+	key := strings.TrimPrefix(url, "http://localhost:8080/api/v1/files/")
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(key)))
+	require.NoError(t, err)
+	assert.Equal(t, "img", string(data))
+
+	_, err = svc.Upload(context.Background(), strings.NewReader("img"), UploadParams{Folder: "../etc"})
+	assert.ErrorIs(t, err, ErrInvalidFolder)
+}
+
+func TestS3FileService_Upload(t *testing.T) {
+	var gotPath, gotAuth, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+	}))
+	defer server.Close()
+
+	svc := NewS3FileService(S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "ink-flow",
+		AccessKey: "ak",
+		SecretKey: "sk",
+		PathStyle: true,
+		PublicUrl: "https://cdn.example.com",
+	})
+	url, err := svc.Upload(context.Background(), strings.NewReader("img"), UploadParams{
+		Folder:      FolderCover,
+		ContentType: "image/png",
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(gotPath, "/ink-flow/cover/"))
+	assert.Equal(t, "https://cdn.example.com"+strings.TrimPrefix(gotPath, "/ink-flow"), url)
+	assert.Contains(t, gotAuth, "AWS4-HMAC-SHA256 Credential=ak/")
+	assert.Contains(t, gotAuth, "SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date")
+	assert.Equal(t, "img", gotBody)
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	// MaxImageSize 上传图片的最大字节数
+	MaxImageSize = 10 << 20
+	// maxImagePixels 解码前检查像素数, 防止解压炸弹
+	maxImagePixels = 50_000_000
+
+	ThumbSuffix = "_thumb"
+
+	imageContentType = "image/jpeg"
+	imageExt         = ".jpg"
+	imageQuality     = 85
+	thumbQuality     = 80
+)
+
+var (
+	ErrNotImage      = errors.New("not a supported image")
+	ErrImageTooLarge = errors.New("image too large")
+)
+
+// ImageSpec 不同用途图片的处理规格
+type ImageSpec struct {
+	MaxWidth  int
+	MaxHeight int
+	// AspectWidth, AspectHeight 不为 0 时按该比例居中裁剪
+	AspectWidth  int
+	AspectHeight int
+	ThumbWidth   int
+	ThumbHeight  int
+}
+
+var imageSpecs = map[string]ImageSpec{
+	FolderAvatar: {MaxWidth: 512, MaxHeight: 512, AspectWidth: 1, AspectHeight: 1, ThumbWidth: 128, ThumbHeight: 128},
+	FolderCover:  {MaxWidth: 1920, MaxHeight: 1080, AspectWidth: 16, AspectHeight: 9, ThumbWidth: 480, ThumbHeight: 270},
+	FolderImage:  {MaxWidth: 2560, MaxHeight: 2560, ThumbWidth: 480, ThumbHeight: 480},
+}
+
+type ProcessedImage struct {
+	Data        []byte
+	Thumb       []byte
+	ContentType string
+	Ext         string
+}
+
+// ImageProcessor 校验上传的图片, 去除 EXIF 等元数据, 按用途裁剪缩放并生成缩略图
+type ImageProcessor interface {
+	Process(data []byte, folder string) (ProcessedImage, error)
+}
+
+// StdImageProcessor 基于标准库实现, 支持 jpeg/png/gif 输入.
+// 标准库没有 webp 编码器, 统一重新编码为 jpeg, 重新编码本身即去除了 EXIF
+type StdImageProcessor struct {
+	specs map[string]ImageSpec
+}
+
+func NewImageProcessor() ImageProcessor {
+	return &StdImageProcessor{
+		specs: imageSpecs,
+	}
+}
+
+func (p *StdImageProcessor) Process(data []byte, folder string) (ProcessedImage, error) {
+	spec, ok := p.specs[folder]
+	if !ok {
+		return ProcessedImage{}, ErrInvalidFolder
+	}
+	if len(data) > MaxImageSize {
+		return ProcessedImage{}, ErrImageTooLarge
+	}
+	switch http.DetectContentType(data) {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return ProcessedImage{}, ErrNotImage
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ProcessedImage{}, ErrNotImage
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return ProcessedImage{}, ErrImageTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return ProcessedImage{}, ErrNotImage
+	}
+
+	img := orient(flatten(src), jpegOrientation(data))
+	if spec.AspectWidth > 0 && spec.AspectHeight > 0 {
+		img = cropToAspect(img, spec.AspectWidth, spec.AspectHeight)
+	}
+	main := fit(img, spec.MaxWidth, spec.MaxHeight)
+	thumb := fit(img, spec.ThumbWidth, spec.ThumbHeight)
+
+	res := ProcessedImage{
+		ContentType: imageContentType,
+		Ext:         imageExt,
+	}
+	if res.Data, err = encodeJpeg(main, imageQuality); err != nil {
+		return ProcessedImage{}, err
+	}
+	if res.Thumb, err = encodeJpeg(thumb, thumbQuality); err != nil {
+		return ProcessedImage{}, err
+	}
+	return res, nil
+}
+
+var pipelineImageName = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\.jpg$`)
+
+// ThumbnailURL 返回图片对应的缩略图地址, 非上传流水线生成的图片返回原地址
+func ThumbnailURL(url string) string {
+	base := path.Base(url)
+	if !pipelineImageName.MatchString(base) {
+		return url
+	}
+	return strings.TrimSuffix(url, imageExt) + ThumbSuffix + imageExt
+}
+
+func encodeJpeg(img image.Image, quality int) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := jpeg.Encode(buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// flatten 转为 RGBA 并将透明部分铺上白色背景
+func flatten(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Over)
+	return dst
+}
+
+func cropToAspect(img *image.RGBA, aw, ah int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	cw, ch := w, w*ah/aw
+	if ch > h {
+		cw, ch = h*aw/ah, h
+	}
+	if cw == w && ch == h {
+		return img
+	}
+	x0, y0 := (w-cw)/2, (h-ch)/2
+	dst := image.NewRGBA(image.Rect(0, 0, cw, ch))
+	draw.Draw(dst, dst.Bounds(), img, image.Pt(x0, y0), draw.Src)
+	return dst
+}
+
+// fit 等比缩小到 maxW * maxH 以内, 不放大
+func fit(img *image.RGBA, maxW, maxH int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= maxW && h <= maxH {
+		return img
+	}
+	nw, nh := maxW, h*maxW/w
+	if nh > maxH {
+		nw, nh = w*maxH/h, maxH
+	}
+	return resize(img, max(nw, 1), max(nh, 1))
+}
+
+// resize 使用区域平均进行缩小, 每个目标像素取对应源区域的均值
+func resize(src *image.RGBA, dw, dh int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*sh/dh, max((dy+1)*sh/dh, dy*sh/dh+1)
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*sw/dw, max((dx+1)*sw/dw, dx*sw/dw+1)
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				off := y*src.Stride + x0*4
+				for x := x0; x < x1; x++ {
+					r += uint64(src.Pix[off])
+					g += uint64(src.Pix[off+1])
+					b += uint64(src.Pix[off+2])
+					a += uint64(src.Pix[off+3])
+					off += 4
+					n++
+				}
+			}
+			i := dy*dst.Stride + dx*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// orient 按 EXIF Orientation 旋转或翻转图片, 重新编码后 EXIF 丢失, 需要提前应用
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			si := y*src.Stride + x*4
+			di := dy*dst.Stride + dx*4
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+// jpegOrientation 从 jpeg 的 APP1 段中读取 EXIF Orientation, 读取失败返回 1
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		// SOS 之后是图像数据, 不会再有 EXIF
+		if marker == 0xDA {
+			return 1
+		}
+		size := int(data[i+2])<<8 | int(data[i+3])
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		seg := data[i+4 : i+2+size]
+		if marker == 0xE1 && len(seg) > 6 && string(seg[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(seg[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var u16 func(b []byte) int
+	var u32 func(b []byte) int
+	switch string(tiff[:2]) {
+	case "II":
+		u16 = func(b []byte) int { return int(b[0]) | int(b[1])<<8 }
+		u32 = func(b []byte) int { return int(b[0]) | int(b[1])<<8 | int(b[2])<<16 | int(b[3])<<24 }
+	case "MM":
+		u16 = func(b []byte) int { return int(b[0])<<8 | int(b[1]) }
+		u32 = func(b []byte) int { return int(b[0])<<24 | int(b[1])<<16 | int(b[2])<<8 | int(b[3]) }
+	default:
+		return 1
+	}
+	ifd := u32(tiff[4:8])
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := u16(tiff[ifd : ifd+2])
+	for n := 0; n < entries; n++ {
+		e := ifd + 2 + n*12
+		if e+12 > len(tiff) {
+			return 1
+		}
+		if u16(tiff[e:e+2]) == 0x0112 {
+			return u16(tiff[e+8 : e+10])
+		}
+	}
+	return 1
+}
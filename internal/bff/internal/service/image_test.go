@@ -0,0 +1,99 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePng(t *testing.T, w, h int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 100, A: 200})
+		}
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, png.Encode(buf, img))
+	return buf.Bytes()
+}
+
+func decodeSize(t *testing.T, data []byte) (int, int) {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	return img.Bounds().Dx(), img.Bounds().Dy()
+}
+
+func TestStdImageProcessor_Process(t *testing.T) {
+	p := NewImageProcessor()
+	testCases := []struct {
+		name   string
+		data   []byte
+		folder string
+		wantW  int
+		wantH  int
+		thumbW int
+		thumbH int
+		err    error
+	}{
+		{name: "头像裁剪为正方形", data: encodePng(t, 1000, 400), folder: FolderAvatar, wantW: 400, wantH: 400, thumbW: 128, thumbH: 128},
+		{name: "封面裁剪为16:9并缩小", data: encodePng(t, 3200, 2400), folder: FolderCover, wantW: 1920, wantH: 1080, thumbW: 480, thumbH: 270},
+		{name: "普通图片等比缩小", data: encodePng(t, 3000, 1000), folder: FolderImage, wantW: 2560, wantH: 853, thumbW: 480, thumbH: 160},
+		{name: "非图片", data: []byte("<html></html>"), folder: FolderImage, err: ErrNotImage},
+		{name: "未知用途", data: encodePng(t, 10, 10), folder: "unknown", err: ErrInvalidFolder},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := p.Process(tc.data, tc.folder)
+			assert.ErrorIs(t, err, tc.err)
+			if tc.err != nil {
+				return
+			}
+			assert.Equal(t, "image/jpeg", res.ContentType)
+			w, h := decodeSize(t, res.Data)
+			assert.Equal(t, tc.wantW, w)
+			assert.Equal(t, tc.wantH, h)
+			w, h = decodeSize(t, res.Thumb)
+			assert.Equal(t, tc.thumbW, w)
+			assert.Equal(t, tc.thumbH, h)
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	src := flatten(encodeDecode(t, 3, 2))
+	dst := orient(src, 6)
+	assert.Equal(t, 2, dst.Bounds().Dx())
+	assert.Equal(t, 3, dst.Bounds().Dy())
+	// 顺时针旋转 90 度后, 原左上角位于右上角
+	assert.Equal(t, src.RGBAAt(0, 0), dst.RGBAAt(1, 0))
+}
+
+func encodeDecode(t *testing.T, w, h int) image.Image {
+	img, err := png.Decode(bytes.NewReader(encodePng(t, w, h)))
+	require.NoError(t, err)
+	return img
+}
+
+func TestJpegOrientation(t *testing.T) {
+	// SOI + APP1(Exif, 小端, IFD0 一个 Orientation=6 的条目) + SOS
+	tiff := []byte{'I', 'I', 42, 0, 8, 0, 0, 0, 1, 0, 0x12, 0x01, 3, 0, 1, 0, 0, 0, 6, 0, 0, 0, 0, 0, 0, 0}
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+	size := len(seg) + 2
+	data := append([]byte{0xFF, 0xD8, 0xFF, 0xE1, byte(size >> 8), byte(size)}, seg...)
+	data = append(data, 0xFF, 0xDA)
+	assert.Equal(t, 6, jpegOrientation(data))
+	assert.Equal(t, 1, jpegOrientation([]byte{0xFF, 0xD8, 0xFF, 0xDA}))
+}
+
+func TestThumbnailURL(t *testing.T) {
+	assert.Equal(t, "https://cdn.com/avatar/20260101/0b6c3d0e-6b8a-4d55-9a55-3a1f0f2b9e61_thumb.jpg",
+		ThumbnailURL("https://cdn.com/avatar/20260101/0b6c3d0e-6b8a-4d55-9a55-3a1f0f2b9e61.jpg"))
+	assert.Equal(t, "https://cdn.com/legacy.png", ThumbnailURL("https://cdn.com/legacy.png"))
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StaticFileService 文件保存在本地, 需要由 web 层注册静态路由
+type StaticFileService interface {
+	FileService
+	Dir() string
+}
+
+// LocalFileService 将文件保存到本地磁盘, 由 gin 的静态路由对外提供访问
+type LocalFileService struct {
+	dir     string
+	baseUrl string
+}
+
+// NewLocalFileService dir 为文件保存目录, baseUrl 为静态路由对外的访问前缀
+func NewLocalFileService(dir, baseUrl string) StaticFileService {
+	return &LocalFileService{
+		dir:     dir,
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+	}
+}
+
+// Dir 返回文件保存目录, 用于注册静态路由
+func (s *LocalFileService) Dir() string {
+	return s.dir
+}
+
+func (s *LocalFileService) Upload(ctx context.Context, reader io.Reader, params UploadParams) (string, error) {
+	key, err := objectKey(params)
+	if err != nil {
+		return "", err
+	}
+	filename := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err = os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(f, reader); err != nil {
+		_ = f.Close()
+		_ = os.Remove(filename)
+		return "", err
+	}
+	if err = f.Close(); err != nil {
+		return "", err
+	}
+	return s.baseUrl + "/" + key, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/KNICEX/InkFlow/pkg/ratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrUploadCountExceeded = errors.New("upload count quota exceeded")
+	ErrUploadBytesExceeded = errors.New("upload bytes quota exceeded")
+	ErrUploadBanned        = errors.New("upload banned")
+)
+
+const quotaWindow = time.Hour * 24
+
+// UploadQuota 单个用户在某个目录下每天的上传配额, 为 0 表示不限制
+type UploadQuota struct {
+	MaxFiles int64
+	MaxBytes int64
+}
+
+type UploadGuardConfig struct {
+	// Quotas 按上传目录配置, 未配置的目录不限制
+	Quotas map[string]UploadQuota
+	// MaxViolations 窗口内允许超出配额的次数, 超过后临时封禁上传
+	MaxViolations   int64
+	ViolationWindow time.Duration
+	BanDuration     time.Duration
+}
+
+// UploadGuard 上传配额与异常上传检测
+type UploadGuard interface {
+	// Acquire 占用 uid 当天在 folder 下的一次文件数配额和 size 字节配额, 任一配额不足时都不占用,
+	// 超出配额时返回 ErrUploadCountExceeded / ErrUploadBytesExceeded, 被封禁时返回 ErrUploadBanned
+	Acquire(ctx context.Context, uid int64, folder string, size int64) error
+	// Release 归还 Acquire 占用的 files 个文件数配额和 size 字节配额, 用于上传最终被拒绝或实际大小更小时
+	Release(ctx context.Context, uid int64, folder string, files, size int64) error
+}
+
+type redisUploadGuard struct {
+	cmd              redis.Cmdable
+	cfg              UploadGuardConfig
+	countLimiters    map[string]ratelimit.WeightedKeyLimiter
+	bytesLimiters    map[string]ratelimit.WeightedKeyLimiter
+	violationLimiter ratelimit.KeyLimiter
+}
+
+func NewRedisUploadGuard(cmd redis.Cmdable, cfg UploadGuardConfig) UploadGuard {
+	g := &redisUploadGuard{
+		cmd:              cmd,
+		cfg:              cfg,
+		countLimiters:    make(map[string]ratelimit.WeightedKeyLimiter, len(cfg.Quotas)),
+		bytesLimiters:    make(map[string]ratelimit.WeightedKeyLimiter, len(cfg.Quotas)),
+		violationLimiter: ratelimit.NewRedisFixedWindowKeyLimiter(cmd, cfg.ViolationWindow, cfg.MaxViolations),
+	}
+	for folder, quota := range cfg.Quotas {
+		if quota.MaxFiles > 0 {
+			g.countLimiters[folder] = ratelimit.NewRedisFixedWindowKeyLimiter(cmd, quotaWindow, quota.MaxFiles)
+		}
+		if quota.MaxBytes > 0 {
+			g.bytesLimiters[folder] = ratelimit.NewRedisFixedWindowKeyLimiter(cmd, quotaWindow, quota.MaxBytes)
+		}
+	}
+	return g
+}
+
+func (g *redisUploadGuard) Acquire(ctx context.Context, uid int64, folder string, size int64) error {
+	banned, err := g.cmd.Exists(ctx, g.banKey(uid)).Result()
+	if err != nil {
+		return err
+	}
+	if banned > 0 {
+		return ErrUploadBanned
+	}
+
+	day := g.day()
+	countLim, hasCount := g.countLimiters[folder]
+	if hasCount {
+		limited, err := countLim.Limited(ctx, g.quotaKey("count", folder, uid, day))
+		if err != nil {
+			return err
+		}
+		if limited {
+			return g.violate(ctx, uid, ErrUploadCountExceeded)
+		}
+	}
+	if lim, ok := g.bytesLimiters[folder]; ok {
+		limited, err := lim.LimitedN(ctx, g.quotaKey("bytes", folder, uid, day), size)
+		if err != nil {
+			return g.rollbackCount(ctx, uid, folder, day, err)
+		}
+		if limited {
+			return g.rollbackCount(ctx, uid, folder, day, g.violate(ctx, uid, ErrUploadBytesExceeded))
+		}
+	}
+	return nil
+}
+
+// rollbackCount 字节配额不足时归还已经占用的文件数配额
+func (g *redisUploadGuard) rollbackCount(ctx context.Context, uid int64, folder, day string, cause error) error {
+	lim, ok := g.countLimiters[folder]
+	if !ok {
+		return cause
+	}
+	if err := lim.ReleaseN(ctx, g.quotaKey("count", folder, uid, day), 1); err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
+
+func (g *redisUploadGuard) Release(ctx context.Context, uid int64, folder string, files, size int64) error {
+	day := g.day()
+	var err error
+	if lim, ok := g.countLimiters[folder]; ok && files > 0 {
+		err = lim.ReleaseN(ctx, g.quotaKey("count", folder, uid, day), files)
+	}
+	if lim, ok := g.bytesLimiters[folder]; ok && size > 0 {
+		err = errors.Join(err, lim.ReleaseN(ctx, g.quotaKey("bytes", folder, uid, day), size))
+	}
+	return err
+}
+
+// violate 记录一次超额, 短时间内反复超额的用户会被临时封禁
+func (g *redisUploadGuard) violate(ctx context.Context, uid int64, cause error) error {
+	if g.cfg.MaxViolations <= 0 {
+		return cause
+	}
+	limited, err := g.violationLimiter.Limited(ctx, fmt.Sprintf("upload:violation:%d", uid))
+	if err != nil {
+		return errors.Join(cause, err)
+	}
+	if !limited {
+		return cause
+	}
+	if err = g.cmd.Set(ctx, g.banKey(uid), time.Now().UnixMilli(), g.cfg.BanDuration).Err(); err != nil {
+		return errors.Join(cause, err)
+	}
+	return ErrUploadBanned
+}
+
+func (g *redisUploadGuard) day() string {
+	return time.Now().Format("20060102")
+}
+
+func (g *redisUploadGuard) quotaKey(typ, folder string, uid int64, day string) string {
+	return fmt.Sprintf("upload:quota:%s:%s:%d:%s", typ, folder, uid, day)
+}
+
+func (g *redisUploadGuard) banKey(uid int64) string {
+	return fmt.Sprintf("upload:ban:%d", uid)
+}
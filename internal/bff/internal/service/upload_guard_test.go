@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisUploadGuard(t *testing.T) {
+	cmd := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	ctx := context.Background()
+	if err := cmd.Ping(ctx).Err(); err != nil {
+		t.Skip("redis not available:", err)
+	}
+	uid := time.Now().UnixNano()
+	g := NewRedisUploadGuard(cmd, UploadGuardConfig{
+		Quotas: map[string]UploadQuota{
+			FolderImage: {MaxFiles: 2, MaxBytes: 100},
+		},
+	}).(*redisUploadGuard)
+	defer cmd.Del(ctx,
+		g.quotaKey("count", FolderImage, uid, g.day()),
+		g.quotaKey("bytes", FolderImage, uid, g.day()),
+	)
+	count := func() int64 {
+		cnt, err := cmd.Get(ctx, g.quotaKey("count", FolderImage, uid, g.day())).Int64()
+		require.NoError(t, err)
+		return cnt
+	}
+
+	require.NoError(t, g.Acquire(ctx, uid, FolderImage, 60))
+	// 字节配额不足时不占用文件数配额
+	assert.ErrorIs(t, g.Acquire(ctx, uid, FolderImage, 60), ErrUploadBytesExceeded)
+	assert.Equal(t, int64(1), count())
+
+	// 上传被拒绝后归还全部配额
+	require.NoError(t, g.Acquire(ctx, uid, FolderImage, 40))
+	require.NoError(t, g.Release(ctx, uid, FolderImage, 1, 40))
+	assert.Equal(t, int64(1), count())
+
+	require.NoError(t, g.Acquire(ctx, uid, FolderImage, 40))
+	assert.ErrorIs(t, g.Acquire(ctx, uid, FolderImage, 0), ErrUploadCountExceeded)
+	// 其他目录不限制
+	assert.NoError(t, g.Acquire(ctx, uid, fmt.Sprintf("%s_other", FolderImage), 1000))
+}
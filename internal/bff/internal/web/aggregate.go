@@ -2,11 +2,14 @@ package web
 
 import (
 	"context"
+	"errors"
 	"github.com/KNICEX/InkFlow/internal/comment"
 	"github.com/KNICEX/InkFlow/internal/ink"
 	"github.com/KNICEX/InkFlow/internal/interactive"
+	"github.com/KNICEX/InkFlow/internal/poll"
 	"github.com/KNICEX/InkFlow/internal/relation"
 	"github.com/KNICEX/InkFlow/internal/user"
+	"github.com/KNICEX/InkFlow/pkg/logx"
 	"github.com/samber/lo"
 	"golang.org/x/sync/errgroup"
 )
@@ -85,22 +88,27 @@ func (u *UserAggregate) GetUserList(ctx context.Context, uids []int64, viewUid i
 
 type InkAggregate struct {
 	inkSvc        ink.Service
+	pollSvc       poll.Service
 	userAggregate *UserAggregate
 	intrAggregate *InteractiveAggregate
+	l             logx.Logger
 }
 
-func NewInkAggregate(inkSvc ink.Service, userAggregate *UserAggregate,
-	intrAggregate *InteractiveAggregate) *InkAggregate {
+func NewInkAggregate(inkSvc ink.Service, pollSvc poll.Service, userAggregate *UserAggregate,
+	intrAggregate *InteractiveAggregate, l logx.Logger) *InkAggregate {
 	return &InkAggregate{
 		inkSvc:        inkSvc,
+		pollSvc:       pollSvc,
 		userAggregate: userAggregate,
 		intrAggregate: intrAggregate,
+		l:             l,
 	}
 }
 
 func (i *InkAggregate) GetInk(ctx context.Context, id int64, viewUid int64) (InkVO, error) {
 	var author UserVO
 	var intr InteractiveVO
+	var pollVO *PollVO
 	inkInfo, err := i.inkSvc.FindLiveInk(ctx, id)
 	if err != nil {
 		return InkVO{}, err
@@ -120,6 +128,19 @@ func (i *InkAggregate) GetInk(ctx context.Context, id int64, viewUid int64) (Ink
 		intr, er = i.intrAggregate.GetInteractive(ctx, bizInk, inkInfo.Id, viewUid)
 		return er
 	})
+	eg.Go(func() error {
+		p, er := i.pollSvc.FindByBiz(ctx, poll.BizInk, inkInfo.Id, viewUid)
+		if er != nil {
+			// 文章没有投票, 或者投票查询失败时不影响文章详情
+			if !errors.Is(er, poll.ErrNotFound) {
+				i.l.WithCtx(ctx).Error("get ink poll error", logx.Error(er), logx.Int64("inkId", inkInfo.Id))
+			}
+			return nil
+		}
+		vo := pollToVO(p)
+		pollVO = &vo
+		return nil
+	})
 	if err = eg.Wait(); err != nil {
 		return InkVO{}, err
 	}
@@ -127,6 +148,7 @@ func (i *InkAggregate) GetInk(ctx context.Context, id int64, viewUid int64) (Ink
 	vo := inkToVO(inkInfo)
 	vo.Author = author
 	vo.Interactive = intr
+	vo.Poll = pollVO
 	return vo, nil
 }
 
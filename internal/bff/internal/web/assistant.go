@@ -0,0 +1,131 @@
+package web
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/KNICEX/InkFlow/internal/ai"
+	"github.com/KNICEX/InkFlow/internal/assistant"
+	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/pkg/ginx"
+	"github.com/KNICEX/InkFlow/pkg/ginx/jwt"
+	"github.com/KNICEX/InkFlow/pkg/ginx/middleware"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+)
+
+// AssistantHandler 写作助手, 会话绑定到作者自己的草稿
+type AssistantHandler struct {
+	svc  assistant.Service
+	auth middleware.Authentication
+	l    logx.Logger
+}
+
+func NewAssistantHandler(svc assistant.Service, auth middleware.Authentication, l logx.Logger) *AssistantHandler {
+	return &AssistantHandler{
+		svc:  svc,
+		auth: auth,
+		l:    l,
+	}
+}
+
+func (h *AssistantHandler) RegisterRoutes(server *gin.RouterGroup) {
+	assistantGroup := server.Group("/assistant", h.auth.CheckLogin())
+	{
+		assistantGroup.POST("/sessions", ginx.WrapBody(h.l, h.CreateSession))
+		assistantGroup.GET("/sessions", ginx.WrapBody(h.l, h.ListSessions))
+		assistantGroup.DELETE("/sessions/:id", ginx.Wrap(h.l, h.DelSession))
+		assistantGroup.GET("/sessions/:id/messages", ginx.WrapBody(h.l, h.History))
+		// 以 SSE 返回, delta 事件为回答的片段, 最后的 result 事件为保存后的回答
+		assistantGroup.POST("/sessions/:id/chat", ginx.WrapStream(h.l, h.Chat))
+	}
+}
+
+func (h *AssistantHandler) CreateSession(ctx *gin.Context, req CreateAssistantSessionReq) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	session, err := h.svc.CreateSession(ctx, uc.UserId, req.InkId)
+	if err != nil {
+		if errors.Is(err, ink.ErrNotFound) {
+			return ginx.NotFound(), nil
+		}
+		return ginx.InternalError(), err
+	}
+	return ginx.SuccessWithData(assistantSessionToVO(session)), nil
+}
+
+func (h *AssistantHandler) ListSessions(ctx *gin.Context, req ListAssistantSessionReq) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	sessions, err := h.svc.ListSessions(ctx, uc.UserId, req.InkId, req.Offset, req.Limit)
+	if err != nil {
+		return ginx.InternalError(), err
+	}
+	return ginx.SuccessWithData(lo.Map(sessions, func(item assistant.Session, index int) AssistantSessionVO {
+		return assistantSessionToVO(item)
+	})), nil
+}
+
+func (h *AssistantHandler) DelSession(ctx *gin.Context) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.InvalidParam(), nil
+	}
+	if err = h.svc.DelSession(ctx, uc.UserId, id); err != nil {
+		if errors.Is(err, assistant.ErrSessionNotFound) {
+			return ginx.NotFound(), nil
+		}
+		return ginx.InternalError(), err
+	}
+	return ginx.Success(), nil
+}
+
+func (h *AssistantHandler) History(ctx *gin.Context, req ListAssistantMessageReq) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.InvalidParam(), nil
+	}
+	msgs, err := h.svc.History(ctx, uc.UserId, id, req.MaxId, req.Limit)
+	if err != nil {
+		if errors.Is(err, assistant.ErrSessionNotFound) {
+			return ginx.NotFound(), nil
+		}
+		return ginx.InternalError(), err
+	}
+	return ginx.SuccessWithData(lo.Map(msgs, func(item assistant.Message, index int) AssistantMessageVO {
+		return assistantMessageToVO(item)
+	})), nil
+}
+
+func (h *AssistantHandler) Chat(ctx *gin.Context, req AssistantChatReq, send ginx.Sender) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.InvalidParam(), nil
+	}
+	msg, err := h.svc.Chat(ctx, uc.UserId, id, assistant.ChatReq{
+		Action:  assistant.Action(req.Action),
+		Content: req.Content,
+		Lang:    req.Lang,
+	}, func(chunk string) error {
+		return send("delta", gin.H{"content": chunk})
+	})
+	switch {
+	case err == nil:
+		return ginx.SuccessWithData(assistantMessageToVO(msg)), nil
+	case errors.Is(err, assistant.ErrSessionNotFound), errors.Is(err, ink.ErrNotFound):
+		return ginx.NotFound(), nil
+	case errors.Is(err, assistant.ErrEmptyContent):
+		return ginx.InvalidParamWithMsg("内容不能为空"), nil
+	case errors.Is(err, assistant.ErrInvalidAction):
+		return ginx.InvalidParam(), nil
+	case errors.Is(err, ai.ErrBudgetExhausted):
+		return ginx.QuotaExceeded("今日写作助手额度已用完"), nil
+	case ctx.Request.Context().Err() != nil:
+		// 客户端已经断开, 本轮对话不保存
+		return ginx.Success(), nil
+	default:
+		return ginx.InternalError(), err
+	}
+}
@@ -0,0 +1,65 @@
+package web
+
+import (
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/assistant"
+)
+
+type CreateAssistantSessionReq struct {
+	InkId int64 `json:"inkId,string" binding:"required"`
+}
+
+type ListAssistantSessionReq struct {
+	InkId  int64 `json:"inkId,string" form:"inkId"`
+	Offset int   `json:"offset" form:"offset"`
+	Limit  int   `json:"limit" form:"limit" binding:"required,max=100"`
+}
+
+type ListAssistantMessageReq struct {
+	MaxId int64 `json:"maxId,string" form:"maxId"`
+	Limit int   `json:"limit" form:"limit" binding:"required,max=100"`
+}
+
+type AssistantChatReq struct {
+	// Action 为空时为普通对话
+	Action  string `json:"action" binding:"omitempty,oneof=chat continue polish translate title summary"`
+	Content string `json:"content"`
+	Lang    string `json:"lang" binding:"max=32"`
+}
+
+type AssistantSessionVO struct {
+	Id        int64     `json:"id,string"`
+	InkId     int64     `json:"inkId,string"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type AssistantMessageVO struct {
+	Id        int64     `json:"id,string"`
+	Role      string    `json:"role"`
+	Action    string    `json:"action"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func assistantSessionToVO(session assistant.Session) AssistantSessionVO {
+	return AssistantSessionVO{
+		Id:        session.Id,
+		InkId:     session.InkId,
+		Title:     session.Title,
+		CreatedAt: session.CreatedAt,
+		UpdatedAt: session.UpdatedAt,
+	}
+}
+
+func assistantMessageToVO(msg assistant.Message) AssistantMessageVO {
+	return AssistantMessageVO{
+		Id:        msg.Id,
+		Role:      string(msg.Role),
+		Action:    string(msg.Action),
+		Content:   msg.Content,
+		CreatedAt: msg.CreatedAt,
+	}
+}
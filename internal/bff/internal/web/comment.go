@@ -1,6 +1,7 @@
 package web
 
 import (
+	"errors"
 	"github.com/KNICEX/InkFlow/internal/comment"
 	"github.com/KNICEX/InkFlow/internal/relation"
 	"github.com/KNICEX/InkFlow/internal/user"
@@ -66,10 +67,18 @@ func (h *CommentHandler) Reply(ctx *gin.Context, req PostReplyReq) (ginx.Result,
 			Id: req.RootId,
 		},
 	})
-	if err != nil {
+	switch {
+	case err == nil:
+		return ginx.SuccessWithData(strconv.FormatInt(id, 10)), nil
+	case errors.Is(err, comment.ErrSensitiveContent):
+		return ginx.BizError("评论包含违规内容"), nil
+	case errors.Is(err, comment.ErrDuplicateComment):
+		return ginx.BizError("请勿重复评论"), nil
+	case errors.Is(err, comment.ErrCommentTooFrequent):
+		return ginx.BizError("评论太频繁, 请稍后再试"), nil
+	default:
 		return ginx.InternalError(), err
 	}
-	return ginx.SuccessWithData(strconv.FormatInt(id, 10)), nil
 }
 
 func (h *CommentHandler) DelComment(ctx *gin.Context) (ginx.Result, error) {
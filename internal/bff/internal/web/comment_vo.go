@@ -18,6 +18,10 @@ type CommentVO struct {
 	Stats       CommentStats `json:"stats"`
 	Children    []CommentVO  `json:"children"`
 	CreatedAt   time.Time    `json:"createdAt"`
+	// Pending 评论正在审核, 只有评论者本人能看到
+	Pending bool `json:"pending"`
+	// Highlights 见 InkVO.Highlights
+	Highlights map[string]string `json:"highlights,omitempty"`
 }
 
 type CommentStats struct {
@@ -67,6 +71,7 @@ func commentToVO(com comment.Comment) CommentVO {
 		Children:  children,
 		Stats:     commentStatsToVO(com.Stats),
 		CreatedAt: com.CreatedAt,
+		Pending:   com.Status == comment.StatusPending,
 	}
 }
 
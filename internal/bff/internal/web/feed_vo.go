@@ -1,6 +1,9 @@
 package web
 
-import "github.com/KNICEX/InkFlow/internal/feed"
+import (
+	"github.com/KNICEX/InkFlow/internal/bff/internal/service"
+	"github.com/KNICEX/InkFlow/internal/feed"
+)
 
 type FeedFollowReq struct {
 	MaxId     int64 `json:"maxId,string" form:"maxId"`
@@ -19,8 +22,12 @@ func feedInkToVO(feedInk feed.Ink) InkVO {
 		Author: UserVO{
 			Id: feedInk.AuthorId,
 		},
+		Summary:     feedInk.Summary,
 		ContentHtml: feedInk.Abstract,
+		WordCount:   feedInk.WordCount,
+		ReadingTime: feedInk.ReadingTime,
 		Cover:       feedInk.Cover,
+		CoverThumb:  service.ThumbnailURL(feedInk.Cover),
 		Title:       feedInk.Title,
 		CreatedAt:   feedInk.CreatedAt,
 		UpdatedAt:   feedInk.CreatedAt,
@@ -1,95 +1,182 @@
 package web
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/KNICEX/InkFlow/internal/bff/internal/service"
 	"github.com/KNICEX/InkFlow/pkg/ginx"
+	"github.com/KNICEX/InkFlow/pkg/ginx/jwt"
 	"github.com/KNICEX/InkFlow/pkg/ginx/middleware"
 	"github.com/KNICEX/InkFlow/pkg/logx"
-	"github.com/cloudinary/cloudinary-go/v2"
-	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 	"github.com/gin-gonic/gin"
 )
 
+// LocalFileRoute 本地存储时静态文件的访问路由
+const LocalFileRoute = "/files"
+
+// uploadedSizeKey 上传成功后记录实际占用的字节数, 用于归还多占用的配额
+const uploadedSizeKey = "uploaded_size"
+
+// 超出上传配额的具体原因
+const (
+	quotaReasonCount  = "count"
+	quotaReasonBytes  = "bytes"
+	quotaReasonBanned = "banned"
+)
+
 type FileHandler struct {
-	auth  middleware.Authentication
-	cloud *cloudinary.Cloudinary
-	l     logx.Logger
+	auth      middleware.Authentication
+	svc       service.FileService
+	processor service.ImageProcessor
+	guard     service.UploadGuard
+	l         logx.Logger
 }
 
-func NewFileHandler(cloud *cloudinary.Cloudinary, auth middleware.Authentication, l logx.Logger) *FileHandler {
+func NewFileHandler(svc service.FileService, processor service.ImageProcessor, guard service.UploadGuard,
+	auth middleware.Authentication, l logx.Logger) *FileHandler {
 	return &FileHandler{
-		auth:  auth,
-		cloud: cloud,
-		l:     l,
+		auth:      auth,
+		svc:       svc,
+		processor: processor,
+		guard:     guard,
+		l:         l,
 	}
 }
 
 func (handler *FileHandler) RegisterRoutes(server *gin.RouterGroup) {
+	if static, ok := handler.svc.(service.StaticFileService); ok {
+		server.Static(LocalFileRoute, static.Dir())
+	}
 	fileGroup := server.Group("/file", handler.auth.CheckLogin(), handler.monitor())
 	fileGroup.POST("/avatar", ginx.Wrap(handler.l, handler.UploadAvatar))
 	fileGroup.POST("/cover", ginx.Wrap(handler.l, handler.UploadCover))
 	fileGroup.POST("/image", ginx.Wrap(handler.l, handler.UploadImage))
 }
 
+// monitor 监控用户异常上传行为, 按用户和上传目录限制每天的文件数和字节数.
+// 读取请求体前按请求长度占用配额, 上传被拒绝时全部归还, 成功时归还多占用的字节数
 func (handler *FileHandler) monitor() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		// 监控用户异常上传行为
+		// 限制请求体大小, 预留 multipart 表单的额外开销
+		maxBodySize := int64(service.MaxImageSize + 1<<20)
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBodySize)
+
+		uc := jwt.MustGetUserClaims(ctx)
+		// 路由最后一段即上传目录
+		folder := path.Base(ctx.FullPath())
+		size := ctx.Request.ContentLength
+		if size < 0 || size > maxBodySize {
+			// chunked 请求拿不到长度, 按最大值占用配额
+			size = maxBodySize
+		}
+
+		err := handler.guard.Acquire(ctx, uc.UserId, folder, size)
+		var reason string
+		switch {
+		case err == nil:
+			ctx.Next()
+			handler.settle(ctx, uc.UserId, folder, size)
+			return
+		case errors.Is(err, service.ErrUploadBanned):
+			reason = quotaReasonBanned
+		case errors.Is(err, service.ErrUploadBytesExceeded):
+			reason = quotaReasonBytes
+		case errors.Is(err, service.ErrUploadCountExceeded):
+			reason = quotaReasonCount
+		default:
+			// 配额检查失败不影响正常上传
+			handler.l.WithCtx(ctx).Error("check upload quota error", logx.Error(err),
+				logx.Int64("uid", uc.UserId), logx.String("folder", folder))
+			ctx.Next()
+			return
+		}
+		handler.l.WithCtx(ctx).Warn("upload quota exceeded", logx.Error(err),
+			logx.Int64("uid", uc.UserId), logx.String("folder", folder), logx.Int64("size", size))
+		ctx.AbortWithStatusJSON(http.StatusOK, ginx.QuotaExceeded(UploadQuotaVO{Reason: reason}))
 	}
 }
 
-func (handler *FileHandler) UploadAvatar(ctx *gin.Context) (ginx.Result, error) {
-	// 获取图片流
-	multi, err := ctx.FormFile("avatar")
-	if err != nil {
-		return ginx.InvalidParam(), err
+// settle 根据上传结果归还多占用的配额
+func (handler *FileHandler) settle(ctx *gin.Context, uid int64, folder string, reserved int64) {
+	files, size := int64(1), reserved
+	if uploaded, ok := ctx.Get(uploadedSizeKey); ok {
+		files, size = 0, reserved-uploaded.(int64)
 	}
-
-	// TODO 压缩图片
-
-	reader, err := multi.Open()
-	if err != nil {
-		return ginx.InternalError(), err
+	if files == 0 && size <= 0 {
+		return
 	}
-
-	resp, err := handler.cloud.Upload.Upload(ctx, reader, uploader.UploadParams{})
-	if err != nil {
-		return ginx.InternalError(), err
+	if err := handler.guard.Release(ctx, uid, folder, files, size); err != nil {
+		handler.l.WithCtx(ctx).Error("release upload quota error", logx.Error(err),
+			logx.Int64("uid", uid), logx.String("folder", folder))
 	}
-	return ginx.SuccessWithData(resp.URL), nil
+}
+
+func (handler *FileHandler) UploadAvatar(ctx *gin.Context) (ginx.Result, error) {
+	return handler.upload(ctx, "avatar", service.FolderAvatar)
 }
 
 func (handler *FileHandler) UploadCover(ctx *gin.Context) (ginx.Result, error) {
+	return handler.upload(ctx, "cover", service.FolderCover)
+}
+
+func (handler *FileHandler) UploadImage(ctx *gin.Context) (ginx.Result, error) {
+	return handler.upload(ctx, "image", service.FolderImage)
+}
+
+// upload 读取表单中 field 对应的图片, 处理后连同缩略图一起上传到 folder 目录,
+// 返回原图地址, 缩略图地址可通过 service.ThumbnailURL 得到
+func (handler *FileHandler) upload(ctx *gin.Context, field, folder string) (ginx.Result, error) {
 	// 获取图片流
-	multi, err := ctx.FormFile("cover")
+	multi, err := ctx.FormFile(field)
 	if err != nil {
 		return ginx.InvalidParam(), err
 	}
+	if multi.Size > service.MaxImageSize {
+		return ginx.BizError("图片过大"), nil
+	}
 
 	reader, err := multi.Open()
 	if err != nil {
 		return ginx.InternalError(), err
 	}
-
-	resp, err := handler.cloud.Upload.Upload(ctx, reader, uploader.UploadParams{})
+	defer reader.Close()
+	data, err := io.ReadAll(io.LimitReader(reader, service.MaxImageSize+1))
 	if err != nil {
 		return ginx.InternalError(), err
 	}
-	return ginx.SuccessWithData(resp.URL), nil
-}
 
-func (handler *FileHandler) UploadImage(ctx *gin.Context) (ginx.Result, error) {
-	multi, err := ctx.FormFile("image")
-	if err != nil {
-		return ginx.InvalidParam(), err
+	img, err := handler.processor.Process(data, folder)
+	switch {
+	case errors.Is(err, service.ErrImageTooLarge):
+		return ginx.BizError("图片过大"), nil
+	case errors.Is(err, service.ErrNotImage):
+		return ginx.BizError("仅支持 jpeg/png/gif 格式的图片"), nil
+	case err != nil:
+		return ginx.InternalError(), err
 	}
 
-	reader, err := multi.Open()
+	name := service.NewObjectName(img.Ext)
+	url, err := handler.svc.Upload(ctx, bytes.NewReader(img.Data), service.UploadParams{
+		Folder:      folder,
+		Name:        name,
+		ContentType: img.ContentType,
+	})
 	if err != nil {
 		return ginx.InternalError(), err
 	}
-
-	resp, err := handler.cloud.Upload.Upload(ctx, reader, uploader.UploadParams{})
+	_, err = handler.svc.Upload(ctx, bytes.NewReader(img.Thumb), service.UploadParams{
+		Folder:      folder,
+		Name:        strings.TrimSuffix(name, img.Ext) + service.ThumbSuffix + img.Ext,
+		ContentType: img.ContentType,
+	})
 	if err != nil {
 		return ginx.InternalError(), err
 	}
-	return ginx.SuccessWithData(resp.URL), nil
+	ctx.Set(uploadedSizeKey, multi.Size)
+	return ginx.SuccessWithData(url), nil
 }
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"github.com/KNICEX/InkFlow/internal/ink"
 	"github.com/KNICEX/InkFlow/internal/interactive"
+	"github.com/KNICEX/InkFlow/internal/poll"
+	"github.com/KNICEX/InkFlow/internal/review"
 	"github.com/KNICEX/InkFlow/internal/workflow/inkpub"
 	"github.com/KNICEX/InkFlow/pkg/ginx"
 	"github.com/KNICEX/InkFlow/pkg/ginx/jwt"
@@ -11,6 +13,7 @@ import (
 	"github.com/KNICEX/InkFlow/pkg/logx"
 	"github.com/gin-gonic/gin"
 	"github.com/samber/lo"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
 	"golang.org/x/sync/errgroup"
 	"strconv"
@@ -20,11 +23,17 @@ import (
 const (
 	bizInk = "ink"
 
-	inkPubQueue = "ink-pub-queue"
+	inkPubQueue      = "ink-pub-queue"
+	searchIndexQueue = "search-index-queue"
+
+	// maxAppeals 每篇 ink 最多申诉次数
+	maxAppeals = 3
 )
 
 type InkHandler struct {
 	svc            ink.Service
+	revisionSvc    ink.RevisionService
+	moderationSvc  review.ModerationService
 	inkRankService ink.RankingService
 	workflowCli    client.Client
 	interactiveSvc interactive.Service
@@ -35,17 +44,19 @@ type InkHandler struct {
 	l              logx.Logger
 }
 
-func NewInkHandler(svc ink.Service, userAggregate *UserAggregate, intrAggregate *InteractiveAggregate,
+func NewInkHandler(svc ink.Service, revisionSvc ink.RevisionService, moderationSvc review.ModerationService, pollSvc poll.Service, userAggregate *UserAggregate, intrAggregate *InteractiveAggregate,
 	intrSvc interactive.Service,
 	auth middleware.Authentication,
 	workflowCli client.Client, l logx.Logger) *InkHandler {
 	return &InkHandler{
 		svc:            svc,
+		revisionSvc:    revisionSvc,
+		moderationSvc:  moderationSvc,
 		workflowCli:    workflowCli,
 		interactiveSvc: intrSvc,
 		auth:           auth,
 		userAggregate:  userAggregate,
-		inkAggregate:   NewInkAggregate(svc, userAggregate, intrAggregate),
+		inkAggregate:   NewInkAggregate(svc, pollSvc, userAggregate, intrAggregate, l),
 		intrAggregate:  intrAggregate,
 		l:              l,
 	}
@@ -60,13 +71,17 @@ func (h *InkHandler) RegisterRoutes(server *gin.RouterGroup) {
 	checkGroup := inkGroup.Use(h.auth.CheckLogin())
 	{
 		checkGroup.POST("/draft/save", ginx.WrapBody(h.l, h.SaveDraft))
-		checkGroup.POST("/draft/publish/:id", ginx.Wrap(h.l, h.Publish))
+		checkGroup.POST("/draft/publish/:id", ginx.WrapBody(h.l, h.Publish))
 
 		checkGroup.GET("/draft", ginx.WrapBody(h.l, h.ListDraft))
 		checkGroup.GET("/pending", ginx.WrapBody(h.l, h.ListPending))
 		checkGroup.GET("/private", ginx.WrapBody(h.l, h.ListPrivate))
 		checkGroup.GET("/rejected", ginx.WrapBody(h.l, h.ListReviewRejected))
+		checkGroup.GET("/scheduled", ginx.WrapBody(h.l, h.ListScheduled))
+		checkGroup.POST("/scheduled/reschedule/:id", ginx.WrapBody(h.l, h.Reschedule))
+		checkGroup.POST("/scheduled/cancel/:id", ginx.Wrap(h.l, h.CancelSchedule))
 		checkGroup.GET("/rejected/:id", ginx.Wrap(h.l, h.DetailRejected))
+		checkGroup.POST("/rejected/appeal/:id", ginx.WrapBody(h.l, h.Appeal))
 
 		checkGroup.GET("/draft/:id", ginx.Wrap(h.l, h.DetailDraft))
 		checkGroup.GET("/pending/:id", ginx.Wrap(h.l, h.DetailPending))
@@ -80,6 +95,11 @@ func (h *InkHandler) RegisterRoutes(server *gin.RouterGroup) {
 		checkGroup.GET("/favorited", ginx.WrapBody(h.l, h.ListFavorited))
 		checkGroup.POST("/withdraw/:id", ginx.Wrap(h.l, h.Withdraw))
 
+		checkGroup.GET("/revision", ginx.WrapBody(h.l, h.ListRevision))
+		checkGroup.GET("/revision/:id", ginx.Wrap(h.l, h.DetailRevision))
+		checkGroup.GET("/revision/diff", ginx.WrapBody(h.l, h.DiffRevision))
+		checkGroup.POST("/revision/rollback/:id", ginx.Wrap(h.l, h.RollbackRevision))
+
 		checkGroup.POST("/like/:id", ginx.Wrap(h.l, h.Like))
 		checkGroup.DELETE("/like/:id", ginx.Wrap(h.l, h.CancelLike))
 		checkGroup.POST("/favorite/:id", ginx.WrapBody(h.l, h.Favorite))
@@ -103,6 +123,9 @@ func (h *InkHandler) SaveDraft(ctx *gin.Context, req SaveInkReq) (ginx.Result, e
 		},
 	})
 	if err != nil {
+		if res, ok := inkStatusErrResult(err); ok {
+			return res, nil
+		}
 		return ginx.InternalError(), err
 	}
 	type SaveResp struct {
@@ -113,29 +136,36 @@ func (h *InkHandler) SaveDraft(ctx *gin.Context, req SaveInkReq) (ginx.Result, e
 	}), nil
 }
 
-func (h *InkHandler) Publish(ctx *gin.Context) (ginx.Result, error) {
+func (h *InkHandler) Publish(ctx *gin.Context, req PublishReq) (ginx.Result, error) {
 	uc := jwt.MustGetUserClaims(ctx)
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.InvalidParam(), err
+	}
+	var publishAt time.Time
+	if req.PublishAt != nil {
+		publishAt = *req.PublishAt
+	}
 
 	err = h.svc.Publish(ctx, ink.Ink{
 		Id: id,
 		Author: ink.Author{
 			Id: uc.UserId,
 		},
+		ScheduledAt: publishAt,
 	})
 	if err != nil {
+		if errors.Is(err, ink.ErrInvalidSchedule) {
+			return ginx.BizError("发布时间不能早于当前时间"), nil
+		}
+		if res, ok := inkStatusErrResult(err); ok {
+			return res, nil
+		}
 		return ginx.InternalError(), err
 	}
 
-	_, err = h.workflowCli.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
-		ID:        inkpub.WorkflowId(id, time.Now()),
-		TaskQueue: inkPubQueue,
-	}, inkpub.InkPublish, id, uc.UserId)
-	if err != nil {
-		h.l.WithCtx(ctx).Error("start ink publish workflow failed",
-			logx.Int64("inkId", id),
-			logx.Error(err))
-		return ginx.InternalError(), err
+	if err = h.startPublishWorkflow(ctx, id, uc.UserId); err != nil {
+		return publishWorkflowErrResult(err)
 	}
 
 	type PublishResp struct {
@@ -146,6 +176,48 @@ func (h *InkHandler) Publish(ctx *gin.Context) (ginx.Result, error) {
 	}), nil
 }
 
+// startPublishWorkflow 发起发布流程, 草稿需要已经处于待审核状态.
+// 同一篇 ink 同时只能有一个流程, 不会挂到仍在运行的旧流程上
+func (h *InkHandler) startPublishWorkflow(ctx *gin.Context, id, uid int64) error {
+	_, err := h.workflowCli.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:                                       inkpub.WorkflowId(id),
+		TaskQueue:                                inkPubQueue,
+		WorkflowExecutionErrorWhenAlreadyStarted: true,
+	}, inkpub.InkPublish, id, uid)
+	if err != nil {
+		h.l.WithCtx(ctx).Error("start ink publish workflow failed",
+			logx.Int64("inkId", id),
+			logx.Error(err))
+		// 流程未启动, 恢复为草稿以便重新发布
+		if er := h.svc.UpdateDraftStatus(ctx, id, uid, ink.StatusUnPublished); er != nil {
+			h.l.WithCtx(ctx).Error("restore unpublished ink status failed",
+				logx.Int64("inkId", id),
+				logx.Error(er))
+		}
+	}
+	return err
+}
+
+func publishWorkflowErrResult(err error) (ginx.Result, error) {
+	var started *serviceerror.WorkflowExecutionAlreadyStarted
+	if errors.As(err, &started) {
+		return ginx.BizError("文章正在发布中, 请稍后再试"), nil
+	}
+	return ginx.InternalError(), err
+}
+
+// inkStatusErrResult 处理草稿已绑定到发布流程时的修改
+func inkStatusErrResult(err error) (ginx.Result, bool) {
+	switch {
+	case errors.Is(err, ink.ErrInkUnderReview):
+		return ginx.BizError("文章正在审核中"), true
+	case errors.Is(err, ink.ErrInkScheduled):
+		return ginx.BizError("文章正在等待定时发布, 请先取消定时"), true
+	default:
+		return ginx.Result{}, false
+	}
+}
+
 func (h *InkHandler) Detail(ctx *gin.Context) (ginx.Result, error) {
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
 	if err != nil {
@@ -222,6 +294,52 @@ func (h *InkHandler) DetailRejected(ctx *gin.Context) (ginx.Result, error) {
 	return ginx.SuccessWithData(inkToVO(draft)), nil
 }
 
+// Appeal 对被拒绝的 ink 发起申诉, 重新进入人工审核
+func (h *InkHandler) Appeal(ctx *gin.Context, req AppealReq) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.InvalidParam(), err
+	}
+	if _, err = h.svc.FindRejectedInk(ctx, id, uc.UserId); err != nil {
+		switch {
+		case errors.Is(err, ink.ErrNoPermission):
+			return ginx.NoPermission(), err
+		case errors.Is(err, ink.ErrNotFound):
+			return ginx.NotFound(), nil
+		default:
+			return ginx.InternalError(), err
+		}
+	}
+
+	cnt, err := h.moderationSvc.CountAppeals(ctx, review.TypeInk, id)
+	if err != nil {
+		return ginx.InternalError(), err
+	}
+	if cnt >= maxAppeals {
+		return ginx.BizError("申诉次数已达上限"), nil
+	}
+
+	if err = h.svc.UpdateDraftStatus(ctx, id, uc.UserId, ink.StatusPending); err != nil {
+		return ginx.InternalError(), err
+	}
+	_, err = h.workflowCli.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:                                       inkpub.WorkflowId(id),
+		TaskQueue:                                inkPubQueue,
+		WorkflowExecutionErrorWhenAlreadyStarted: true,
+	}, inkpub.InkAppeal, id, uc.UserId, req.Reason)
+	if err != nil {
+		// 流程未启动, 恢复为已拒绝以便重新申诉
+		if er := h.svc.UpdateDraftStatus(ctx, id, uc.UserId, ink.StatusRejected); er != nil {
+			h.l.WithCtx(ctx).Error("restore rejected ink status failed",
+				logx.Int64("inkId", id),
+				logx.Error(er))
+		}
+		return publishWorkflowErrResult(err)
+	}
+	return ginx.Success(), nil
+}
+
 func (h *InkHandler) Withdraw(ctx *gin.Context) (ginx.Result, error) {
 	u := jwt.MustGetUserClaims(ctx)
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
@@ -334,6 +452,65 @@ func (h *InkHandler) ListPending(ctx *gin.Context, req ListSelfReq) (ginx.Result
 	return ginx.SuccessWithData(res), nil
 }
 
+func (h *InkHandler) ListScheduled(ctx *gin.Context, req ListSelfReq) (ginx.Result, error) {
+	u := jwt.MustGetUserClaims(ctx)
+	inks, err := h.svc.ListScheduledByAuthorId(ctx, u.UserId, req.Offset, req.Limit)
+	if err != nil {
+		return ginx.InternalError(), err
+	}
+	res := make([]InkVO, 0, len(inks))
+	for _, item := range inks {
+		res = append(res, inkToVO(item))
+	}
+	return ginx.SuccessWithData(res), nil
+}
+
+// Reschedule 修改定时发布时间, 审核中的 ink 也可以修改
+func (h *InkHandler) Reschedule(ctx *gin.Context, req RescheduleReq) (ginx.Result, error) {
+	if req.PublishAt.Before(time.Now()) {
+		return ginx.BizError("发布时间不能早于当前时间"), nil
+	}
+	return h.signalSchedule(ctx, inkpub.Schedule{
+		PublishAt: req.PublishAt,
+	})
+}
+
+func (h *InkHandler) CancelSchedule(ctx *gin.Context) (ginx.Result, error) {
+	return h.signalSchedule(ctx, inkpub.Schedule{
+		Cancel: true,
+	})
+}
+
+func (h *InkHandler) signalSchedule(ctx *gin.Context, s inkpub.Schedule) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.InvalidParam(), err
+	}
+	if _, err = h.svc.FindScheduledInk(ctx, id, uc.UserId); err != nil {
+		if errors.Is(err, ink.ErrNotFound) {
+			return ginx.NotFound(), nil
+		}
+		return ginx.InternalError(), err
+	}
+	if !s.Cancel {
+		// 审核中的流程要等审核结束才处理信号, 先写库保证展示的发布时间是最新的
+		if err = h.svc.Reschedule(ctx, id, uc.UserId, s.PublishAt); err != nil {
+			return ginx.InternalError(), err
+		}
+	}
+	err = h.workflowCli.SignalWorkflow(ctx, inkpub.WorkflowId(id), "", inkpub.ScheduleSignal, s)
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			// 发布流程已经结束
+			return ginx.BizError("文章已发布"), nil
+		}
+		return ginx.InternalError(), err
+	}
+	return ginx.Success(), nil
+}
+
 func (h *InkHandler) ListReviewRejected(ctx *gin.Context, req ListSelfReq) (ginx.Result, error) {
 	u := jwt.MustGetUserClaims(ctx)
 	inks, err := h.svc.ListReviewRejectedByAuthorId(ctx, u.UserId, req.Offset, req.Limit)
@@ -506,3 +683,70 @@ func (h *InkHandler) CancelFavorite(ctx *gin.Context) (ginx.Result, error) {
 	}
 	return ginx.Success(), nil
 }
+
+func (h *InkHandler) ListRevision(ctx *gin.Context, req ListRevisionReq) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	revisions, err := h.revisionSvc.List(ctx, req.InkId, uc.UserId, req.Offset, req.Limit)
+	if err != nil {
+		return ginx.InternalError(), err
+	}
+	return ginx.SuccessWithData(lo.Map(revisions, func(item ink.Revision, index int) RevisionVO {
+		// 列表不返回正文
+		item.ContentHtml = ""
+		item.ContentMeta = ""
+		return revisionToVO(item)
+	})), nil
+}
+
+func (h *InkHandler) DetailRevision(ctx *gin.Context) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.InvalidParam(), err
+	}
+	r, err := h.revisionSvc.FindById(ctx, id, uc.UserId)
+	if err != nil {
+		return h.revisionErrResult(err)
+	}
+	return ginx.SuccessWithData(revisionToVO(r)), nil
+}
+
+func (h *InkHandler) DiffRevision(ctx *gin.Context, req DiffRevisionReq) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	diff, err := h.revisionSvc.Diff(ctx, uc.UserId, req.From, req.To)
+	if err != nil {
+		return h.revisionErrResult(err)
+	}
+	return ginx.SuccessWithData(revisionDiffToVO(diff)), nil
+}
+
+// RollbackRevision 将草稿回滚到历史版本并重新走发布审核流程
+func (h *InkHandler) RollbackRevision(ctx *gin.Context) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.InvalidParam(), err
+	}
+	draft, err := h.revisionSvc.Rollback(ctx, id, uc.UserId)
+	if err != nil {
+		if res, ok := inkStatusErrResult(err); ok {
+			return res, nil
+		}
+		return h.revisionErrResult(err)
+	}
+	if err = h.startPublishWorkflow(ctx, draft.Id, uc.UserId); err != nil {
+		return publishWorkflowErrResult(err)
+	}
+	return ginx.Success(), nil
+}
+
+func (h *InkHandler) revisionErrResult(err error) (ginx.Result, error) {
+	switch {
+	case errors.Is(err, ink.ErrRevisionNotFound):
+		return ginx.NotFound(), nil
+	case errors.Is(err, ink.ErrNoPermission):
+		return ginx.NoPermission(), err
+	default:
+		return ginx.InternalError(), err
+	}
+}
@@ -3,7 +3,10 @@ package web
 import (
 	"time"
 
+	"github.com/KNICEX/InkFlow/internal/bff/internal/service"
 	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/pkg/diffx"
+	"github.com/samber/lo"
 )
 
 type SaveInkReq struct {
@@ -11,26 +14,43 @@ type SaveInkReq struct {
 	Title       string   `json:"title" binding:"required,max=100"`
 	Cover       string   `json:"cover"`
 	Summary     string   `json:"summary"`
-	ContentHtml string   `json:"contentHtml"`
-	ContentMeta string   `json:"contentMeta" binding:"required"`
+	ContentHtml string   `json:"contentHtml" binding:"max=400000"`
+	ContentMeta string   `json:"contentMeta" binding:"required,max=200000"`
 	Tags        []string `json:"tags"`
 }
 
+type PublishReq struct {
+	// PublishAt 定时发布时间, 为空时审核通过后立即发布
+	PublishAt *time.Time `json:"publishAt"`
+}
+
+type RescheduleReq struct {
+	PublishAt time.Time `json:"publishAt" binding:"required"`
+}
+
 type InkVO struct {
 	Id          int64         `json:"id,string"`
 	Title       string        `json:"title"`
 	Author      UserVO        `json:"author"`
 	Cover       string        `json:"cover"`
+	CoverThumb  string        `json:"coverThumb"`
 	Summary     string        `json:"summary"`
 	Category    InkCategory   `json:"category"`
 	ContentType int           `json:"contentType"`
 	Tags        []string      `json:"tags"`
 	ContentHtml string        `json:"contentHtml"`
 	ContentMeta string        `json:"contentMeta"`
+	WordCount   int           `json:"wordCount"`
+	ReadingTime int           `json:"readingTime"`
 	Status      int           `json:"status"`
 	CreatedAt   time.Time     `json:"createdAt"`
 	UpdatedAt   time.Time     `json:"updatedAt"`
+	ScheduledAt *time.Time    `json:"scheduledAt,omitempty"`
 	Interactive InteractiveVO `json:"interactive"`
+	// Poll 文章附带的投票, 仅详情返回
+	Poll *PollVO `json:"poll,omitempty"`
+	// Highlights 搜索结果的高亮片段, 字段 -> 片段, 命中的词用 <em> 包裹
+	Highlights map[string]string `json:"highlights,omitempty"`
 }
 
 type InkCategory struct {
@@ -39,19 +59,27 @@ type InkCategory struct {
 }
 
 func inkToVO(i ink.Ink) InkVO {
+	var scheduledAt *time.Time
+	if !i.ScheduledAt.IsZero() {
+		scheduledAt = &i.ScheduledAt
+	}
 	return InkVO{
 		Id:          i.Id,
 		Title:       i.Title,
 		Summary:     i.Summary,
 		Cover:       i.Cover,
+		CoverThumb:  service.ThumbnailURL(i.Cover),
 		Tags:        i.Tags,
 		Category:    InkCategory{Id: i.Category.Id},
 		ContentType: i.ContentType.ToInt(),
 		ContentHtml: i.ContentHtml,
 		ContentMeta: i.ContentMeta,
+		WordCount:   i.WordCount,
+		ReadingTime: i.ReadingTime,
 		Status:      i.Status.ToInt(),
 		CreatedAt:   i.CreatedAt,
 		UpdatedAt:   i.UpdatedAt,
+		ScheduledAt: scheduledAt,
 	}
 }
 
@@ -91,3 +119,84 @@ type ListFavoriteReq struct {
 type FavoriteReq struct {
 	FavoriteId int64 `json:"favoriteId,string" from:"favoriteId"`
 }
+
+type ListRevisionReq struct {
+	InkId  int64 `json:"inkId,string" form:"inkId" binding:"required"`
+	Offset int   `json:"offset" form:"offset"`
+	Limit  int   `json:"limit" form:"limit" binding:"required,max=100"`
+}
+
+type DiffRevisionReq struct {
+	From int64 `json:"from,string" form:"from" binding:"required"`
+	// To 为空时与当前草稿比较
+	To int64 `json:"to,string" form:"to"`
+}
+
+type RevisionVO struct {
+	Id          int64     `json:"id,string"`
+	Version     int       `json:"version"`
+	InkId       int64     `json:"inkId,string"`
+	Title       string    `json:"title"`
+	Cover       string    `json:"cover"`
+	Summary     string    `json:"summary"`
+	ContentHtml string    `json:"contentHtml,omitempty"`
+	ContentMeta string    `json:"contentMeta,omitempty"`
+	Tags        []string  `json:"tags"`
+	AiTags      []string  `json:"aiTags"`
+	Passed      bool      `json:"passed"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func revisionToVO(r ink.Revision) RevisionVO {
+	return RevisionVO{
+		Id:          r.Id,
+		Version:     r.Version,
+		InkId:       r.InkId,
+		Title:       r.Title,
+		Cover:       r.Cover,
+		Summary:     r.Summary,
+		ContentHtml: r.ContentHtml,
+		ContentMeta: r.ContentMeta,
+		Tags:        r.Tags,
+		AiTags:      r.AiTags,
+		Passed:      r.Review.Passed,
+		Reason:      r.Review.Reason,
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+type DiffLineVO struct {
+	// Op equal / insert / delete
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+type RevisionDiffVO struct {
+	From    int64        `json:"from,string"`
+	To      int64        `json:"to,string"`
+	Title   []DiffLineVO `json:"title"`
+	Content []DiffLineVO `json:"content"`
+	// ContentTooLarge 正文改动太大, 不返回逐行差异
+	ContentTooLarge bool     `json:"contentTooLarge"`
+	AddedTags       []string `json:"addedTags"`
+	RemovedTags     []string `json:"removedTags"`
+}
+
+func revisionDiffToVO(d ink.RevisionDiff) RevisionDiffVO {
+	toVO := func(item diffx.Line, index int) DiffLineVO {
+		return DiffLineVO{
+			Op:   item.Op.String(),
+			Text: item.Text,
+		}
+	}
+	return RevisionDiffVO{
+		From:            d.From,
+		To:              d.To,
+		Title:           lo.Map(d.Title, toVO),
+		Content:         lo.Map(d.Content, toVO),
+		ContentTooLarge: d.ContentTooLarge,
+		AddedTags:       d.AddedTags,
+		RemovedTags:     d.RemovedTags,
+	}
+}
@@ -0,0 +1,272 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/prompt"
+	"github.com/KNICEX/InkFlow/internal/review"
+	"github.com/KNICEX/InkFlow/internal/search"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
+	"github.com/KNICEX/InkFlow/internal/workflow/searchindex"
+	"github.com/KNICEX/InkFlow/pkg/ginx"
+	"github.com/KNICEX/InkFlow/pkg/ginx/jwt"
+	"github.com/KNICEX/InkFlow/pkg/ginx/middleware"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+)
+
+// ModerationHandler 人工审核队列、敏感词库、审核提示词管理和搜索质量报表, 仅审核员可以访问
+type ModerationHandler struct {
+	svc          review.ModerationService
+	sensitiveSvc sensitive.Service
+	promptSvc    prompt.Service
+	analyticsSvc search.AnalyticsService
+	workflowCli  client.Client
+	// moderators 审核员的用户 id
+	moderators []int64
+	auth       middleware.Authentication
+	l          logx.Logger
+}
+
+func NewModerationHandler(svc review.ModerationService, sensitiveSvc sensitive.Service, promptSvc prompt.Service,
+	analyticsSvc search.AnalyticsService, workflowCli client.Client, moderators []int64,
+	auth middleware.Authentication, l logx.Logger) *ModerationHandler {
+	return &ModerationHandler{
+		svc:          svc,
+		sensitiveSvc: sensitiveSvc,
+		promptSvc:    promptSvc,
+		analyticsSvc: analyticsSvc,
+		workflowCli:  workflowCli,
+		moderators:   moderators,
+		auth:         auth,
+		l:            l,
+	}
+}
+
+func (h *ModerationHandler) RegisterRoutes(server *gin.RouterGroup) {
+	moderationGroup := server.Group("/moderation", h.auth.CheckLogin(), h.checkModerator)
+	{
+		moderationGroup.GET("/tasks", ginx.WrapBody(h.l, h.List))
+		moderationGroup.POST("/tasks/:id/claim", ginx.Wrap(h.l, h.Claim))
+		moderationGroup.POST("/tasks/:id/approve", ginx.WrapBody(h.l, h.Approve))
+		moderationGroup.POST("/tasks/:id/reject", ginx.WrapBody(h.l, h.Reject))
+		moderationGroup.GET("/audits", ginx.WrapBody(h.l, h.ListAudits))
+
+		moderationGroup.GET("/sensitive-words", ginx.WrapBody(h.l, h.ListSensitiveWords))
+		moderationGroup.POST("/sensitive-words", ginx.WrapBody(h.l, h.AddSensitiveWords))
+		moderationGroup.DELETE("/sensitive-words", ginx.WrapBody(h.l, h.DelSensitiveWords))
+
+		moderationGroup.GET("/prompts", ginx.WrapBody(h.l, h.ListPrompts))
+		moderationGroup.POST("/prompts", ginx.WrapBody(h.l, h.CreatePrompt))
+		moderationGroup.POST("/prompts/weight", ginx.WrapBody(h.l, h.SetPromptWeight))
+
+		moderationGroup.GET("/search/zero-result", ginx.WrapBody(h.l, h.ZeroResultQueries))
+		moderationGroup.POST("/search/rebuild", ginx.WrapBody(h.l, h.RebuildSearchIndex))
+	}
+}
+
+func (h *ModerationHandler) checkModerator(ctx *gin.Context) {
+	uc := jwt.MustGetUserClaims(ctx)
+	if !slices.Contains(h.moderators, uc.UserId) {
+		ctx.AbortWithStatusJSON(http.StatusOK, ginx.NoPermission())
+		return
+	}
+	ctx.Next()
+}
+
+func (h *ModerationHandler) List(ctx *gin.Context, req ListModerationReq) (ginx.Result, error) {
+	status := review.ModerationStatusPending
+	if req.Status != "" {
+		status = review.ModerationStatus(req.Status)
+	}
+	tasks, err := h.svc.List(ctx, status, req.Offset, req.Limit)
+	if err != nil {
+		return ginx.InternalError(), err
+	}
+	return ginx.SuccessWithData(lo.Map(tasks, func(item review.ModerationTask, index int) ModerationTaskVO {
+		return moderationTaskToVO(item)
+	})), nil
+}
+
+func (h *ModerationHandler) Claim(ctx *gin.Context) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.InvalidParam(), err
+	}
+	return h.result(h.svc.Claim(ctx, id, uc.UserId))
+}
+
+func (h *ModerationHandler) Approve(ctx *gin.Context, req ModerationDecideReq) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.InvalidParam(), err
+	}
+	return h.result(h.svc.Approve(ctx, id, uc.UserId, req.Reason))
+}
+
+func (h *ModerationHandler) Reject(ctx *gin.Context, req ModerationDecideReq) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.InvalidParam(), err
+	}
+	if req.Reason == "" {
+		return ginx.BizError("请填写拒绝理由"), nil
+	}
+	return h.result(h.svc.Reject(ctx, id, uc.UserId, req.Reason))
+}
+
+func (h *ModerationHandler) ListAudits(ctx *gin.Context, req ListAuditReq) (ginx.Result, error) {
+	logs, err := h.svc.ListAudits(ctx, review.ReviewType(req.Biz), req.BizId)
+	if err != nil {
+		return ginx.InternalError(), err
+	}
+	return ginx.SuccessWithData(lo.Map(logs, func(item review.AuditLog, index int) AuditLogVO {
+		return auditLogToVO(item)
+	})), nil
+}
+
+func (h *ModerationHandler) ListSensitiveWords(ctx *gin.Context, req ListSensitiveWordReq) (ginx.Result, error) {
+	words, err := h.sensitiveSvc.ListWords(ctx, req.Category, req.Offset, req.Limit)
+	if err != nil {
+		return ginx.InternalError(), err
+	}
+	return ginx.SuccessWithData(lo.Map(words, func(item sensitive.Word, index int) SensitiveWordVO {
+		return sensitiveWordToVO(item)
+	})), nil
+}
+
+func (h *ModerationHandler) AddSensitiveWords(ctx *gin.Context, req AddSensitiveWordReq) (ginx.Result, error) {
+	words := lo.Map(req.Words, func(item SensitiveWordVO, index int) sensitive.Word {
+		return sensitive.Word{
+			Word:     item.Word,
+			Category: item.Category,
+		}
+	})
+	err := h.sensitiveSvc.AddWords(ctx, words)
+	if errors.Is(err, sensitive.ErrInvalidWord) {
+		return ginx.InvalidParamWithMsg("敏感词不能为空且不能超过 64 个字符"), nil
+	}
+	if err != nil {
+		return ginx.InternalError(), err
+	}
+	return ginx.Success(), nil
+}
+
+func (h *ModerationHandler) DelSensitiveWords(ctx *gin.Context, req DelSensitiveWordReq) (ginx.Result, error) {
+	ids := make([]int64, 0, len(req.Ids))
+	for _, item := range req.Ids {
+		id, err := strconv.ParseInt(item, 10, 64)
+		if err != nil {
+			return ginx.InvalidParam(), err
+		}
+		ids = append(ids, id)
+	}
+	if err := h.sensitiveSvc.DelWords(ctx, ids); err != nil {
+		return ginx.InternalError(), err
+	}
+	return ginx.Success(), nil
+}
+
+func (h *ModerationHandler) ListPrompts(ctx *gin.Context, req ListPromptReq) (ginx.Result, error) {
+	prompts, err := h.promptSvc.List(ctx, req.Purpose)
+	if err != nil {
+		return ginx.InternalError(), err
+	}
+	return ginx.SuccessWithData(lo.Map(prompts, func(item prompt.Prompt, index int) PromptVO {
+		return promptToVO(item)
+	})), nil
+}
+
+func (h *ModerationHandler) CreatePrompt(ctx *gin.Context, req CreatePromptReq) (ginx.Result, error) {
+	_, err := h.promptSvc.Create(ctx, prompt.Prompt{
+		Purpose:  req.Purpose,
+		Version:  req.Version,
+		Template: req.Template,
+		Weight:   req.Weight,
+		Remark:   req.Remark,
+	})
+	switch {
+	case errors.Is(err, prompt.ErrInvalidPrompt):
+		return ginx.InvalidParamWithMsg(err.Error()), nil
+	case errors.Is(err, prompt.ErrDuplicateVersion):
+		return ginx.BizError("版本已存在"), nil
+	case err != nil:
+		return ginx.InternalError(), err
+	}
+	return ginx.Success(), nil
+}
+
+func (h *ModerationHandler) SetPromptWeight(ctx *gin.Context, req SetPromptWeightReq) (ginx.Result, error) {
+	err := h.promptSvc.SetWeight(ctx, req.Purpose, req.Version, req.Weight)
+	switch {
+	case errors.Is(err, prompt.ErrPromptNotFound):
+		return ginx.NotFound(), nil
+	case errors.Is(err, prompt.ErrInvalidPrompt):
+		return ginx.InvalidParam(), nil
+	case err != nil:
+		return ginx.InternalError(), err
+	}
+	return ginx.Success(), nil
+}
+
+func (h *ModerationHandler) result(err error) (ginx.Result, error) {
+	switch {
+	case err == nil:
+		return ginx.Success(), nil
+	case errors.Is(err, review.ErrTaskNotFound):
+		return ginx.NotFound(), nil
+	case errors.Is(err, review.ErrTaskConflict):
+		return ginx.BizError("任务已被其他审核员处理"), nil
+	default:
+		return ginx.InternalError(), err
+	}
+}
+
+// ZeroResultQueries 最近 days 天没有搜索结果的搜索词, 默认 7 天
+func (h *ModerationHandler) ZeroResultQueries(ctx *gin.Context, req ZeroResultQueryReq) (ginx.Result, error) {
+	if req.Days <= 0 {
+		req.Days = 7
+	}
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+	since := time.Now().AddDate(0, 0, -req.Days)
+	stats, err := h.analyticsSvc.ZeroResultQueries(ctx, since, req.Offset, req.Limit)
+	if err != nil {
+		return ginx.InternalError(), err
+	}
+	return ginx.SuccessWithData(lo.Map(stats, func(item search.QueryStats, index int) QueryStatsVO {
+		return queryStatsToVO(item)
+	})), nil
+}
+
+// RebuildSearchIndex 从数据库全量重建搜索索引, types 为空时重建全部索引, 同一时间只能有一个重建流程
+func (h *ModerationHandler) RebuildSearchIndex(ctx *gin.Context, req RebuildSearchIndexReq) (ginx.Result, error) {
+	types := lo.Map(req.Types, func(item string, index int) search.SearchType {
+		return search.SearchType(item)
+	})
+	_, err := h.workflowCli.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:        searchindex.RebuildWorkflowId,
+		TaskQueue: searchIndexQueue,
+
+		WorkflowExecutionErrorWhenAlreadyStarted: true,
+	}, searchindex.RebuildSearchIndex, types)
+	if err != nil {
+		var started *serviceerror.WorkflowExecutionAlreadyStarted
+		if errors.As(err, &started) {
+			return ginx.BizError("索引正在重建"), nil
+		}
+		return ginx.InternalError(), err
+	}
+	return ginx.Success(), nil
+}
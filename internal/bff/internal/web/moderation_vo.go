@@ -0,0 +1,179 @@
+package web
+
+import (
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/prompt"
+	"github.com/KNICEX/InkFlow/internal/review"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
+)
+
+type ListModerationReq struct {
+	Status string `json:"status" form:"status" binding:"omitempty,oneof=pending claimed approved rejected"`
+	Offset int    `json:"offset" form:"offset"`
+	Limit  int    `json:"limit" form:"limit" binding:"required,max=100"`
+}
+
+type ModerationDecideReq struct {
+	Reason string `json:"reason" binding:"max=512"`
+}
+
+type ListAuditReq struct {
+	Biz   string `json:"biz" form:"biz" binding:"required"`
+	BizId int64  `json:"bizId,string" form:"bizId" binding:"required"`
+}
+
+type AppealReq struct {
+	Reason string `json:"reason" binding:"required,max=512"`
+}
+
+type ListSensitiveWordReq struct {
+	Category string `json:"category" form:"category"`
+	Offset   int    `json:"offset" form:"offset"`
+	Limit    int    `json:"limit" form:"limit" binding:"required,max=100"`
+}
+
+type AddSensitiveWordReq struct {
+	Words []SensitiveWordVO `json:"words" binding:"required,min=1,max=1000"`
+}
+
+type DelSensitiveWordReq struct {
+	Ids []string `json:"ids" binding:"required,min=1,max=1000"`
+}
+
+type ListPromptReq struct {
+	Purpose string `json:"purpose" form:"purpose"`
+}
+
+type CreatePromptReq struct {
+	Purpose  string `json:"purpose" binding:"required,max=64"`
+	Version  string `json:"version" binding:"required,max=32"`
+	Template string `json:"template" binding:"required"`
+	Weight   int    `json:"weight" binding:"min=0"`
+	Remark   string `json:"remark" binding:"max=255"`
+}
+
+type SetPromptWeightReq struct {
+	Purpose string `json:"purpose" binding:"required"`
+	Version string `json:"version" binding:"required"`
+	Weight  int    `json:"weight" binding:"min=0"`
+}
+
+type ZeroResultQueryReq struct {
+	Days   int `json:"days" form:"days" binding:"max=90"`
+	Offset int `json:"offset" form:"offset"`
+	Limit  int `json:"limit" form:"limit" binding:"max=200"`
+}
+
+// RebuildSearchIndexReq Types 为 ink、user、comment
+type RebuildSearchIndexReq struct {
+	Types []string `json:"types" binding:"dive,oneof=ink user comment"`
+}
+
+type PromptVO struct {
+	Id        int64     `json:"id,string"`
+	Purpose   string    `json:"purpose"`
+	Version   string    `json:"version"`
+	Template  string    `json:"template"`
+	Weight    int       `json:"weight"`
+	Remark    string    `json:"remark"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type ModerationTaskVO struct {
+	Id           int64     `json:"id,string"`
+	Biz          string    `json:"biz"`
+	BizId        int64     `json:"bizId,string"`
+	AuthorId     int64     `json:"authorId,string"`
+	Title        string    `json:"title"`
+	Cover        string    `json:"cover"`
+	Content      string    `json:"content"`
+	Source       string    `json:"source"`
+	AppealReason string    `json:"appealReason"`
+	LLMPassed    bool      `json:"llmPassed"`
+	LLMReason    string    `json:"llmReason"`
+	LLMScore     int64     `json:"llmScore"`
+	Confidence   int64     `json:"confidence"`
+	Status       string    `json:"status"`
+	ModeratorId  int64     `json:"moderatorId,string"`
+	Reason       string    `json:"reason"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type AuditLogVO struct {
+	Id                 int64     `json:"id,string"`
+	TaskId             int64     `json:"taskId,string"`
+	OperatorId         int64     `json:"operatorId,string"`
+	Action             string    `json:"action"`
+	Reason             string    `json:"reason"`
+	PromptVersion      string    `json:"promptVersion,omitempty"`
+	ImagePromptVersion string    `json:"imagePromptVersion,omitempty"`
+	TagPromptVersion   string    `json:"tagPromptVersion,omitempty"`
+	CreatedAt          time.Time `json:"createdAt"`
+}
+
+type SensitiveWordVO struct {
+	Id        int64     `json:"id,string"`
+	Word      string    `json:"word"`
+	Category  string    `json:"category"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func sensitiveWordToVO(w sensitive.Word) SensitiveWordVO {
+	return SensitiveWordVO{
+		Id:        w.Id,
+		Word:      w.Word,
+		Category:  w.Category,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+func moderationTaskToVO(task review.ModerationTask) ModerationTaskVO {
+	return ModerationTaskVO{
+		Id:           task.Id,
+		Biz:          string(task.Type),
+		BizId:        task.BizId,
+		AuthorId:     task.AuthorId,
+		Title:        task.Title,
+		Cover:        task.Cover,
+		Content:      task.Content,
+		Source:       string(task.Source),
+		AppealReason: task.AppealReason,
+		LLMPassed:    task.LLMResult.Passed,
+		LLMReason:    task.LLMResult.Reason,
+		LLMScore:     task.LLMResult.ReviewScore,
+		Confidence:   task.LLMResult.Confidence,
+		Status:       string(task.Status),
+		ModeratorId:  task.ModeratorId,
+		Reason:       task.Reason,
+		CreatedAt:    task.CreatedAt,
+	}
+}
+
+func promptToVO(p prompt.Prompt) PromptVO {
+	return PromptVO{
+		Id:        p.Id,
+		Purpose:   p.Purpose,
+		Version:   p.Version,
+		Template:  p.Template,
+		Weight:    p.Weight,
+		Remark:    p.Remark,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
+
+func auditLogToVO(log review.AuditLog) AuditLogVO {
+	return AuditLogVO{
+		Id:                 log.Id,
+		TaskId:             log.TaskId,
+		OperatorId:         log.OperatorId,
+		Action:             string(log.Action),
+		Reason:             log.Reason,
+		PromptVersion:      log.PromptVersion,
+		ImagePromptVersion: log.ImagePromptVersion,
+		TagPromptVersion:   log.TagPromptVersion,
+		CreatedAt:          log.CreatedAt,
+	}
+}
@@ -0,0 +1,133 @@
+package web
+
+import (
+	"errors"
+	"github.com/KNICEX/InkFlow/internal/poll"
+	"github.com/KNICEX/InkFlow/pkg/ginx"
+	"github.com/KNICEX/InkFlow/pkg/ginx/jwt"
+	"github.com/KNICEX/InkFlow/pkg/ginx/middleware"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"strconv"
+	"time"
+)
+
+type PollHandler struct {
+	svc  poll.Service
+	auth middleware.Authentication
+	l    logx.Logger
+}
+
+func NewPollHandler(svc poll.Service, auth middleware.Authentication, l logx.Logger) *PollHandler {
+	return &PollHandler{
+		svc:  svc,
+		auth: auth,
+		l:    l,
+	}
+}
+
+func (h *PollHandler) RegisterRoutes(server *gin.RouterGroup) {
+	pollGroup := server.Group("/poll")
+	{
+		pollGroup.GET("/:id", h.auth.ExtractPayload(), ginx.Wrap(h.l, h.Detail))
+		pollGroup.GET("/biz", h.auth.ExtractPayload(), ginx.WrapBody(h.l, h.DetailByBiz))
+		pollGroup.POST("", h.auth.CheckLogin(), ginx.WrapBody(h.l, h.Create))
+		pollGroup.POST("/:id/vote", h.auth.CheckLogin(), ginx.WrapBody(h.l, h.Poll))
+	}
+}
+
+func (h *PollHandler) Create(ctx *gin.Context, req CreatePollReq) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	var endAt time.Time
+	if req.EndAt != nil {
+		endAt = *req.EndAt
+	}
+	id, err := h.svc.Create(ctx, poll.Poll{
+		CreatorId:  uc.UserId,
+		BizType:    req.BizType,
+		BizId:      req.BizId,
+		Title:      req.Title,
+		MaxChoices: req.MaxChoices,
+		EndAt:      endAt,
+		Options: lo.Map(req.Options, func(item string, index int) poll.Option {
+			return poll.Option{
+				Title: item,
+			}
+		}),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, poll.ErrInvalidPoll):
+			return ginx.InvalidParam(), nil
+		case errors.Is(err, poll.ErrNoPermission):
+			return ginx.NoPermission(), err
+		case errors.Is(err, poll.ErrPollExists):
+			return ginx.BizError("该内容已经存在投票"), nil
+		default:
+			return ginx.InternalError(), err
+		}
+	}
+	return ginx.SuccessWithData(strconv.FormatInt(id, 10)), nil
+}
+
+func (h *PollHandler) Detail(ctx *gin.Context) (ginx.Result, error) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.InvalidParam(), err
+	}
+	uc, _ := jwt.GetUserClaims(ctx)
+	p, err := h.svc.FindById(ctx, id, uc.UserId)
+	if err != nil {
+		if errors.Is(err, poll.ErrNotFound) {
+			return ginx.NotFound(), nil
+		}
+		return ginx.InternalError(), err
+	}
+	return ginx.SuccessWithData(pollToVO(p)), nil
+}
+
+func (h *PollHandler) DetailByBiz(ctx *gin.Context, req BizPollReq) (ginx.Result, error) {
+	uc, _ := jwt.GetUserClaims(ctx)
+	p, err := h.svc.FindByBiz(ctx, req.BizType, req.BizId, uc.UserId)
+	if err != nil {
+		if errors.Is(err, poll.ErrNotFound) {
+			return ginx.NotFound(), nil
+		}
+		return ginx.InternalError(), err
+	}
+	return ginx.SuccessWithData(pollToVO(p)), nil
+}
+
+func (h *PollHandler) Poll(ctx *gin.Context, req PollReq) (ginx.Result, error) {
+	uc := jwt.MustGetUserClaims(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.InvalidParam(), err
+	}
+	optionIds := make([]int64, 0, len(req.OptionIds))
+	for _, item := range req.OptionIds {
+		oid, er := strconv.ParseInt(item, 10, 64)
+		if er != nil {
+			return ginx.InvalidParam(), er
+		}
+		optionIds = append(optionIds, oid)
+	}
+	err = h.svc.Poll(ctx, uc.UserId, id, optionIds)
+	switch {
+	case err == nil:
+		return ginx.Success(), nil
+	case errors.Is(err, poll.ErrNotFound):
+		return ginx.NotFound(), nil
+	case errors.Is(err, poll.ErrPollClosed):
+		return ginx.BizError("投票已截止"), nil
+	case errors.Is(err, poll.ErrAlreadyPolled):
+		return ginx.BizError("已经投过票了"), nil
+	case errors.Is(err, poll.ErrTooManyChoices):
+		return ginx.BizError("选择的选项过多"), nil
+	case errors.Is(err, poll.ErrInvalidOption):
+		return ginx.InvalidParam(), nil
+	default:
+		return ginx.InternalError(), err
+	}
+}
@@ -0,0 +1,79 @@
+package web
+
+import (
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/poll"
+)
+
+type CreatePollReq struct {
+	BizType    string   `json:"bizType" binding:"required,oneof=ink comment"`
+	BizId      int64    `json:"bizId,string" binding:"required"`
+	Title      string   `json:"title" binding:"required,max=256"`
+	Options    []string `json:"options" binding:"required,min=2,max=20,dive,max=256"`
+	MaxChoices int      `json:"maxChoices" binding:"min=0"`
+	// EndAt 为空表示不截止
+	EndAt *time.Time `json:"endAt"`
+}
+
+type PollReq struct {
+	// id 超出前端number精度, 使用字符串传递
+	OptionIds []string `json:"optionIds" binding:"required,min=1"`
+}
+
+type BizPollReq struct {
+	BizType string `json:"bizType" form:"bizType" binding:"required"`
+	BizId   int64  `json:"bizId,string" form:"bizId" binding:"required"`
+}
+
+type PollVO struct {
+	Id         int64          `json:"id,string"`
+	CreatorId  int64          `json:"creatorId,string"`
+	BizType    string         `json:"bizType"`
+	BizId      int64          `json:"bizId,string"`
+	Title      string         `json:"title"`
+	MaxChoices int            `json:"maxChoices"`
+	EndAt      *time.Time     `json:"endAt"`
+	Closed     bool           `json:"closed"`
+	Polled     bool           `json:"polled"`
+	TotalCnt   int64          `json:"totalCnt"`
+	Options    []PollOptionVO `json:"options"`
+	CreatedAt  time.Time      `json:"createdAt"`
+}
+
+type PollOptionVO struct {
+	Id     int64  `json:"id,string"`
+	Title  string `json:"title"`
+	Count  int64  `json:"count"`
+	Polled bool   `json:"polled"`
+}
+
+func pollToVO(p poll.Poll) PollVO {
+	var endAt *time.Time
+	if !p.EndAt.IsZero() {
+		endAt = &p.EndAt
+	}
+	options := make([]PollOptionVO, 0, len(p.Options))
+	for _, opt := range p.Options {
+		options = append(options, PollOptionVO{
+			Id:     opt.Id,
+			Title:  opt.Title,
+			Count:  opt.Count,
+			Polled: opt.Polled,
+		})
+	}
+	return PollVO{
+		Id:         p.Id,
+		CreatorId:  p.CreatorId,
+		BizType:    p.BizType,
+		BizId:      p.BizId,
+		Title:      p.Title,
+		MaxChoices: p.MaxChoices,
+		EndAt:      endAt,
+		Closed:     p.Closed(time.Now()),
+		Polled:     p.Polled(),
+		TotalCnt:   p.TotalCount(),
+		Options:    options,
+		CreatedAt:  p.CreatedAt,
+	}
+}
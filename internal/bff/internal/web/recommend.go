@@ -2,6 +2,7 @@ package web
 
 import (
 	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/internal/poll"
 	"github.com/KNICEX/InkFlow/internal/recommend"
 	"github.com/KNICEX/InkFlow/pkg/ginx"
 	"github.com/KNICEX/InkFlow/pkg/ginx/jwt"
@@ -19,11 +20,11 @@ type RecommendHandler struct {
 	l             logx.Logger
 }
 
-func NewRecommendHandler(svc recommend.Service, inkSvc ink.Service, userAggregate *UserAggregate, intrAggregate *InteractiveAggregate,
+func NewRecommendHandler(svc recommend.Service, inkSvc ink.Service, pollSvc poll.Service, userAggregate *UserAggregate, intrAggregate *InteractiveAggregate,
 	auth middleware.Authentication, l logx.Logger) *RecommendHandler {
 	return &RecommendHandler{
 		svc:           svc,
-		inkAggregate:  NewInkAggregate(inkSvc, userAggregate, intrAggregate),
+		inkAggregate:  NewInkAggregate(inkSvc, pollSvc, userAggregate, intrAggregate, l),
 		userAggregate: userAggregate,
 		auth:          auth,
 		l:             l,
@@ -1,8 +1,11 @@
 package web
 
 import (
+	"errors"
+	"github.com/KNICEX/InkFlow/internal/bff/internal/service"
 	"github.com/KNICEX/InkFlow/internal/relation"
 	"github.com/KNICEX/InkFlow/internal/search"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
 	"github.com/KNICEX/InkFlow/pkg/ginx"
 	"github.com/KNICEX/InkFlow/pkg/ginx/jwt"
 	"github.com/KNICEX/InkFlow/pkg/ginx/middleware"
@@ -12,18 +15,23 @@ import (
 )
 
 type SearchHandler struct {
-	auth      middleware.Authentication
-	svc       search.Service
-	followSvc relation.FollowService
-	l         logx.Logger
+	auth         middleware.Authentication
+	svc          search.Service
+	analyticsSvc search.AnalyticsService
+	followSvc    relation.FollowService
+	sensitiveSvc sensitive.Service
+	l            logx.Logger
 }
 
-func NewSearchHandler(auth middleware.Authentication, svc search.Service, followSvc relation.FollowService, l logx.Logger) *SearchHandler {
+func NewSearchHandler(auth middleware.Authentication, svc search.Service, analyticsSvc search.AnalyticsService,
+	followSvc relation.FollowService, sensitiveSvc sensitive.Service, l logx.Logger) *SearchHandler {
 	return &SearchHandler{
-		auth:      auth,
-		svc:       svc,
-		followSvc: followSvc,
-		l:         l,
+		auth:         auth,
+		svc:          svc,
+		analyticsSvc: analyticsSvc,
+		followSvc:    followSvc,
+		sensitiveSvc: sensitiveSvc,
+		l:            l,
 	}
 }
 
@@ -33,15 +41,23 @@ func (h *SearchHandler) RegisterRoutes(server *gin.RouterGroup) {
 		searchGroup.GET("/user", ginx.WrapBody(h.l, h.SearchUser))
 		searchGroup.GET("/ink", ginx.WrapBody(h.l, h.SearchInK))
 		searchGroup.GET("/comment", ginx.WrapBody(h.l, h.SearchComment))
+		searchGroup.GET("/suggest", ginx.WrapBody(h.l, h.Suggest))
+		searchGroup.GET("/trending", ginx.WrapBody(h.l, h.Trending))
+		searchGroup.POST("/click", ginx.WrapBody(h.l, h.Click))
 	}
 }
 
+// SearchUser 搜索词命中敏感词时直接返回空结果, 搜索 ink 和评论同理
 func (h *SearchHandler) SearchUser(ctx *gin.Context, req SearchReq) (ginx.Result, error) {
+	if h.sensitiveSvc.Contains(ctx, req.Keyword) {
+		return ginx.SuccessWithData([]UserVO{}), nil
+	}
 	u, _ := jwt.GetUserClaims(ctx)
 	users, err := h.svc.SearchUser(ctx, req.Keyword, req.Offset, req.Limit)
 	if err != nil {
 		return ginx.InternalError(), err
 	}
+	h.recordSearch(ctx, search.SearchTypeUser, req.Keyword, 0, req.Offset, int64(len(users)), nil)
 	uids := lo.Map(users, func(item search.User, index int) int64 {
 		return item.Id
 	})
@@ -59,36 +75,147 @@ func (h *SearchHandler) SearchUser(ctx *gin.Context, req SearchReq) (ginx.Result
 			followStats = relation.FollowStatistic{}
 		}
 		res = append(res, UserVO{
-			Id:        user.Id,
-			Username:  user.Username,
-			Account:   user.Account,
-			Avatar:    user.Avatar,
-			AboutMe:   user.AboutMe,
-			CreatedAt: user.CreatedAt,
-			Following: followStats.Following,
-			Followers: followStats.Followers,
-			Followed:  followStats.Followed,
+			Id:          user.Id,
+			Username:    user.Username,
+			Account:     user.Account,
+			Avatar:      user.Avatar,
+			AvatarThumb: service.ThumbnailURL(user.Avatar),
+			AboutMe:     user.AboutMe,
+			CreatedAt:   user.CreatedAt,
+			Following:   followStats.Following,
+			Followers:   followStats.Followers,
+			Followed:    followStats.Followed,
 		})
 	}
 	return ginx.SuccessWithData(res), nil
 }
 
-func (h *SearchHandler) SearchInK(ctx *gin.Context, req SearchReq) (ginx.Result, error) {
-	inks, err := h.svc.SearchInk(ctx, req.Keyword, req.Offset, req.Limit)
+func (h *SearchHandler) SearchInK(ctx *gin.Context, req SearchInkReq) (ginx.Result, error) {
+	if h.sensitiveSvc.Contains(ctx, req.Keyword) {
+		return ginx.SuccessWithData(SearchInkVO{Inks: []InkVO{}}), nil
+	}
+	res, err := h.svc.SearchInk(ctx, req.toQuery())
 	if err != nil {
 		return ginx.InternalError(), err
 	}
-	return ginx.SuccessWithData(lo.Map(inks, func(item search.Ink, index int) InkVO {
-		return searchInkToInkVO(item)
-	})), nil
+	return ginx.SuccessWithData(SearchInkVO{
+		SearchId: h.recordSearch(ctx, search.SearchTypeInk, req.Keyword, req.SearchId, req.Offset, res.Total,
+			lo.Map(res.Inks, func(item search.Ink, index int) int64 {
+				return item.Id
+			})),
+		Inks: lo.Map(res.Inks, func(item search.Ink, index int) InkVO {
+			return searchInkToInkVO(item)
+		}),
+		Total:  res.Total,
+		Facets: res.Facets,
+	}), nil
 }
 
 func (h *SearchHandler) SearchComment(ctx *gin.Context, req SearchReq) (ginx.Result, error) {
+	if h.sensitiveSvc.Contains(ctx, req.Keyword) {
+		return ginx.SuccessWithData([]CommentVO{}), nil
+	}
 	comments, err := h.svc.SearchComment(ctx, req.Keyword, req.Offset, req.Limit)
 	if err != nil {
 		return ginx.InvalidParam(), err
 	}
+	h.recordSearch(ctx, search.SearchTypeComment, req.Keyword, 0, req.Offset, int64(len(comments)), nil)
 	return ginx.SuccessWithData(lo.Map(comments, func(item search.Comment, index int) CommentVO {
 		return searchCommentToCommentVO(item)
 	})), nil
 }
+
+// Suggest 搜索框输入补全, 默认返回 10 个
+func (h *SearchHandler) Suggest(ctx *gin.Context, req SuggestReq) (ginx.Result, error) {
+	if h.sensitiveSvc.Contains(ctx, req.Prefix) {
+		return ginx.SuccessWithData([]SuggestionVO{}), nil
+	}
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+	suggestions, err := h.svc.Suggest(ctx, req.Prefix, req.Limit)
+	if err != nil {
+		return ginx.InternalError(), err
+	}
+	// 热门搜索词来自用户输入, 展示前过滤敏感词
+	suggestions = lo.Filter(suggestions, func(item search.Suggestion, index int) bool {
+		return !h.sensitiveSvc.Contains(ctx, item.Text)
+	})
+	return ginx.SuccessWithData(lo.Map(suggestions, func(item search.Suggestion, index int) SuggestionVO {
+		return SuggestionVO{
+			Text: item.Text,
+			Type: string(item.Type),
+		}
+	})), nil
+}
+
+// recordSearch 只记录第一页, 翻页时前端带上第一页的 searchId, 这一页的结果加入第一页的搜索,
+// 之后沿用该 searchId 上报点击, 返回 0 时不上报. 记录失败不影响搜索结果
+func (h *SearchHandler) recordSearch(ctx *gin.Context, typ search.SearchType, keyword string, searchId int64,
+	offset int, resultCnt int64, resultIds []int64) int64 {
+	u, _ := jwt.GetUserClaims(ctx)
+	if offset > 0 {
+		if searchId == 0 {
+			return 0
+		}
+		err := h.analyticsSvc.AppendResults(ctx, searchId, u.UserId, resultIds)
+		if err != nil {
+			if !errors.Is(err, search.ErrInvalidClick) {
+				h.l.WithCtx(ctx).Warn("append search results error", logx.Int64("searchId", searchId), logx.Error(err))
+			}
+			return 0
+		}
+		return searchId
+	}
+	id, err := h.analyticsSvc.RecordSearch(ctx, search.SearchLog{
+		Uid:       u.UserId,
+		Query:     keyword,
+		Type:      typ,
+		ResultCnt: resultCnt,
+		ResultIds: resultIds,
+	})
+	if err != nil {
+		h.l.WithCtx(ctx).Warn("record search error", logx.String("keyword", keyword), logx.Error(err))
+	}
+	return id
+}
+
+// Click 上报从 ink 搜索结果中点击的内容, 登录用户的点击同时作为推荐的正反馈.
+// 只接受当前用户最近一小时内的搜索, 并且点击的内容在返回的结果中
+func (h *SearchHandler) Click(ctx *gin.Context, req SearchClickReq) (ginx.Result, error) {
+	u, _ := jwt.GetUserClaims(ctx)
+	err := h.analyticsSvc.RecordClick(ctx, search.SearchClick{
+		SearchId: req.SearchId,
+		Uid:      u.UserId,
+		Type:     search.SearchTypeInk,
+		ResultId: req.ResultId,
+	})
+	if errors.Is(err, search.ErrInvalidClick) {
+		return ginx.InvalidParam(), nil
+	}
+	if err != nil {
+		return ginx.InternalError(), err
+	}
+	return ginx.Success(), nil
+}
+
+// Trending 最近 24 小时的热搜, type 为空时不区分搜索类型, 默认返回 10 个.
+// 搜索词来自用户输入, 多取一些过滤掉敏感词后再截断
+func (h *SearchHandler) Trending(ctx *gin.Context, req TrendingReq) (ginx.Result, error) {
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+	stats, err := h.analyticsSvc.Trending(ctx, search.SearchType(req.Type), req.Limit*2)
+	if err != nil {
+		return ginx.InternalError(), err
+	}
+	stats = lo.Filter(stats, func(item search.QueryStats, index int) bool {
+		return !h.sensitiveSvc.Contains(ctx, item.Query)
+	})
+	if len(stats) > req.Limit {
+		stats = stats[:req.Limit]
+	}
+	return ginx.SuccessWithData(lo.Map(stats, func(item search.QueryStats, index int) QueryStatsVO {
+		return queryStatsToVO(item)
+	})), nil
+}
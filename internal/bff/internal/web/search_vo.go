@@ -1,6 +1,11 @@
 package web
 
-import "github.com/KNICEX/InkFlow/internal/search"
+import (
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/bff/internal/service"
+	"github.com/KNICEX/InkFlow/internal/search"
+)
 
 type SearchReq struct {
 	Keyword string `json:"keyword" form:"keyword" binding:"required"`
@@ -9,27 +14,120 @@ type SearchReq struct {
 	Limit   int    `json:"limit" form:"limit"`
 }
 
+// SearchInkReq keyword 为空时只按过滤条件查找, 时间范围为毫秒时间戳, 左闭右开,
+// 翻页时带上第一页返回的 searchId, 这一页的结果才能上报点击
+type SearchInkReq struct {
+	SearchId      int64    `json:"searchId,string" form:"searchId"`
+	Keyword       string   `json:"keyword" form:"keyword"`
+	Tags          []string `json:"tags" form:"tags"`
+	AuthorId      int64    `json:"authorId,string" form:"authorId"`
+	ContentType   int      `json:"contentType" form:"contentType"`
+	CategoryId    int64    `json:"categoryId,string" form:"categoryId"`
+	CreatedAfter  int64    `json:"createdAfter,string" form:"createdAfter"`
+	CreatedBefore int64    `json:"createdBefore,string" form:"createdBefore"`
+	Sort          string   `json:"sort" form:"sort" binding:"omitempty,oneof=relevance newest most_liked"`
+	Offset        int      `json:"offset" form:"offset"`
+	Limit         int      `json:"limit" form:"limit"`
+}
+
+func (req SearchInkReq) toQuery() search.InkQuery {
+	q := search.InkQuery{
+		Keyword:     req.Keyword,
+		Tags:        req.Tags,
+		AuthorId:    req.AuthorId,
+		ContentType: req.ContentType,
+		CategoryId:  req.CategoryId,
+		Sort:        search.InkSort(req.Sort),
+		Offset:      req.Offset,
+		Limit:       req.Limit,
+	}
+	if req.CreatedAfter > 0 {
+		q.CreatedAfter = time.UnixMilli(req.CreatedAfter)
+	}
+	if req.CreatedBefore > 0 {
+		q.CreatedBefore = time.UnixMilli(req.CreatedBefore)
+	}
+	return q
+}
+
+// SearchInkVO facets 为字段 -> 取值 -> 命中数量, 字段包括 tags, content_type, category_id,
+// 用户点击结果时带上 searchId 上报
+type SearchInkVO struct {
+	SearchId int64                       `json:"searchId,string"`
+	Inks     []InkVO                     `json:"inks"`
+	Total    int64                       `json:"total"`
+	Facets   map[string]map[string]int64 `json:"facets"`
+}
+
+type SearchClickReq struct {
+	SearchId int64 `json:"searchId,string" binding:"required"`
+	ResultId int64 `json:"resultId,string" binding:"required"`
+}
+
+type TrendingReq struct {
+	Type  string `json:"type" form:"type" binding:"omitempty,oneof=ink user comment"`
+	Limit int    `json:"limit" form:"limit" binding:"omitempty,max=50"`
+}
+
+// QueryStatsVO 热搜和无结果报表共用
+type QueryStatsVO struct {
+	Query         string `json:"query"`
+	Type          string `json:"type"`
+	SearchCnt     int64  `json:"searchCnt"`
+	ZeroResultCnt int64  `json:"zeroResultCnt"`
+	ClickCnt      int64  `json:"clickCnt"`
+}
+
+func queryStatsToVO(s search.QueryStats) QueryStatsVO {
+	return QueryStatsVO{
+		Query:         s.Query,
+		Type:          string(s.Type),
+		SearchCnt:     s.SearchCnt,
+		ZeroResultCnt: s.ZeroResultCnt,
+		ClickCnt:      s.ClickCnt,
+	}
+}
+
+type SuggestReq struct {
+	Prefix string `json:"prefix" form:"prefix" binding:"required"`
+	Limit  int    `json:"limit" form:"limit" binding:"omitempty,max=20"`
+}
+
+// SuggestionVO type 为 query, tag 或 user
+type SuggestionVO struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
 func searchInkToInkVO(ink search.Ink) InkVO {
 	return InkVO{
 		Id:          ink.Id,
 		Title:       ink.Title,
 		Cover:       ink.Cover,
+		CoverThumb:  service.ThumbnailURL(ink.Cover),
 		Author:      searchUserToUserVO(ink.Author),
+		Summary:     ink.Summary,
+		Category:    InkCategory{Id: ink.CategoryId},
+		ContentType: ink.ContentType,
 		ContentHtml: ink.Content,
+		WordCount:   ink.WordCount,
+		ReadingTime: ink.ReadingTime,
 		CreatedAt:   ink.CreatedAt,
 		UpdatedAt:   ink.UpdatedAt,
 		Tags:        ink.Tags,
+		Highlights:  ink.Highlights,
 	}
 }
 
 func searchUserToUserVO(user search.User) UserVO {
 	return UserVO{
-		Id:        user.Id,
-		Username:  user.Username,
-		Account:   user.Account,
-		AboutMe:   user.AboutMe,
-		Avatar:    user.Avatar,
-		CreatedAt: user.CreatedAt,
+		Id:          user.Id,
+		Username:    user.Username,
+		Account:     user.Account,
+		AboutMe:     user.AboutMe,
+		Avatar:      user.Avatar,
+		AvatarThumb: service.ThumbnailURL(user.Avatar),
+		CreatedAt:   user.CreatedAt,
 	}
 }
 
@@ -43,6 +141,7 @@ func searchCommentToCommentVO(comment search.Comment) CommentVO {
 			Content: comment.Content,
 			Images:  comment.Images,
 		},
-		CreatedAt: comment.CreatedAt,
+		CreatedAt:  comment.CreatedAt,
+		Highlights: comment.Highlights,
 	}
 }
@@ -2,11 +2,13 @@ package web
 
 import (
 	"errors"
+	"github.com/KNICEX/InkFlow/internal/action"
 	"github.com/KNICEX/InkFlow/internal/code"
 	"github.com/KNICEX/InkFlow/internal/comment"
 	"github.com/KNICEX/InkFlow/internal/ink"
 	"github.com/KNICEX/InkFlow/internal/interactive"
 	"github.com/KNICEX/InkFlow/internal/relation"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
 	"github.com/KNICEX/InkFlow/internal/user"
 	"github.com/KNICEX/InkFlow/pkg/ginx"
 	"github.com/KNICEX/InkFlow/pkg/ginx/jwt"
@@ -35,9 +37,11 @@ type UserHandler struct {
 	svc           user.Service
 	codeSvc       code.Service
 	followService relation.FollowService
+	actionSvc     action.Service
 	intrSvc       interactive.Service
 	commentSvc    comment.Service
 	inkSvc        ink.Service
+	sensitiveSvc  sensitive.Service
 	phoneReg      *regexp.Regexp
 	emailReg      *regexp.Regexp
 	l             logx.Logger
@@ -47,15 +51,18 @@ type UserHandler struct {
 
 func NewUserHandler(svc user.Service, inkSvc ink.Service,
 	comment comment.Service, intrSvc interactive.Service,
-	codeSvc code.Service, followService relation.FollowService,
+	codeSvc code.Service, followService relation.FollowService, actionSvc action.Service,
+	sensitiveSvc sensitive.Service,
 	jwtHandler jwt.Handler, auth middleware.Authentication, log logx.Logger) *UserHandler {
 	return &UserHandler{
 		svc:           svc,
 		codeSvc:       codeSvc,
 		followService: followService,
+		actionSvc:     actionSvc,
 		inkSvc:        inkSvc,
 		commentSvc:    comment,
 		intrSvc:       intrSvc,
+		sensitiveSvc:  sensitiveSvc,
 		phoneReg:      regexp.MustCompile(`^1[3456789]\d{9}$`),
 		emailReg:      regexp.MustCompile(`^\w+([-+.]\w+)*@\w+([-.]\w+)*\.\w+([-.]\w+)*$`),
 		Handler:       jwtHandler,
@@ -354,6 +361,12 @@ func (h *UserHandler) EditProfile(ctx *gin.Context, req EditProfileReq) (ginx.Re
 			return ginx.InvalidParamWithMsg("生日格式错误"), nil
 		}
 	}
+	if matches := h.sensitiveSvc.Match(ctx, req.Username); len(matches) > 0 {
+		return ginx.BizErrorWithData("用户名包含敏感词", SensitiveHitVO{Field: "username", Matches: matches}), nil
+	}
+	if matches := h.sensitiveSvc.Match(ctx, req.AboutMe); len(matches) > 0 {
+		return ginx.BizErrorWithData("个人简介包含敏感词", SensitiveHitVO{Field: "aboutMe", Matches: matches}), nil
+	}
 	uc := jwt.MustGetUserClaims(ctx)
 	err = h.svc.UpdateNonSensitiveInfo(ctx, user.User{
 		Id:       uc.UserId,
@@ -565,6 +578,14 @@ func (h *UserHandler) ActionStats(ctx *gin.Context) (ginx.Result, error) {
 		followStats, err = h.followService.FindFollowStats(ctx, uc.UserId, 0)
 		return err
 	})
+	// 近 30 天的行为统计
+	var actionStats []action.Statistics
+	eg.Go(func() error {
+		var err error
+		now := time.Now()
+		actionStats, err = h.actionSvc.FindStatistics(ctx, uc.UserId, now.Add(-time.Hour*24*30), now)
+		return err
+	})
 	if err := eg.Wait(); err != nil {
 		return ginx.InternalError(), err
 	}
@@ -577,6 +598,14 @@ func (h *UserHandler) ActionStats(ctx *gin.Context) (ginx.Result, error) {
 		LikeCount:      userIntrStats.LikeCnt,
 		FollowerCount:  followStats.Followers,
 		FollowingCount: followStats.Following,
+		Actions: lo.Map(actionStats, func(item action.Statistics, index int) ActionStatsVO {
+			return ActionStatsVO{
+				TargetType: string(item.TargetType),
+				ActionCnt:  item.ActionCnt,
+				StartTime:  item.StartTime,
+				EndTime:    item.EndTime,
+			}
+		}),
 	}
 	return ginx.SuccessWithData(dashboardInfo), nil
 }
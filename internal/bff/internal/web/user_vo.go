@@ -3,6 +3,8 @@ package web
 import (
 	"time"
 
+	"github.com/KNICEX/InkFlow/internal/bff/internal/service"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
 	"github.com/KNICEX/InkFlow/internal/user"
 )
 
@@ -46,6 +48,12 @@ type EditProfileReq struct {
 	AboutMe  string   `json:"aboutMe" binding:"max=1024"`
 }
 
+// SensitiveHitVO 命中敏感词的字段, 前端用于标出具体位置
+type SensitiveHitVO struct {
+	Field   string            `json:"field"`
+	Matches []sensitive.Match `json:"matches"`
+}
+
 type ProfileReq struct {
 	Uid     int64  `json:"uid,string" form:"uid"`
 	Account string `json:"account" form:"account" binding:"max=30"`
@@ -75,17 +83,19 @@ type UserVO struct {
 	Id int64 `json:"id,string"`
 	//Email     string    `json:"email"`
 	//Phone     string    `json:"phone"`
-	Account   string    `json:"account"`
-	Username  string    `json:"username"`
-	Avatar    string    `json:"avatar"`
-	Banner    string    `json:"banner"`
-	Birthday  string    `json:"birthday"`
-	AboutMe   string    `json:"aboutMe"`
-	Followers int64     `json:"followers"`
-	Following int64     `json:"following"`
-	Followed  bool      `json:"followed"`
-	Links     []string  `json:"links"`
-	CreatedAt time.Time `json:"createdAt"`
+	Account  string `json:"account"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+	// AvatarThumb 头像缩略图, 旧头像没有缩略图时与 Avatar 相同
+	AvatarThumb string    `json:"avatarThumb"`
+	Banner      string    `json:"banner"`
+	Birthday    string    `json:"birthday"`
+	AboutMe     string    `json:"aboutMe"`
+	Followers   int64     `json:"followers"`
+	Following   int64     `json:"following"`
+	Followed    bool      `json:"followed"`
+	Links       []string  `json:"links"`
+	CreatedAt   time.Time `json:"createdAt"`
 }
 
 type UserFollowVO struct {
@@ -105,13 +115,14 @@ func userToVO(u user.User) UserVO {
 		birthday = u.Birthday.Format(time.DateOnly)
 	}
 	return UserVO{
-		Id:       u.Id,
-		Account:  u.Account,
-		Username: u.Username,
-		Birthday: birthday,
-		AboutMe:  u.AboutMe,
-		Avatar:   u.Avatar,
-		Banner:   u.Banner,
+		Id:          u.Id,
+		Account:     u.Account,
+		Username:    u.Username,
+		Birthday:    birthday,
+		AboutMe:     u.AboutMe,
+		Avatar:      u.Avatar,
+		AvatarThumb: service.ThumbnailURL(u.Avatar),
+		Banner:      u.Banner,
 		//Followers: u.Followers,
 		//Following: u.Following,
 		//Followed:  u.Followed,
@@ -133,4 +144,13 @@ type DashboardInfo struct {
 	FavoriteCount  int64 `json:"favoriteCount"`
 	ViewCount      int64 `json:"viewCount"`
 	LikeCount      int64 `json:"likeCount"`
+	// Actions 近 30 天按目标类型统计的行为次数
+	Actions []ActionStatsVO `json:"actions"`
+}
+
+type ActionStatsVO struct {
+	TargetType string    `json:"targetType"`
+	ActionCnt  int64     `json:"actionCnt"`
+	StartTime  time.Time `json:"startTime"`
+	EndTime    time.Time `json:"endTime"`
 }
@@ -13,3 +13,8 @@ type MaxIdPagedReq struct {
 	MaxId int64 `json:"maxId,string" form:"maxId"`
 	Limit int   `json:"limit" form:"limit"`
 }
+
+type UploadQuotaVO struct {
+	// Reason count 文件数超额, bytes 字节数超额, banned 被临时封禁
+	Reason string `json:"reason"`
+}
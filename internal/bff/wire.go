@@ -3,6 +3,9 @@
 package bff
 
 import (
+	"github.com/KNICEX/InkFlow/internal/action"
+	"github.com/KNICEX/InkFlow/internal/assistant"
+	"github.com/KNICEX/InkFlow/internal/bff/internal/service"
 	"github.com/KNICEX/InkFlow/internal/bff/internal/web"
 	"github.com/KNICEX/InkFlow/internal/code"
 	"github.com/KNICEX/InkFlow/internal/comment"
@@ -10,32 +13,38 @@ import (
 	"github.com/KNICEX/InkFlow/internal/ink"
 	"github.com/KNICEX/InkFlow/internal/interactive"
 	"github.com/KNICEX/InkFlow/internal/notification"
+	"github.com/KNICEX/InkFlow/internal/poll"
+	"github.com/KNICEX/InkFlow/internal/prompt"
 	"github.com/KNICEX/InkFlow/internal/recommend"
 	"github.com/KNICEX/InkFlow/internal/relation"
+	"github.com/KNICEX/InkFlow/internal/review"
 	"github.com/KNICEX/InkFlow/internal/search"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
 	"github.com/KNICEX/InkFlow/internal/user"
 	"github.com/KNICEX/InkFlow/pkg/ginx"
 	"github.com/KNICEX/InkFlow/pkg/ginx/jwt"
 	"github.com/KNICEX/InkFlow/pkg/ginx/middleware"
 	"github.com/KNICEX/InkFlow/pkg/logx"
 	"github.com/google/wire"
+	"github.com/redis/go-redis/v9"
 	"go.temporal.io/sdk/client"
 )
 
 func InitHandlers(uh *web.UserHandler, ih *web.InkHandler, fh *web.FileHandler,
 	ch *web.CommentHandler, nh *web.NotificationHandler, sh *web.SearchHandler, feedH *web.FeedHandler,
-	statsH *web.StatsHandler, intrH *web.InteractiveHandler, rh *web.RecommendHandler) []ginx.Handler {
-	return []ginx.Handler{uh, ih, fh, ch, nh, sh, feedH, statsH, intrH, rh}
+	statsH *web.StatsHandler, intrH *web.InteractiveHandler, rh *web.RecommendHandler, ph *web.PollHandler,
+	mh *web.ModerationHandler, ah *web.AssistantHandler) []ginx.Handler {
+	return []ginx.Handler{uh, ih, fh, ch, nh, sh, feedH, statsH, intrH, rh, ph, mh, ah}
 }
 
 func initUserAggregate(userSvc user.Service, followSvc relation.FollowService) *web.UserAggregate {
 	return web.NewUserAggregate(userSvc, followSvc)
 }
 
-func initInkAggregate(inkSvc ink.Service, userAggregate *web.UserAggregate,
+func initInkAggregate(inkSvc ink.Service, pollSvc poll.Service, userAggregate *web.UserAggregate,
 	interactiveAggregate *web.InteractiveAggregate,
-	intrSvc interactive.Service, commentSvc comment.Service) *web.InkAggregate {
-	return web.NewInkAggregate(inkSvc, userAggregate, interactiveAggregate)
+	intrSvc interactive.Service, commentSvc comment.Service, l logx.Logger) *web.InkAggregate {
+	return web.NewInkAggregate(inkSvc, pollSvc, userAggregate, interactiveAggregate, l)
 }
 func initInteractiveAggregate(intrSvc interactive.Service, commentSvc comment.Service) *web.InteractiveAggregate {
 	return web.NewInteractiveAggregate(intrSvc, commentSvc)
@@ -43,14 +52,23 @@ func initInteractiveAggregate(intrSvc interactive.Service, commentSvc comment.Se
 
 func InitBff(userSvc user.Service, codeSvc code.Service, inkService ink.Service,
 	inkRankService ink.RankingService,
+	inkRevisionSvc ink.RevisionService,
+	moderationSvc review.ModerationService,
 	followService relation.FollowService,
+	actionSvc action.Service,
 	interactiveSvc interactive.Service,
 	commentSvc comment.Service,
+	pollSvc poll.Service,
 	notificationSvc notification.Service,
 	recommendSvc recommend.Service,
 	feedSvc feed.Service,
 	searchSvc search.Service,
+	analyticsSvc search.AnalyticsService,
+	sensitiveSvc sensitive.Service,
+	promptSvc prompt.Service,
+	assistantSvc assistant.Service,
 	workflowCli client.Client,
+	cmd redis.Cmdable,
 	jwtHandler jwt.Handler, auth middleware.Authentication, log logx.Logger) []ginx.Handler {
 	wire.Build(
 		web.NewUserAggregate,
@@ -64,9 +82,14 @@ func InitBff(userSvc user.Service, codeSvc code.Service, inkService ink.Service,
 		web.NewFeedHandler,
 		web.NewInteractiveHandler,
 		web.NewStatsHandler,
-		initCloudinary,
+		initFileService,
+		initUploadGuard,
+		service.NewImageProcessor,
 		web.NewFileHandler,
 		web.NewRecommendHandler,
+		web.NewPollHandler,
+		initModerationHandler,
+		web.NewAssistantHandler,
 		InitHandlers,
 	)
 	return []ginx.Handler{}
@@ -7,6 +7,9 @@
 package bff
 
 import (
+	"github.com/KNICEX/InkFlow/internal/action"
+	"github.com/KNICEX/InkFlow/internal/assistant"
+	"github.com/KNICEX/InkFlow/internal/bff/internal/service"
 	"github.com/KNICEX/InkFlow/internal/bff/internal/web"
 	"github.com/KNICEX/InkFlow/internal/code"
 	"github.com/KNICEX/InkFlow/internal/comment"
@@ -14,38 +17,51 @@ import (
 	"github.com/KNICEX/InkFlow/internal/ink"
 	"github.com/KNICEX/InkFlow/internal/interactive"
 	"github.com/KNICEX/InkFlow/internal/notification"
+	"github.com/KNICEX/InkFlow/internal/poll"
+	"github.com/KNICEX/InkFlow/internal/prompt"
 	"github.com/KNICEX/InkFlow/internal/recommend"
 	"github.com/KNICEX/InkFlow/internal/relation"
+	"github.com/KNICEX/InkFlow/internal/review"
 	"github.com/KNICEX/InkFlow/internal/search"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
 	"github.com/KNICEX/InkFlow/internal/user"
 	"github.com/KNICEX/InkFlow/pkg/ginx"
 	"github.com/KNICEX/InkFlow/pkg/ginx/jwt"
 	"github.com/KNICEX/InkFlow/pkg/ginx/middleware"
 	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/redis/go-redis/v9"
 	"go.temporal.io/sdk/client"
 )
 
 // Injectors from wire.go:
 
 func InitBff(userSvc user.Service, codeSvc code.Service, inkService ink.Service, inkRankService ink.RankingService,
-	followService relation.FollowService, interactiveSvc interactive.Service, commentSvc comment.Service,
-	notificationSvc notification.Service, recommendSvc recommend.Service, feedSvc feed.Service, searchSvc search.Service,
-	workflowCli client.Client, jwtHandler jwt.Handler, auth middleware.Authentication, log logx.Logger) []ginx.Handler {
-	userHandler := web.NewUserHandler(userSvc, inkService, commentSvc, interactiveSvc, codeSvc, followService, jwtHandler, auth, log)
+	inkRevisionSvc ink.RevisionService, moderationSvc review.ModerationService, followService relation.FollowService,
+	actionSvc action.Service, interactiveSvc interactive.Service, commentSvc comment.Service, pollSvc poll.Service,
+	notificationSvc notification.Service, recommendSvc recommend.Service, feedSvc feed.Service,
+	searchSvc search.Service, analyticsSvc search.AnalyticsService, sensitiveSvc sensitive.Service,
+	promptSvc prompt.Service, assistantSvc assistant.Service, workflowCli client.Client, cmd redis.Cmdable,
+	jwtHandler jwt.Handler, auth middleware.Authentication, log logx.Logger) []ginx.Handler {
+	userHandler := web.NewUserHandler(userSvc, inkService, commentSvc, interactiveSvc, codeSvc, followService, actionSvc, sensitiveSvc, jwtHandler, auth, log)
 	userAggregate := web.NewUserAggregate(userSvc, followService)
 	interactiveAggregate := web.NewInteractiveAggregate(interactiveSvc, commentSvc)
-	inkHandler := web.NewInkHandler(inkService, userAggregate, interactiveAggregate, interactiveSvc, auth, workflowCli, log)
-	cloudinary := initCloudinary()
-	fileHandler := web.NewFileHandler(cloudinary, auth, log)
+	inkHandler := web.NewInkHandler(inkService, inkRevisionSvc, moderationSvc, pollSvc, userAggregate, interactiveAggregate, interactiveSvc, auth, workflowCli, log)
+	fileService := initFileService()
+	imageProcessor := service.NewImageProcessor()
+	uploadGuard := initUploadGuard(cmd)
+	fileHandler := web.NewFileHandler(fileService, imageProcessor, uploadGuard, auth, log)
 	commentHandler := web.NewCommentHandler(commentSvc, followService, userSvc, auth, log)
 	notificationHandler := web.NewNotificationHandler(notificationSvc, userAggregate, inkService, commentSvc, auth, log)
-	searchHandler := web.NewSearchHandler(auth, searchSvc, followService, log)
-	inkAggregate := web.NewInkAggregate(inkService, userAggregate, interactiveAggregate)
+	searchHandler := web.NewSearchHandler(auth, searchSvc, analyticsSvc, followService, sensitiveSvc, log)
+	inkAggregate := web.NewInkAggregate(inkService, pollSvc, userAggregate, interactiveAggregate, log)
 	feedHandler := web.NewFeedHandler(feedSvc, inkRankService, inkAggregate, userAggregate, interactiveAggregate, recommendSvc, auth, log)
 	statsHandler := web.NewStatsHandler(inkRankService, log)
 	interactiveHandler := web.NewInteractiveHandler(interactiveSvc, auth, log)
-	recommendHandler := web.NewRecommendHandler(recommendSvc, inkService, userAggregate, interactiveAggregate, auth, log)
-	v := InitHandlers(userHandler, inkHandler, fileHandler, commentHandler, notificationHandler, searchHandler, feedHandler, statsHandler, interactiveHandler, recommendHandler)
+	recommendHandler := web.NewRecommendHandler(recommendSvc, inkService, pollSvc, userAggregate, interactiveAggregate, auth, log)
+	pollHandler := web.NewPollHandler(pollSvc, auth, log)
+	moderationHandler := initModerationHandler(moderationSvc, sensitiveSvc, promptSvc, analyticsSvc, workflowCli, auth, log)
+	assistantHandler := web.NewAssistantHandler(assistantSvc, auth, log)
+	v := InitHandlers(userHandler, inkHandler, fileHandler, commentHandler, notificationHandler, searchHandler, feedHandler, statsHandler, interactiveHandler, recommendHandler, pollHandler, moderationHandler, assistantHandler)
 	return v
 }
 
@@ -53,18 +69,19 @@ func InitBff(userSvc user.Service, codeSvc code.Service, inkService ink.Service,
 
 func InitHandlers(uh *web.UserHandler, ih *web.InkHandler, fh *web.FileHandler,
 	ch *web.CommentHandler, nh *web.NotificationHandler, sh *web.SearchHandler, feedH *web.FeedHandler,
-	statsH *web.StatsHandler, intrH *web.InteractiveHandler, rh *web.RecommendHandler) []ginx.Handler {
-	return []ginx.Handler{uh, ih, fh, ch, nh, sh, feedH, statsH, intrH, rh}
+	statsH *web.StatsHandler, intrH *web.InteractiveHandler, rh *web.RecommendHandler, ph *web.PollHandler,
+	mh *web.ModerationHandler, ah *web.AssistantHandler) []ginx.Handler {
+	return []ginx.Handler{uh, ih, fh, ch, nh, sh, feedH, statsH, intrH, rh, ph, mh, ah}
 }
 
 func initUserAggregate(userSvc user.Service, followSvc relation.FollowService) *web.UserAggregate {
 	return web.NewUserAggregate(userSvc, followSvc)
 }
 
-func initInkAggregate(inkSvc ink.Service, userAggregate *web.UserAggregate,
+func initInkAggregate(inkSvc ink.Service, pollSvc poll.Service, userAggregate *web.UserAggregate,
 	interactiveAggregate *web.InteractiveAggregate,
-	intrSvc interactive.Service, commentSvc comment.Service) *web.InkAggregate {
-	return web.NewInkAggregate(inkSvc, userAggregate, interactiveAggregate)
+	intrSvc interactive.Service, commentSvc comment.Service, l logx.Logger) *web.InkAggregate {
+	return web.NewInkAggregate(inkSvc, pollSvc, userAggregate, interactiveAggregate, l)
 }
 
 func initInteractiveAggregate(intrSvc interactive.Service, commentSvc comment.Service) *web.InteractiveAggregate {
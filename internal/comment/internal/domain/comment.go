@@ -13,9 +13,21 @@ type Comment struct {
 	Children    []Comment
 	Payload     Payload
 	Stats       CommentStats
+	Status      Status
 	CreatedAt   time.Time
 }
 
+// Status 评论的审核状态, 零值为可见以兼容审核流程上线前的评论
+type Status int
+
+const (
+	StatusVisible Status = iota
+	// StatusPending 等待异步审核, 仅评论者本人可见
+	StatusPending
+	// StatusHidden 审核未通过, 所有人不可见
+	StatusHidden
+)
+
 type Commentator struct {
 	Id       int64
 	IsAuthor bool
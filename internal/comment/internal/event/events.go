@@ -1,6 +1,10 @@
 package event
 
-import "time"
+import (
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/comment/internal/domain"
+)
 
 type ReplyEvent struct {
 	CommentId     int64     `json:"commentId"`
@@ -13,6 +17,29 @@ type ReplyEvent struct {
 	CreatedAt     time.Time `json:"createdAt"`
 }
 
+func NewReplyEvent(comment domain.Comment) ReplyEvent {
+	var rootId, parentId int64
+	if comment.Root != nil {
+		rootId = comment.Root.Id
+	}
+	if comment.Parent != nil {
+		parentId = comment.Parent.Id
+	}
+	return ReplyEvent{
+		CommentId:     comment.Id,
+		RootId:        rootId,
+		ParentId:      parentId,
+		Biz:           comment.Biz,
+		BizId:         comment.BizId,
+		CommentatorId: comment.Commentator.Id,
+		Payload: Payload{
+			Content: comment.Payload.Content,
+			Images:  comment.Payload.Images,
+		},
+		CreatedAt: time.Now(),
+	}
+}
+
 type Payload struct {
 	Content string   `json:"content"`
 	Images  []string `json:"images"`
@@ -23,6 +50,14 @@ type DeleteEvent struct {
 	CreatedAt time.Time `json:"createdAt"`
 }
 
+// ReviewedEvent 审核服务对评论的审核结果
+type ReviewedEvent struct {
+	CommentId int64  `json:"commentId"`
+	AuthorId  int64  `json:"authorId"`
+	Passed    bool   `json:"passed"`
+	Reason    string `json:"reason"`
+}
+
 type LikeEvent struct {
 	CommentId int64     `json:"commentId"`
 	LikeUid   int64     `json:"likeUid"`
@@ -0,0 +1,78 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/internal/comment/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/comment/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
+)
+
+const (
+	topicReviewed = "comment-reviewed"
+
+	reviewedGroup = "comment-reviewed-group"
+)
+
+// ReviewedConsumer 处理评论的审核结果, 通过后公开评论, 未通过时隐藏评论
+type ReviewedConsumer struct {
+	client   sarama.Client
+	repo     repo.CommentRepo
+	producer CommentEvtProducer
+	retry    *saramax.RetryHandler
+	l        logx.Logger
+}
+
+func NewReviewedConsumer(client sarama.Client, repo repo.CommentRepo, producer CommentEvtProducer,
+	retry *saramax.RetryHandler, l logx.Logger) *ReviewedConsumer {
+	return &ReviewedConsumer{
+		client:   client,
+		repo:     repo,
+		producer: producer,
+		retry:    retry.WithGroup(reviewedGroup),
+		l:        l,
+	}
+}
+
+func (c *ReviewedConsumer) Start() error {
+	cg, err := sarama.NewConsumerGroupFromClient(reviewedGroup, c.client)
+	if err != nil {
+		return err
+	}
+	go func() {
+		er := cg.Consume(context.Background(),
+			saramax.WithRetryTopics(topicReviewed),
+			saramax.NewHandler[ReviewedEvent](c, c.l, saramax.WithRetryHandler(c.retry)))
+		if er != nil {
+			c.l.Warn("comment reviewed consumer quit...", logx.Error(er))
+		}
+	}()
+	return nil
+}
+
+func (c *ReviewedConsumer) Consume(msg *sarama.ConsumerMessage, evt ReviewedEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	if !evt.Passed {
+		c.l.Info("comment rejected by review", logx.Int64("commentId", evt.CommentId),
+			logx.Int64("uid", evt.AuthorId), logx.String("reason", evt.Reason))
+		return c.repo.UpdateStatus(ctx, evt.CommentId, domain.StatusHidden)
+	}
+
+	comment, err := c.repo.FindById(ctx, evt.CommentId)
+	if err != nil {
+		return err
+	}
+	if comment.Status == domain.StatusVisible {
+		// 重复消费
+		return nil
+	}
+	// 先发送回复事件再公开评论, 发送失败时评论仍是待审核状态, 重试时不会被当作重复消费跳过
+	if err = c.producer.ProduceReply(ctx, NewReplyEvent(comment)); err != nil {
+		return err
+	}
+	return c.repo.UpdateStatus(ctx, evt.CommentId, domain.StatusVisible)
+}
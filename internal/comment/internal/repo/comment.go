@@ -13,16 +13,20 @@ import (
 	"github.com/KNICEX/InkFlow/internal/comment/internal/domain"
 )
 
+var ErrCommentNotFound = dao.ErrRecordNotFound
+
 // CommentRepo defines the data access operations for comments
 type CommentRepo interface {
 	CreateComment(ctx context.Context, comment domain.Comment) (int64, error)
 	DelComment(ctx context.Context, id int64) error
+	UpdateStatus(ctx context.Context, id int64, status domain.Status) error
 	LikeComment(ctx context.Context, uid, cid int64) error
 	CancelLike(ctx context.Context, uid, cid int64) error
 
-	FindByBiz(ctx context.Context, biz string, bizId int64, maxId int64, limit int) ([]domain.Comment, error)
-	FindByRootId(ctx context.Context, rootId int64, maxId int64, limit int) ([]domain.Comment, error)
-	FindByParentId(ctx context.Context, parentId int64, maxId int64, limit int) ([]domain.Comment, error)
+	// FindByBiz 查找可见的评论, 以及 uid 自己待审核的评论, FindByRootId 和 FindByParentId 同理
+	FindByBiz(ctx context.Context, biz string, bizId int64, uid, maxId int64, limit int) ([]domain.Comment, error)
+	FindByRootId(ctx context.Context, rootId int64, uid, maxId int64, limit int) ([]domain.Comment, error)
+	FindByParentId(ctx context.Context, parentId int64, uid, maxId int64, limit int) ([]domain.Comment, error)
 	FindByIds(ctx context.Context, ids []int64) (map[int64]domain.Comment, error)
 	FindById(ctx context.Context, id int64) (domain.Comment, error)
 	FindAuthorReplyIn(ctx context.Context, ids []int64) (map[int64][]domain.Comment, error)
@@ -30,6 +34,7 @@ type CommentRepo interface {
 	FindStats(ctx context.Context, ids []int64, uid int64) (map[int64]domain.CommentStats, error)
 	BizReplyCount(ctx context.Context, biz string, bizIds []int64) (map[int64]int64, error)
 	CountUserComments(ctx context.Context, uid int64) (int64, error)
+	FindAll(ctx context.Context, maxId int64, limit int, status ...domain.Status) ([]domain.Comment, error)
 }
 
 type CachedCommentRepo struct {
@@ -47,7 +52,7 @@ func NewCachedCommentRepo(dao dao.CommentDAO, cache cache.CommentCache, l logx.L
 }
 
 func (repo *CachedCommentRepo) CreateComment(ctx context.Context, comment domain.Comment) (int64, error) {
-	if comment.Root == nil {
+	if comment.Root == nil && comment.Status == domain.StatusVisible {
 		// 一级评论, 增加评论数
 		go func() {
 			er := repo.cache.IncrBizReply(ctx, comment.Biz, comment.BizId)
@@ -67,6 +72,30 @@ func (repo *CachedCommentRepo) DelComment(ctx context.Context, id int64) error {
 	return repo.dao.Delete(ctx, id)
 }
 
+func (repo *CachedCommentRepo) UpdateStatus(ctx context.Context, id int64, status domain.Status) error {
+	old, err := repo.dao.UpdateStatus(ctx, id, int(status))
+	if err != nil {
+		return err
+	}
+	oldVisible, visible := old.Status == dao.StatusVisible, status == domain.StatusVisible
+	if old.ParentId != 0 || oldVisible == visible {
+		return nil
+	}
+	// 一级评论可见性变化, 同步评论数缓存
+	if visible {
+		err = repo.cache.IncrBizReply(ctx, old.Biz, old.BizId)
+	} else {
+		err = repo.cache.DecrBizReply(ctx, old.Biz, old.BizId)
+	}
+	if err != nil {
+		repo.l.WithCtx(ctx).Error("comment cache update biz reply error",
+			logx.String("biz", old.Biz),
+			logx.Int64("bizId", old.BizId),
+			logx.Error(err))
+	}
+	return nil
+}
+
 func (repo *CachedCommentRepo) LikeComment(ctx context.Context, uid, cid int64) error {
 	return repo.dao.Like(ctx, uid, cid)
 }
@@ -75,8 +104,8 @@ func (repo *CachedCommentRepo) CancelLike(ctx context.Context, uid, cid int64) e
 	return repo.dao.CancelLike(ctx, uid, cid)
 }
 
-func (repo *CachedCommentRepo) FindByBiz(ctx context.Context, biz string, bizId int64, maxId int64, limit int) ([]domain.Comment, error) {
-	comments, err := repo.dao.FindByBiz(ctx, biz, bizId, maxId, limit)
+func (repo *CachedCommentRepo) FindByBiz(ctx context.Context, biz string, bizId int64, uid, maxId int64, limit int) ([]domain.Comment, error) {
+	comments, err := repo.dao.FindByBiz(ctx, biz, bizId, uid, maxId, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -85,8 +114,8 @@ func (repo *CachedCommentRepo) FindByBiz(ctx context.Context, biz string, bizId
 	}), nil
 }
 
-func (repo *CachedCommentRepo) FindByRootId(ctx context.Context, rootId int64, maxId int64, limit int) ([]domain.Comment, error) {
-	comments, err := repo.dao.FindRepliesByRid(ctx, rootId, maxId, limit)
+func (repo *CachedCommentRepo) FindByRootId(ctx context.Context, rootId int64, uid, maxId int64, limit int) ([]domain.Comment, error) {
+	comments, err := repo.dao.FindRepliesByRid(ctx, rootId, uid, maxId, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -95,8 +124,8 @@ func (repo *CachedCommentRepo) FindByRootId(ctx context.Context, rootId int64, m
 	}), nil
 }
 
-func (repo *CachedCommentRepo) FindByParentId(ctx context.Context, parentId int64, maxId int64, limit int) ([]domain.Comment, error) {
-	comments, err := repo.dao.FindRepliesByPid(ctx, parentId, maxId, limit)
+func (repo *CachedCommentRepo) FindByParentId(ctx context.Context, parentId int64, uid, maxId int64, limit int) ([]domain.Comment, error) {
+	comments, err := repo.dao.FindRepliesByPid(ctx, parentId, uid, maxId, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -234,6 +263,7 @@ func (repo *CachedCommentRepo) toDomain(en dao.Comment) domain.Comment {
 			Content: en.Content,
 			Images:  stringx.Split(en.Images, ","),
 		},
+		Status:    domain.Status(en.Status),
 		CreatedAt: en.CreatedAt,
 	}
 }
@@ -256,6 +286,7 @@ func (repo *CachedCommentRepo) toEntity(comment domain.Comment) dao.Comment {
 		Images:        strings.Join(comment.Payload.Images, ","),
 		CommentatorId: comment.Commentator.Id,
 		IsAuthor:      comment.Commentator.IsAuthor,
+		Status:        int(comment.Status),
 		CreatedAt:     comment.CreatedAt,
 	}
 }
@@ -263,3 +294,15 @@ func (repo *CachedCommentRepo) toEntity(comment domain.Comment) dao.Comment {
 func (repo *CachedCommentRepo) CountUserComments(ctx context.Context, uid int64) (int64, error) {
 	return repo.dao.CountUserComments(ctx, uid)
 }
+
+func (repo *CachedCommentRepo) FindAll(ctx context.Context, maxId int64, limit int, status ...domain.Status) ([]domain.Comment, error) {
+	comments, err := repo.dao.FindAll(ctx, maxId, limit, lo.Map(status, func(item domain.Status, index int) int {
+		return int(item)
+	})...)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(comments, func(item dao.Comment, index int) domain.Comment {
+		return repo.toDomain(item)
+	}), nil
+}
@@ -11,6 +11,8 @@ import (
 	"time"
 )
 
+var ErrRecordNotFound = gorm.ErrRecordNotFound
+
 type Comment struct {
 	Id    int64  `gorm:"primaryKey"`
 	Biz   string `gorm:"index:biz_type_id"`
@@ -20,6 +22,7 @@ type Comment struct {
 	IsAuthor      bool
 	Content       string
 	Images        string
+	Status        int `gorm:"index"`
 
 	// 根评论id
 	RootId int64 `gorm:"index"`
@@ -29,6 +32,12 @@ type Comment struct {
 	CreatedAt time.Time
 }
 
+const (
+	StatusVisible = iota
+	StatusPending
+	StatusHidden
+)
+
 type CommentLike struct {
 	Id        int64
 	CommentId int64 `gorm:"uniqueIndex:comment_user_id"`
@@ -52,11 +61,13 @@ type CommentDAO interface {
 
 	CommentCnt(ctx context.Context, biz string, bizId int64) (int64, error)
 	Delete(ctx context.Context, id int64) error
+	// UpdateStatus 更新审核状态, 返回更新前的评论
+	UpdateStatus(ctx context.Context, id int64, status int) (Comment, error)
 	DeleteByBiz(ctx context.Context, biz string, bizId int64) error
-	// FindByBiz 查找最新一级评论
-	FindByBiz(ctx context.Context, biz string, bizId int64, maxId int64, limit int) ([]Comment, error)
-	FindRepliesByPid(ctx context.Context, pid int64, maxId int64, limit int) ([]Comment, error)
-	FindRepliesByRid(ctx context.Context, rid int64, maxId int64, limit int) ([]Comment, error)
+	// FindByBiz 查找最新一级评论, 包括 uid 自己待审核的评论
+	FindByBiz(ctx context.Context, biz string, bizId int64, uid, maxId int64, limit int) ([]Comment, error)
+	FindRepliesByPid(ctx context.Context, pid int64, uid, maxId int64, limit int) ([]Comment, error)
+	FindRepliesByRid(ctx context.Context, rid int64, uid, maxId int64, limit int) ([]Comment, error)
 	Like(ctx context.Context, userId int64, cid int64) error
 	CancelLike(ctx context.Context, userId int64, cid int64) error
 	FindByIds(ctx context.Context, ids []int64) (map[int64]Comment, error)
@@ -66,6 +77,8 @@ type CommentDAO interface {
 	Liked(ctx context.Context, uid int64, cids []int64) (map[int64]bool, error)
 	ReplyCount(ctx context.Context, biz string, bizIds []int64) (map[int64]int64, error)
 	CountUserComments(ctx context.Context, uid int64) (int64, error)
+	// FindAll 按 id 倒序分页遍历全部评论, maxId 为 0 时从最新的开始
+	FindAll(ctx context.Context, maxId int64, limit int, status ...int) ([]Comment, error)
 }
 
 type GormCommentDAO struct {
@@ -93,7 +106,8 @@ func (dao *GormCommentDAO) Insert(ctx context.Context, c Comment) (int64, error)
 		if err != nil {
 			return err
 		}
-		if c.ParentId == 0 {
+		if c.ParentId == 0 || c.Status != StatusVisible {
+			// 待审核的回复在审核通过后再计数
 			return nil
 		}
 
@@ -127,7 +141,7 @@ func (dao *GormCommentDAO) Delete(ctx context.Context, id int64) error {
 			return err
 		}
 
-		if c.RootId != 0 {
+		if c.RootId != 0 && c.Status == StatusVisible {
 			// 扣除根评论的回复数
 			err = tx.WithContext(ctx).Model(&CommentStats{}).Where("comment_id = ?", c.RootId).Updates(map[string]any{
 				"reply_count": gorm.Expr("reply_count - 1"),
@@ -146,6 +160,49 @@ func (dao *GormCommentDAO) Delete(ctx context.Context, id int64) error {
 	})
 }
 
+func (dao *GormCommentDAO) UpdateStatus(ctx context.Context, id int64, status int) (Comment, error) {
+	var c Comment
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("id = ?", id).First(&c).Error
+		if err != nil {
+			return err
+		}
+		if c.Status == status {
+			return nil
+		}
+		err = tx.Model(&Comment{}).Where("id = ?", id).Update("status", status).Error
+		if err != nil {
+			return err
+		}
+		if c.RootId == 0 || (c.Status != StatusVisible && status != StatusVisible) {
+			return nil
+		}
+		// 可见性变化时同步根评论的回复数
+		delta := 1
+		if status != StatusVisible {
+			delta = -1
+		}
+		now := time.Now()
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{
+				{Name: "comment_id"},
+			},
+			DoUpdates: clause.Assignments(map[string]any{
+				"reply_count": gorm.Expr("comment_stats.reply_count + ?", delta),
+				"updated_at":  now,
+			}),
+		}).Create(&CommentStats{
+			Id:         dao.node.NextID(),
+			CommentId:  c.RootId,
+			ReplyCount: int64(max(delta, 0)),
+			Heat:       1,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}).Error
+	})
+	return c, err
+}
+
 func (dao *GormCommentDAO) DeleteByBiz(ctx context.Context, biz string, bizId int64) error {
 	return dao.db.Transaction(func(tx *gorm.DB) error {
 		err := tx.WithContext(ctx).Where("biz = ? AND biz_id = ?", biz, bizId).Delete(&Comment{}).Error
@@ -160,17 +217,23 @@ func (dao *GormCommentDAO) DeleteByBiz(ctx context.Context, biz string, bizId in
 func (dao *GormCommentDAO) CommentCnt(ctx context.Context, biz string, bizId int64) (int64, error) {
 	var cnt int64
 	// 只统计直接评论数
-	err := dao.db.WithContext(ctx).Model(&Comment{}).Where("biz = ? AND biz_id = ? AND parent_id = 0", biz, bizId).Count(&cnt).Error
+	err := dao.db.WithContext(ctx).Model(&Comment{}).
+		Where("biz = ? AND biz_id = ? AND parent_id = 0 AND status = ?", biz, bizId, StatusVisible).Count(&cnt).Error
 	if err != nil {
 		return 0, err
 	}
 	return cnt, nil
 }
 
-func (dao *GormCommentDAO) FindByBiz(ctx context.Context, biz string, bizId int64, maxId int64, limit int) ([]Comment, error) {
+// visible 可见的评论, 以及 uid 自己待审核的评论
+func (dao *GormCommentDAO) visible(tx *gorm.DB, uid int64) *gorm.DB {
+	return tx.Where("status = ? OR (status = ? AND commentator_id = ?)", StatusVisible, StatusPending, uid)
+}
+
+func (dao *GormCommentDAO) FindByBiz(ctx context.Context, biz string, bizId int64, uid, maxId int64, limit int) ([]Comment, error) {
 	var res []Comment
 	var err error
-	tx := dao.db.WithContext(ctx)
+	tx := dao.visible(dao.db.WithContext(ctx), uid)
 	if maxId == 0 {
 		err = tx.Where("biz = ? AND biz_id = ? AND parent_id = 0", biz, bizId).Order("id DESC").Limit(limit).Find(&res).Error
 	} else {
@@ -179,10 +242,10 @@ func (dao *GormCommentDAO) FindByBiz(ctx context.Context, biz string, bizId int6
 	return res, err
 }
 
-func (dao *GormCommentDAO) FindRepliesByPid(ctx context.Context, pid int64, maxId int64, limit int) ([]Comment, error) {
+func (dao *GormCommentDAO) FindRepliesByPid(ctx context.Context, pid int64, uid, maxId int64, limit int) ([]Comment, error) {
 	var res []Comment
 	var err error
-	tx := dao.db.WithContext(ctx)
+	tx := dao.visible(dao.db.WithContext(ctx), uid)
 	if maxId == 0 {
 		err = tx.Where("parent_id = ?", pid).Order("id DESC").Limit(limit).Find(&res).Error
 	} else {
@@ -191,10 +254,10 @@ func (dao *GormCommentDAO) FindRepliesByPid(ctx context.Context, pid int64, maxI
 	return res, err
 }
 
-func (dao *GormCommentDAO) FindRepliesByRid(ctx context.Context, rid int64, maxId int64, limit int) ([]Comment, error) {
+func (dao *GormCommentDAO) FindRepliesByRid(ctx context.Context, rid int64, uid, maxId int64, limit int) ([]Comment, error) {
 	var res []Comment
 	var err error
-	tx := dao.db.WithContext(ctx)
+	tx := dao.visible(dao.db.WithContext(ctx), uid)
 	if maxId == 0 {
 		err = tx.Where("root_id = ?", rid).Order("id DESC").Limit(limit).Find(&res).Error
 	} else {
@@ -280,7 +343,7 @@ func (dao *GormCommentDAO) FindById(ctx context.Context, id int64) (Comment, err
 
 func (dao *GormCommentDAO) FindAuthorReplyIn(ctx context.Context, ids []int64) (map[int64][]Comment, error) {
 	var res []Comment
-	err := dao.db.WithContext(ctx).Where("root_id IN ? AND is_author = true", ids).Find(&res).Error
+	err := dao.db.WithContext(ctx).Where("root_id IN ? AND is_author = true AND status = ?", ids, StatusVisible).Find(&res).Error
 	if err != nil {
 		return nil, err
 	}
@@ -324,7 +387,7 @@ func (dao *GormCommentDAO) ReplyCount(ctx context.Context, biz string, bizIds []
 	}
 	var res []ReplyCount
 	err := dao.db.WithContext(ctx).Model(&Comment{}).Select("biz_id, count(*) as reply_count").
-		Where("biz = ? AND biz_id IN ? AND parent_id = 0", biz, bizIds).
+		Where("biz = ? AND biz_id IN ? AND parent_id = 0 AND status = ?", biz, bizIds, StatusVisible).
 		Group("biz_id").
 		Find(&res).Error
 	if err != nil {
@@ -339,6 +402,19 @@ func (dao *GormCommentDAO) ReplyCount(ctx context.Context, biz string, bizIds []
 
 func (dao *GormCommentDAO) CountUserComments(ctx context.Context, uid int64) (int64, error) {
 	var count int64
-	err := dao.db.WithContext(ctx).Model(&Comment{}).Where("commentator_id = ?", uid).Count(&count).Error
+	err := dao.db.WithContext(ctx).Model(&Comment{}).Where("commentator_id = ? AND status = ?", uid, StatusVisible).Count(&count).Error
 	return count, err
 }
+
+func (dao *GormCommentDAO) FindAll(ctx context.Context, maxId int64, limit int, status ...int) ([]Comment, error) {
+	var comments []Comment
+	tx := dao.db.WithContext(ctx)
+	if maxId != 0 {
+		tx = tx.Where("id < ?", maxId)
+	}
+	if len(status) > 0 {
+		tx = tx.Where("status IN ?", status)
+	}
+	err := tx.Order("id DESC").Limit(limit).Find(&comments).Error
+	return comments, err
+}
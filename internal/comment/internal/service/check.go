@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/KNICEX/InkFlow/internal/comment/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
+	"github.com/KNICEX/InkFlow/pkg/ratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+// 本地检查直接拒绝发布的原因
+var (
+	ErrSensitiveContent   = errors.New("comment contains sensitive content")
+	ErrDuplicateComment   = errors.New("duplicate comment")
+	ErrCommentTooFrequent = errors.New("comment too frequent")
+)
+
+// IsRejected 评论是否被本地检查直接拒绝
+func IsRejected(err error) bool {
+	return errors.Is(err, ErrSensitiveContent) ||
+		errors.Is(err, ErrDuplicateComment) ||
+		errors.Is(err, ErrCommentTooFrequent)
+}
+
+// Checker 评论发布前的快速检查
+type Checker interface {
+	// Check 返回需要异步审核的原因, 为空表示可以直接发布;
+	// 直接拒绝时返回 ErrSensitiveContent 等错误, 其余错误表示检查本身失败
+	Check(ctx context.Context, comment domain.Comment) (string, error)
+	// Release 评论最终没有创建时撤销 Check 中记录的状态, 避免用户重试时被误判为重复评论
+	Release(ctx context.Context, comment domain.Comment) error
+}
+
+// localChecker 组成 Checker 的单项检查
+type localChecker interface {
+	Check(ctx context.Context, comment domain.Comment) (string, error)
+}
+
+// releaser 在 Check 中记录了状态的单项检查需要实现
+type releaser interface {
+	Release(ctx context.Context, comment domain.Comment) error
+}
+
+type CheckConfig struct {
+	// SuspiciousPatterns 命中时交给 LLM 审核, 比如联系方式、引流话术
+	SuspiciousPatterns []string
+	// MaxLinks 链接数超过该值时需要审核, 为 0 表示不检查
+	MaxLinks int
+	// MaxLinkRatio 链接字符占比超过该值时需要审核, 为 0 表示不检查
+	MaxLinkRatio float64
+	// DuplicateWindow 同一用户在窗口内发布相同内容时拒绝,
+	// 不同用户发布相同内容超过 MaxDuplicates 次时需要审核
+	DuplicateWindow time.Duration
+	MaxDuplicates   int64
+	// RateLimit 每个用户在 RateWindow 内最多发布的评论数, 为 0 表示不限制
+	RateWindow time.Duration
+	RateLimit  int64
+}
+
+// NewChecker 按配置组合本地检查, 依次执行, 任意检查拒绝时立即返回
+func NewChecker(cmd redis.Cmdable, sensitiveSvc sensitive.Service, cfg CheckConfig) Checker {
+	checkers := checkerChain{
+		&sensitiveChecker{svc: sensitiveSvc},
+		newPatternChecker(cfg.SuspiciousPatterns),
+		&linkChecker{maxLinks: cfg.MaxLinks, maxRatio: cfg.MaxLinkRatio},
+		&imageChecker{},
+	}
+	if cfg.RateLimit > 0 {
+		checkers = append(checkers, &rateChecker{
+			limiter: ratelimit.NewRedisFixedWindowKeyLimiter(cmd, cfg.RateWindow, cfg.RateLimit),
+		})
+	}
+	if cfg.DuplicateWindow > 0 {
+		checkers = append(checkers, &duplicateChecker{
+			cmd:           cmd,
+			window:        cfg.DuplicateWindow,
+			maxDuplicates: cfg.MaxDuplicates,
+		})
+	}
+	return checkers
+}
+
+type checkerChain []localChecker
+
+func (c checkerChain) Check(ctx context.Context, comment domain.Comment) (string, error) {
+	var reasons []string
+	var errs []error
+	for _, checker := range c {
+		reason, err := checker.Check(ctx, comment)
+		if IsRejected(err) {
+			return "", err
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	return strings.Join(reasons, "; "), errors.Join(errs...)
+}
+
+func (c checkerChain) Release(ctx context.Context, comment domain.Comment) error {
+	var errs []error
+	for _, checker := range c {
+		if r, ok := checker.(releaser); ok {
+			errs = append(errs, r.Release(ctx, comment))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sensitiveChecker 命中敏感词库时直接拒绝
+type sensitiveChecker struct {
+	svc sensitive.Service
+}
+
+func (c *sensitiveChecker) Check(ctx context.Context, comment domain.Comment) (string, error) {
+	if c.svc.Contains(ctx, comment.Payload.Content) {
+		return "", ErrSensitiveContent
+	}
+	return "", nil
+}
+
+type patternChecker struct {
+	patterns []*regexp.Regexp
+}
+
+func newPatternChecker(patterns []string) *patternChecker {
+	c := &patternChecker{}
+	for _, p := range patterns {
+		c.patterns = append(c.patterns, regexp.MustCompile(p))
+	}
+	return c
+}
+
+func (c *patternChecker) Check(ctx context.Context, comment domain.Comment) (string, error) {
+	content := strings.ToLower(comment.Payload.Content)
+	for _, p := range c.patterns {
+		if p.MatchString(content) {
+			return fmt.Sprintf("命中可疑规则 %s", p.String()), nil
+		}
+	}
+	return "", nil
+}
+
+var linkRegexp = regexp.MustCompile(`(?i)(https?://|www\.)[^\s]+`)
+
+type linkChecker struct {
+	maxLinks int
+	maxRatio float64
+}
+
+func (c *linkChecker) Check(ctx context.Context, comment domain.Comment) (string, error) {
+	content := comment.Payload.Content
+	links := linkRegexp.FindAllString(content, -1)
+	if len(links) == 0 {
+		return "", nil
+	}
+	if c.maxLinks > 0 && len(links) > c.maxLinks {
+		return fmt.Sprintf("链接过多(%d)", len(links)), nil
+	}
+	if c.maxRatio > 0 {
+		linkLen := 0
+		for _, link := range links {
+			linkLen += utf8.RuneCountInString(link)
+		}
+		ratio := float64(linkLen) / float64(utf8.RuneCountInString(content))
+		if ratio > c.maxRatio {
+			return fmt.Sprintf("链接占比过高(%.2f)", ratio), nil
+		}
+	}
+	return "", nil
+}
+
+// imageChecker 图片无法在本地检查, 一律交给 LLM 审核
+type imageChecker struct{}
+
+func (c *imageChecker) Check(ctx context.Context, comment domain.Comment) (string, error) {
+	if len(comment.Payload.Images) > 0 {
+		return "包含图片", nil
+	}
+	return "", nil
+}
+
+type rateChecker struct {
+	limiter ratelimit.KeyLimiter
+}
+
+func (c *rateChecker) Check(ctx context.Context, comment domain.Comment) (string, error) {
+	limited, err := c.limiter.Limited(ctx, fmt.Sprintf("comment:rate:%d", comment.Commentator.Id))
+	if err != nil {
+		return "", err
+	}
+	if limited {
+		return "", ErrCommentTooFrequent
+	}
+	return "", nil
+}
+
+type duplicateChecker struct {
+	cmd           redis.Cmdable
+	window        time.Duration
+	maxDuplicates int64
+}
+
+func (c *duplicateChecker) Check(ctx context.Context, comment domain.Comment) (string, error) {
+	fp := fingerprint(comment.Payload.Content)
+	if fp == "" {
+		return "", nil
+	}
+	ok, err := c.cmd.SetNX(ctx, c.userKey(comment.Commentator.Id, fp), 1, c.window).Result()
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrDuplicateComment
+	}
+	if c.maxDuplicates <= 0 {
+		return "", nil
+	}
+
+	// 不同用户发布相同内容, 可能是批量灌水
+	key := c.globalKey(fp)
+	pipe := c.cmd.TxPipeline()
+	cnt := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, c.window)
+	if _, err = pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	if cnt.Val() > c.maxDuplicates {
+		return fmt.Sprintf("相同内容重复出现 %d 次", cnt.Val()), nil
+	}
+	return "", nil
+}
+
+func (c *duplicateChecker) Release(ctx context.Context, comment domain.Comment) error {
+	fp := fingerprint(comment.Payload.Content)
+	if fp == "" {
+		return nil
+	}
+	if err := c.cmd.Del(ctx, c.userKey(comment.Commentator.Id, fp)).Err(); err != nil {
+		return err
+	}
+	if c.maxDuplicates <= 0 {
+		return nil
+	}
+	return c.cmd.Decr(ctx, c.globalKey(fp)).Err()
+}
+
+func (c *duplicateChecker) userKey(uid int64, fp string) string {
+	return fmt.Sprintf("comment:fp:%d:%s", uid, fp)
+}
+
+func (c *duplicateChecker) globalKey(fp string) string {
+	return fmt.Sprintf("comment:fp:%s", fp)
+}
+
+// fingerprint 忽略大小写、空白和标点后的内容摘要, 内容为空时返回空字符串
+func fingerprint(content string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(content) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	sum := sha1.Sum([]byte(sb.String()))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/comment/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
+	"github.com/KNICEX/InkFlow/pkg/ratelimit"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSensitiveService struct {
+	sensitive.Service
+	word string
+}
+
+func (f *fakeSensitiveService) Contains(ctx context.Context, text string) bool {
+	return strings.Contains(strings.ToLower(text), f.word)
+}
+
+func TestLocalCheckers(t *testing.T) {
+	checker := checkerChain{
+		&sensitiveChecker{svc: &fakeSensitiveService{word: "spam"}},
+		newPatternChecker([]string{`vx\s*[:：]?\s*\w{5,}`}),
+		&linkChecker{maxLinks: 1, maxRatio: 0.5},
+		&imageChecker{},
+	}
+	testCases := []struct {
+		name       string
+		content    string
+		images     []string
+		wantErr    error
+		wantReason bool
+	}{
+		{
+			name:    "普通评论",
+			content: "写得很好, 学到了",
+		},
+		{
+			name:    "命中屏蔽词",
+			content: "this is SPAM",
+			wantErr: ErrSensitiveContent,
+		},
+		{
+			name:       "命中可疑规则",
+			content:    "加我 vx: abc12345",
+			wantReason: true,
+		},
+		{
+			name:       "链接过多",
+			content:    "看看 https://a.com 和 https://b.com 这两篇文章写得怎么样",
+			wantReason: true,
+		},
+		{
+			name:       "链接占比过高",
+			content:    "点 https://a.com/abcdefg",
+			wantReason: true,
+		},
+		{
+			name:    "少量链接",
+			content: "参考了 https://a.com 里的做法, 思路很清晰, 感谢作者分享",
+		},
+		{
+			name:       "包含图片",
+			content:    "看图",
+			images:     []string{"https://a.com/1.jpg"},
+			wantReason: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reason, err := checker.Check(context.Background(), domain.Comment{
+				Payload: domain.Payload{Content: tc.content, Images: tc.images},
+			})
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantReason, reason != "")
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, fingerprint("Hello, World!"), fingerprint("hello world"))
+	assert.NotEqual(t, fingerprint("hello world"), fingerprint("hello word"))
+	assert.Empty(t, fingerprint(" ,. !"))
+}
+
+func newTestRedis(t *testing.T) redis.Cmdable {
+	cmd := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	if err := cmd.Ping(context.Background()).Err(); err != nil {
+		t.Skip("redis not available:", err)
+	}
+	return cmd
+}
+
+// testComment 每次使用不同的用户和内容, 避免和之前的测试数据冲突
+func testComment(uid int64) domain.Comment {
+	return domain.Comment{
+		Commentator: domain.Commentator{Id: uid},
+		Payload:     domain.Payload{Content: fmt.Sprintf("写得很好 %d", uid)},
+	}
+}
+
+func TestRateChecker(t *testing.T) {
+	cmd := newTestRedis(t)
+	ctx := context.Background()
+	uid := time.Now().UnixNano()
+	defer cmd.Del(ctx, fmt.Sprintf("comment:rate:%d", uid))
+	checker := &rateChecker{
+		limiter: ratelimit.NewRedisFixedWindowKeyLimiter(cmd, time.Minute, 2),
+	}
+
+	for range 2 {
+		_, err := checker.Check(ctx, testComment(uid))
+		require.NoError(t, err)
+	}
+	_, err := checker.Check(ctx, testComment(uid))
+	assert.ErrorIs(t, err, ErrCommentTooFrequent)
+	// 其他用户不受影响
+	_, err = checker.Check(ctx, testComment(uid+1))
+	assert.NoError(t, err)
+	cmd.Del(ctx, fmt.Sprintf("comment:rate:%d", uid+1))
+}
+
+func TestDuplicateChecker(t *testing.T) {
+	cmd := newTestRedis(t)
+	ctx := context.Background()
+	uid := time.Now().UnixNano()
+	checker := &duplicateChecker{cmd: cmd, window: time.Minute, maxDuplicates: 2}
+	c := testComment(uid)
+	fp := fingerprint(c.Payload.Content)
+	defer cmd.Del(ctx, checker.globalKey(fp), checker.userKey(uid, fp),
+		checker.userKey(uid+1, fp), checker.userKey(uid+2, fp))
+
+	reason, err := checker.Check(ctx, c)
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	// 同一用户重复发布, 忽略大小写和标点
+	c.Payload.Content = "写得很好!!! " + fmt.Sprint(uid)
+	_, err = checker.Check(ctx, c)
+	assert.ErrorIs(t, err, ErrDuplicateComment)
+
+	// 创建失败后撤销, 用户可以重新发布
+	require.NoError(t, checker.Release(ctx, c))
+	reason, err = checker.Check(ctx, c)
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+
+	// 不同用户发布相同内容超过上限时需要审核
+	c.Commentator.Id = uid + 1
+	reason, err = checker.Check(ctx, c)
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	c.Commentator.Id = uid + 2
+	reason, err = checker.Check(ctx, c)
+	require.NoError(t, err)
+	assert.NotEmpty(t, reason)
+}
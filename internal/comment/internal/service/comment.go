@@ -7,6 +7,7 @@ import (
 	"github.com/KNICEX/InkFlow/internal/comment/internal/event"
 	"github.com/KNICEX/InkFlow/internal/comment/internal/repo"
 	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/internal/review"
 	"github.com/KNICEX/InkFlow/pkg/logx"
 	"github.com/samber/lo"
 	"golang.org/x/sync/errgroup"
@@ -15,6 +16,7 @@ import (
 
 var (
 	ErrNoPermission = errors.New("no permission")
+	ErrNotFound     = errors.New("comment not found")
 )
 
 const (
@@ -40,26 +42,33 @@ type CommentService interface {
 	FindBizReplyCount(ctx context.Context, biz string, bizIds []int64) (map[int64]int64, error)
 
 	CountUserComments(ctx context.Context, uid int64) (int64, error)
+	// ListAllVisible 按 id 倒序遍历全部可见评论, 用于重建搜索索引
+	ListAllVisible(ctx context.Context, maxId int64, limit int) ([]domain.Comment, error)
 }
 
 type commentService struct {
-	repo     repo.CommentRepo
-	l        logx.Logger
-	inkSvc   ink.Service
-	producer event.CommentEvtProducer
+	repo      repo.CommentRepo
+	l         logx.Logger
+	inkSvc    ink.Service
+	reviewSvc review.AsyncService
+	checker   Checker
+	producer  event.CommentEvtProducer
 }
 
-func NewCommentService(repo repo.CommentRepo, inkSvc ink.Service, producer event.CommentEvtProducer, l logx.Logger) CommentService {
+func NewCommentService(repo repo.CommentRepo, inkSvc ink.Service, reviewSvc review.AsyncService, checker Checker,
+	producer event.CommentEvtProducer, l logx.Logger) CommentService {
 	return &commentService{
-		repo:     repo,
-		l:        l,
-		inkSvc:   inkSvc,
-		producer: producer,
+		repo:      repo,
+		l:         l,
+		inkSvc:    inkSvc,
+		reviewSvc: reviewSvc,
+		checker:   checker,
+		producer:  producer,
 	}
 }
 
 func (svc *commentService) LoadLastedList(ctx context.Context, biz string, bizId int64, uid, maxId int64, limit int) ([]domain.Comment, error) {
-	comments, err := svc.repo.FindByBiz(ctx, biz, bizId, maxId, limit)
+	comments, err := svc.repo.FindByBiz(ctx, biz, bizId, uid, maxId, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -100,31 +109,56 @@ func (svc *commentService) Create(ctx context.Context, comment domain.Comment) (
 		return 0, err
 	}
 	comment.Commentator.IsAuthor = isAuthor
+
+	reason, err := svc.checker.Check(ctx, comment)
+	if IsRejected(err) {
+		return 0, err
+	}
+	if err != nil {
+		// 检查本身失败时交给 LLM 兜底
+		svc.l.WithCtx(ctx).Warn("comment check error", logx.Error(err),
+			logx.Int64("uid", comment.Commentator.Id))
+		reason = "本地检查失败"
+	}
+	comment.Status = domain.StatusVisible
+	if reason != "" {
+		comment.Status = domain.StatusPending
+	}
+
 	id, err := svc.repo.CreateComment(ctx, comment)
 	if err != nil {
+		svc.release(ctx, comment)
 		return 0, err
 	}
-	go func() {
-		var rootId, parentId int64
-		if comment.Root != nil {
-			rootId = comment.Root.Id
-		}
-		if comment.Parent != nil {
-			parentId = comment.Parent.Id
-		}
-		er := svc.producer.ProduceReply(ctx, event.ReplyEvent{
-			CommentId:     id,
-			RootId:        rootId,
-			ParentId:      parentId,
-			Biz:           comment.Biz,
-			BizId:         comment.BizId,
-			CommentatorId: comment.Commentator.Id,
-			Payload: event.Payload{
-				Content: comment.Payload.Content,
-				Images:  comment.Payload.Images,
-			},
+	comment.Id = id
+
+	if comment.Status == domain.StatusPending {
+		// 可疑评论异步审核, 通过后再发送回复事件
+		err = svc.reviewSvc.SubmitComment(ctx, review.Comment{
+			Id:        id,
+			Biz:       comment.Biz,
+			BizId:     comment.BizId,
+			AuthorId:  comment.Commentator.Id,
+			Content:   comment.Payload.Content,
+			Images:    comment.Payload.Images,
 			CreatedAt: time.Now(),
 		})
+		if err != nil {
+			// 提交审核失败时评论会一直待审核, 直接撤销这条评论让用户重试
+			if er := svc.repo.DelComment(ctx, id); er != nil {
+				svc.l.WithCtx(ctx).Error("rollback pending comment error", logx.Error(er),
+					logx.Int64("commentId", id))
+			}
+			svc.release(ctx, comment)
+			return 0, err
+		}
+		svc.l.WithCtx(ctx).Info("comment pending review", logx.Int64("commentId", id),
+			logx.String("reason", reason))
+		return id, nil
+	}
+
+	go func() {
+		er := svc.producer.ProduceReply(ctx, event.NewReplyEvent(comment))
 		if er != nil {
 			svc.l.WithCtx(ctx).Error("produce reply event error", logx.Error(er),
 				logx.Int64("commentId", id),
@@ -134,6 +168,14 @@ func (svc *commentService) Create(ctx context.Context, comment domain.Comment) (
 	return id, nil
 }
 
+// release 评论最终没有创建时撤销检查记录的状态
+func (svc *commentService) release(ctx context.Context, comment domain.Comment) {
+	if err := svc.checker.Release(ctx, comment); err != nil {
+		svc.l.WithCtx(ctx).Warn("release comment check error", logx.Error(err),
+			logx.Int64("uid", comment.Commentator.Id))
+	}
+}
+
 func (svc *commentService) isAuthor(ctx context.Context, biz string, bizId int64, uid int64) (bool, error) {
 	switch biz {
 	case bizInk:
@@ -226,8 +268,14 @@ func (svc *commentService) FindById(ctx context.Context, commentId, uid int64) (
 	})
 
 	if err := eg.Wait(); err != nil {
+		if errors.Is(err, repo.ErrCommentNotFound) {
+			return domain.Comment{}, ErrNotFound
+		}
 		return domain.Comment{}, err
 	}
+	if comment.Status != domain.StatusVisible && comment.Commentator.Id != uid {
+		return domain.Comment{}, ErrNotFound
+	}
 	comment.Stats = stats[comment.Id]
 	return comment, nil
 }
@@ -237,6 +285,11 @@ func (svc *commentService) FindByIds(ctx context.Context, ids []int64, uid int64
 	if err != nil {
 		return nil, err
 	}
+	for id, comment := range comments {
+		if comment.Status != domain.StatusVisible && comment.Commentator.Id != uid {
+			delete(comments, id)
+		}
+	}
 	stats, err := svc.repo.FindStats(ctx, ids, uid)
 	if err != nil {
 		return nil, err
@@ -248,7 +301,7 @@ func (svc *commentService) FindByIds(ctx context.Context, ids []int64, uid int64
 }
 
 func (svc *commentService) LoadMoreRepliesByRid(ctx context.Context, rid int64, uid, maxId int64, limit int) ([]domain.Comment, error) {
-	comments, err := svc.repo.FindByRootId(ctx, rid, maxId, limit)
+	comments, err := svc.repo.FindByRootId(ctx, rid, uid, maxId, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -269,7 +322,7 @@ func (svc *commentService) LoadMoreRepliesByRid(ctx context.Context, rid int64,
 }
 
 func (svc *commentService) LoadMoreRepliesByPid(ctx context.Context, pid int64, uid, maxId int64, limit int) ([]domain.Comment, error) {
-	comments, err := svc.repo.FindByParentId(ctx, pid, maxId, limit)
+	comments, err := svc.repo.FindByParentId(ctx, pid, uid, maxId, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -295,3 +348,8 @@ func (svc *commentService) FindBizReplyCount(ctx context.Context, biz string, bi
 func (svc *commentService) CountUserComments(ctx context.Context, uid int64) (int64, error) {
 	return svc.repo.CountUserComments(ctx, uid)
 }
+
+// ListAllVisible 不要暴露给用户
+func (svc *commentService) ListAllVisible(ctx context.Context, maxId int64, limit int) ([]domain.Comment, error) {
+	return svc.repo.FindAll(ctx, maxId, limit, domain.StatusVisible)
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/KNICEX/InkFlow/internal/comment/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/comment/internal/repo"
+	"github.com/KNICEX/InkFlow/internal/review"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCommentRepo struct {
+	repo.CommentRepo
+	id      int64
+	err     error
+	deleted []int64
+}
+
+func (r *fakeCommentRepo) CreateComment(ctx context.Context, comment domain.Comment) (int64, error) {
+	return r.id, r.err
+}
+
+func (r *fakeCommentRepo) DelComment(ctx context.Context, id int64) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+type fakeReviewSvc struct {
+	review.AsyncService
+	err error
+}
+
+func (s *fakeReviewSvc) SubmitComment(ctx context.Context, comment review.Comment) error {
+	return s.err
+}
+
+type fakeChecker struct {
+	reason   string
+	released int
+}
+
+func (c *fakeChecker) Check(ctx context.Context, comment domain.Comment) (string, error) {
+	return c.reason, nil
+}
+
+func (c *fakeChecker) Release(ctx context.Context, comment domain.Comment) error {
+	c.released++
+	return nil
+}
+
+func TestCommentService_CreateReleaseCheck(t *testing.T) {
+	dbErr := errors.New("db error")
+	checker := &fakeChecker{}
+	svc := NewCommentService(&fakeCommentRepo{err: dbErr}, nil, nil, checker, nil, logx.NewNopLogger())
+	_, err := svc.Create(context.Background(), domain.Comment{
+		Biz:     "test",
+		Payload: domain.Payload{Content: "写得很好"},
+	})
+	assert.ErrorIs(t, err, dbErr)
+	assert.Equal(t, 1, checker.released)
+}
+
+func TestCommentService_CreateRollbackOnSubmitError(t *testing.T) {
+	submitErr := errors.New("kafka error")
+	checker := &fakeChecker{reason: "链接过多"}
+	commentRepo := &fakeCommentRepo{id: 10}
+	svc := NewCommentService(commentRepo, nil, &fakeReviewSvc{err: submitErr}, checker, nil, logx.NewNopLogger())
+	_, err := svc.Create(context.Background(), domain.Comment{
+		Biz:     "test",
+		Payload: domain.Payload{Content: "http://a.com"},
+	})
+	assert.ErrorIs(t, err, submitErr)
+	// 提交审核失败时撤销已写入的待审核评论
+	assert.Equal(t, []int64{10}, commentRepo.deleted)
+	assert.Equal(t, 1, checker.released)
+}
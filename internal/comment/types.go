@@ -2,6 +2,7 @@ package comment
 
 import (
 	"github.com/KNICEX/InkFlow/internal/comment/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/comment/internal/event"
 	"github.com/KNICEX/InkFlow/internal/comment/internal/service"
 )
 
@@ -11,3 +12,20 @@ type Comment = domain.Comment
 type Payload = domain.Payload
 type Commentator = domain.Commentator
 type Stats = domain.CommentStats
+
+type Status = domain.Status
+
+const (
+	StatusVisible = domain.StatusVisible
+	StatusPending = domain.StatusPending
+	StatusHidden  = domain.StatusHidden
+)
+
+var (
+	ErrNotFound           = service.ErrNotFound
+	ErrSensitiveContent   = service.ErrSensitiveContent
+	ErrDuplicateComment   = service.ErrDuplicateComment
+	ErrCommentTooFrequent = service.ErrCommentTooFrequent
+)
+
+type ReviewedConsumer = event.ReviewedConsumer
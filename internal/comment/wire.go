@@ -3,6 +3,8 @@
 package comment
 
 import (
+	"time"
+
 	"github.com/IBM/sarama"
 	"github.com/KNICEX/InkFlow/internal/comment/internal/event"
 	"github.com/KNICEX/InkFlow/internal/comment/internal/repo"
@@ -10,10 +12,14 @@ import (
 	"github.com/KNICEX/InkFlow/internal/comment/internal/repo/dao"
 	"github.com/KNICEX/InkFlow/internal/comment/internal/service"
 	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/internal/review"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
 	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
 	"github.com/KNICEX/InkFlow/pkg/snowflakex"
 	"github.com/google/wire"
 	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
 	"gorm.io/gorm"
 )
 
@@ -26,13 +32,61 @@ func initDAO(db *gorm.DB, node snowflakex.Node, l logx.Logger) dao.CommentDAO {
 	return dao.NewGormCommentDAO(db, node, l)
 }
 
-func InitCommentService(db *gorm.DB, cmd redis.Cmdable, inkSvc ink.Service, producer sarama.SyncProducer, l logx.Logger) Service {
+// initChecker 读取 comment.check 配置, 未配置时使用默认规则
+func initChecker(cmd redis.Cmdable, sensitiveSvc sensitive.Service) service.Checker {
+	type Config struct {
+		SuspiciousPatterns []string      `mapstructure:"suspicious_patterns"`
+		MaxLinks           int           `mapstructure:"max_links"`
+		MaxLinkRatio       float64       `mapstructure:"max_link_ratio"`
+		DuplicateWindow    time.Duration `mapstructure:"duplicate_window"`
+		MaxDuplicates      int64         `mapstructure:"max_duplicates"`
+		RateWindow         time.Duration `mapstructure:"rate_window"`
+		RateLimit          int64         `mapstructure:"rate_limit"`
+	}
+	cfg := Config{
+		MaxLinks:        2,
+		MaxLinkRatio:    0.5,
+		DuplicateWindow: time.Minute * 10,
+		MaxDuplicates:   5,
+		RateWindow:      time.Minute,
+		RateLimit:       10,
+	}
+	if err := viper.UnmarshalKey("comment.check", &cfg); err != nil {
+		panic(err)
+	}
+	return service.NewChecker(cmd, sensitiveSvc, service.CheckConfig{
+		SuspiciousPatterns: cfg.SuspiciousPatterns,
+		MaxLinks:           cfg.MaxLinks,
+		MaxLinkRatio:       cfg.MaxLinkRatio,
+		DuplicateWindow:    cfg.DuplicateWindow,
+		MaxDuplicates:      cfg.MaxDuplicates,
+		RateWindow:         cfg.RateWindow,
+		RateLimit:          cfg.RateLimit,
+	})
+}
+
+func InitCommentService(db *gorm.DB, cmd redis.Cmdable, inkSvc ink.Service, reviewSvc review.AsyncService,
+	sensitiveSvc sensitive.Service, producer sarama.SyncProducer, l logx.Logger) Service {
 	wire.Build(
 		initSnowflakeNode,
 		initDAO,
 		cache.NewRedisCommentCache,
 		repo.NewCachedCommentRepo,
 		event.NewKafkaCommentEvtProducer,
+		initChecker,
 		service.NewCommentService)
 	return nil
 }
+
+func InitReviewedConsumer(client sarama.Client, db *gorm.DB, cmd redis.Cmdable, producer sarama.SyncProducer,
+	retry *saramax.RetryHandler, l logx.Logger) *ReviewedConsumer {
+	wire.Build(
+		initSnowflakeNode,
+		initDAO,
+		cache.NewRedisCommentCache,
+		repo.NewCachedCommentRepo,
+		event.NewKafkaCommentEvtProducer,
+		event.NewReviewedConsumer,
+	)
+	return nil
+}
@@ -7,6 +7,8 @@
 package comment
 
 import (
+	"time"
+
 	"github.com/IBM/sarama"
 	"github.com/KNICEX/InkFlow/internal/comment/internal/event"
 	"github.com/KNICEX/InkFlow/internal/comment/internal/repo"
@@ -14,24 +16,39 @@ import (
 	"github.com/KNICEX/InkFlow/internal/comment/internal/repo/dao"
 	service2 "github.com/KNICEX/InkFlow/internal/comment/internal/service"
 	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/internal/review"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
 	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
 	"github.com/KNICEX/InkFlow/pkg/snowflakex"
 	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
 	"gorm.io/gorm"
 )
 
 // Injectors from wire.go:
 
-func InitCommentService(db *gorm.DB, cmd redis.Cmdable, inkSvc ink.Service, producer sarama.SyncProducer, l logx.Logger) service2.CommentService {
+func InitCommentService(db *gorm.DB, cmd redis.Cmdable, inkSvc ink.Service, reviewSvc review.AsyncService, sensitiveSvc sensitive.Service, producer sarama.SyncProducer, l logx.Logger) service2.CommentService {
 	node := initSnowflakeNode()
 	commentDAO := initDAO(db, node, l)
 	commentCache := cache.NewRedisCommentCache(cmd)
 	commentRepo := repo.NewCachedCommentRepo(commentDAO, commentCache, l)
+	checker := initChecker(cmd, sensitiveSvc)
 	commentEvtProducer := event.NewKafkaCommentEvtProducer(producer)
-	commentService := service2.NewCommentService(commentRepo, inkSvc, commentEvtProducer, l)
+	commentService := service2.NewCommentService(commentRepo, inkSvc, reviewSvc, checker, commentEvtProducer, l)
 	return commentService
 }
 
+func InitReviewedConsumer(client sarama.Client, db *gorm.DB, cmd redis.Cmdable, producer sarama.SyncProducer, retry *saramax.RetryHandler, l logx.Logger) *event.ReviewedConsumer {
+	node := initSnowflakeNode()
+	commentDAO := initDAO(db, node, l)
+	commentCache := cache.NewRedisCommentCache(cmd)
+	commentRepo := repo.NewCachedCommentRepo(commentDAO, commentCache, l)
+	commentEvtProducer := event.NewKafkaCommentEvtProducer(producer)
+	reviewedConsumer := event.NewReviewedConsumer(client, commentRepo, commentEvtProducer, retry, l)
+	return reviewedConsumer
+}
+
 // wire.go:
 
 func initSnowflakeNode() snowflakex.Node {
@@ -42,3 +59,36 @@ func initDAO(db *gorm.DB, node snowflakex.Node, l logx.Logger) dao.CommentDAO {
 	dao.Init(db)
 	return dao.NewGormCommentDAO(db, node, l)
 }
+
+// initChecker 读取 comment.check 配置, 未配置时使用默认规则
+func initChecker(cmd redis.Cmdable, sensitiveSvc sensitive.Service) service2.Checker {
+	type Config struct {
+		SuspiciousPatterns []string      `mapstructure:"suspicious_patterns"`
+		MaxLinks           int           `mapstructure:"max_links"`
+		MaxLinkRatio       float64       `mapstructure:"max_link_ratio"`
+		DuplicateWindow    time.Duration `mapstructure:"duplicate_window"`
+		MaxDuplicates      int64         `mapstructure:"max_duplicates"`
+		RateWindow         time.Duration `mapstructure:"rate_window"`
+		RateLimit          int64         `mapstructure:"rate_limit"`
+	}
+	cfg := Config{
+		MaxLinks:        2,
+		MaxLinkRatio:    0.5,
+		DuplicateWindow: time.Minute * 10,
+		MaxDuplicates:   5,
+		RateWindow:      time.Minute,
+		RateLimit:       10,
+	}
+	if err := viper.UnmarshalKey("comment.check", &cfg); err != nil {
+		panic(err)
+	}
+	return service2.NewChecker(cmd, sensitiveSvc, service2.CheckConfig{
+		SuspiciousPatterns: cfg.SuspiciousPatterns,
+		MaxLinks:           cfg.MaxLinks,
+		MaxLinkRatio:       cfg.MaxLinkRatio,
+		DuplicateWindow:    cfg.DuplicateWindow,
+		MaxDuplicates:      cfg.MaxDuplicates,
+		RateWindow:         cfg.RateWindow,
+		RateLimit:          cfg.RateLimit,
+	})
+}
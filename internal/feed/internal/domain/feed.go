@@ -17,10 +17,13 @@ const (
 )
 
 type FeedInk struct {
-	InkId     int64     `json:"inkId"`
-	AuthorId  int64     `json:"authorId"`
-	Title     string    `json:"title"`
-	Cover     string    `json:"cover"`
-	Abstract  string    `json:"abstract"`
-	CreatedAt time.Time `json:"createdAt"`
+	InkId       int64     `json:"inkId"`
+	AuthorId    int64     `json:"authorId"`
+	Title       string    `json:"title"`
+	Cover       string    `json:"cover"`
+	Summary     string    `json:"summary"`
+	Abstract    string    `json:"abstract"`
+	WordCount   int       `json:"wordCount"`
+	ReadingTime int       `json:"readingTime"`
+	CreatedAt   time.Time `json:"createdAt"`
 }
@@ -0,0 +1,61 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// FeedAuthor 曾经切换到拉模型的作者, 读取时需要拉取这些作者的 feed,
+// 切回推模型后记录依然保留, 之前只写入拉模型的 feed 才不会丢失
+type FeedAuthor struct {
+	Uid int64 `gorm:"primaryKey;autoIncrement:false"`
+	// Pull 当前是否只写拉模型
+	Pull      bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type FeedAuthorDAO interface {
+	// FindPull 作者当前是否只写拉模型, 没有记录时为推模型
+	FindPull(ctx context.Context, uid int64) (bool, error)
+	Upsert(ctx context.Context, author FeedAuthor) error
+	FindAllUids(ctx context.Context) ([]int64, error)
+}
+
+type GormFeedAuthorDAO struct {
+	db *gorm.DB
+}
+
+func NewGormFeedAuthorDAO(db *gorm.DB) FeedAuthorDAO {
+	return &GormFeedAuthorDAO{
+		db: db,
+	}
+}
+
+func (dao *GormFeedAuthorDAO) FindPull(ctx context.Context, uid int64) (bool, error) {
+	var author FeedAuthor
+	err := dao.db.WithContext(ctx).Where("uid = ?", uid).First(&author).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return author.Pull, err
+}
+
+func (dao *GormFeedAuthorDAO) Upsert(ctx context.Context, author FeedAuthor) error {
+	now := time.Now()
+	author.CreatedAt = now
+	author.UpdatedAt = now
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "uid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"pull", "updated_at"}),
+	}).Create(&author).Error
+}
+
+func (dao *GormFeedAuthorDAO) FindAllUids(ctx context.Context) ([]int64, error) {
+	var uids []int64
+	err := dao.db.WithContext(ctx).Model(&FeedAuthor{}).Pluck("uid", &uids).Error
+	return uids, err
+}
@@ -18,19 +18,27 @@ type FeedRepo interface {
 	FindPushFeed(ctx context.Context, uids int64, maxId, timestamp int64, limit int) ([]domain.Feed, error)
 	FindPullByType(ctx context.Context, uids []int64, biz string, maxId, timestamp int64, limit int, ) ([]domain.Feed, error)
 	FindPushByType(ctx context.Context, uids int64, biz string, maxId, timestamp int64, limit int) ([]domain.Feed, error)
+
+	// FindAuthorPull 作者当前是否只写拉模型
+	FindAuthorPull(ctx context.Context, uid int64) (bool, error)
+	SetAuthorPull(ctx context.Context, uid int64, pull bool) error
+	// FindPullAuthors 曾经切换到拉模型的全部作者
+	FindPullAuthors(ctx context.Context) ([]int64, error)
 }
 
 type NoCacheFeedRepo struct {
-	pushDAO dao.PushFeedDAO
-	pullDAO dao.PullFeedDAO
-	l       logx.Logger
+	pushDAO   dao.PushFeedDAO
+	pullDAO   dao.PullFeedDAO
+	authorDAO dao.FeedAuthorDAO
+	l         logx.Logger
 }
 
-func NewNoCacheFeedRepo(push dao.PushFeedDAO, pull dao.PullFeedDAO, l logx.Logger) FeedRepo {
+func NewNoCacheFeedRepo(push dao.PushFeedDAO, pull dao.PullFeedDAO, author dao.FeedAuthorDAO, l logx.Logger) FeedRepo {
 	return &NoCacheFeedRepo{
-		pullDAO: pull,
-		pushDAO: push,
-		l:       l,
+		pullDAO:   pull,
+		pushDAO:   push,
+		authorDAO: author,
+		l:         l,
 	}
 }
 
@@ -105,6 +113,21 @@ func (repo *NoCacheFeedRepo) FindPushByType(ctx context.Context, uids int64, biz
 	}), nil
 }
 
+func (repo *NoCacheFeedRepo) FindAuthorPull(ctx context.Context, uid int64) (bool, error) {
+	return repo.authorDAO.FindPull(ctx, uid)
+}
+
+func (repo *NoCacheFeedRepo) SetAuthorPull(ctx context.Context, uid int64, pull bool) error {
+	return repo.authorDAO.Upsert(ctx, dao.FeedAuthor{
+		Uid:  uid,
+		Pull: pull,
+	})
+}
+
+func (repo *NoCacheFeedRepo) FindPullAuthors(ctx context.Context) ([]int64, error) {
+	return repo.authorDAO.FindAllUids(ctx)
+}
+
 func (repo *NoCacheFeedRepo) pullToDomain(pull dao.PullFeed) domain.Feed {
 	var content any
 	switch pull.Biz {
@@ -2,12 +2,15 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"github.com/KNICEX/InkFlow/internal/action"
 	"github.com/KNICEX/InkFlow/internal/feed/internal/domain"
 	"github.com/KNICEX/InkFlow/internal/feed/internal/repo"
 	"github.com/KNICEX/InkFlow/internal/relation"
+	"github.com/samber/lo"
 	"golang.org/x/sync/errgroup"
 	"sort"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,44 +19,90 @@ type FeedService interface {
 	FollowFeedInkList(ctx context.Context, uid int64, maxId, timestamp int64, limit int) ([]domain.Feed, error)
 }
 
+const (
+	// DefaultPushThreshold 粉丝数超过该值的作者只写拉模型
+	DefaultPushThreshold int64 = 10000
+	followBatchSize            = 500
+	activeUserDuration         = time.Hour * 24 * 30
+	// pullAuthorsTTL 拉模型作者列表的本地缓存时间, 新切换到拉模型的作者最多延迟这么久被读取
+	pullAuthorsTTL = time.Minute
+)
+
+type pullAuthors struct {
+	uids     []int64
+	loadedAt time.Time
+}
+
 type feedService struct {
 	repo      repo.FeedRepo
 	followSvc relation.FollowService
 	actionSvc action.Service
+	// pushThreshold 推拉混合的粉丝数阈值
+	pushThreshold int64
+	authors       atomic.Pointer[pullAuthors]
 }
 
-func NewFeedService(repo repo.FeedRepo, followSvc relation.FollowService, actionSvc action.Service) FeedService {
+func NewFeedService(repo repo.FeedRepo, followSvc relation.FollowService, actionSvc action.Service, pushThreshold int64) FeedService {
+	if pushThreshold <= 0 {
+		pushThreshold = DefaultPushThreshold
+	}
 	return &feedService{
-		repo:      repo,
-		followSvc: followSvc,
-		actionSvc: actionSvc,
+		repo:          repo,
+		followSvc:     followSvc,
+		actionSvc:     actionSvc,
+		pushThreshold: pushThreshold,
 	}
 }
 
+// CreateFeed 采用推拉混合模型, 始终写入拉模型,
+// 推模型的作者再推送到活跃粉丝的收件箱
 func (f *feedService) CreateFeed(ctx context.Context, feed domain.Feed) error {
 	if err := f.repo.CreatePullFeed(ctx, feed); err != nil {
 		return err
 	}
 
-	// TODO 现在采用全推模型，后续考虑混合
-	followerIds, err := f.followSvc.FollowerIds(ctx, feed.UserId, 0, 10000)
-	if err != nil {
-		return err
-	}
-	// 30 天内活跃用户
-	activeUsers, err := f.actionSvc.FindActiveUser(ctx, followerIds, time.Now().Add(-time.Hour*24*30))
+	pull, err := f.authorPull(ctx, feed.UserId)
 	if err != nil {
 		return err
 	}
-	pushFeeds := make([]domain.Feed, 0, len(activeUsers))
-	for _, user := range activeUsers {
-		feed.UserId = user.Id
-		pushFeeds = append(pushFeeds, feed)
-	}
-	if len(pushFeeds) == 0 {
+	if pull {
+		// 大V 只写拉模型, 由粉丝读取时拉取
 		return nil
 	}
-	return f.repo.CreatePushFeed(ctx, pushFeeds)
+
+	activeSince := time.Now().Add(-activeUserDuration)
+	var maxId int64
+	for {
+		followers, er := f.followSvc.FollowerRelations(ctx, feed.UserId, maxId, followBatchSize)
+		if er != nil {
+			return er
+		}
+		if len(followers) == 0 {
+			return nil
+		}
+		followerIds := lo.Map(followers, func(item relation.FollowRelation, index int) int64 {
+			return item.FollowerId
+		})
+		activeUsers, er := f.actionSvc.FindActiveUser(ctx, followerIds, activeSince)
+		if er != nil {
+			return er
+		}
+		if len(activeUsers) > 0 {
+			pushFeeds := make([]domain.Feed, 0, len(activeUsers))
+			for _, user := range activeUsers {
+				pushFeed := feed
+				pushFeed.UserId = user.Id
+				pushFeeds = append(pushFeeds, pushFeed)
+			}
+			if er = f.repo.CreatePushFeed(ctx, pushFeeds); er != nil {
+				return er
+			}
+		}
+		if len(followers) < followBatchSize {
+			return nil
+		}
+		maxId = followers[len(followers)-1].Id
+	}
 }
 
 func (f *feedService) FollowFeedInkList(ctx context.Context, uid, maxId, timestamp int64, limit int) ([]domain.Feed, error) {
@@ -67,22 +116,86 @@ func (f *feedService) FollowFeedInkList(ctx context.Context, uid, maxId, timesta
 		return er
 	})
 	eg.Go(func() error {
-		// TODO 这里先查2000，后续修改
-		followingIds, er := f.followSvc.FollowingIds(ctx, uid, 0, 2000)
+		bigVIds, er := f.findBigVFollowings(ctx, uid)
 		if er != nil {
 			return er
 		}
-		pullFeeds, er = f.repo.FindPullFeed(ctx, followingIds, maxId, timestamp, limit)
+		if len(bigVIds) == 0 {
+			return nil
+		}
+		pullFeeds, er = f.repo.FindPullFeed(ctx, bigVIds, maxId, timestamp, limit)
 		return er
 	})
 	if err := eg.Wait(); err != nil {
 		return nil, err
 	}
 
-	feeds := append(pushFeeds, pullFeeds...)
+	// 作者粉丝数跨过阈值前后, 同一条 feed 可能同时存在于推拉两侧
+	feeds := lo.UniqBy(append(pushFeeds, pullFeeds...), func(item domain.Feed) string {
+		return fmt.Sprintf("%s:%d", item.Biz, item.BizId)
+	})
 	sort.Slice(feeds, func(i, j int) bool {
+		if feeds[i].CreatedAt.Equal(feeds[j].CreatedAt) {
+			return feeds[i].Id > feeds[j].Id
+		}
 		return feeds[i].CreatedAt.After(feeds[j].CreatedAt)
 	})
 
 	return feeds[:min(len(feeds), limit)], nil
 }
+
+// authorPull 判断作者是否只写拉模型并持久化. 粉丝数超过阈值时切换到拉模型,
+// 降到阈值的 80% 以下才切回推模型, 避免粉丝数在阈值附近波动时频繁切换
+func (f *feedService) authorPull(ctx context.Context, uid int64) (bool, error) {
+	pull, err := f.repo.FindAuthorPull(ctx, uid)
+	if err != nil {
+		return false, err
+	}
+	stats, err := f.followSvc.FindFollowStats(ctx, uid, 0)
+	if err != nil {
+		return false, err
+	}
+	next := pull
+	if !pull && stats.Followers > f.pushThreshold {
+		next = true
+	} else if pull && stats.Followers*5 < f.pushThreshold*4 {
+		next = false
+	}
+	if next != pull {
+		if err = f.repo.SetAuthorPull(ctx, uid, next); err != nil {
+			return false, err
+		}
+	}
+	return next, nil
+}
+
+// findBigVFollowings 在曾经切换到拉模型的作者中找出用户关注的,
+// 切回推模型的作者之前的 feed 只存在于拉模型, 同样需要拉取
+func (f *feedService) findBigVFollowings(ctx context.Context, uid int64) ([]int64, error) {
+	authors, err := f.pullAuthors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(authors) == 0 {
+		return nil, nil
+	}
+	statsMap, err := f.followSvc.FindFollowStatsBatch(ctx, authors, uid)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Filter(authors, func(item int64, index int) bool {
+		return statsMap[item].Followed
+	}), nil
+}
+
+func (f *feedService) pullAuthors(ctx context.Context) ([]int64, error) {
+	if authors := f.authors.Load(); authors != nil && time.Since(authors.loadedAt) < pullAuthorsTTL {
+		return authors.uids, nil
+	}
+	uids, err := f.repo.FindPullAuthors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f.authors.Store(&pullAuthors{uids: uids, loadedAt: time.Now()})
+	return uids, nil
+}
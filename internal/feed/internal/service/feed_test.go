@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/action"
+	"github.com/KNICEX/InkFlow/internal/feed/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/feed/internal/repo"
+	"github.com/KNICEX/InkFlow/internal/relation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFeedRepo struct {
+	repo.FeedRepo
+	authors map[int64]bool
+	pull    []domain.Feed
+	push    []domain.Feed
+}
+
+func (f *fakeFeedRepo) CreatePullFeed(ctx context.Context, pull domain.Feed) error {
+	f.pull = append(f.pull, pull)
+	return nil
+}
+
+func (f *fakeFeedRepo) CreatePushFeed(ctx context.Context, push []domain.Feed) error {
+	f.push = append(f.push, push...)
+	return nil
+}
+
+func (f *fakeFeedRepo) FindPushFeed(ctx context.Context, uid int64, maxId, timestamp int64, limit int) ([]domain.Feed, error) {
+	var res []domain.Feed
+	for _, feed := range f.push {
+		if feed.UserId == uid {
+			res = append(res, feed)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeFeedRepo) FindPullFeed(ctx context.Context, uids []int64, maxId, timestamp int64, limit int) ([]domain.Feed, error) {
+	var res []domain.Feed
+	for _, feed := range f.pull {
+		for _, uid := range uids {
+			if feed.UserId == uid {
+				res = append(res, feed)
+			}
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeFeedRepo) FindAuthorPull(ctx context.Context, uid int64) (bool, error) {
+	return f.authors[uid], nil
+}
+
+func (f *fakeFeedRepo) SetAuthorPull(ctx context.Context, uid int64, pull bool) error {
+	f.authors[uid] = pull
+	return nil
+}
+
+func (f *fakeFeedRepo) FindPullAuthors(ctx context.Context) ([]int64, error) {
+	uids := make([]int64, 0, len(f.authors))
+	for uid := range f.authors {
+		uids = append(uids, uid)
+	}
+	return uids, nil
+}
+
+type fakeFollowService struct {
+	relation.FollowService
+	followers map[int64]int64
+	// follows 粉丝 -> 关注的作者
+	follows map[int64][]int64
+}
+
+func (f *fakeFollowService) FindFollowStats(ctx context.Context, uid, viewUid int64) (relation.FollowStatistic, error) {
+	return relation.FollowStatistic{Uid: uid, Followers: f.followers[uid]}, nil
+}
+
+func (f *fakeFollowService) FindFollowStatsBatch(ctx context.Context, uids []int64, viewUid int64) (map[int64]relation.FollowStatistic, error) {
+	res := make(map[int64]relation.FollowStatistic, len(uids))
+	for _, uid := range uids {
+		followed := false
+		for _, followee := range f.follows[viewUid] {
+			followed = followed || followee == uid
+		}
+		res[uid] = relation.FollowStatistic{Uid: uid, Followers: f.followers[uid], Followed: followed}
+	}
+	return res, nil
+}
+
+func (f *fakeFollowService) FollowerRelations(ctx context.Context, uid int64, maxId int64, limit int) ([]relation.FollowRelation, error) {
+	if maxId != 0 {
+		return nil, nil
+	}
+	var res []relation.FollowRelation
+	for follower, followees := range f.follows {
+		for _, followee := range followees {
+			if followee == uid {
+				res = append(res, relation.FollowRelation{Id: follower, FollowerId: follower, FolloweeId: uid})
+			}
+		}
+	}
+	return res, nil
+}
+
+type fakeActionService struct {
+	action.Service
+}
+
+func (f *fakeActionService) FindActiveUser(ctx context.Context, uids []int64, lastAction time.Time) ([]action.User, error) {
+	res := make([]action.User, 0, len(uids))
+	for _, uid := range uids {
+		res = append(res, action.User{Id: uid})
+	}
+	return res, nil
+}
+
+func TestFeedService_AuthorMode(t *testing.T) {
+	testCases := []struct {
+		name      string
+		pull      bool
+		followers int64
+		wantPull  bool
+	}{
+		{name: "推模型未超过阈值", followers: 100, wantPull: false},
+		{name: "推模型超过阈值", followers: 101, wantPull: true},
+		{name: "拉模型降到阈值以下但高于 80%", pull: true, followers: 90, wantPull: true},
+		{name: "拉模型降到 80% 以下", pull: true, followers: 79, wantPull: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &fakeFeedRepo{authors: map[int64]bool{}}
+			if tc.pull {
+				r.authors[1] = true
+			}
+			followSvc := &fakeFollowService{
+				followers: map[int64]int64{1: tc.followers},
+				follows:   map[int64][]int64{2: {1}},
+			}
+			svc := NewFeedService(r, followSvc, &fakeActionService{}, 100)
+			err := svc.CreateFeed(context.Background(), domain.Feed{Id: 1, UserId: 1, Biz: domain.BizInk, BizId: 1})
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantPull, r.authors[1])
+			assert.Equal(t, !tc.wantPull, len(r.push) > 0)
+		})
+	}
+}
+
+func TestFeedService_FollowFeedInkList(t *testing.T) {
+	now := time.Now()
+	r := &fakeFeedRepo{
+		// 作者 1 曾经是大V, 已经切回推模型, 作者 3 是大V 但用户没有关注
+		authors: map[int64]bool{1: false, 3: true},
+		pull: []domain.Feed{
+			{Id: 1, UserId: 1, Biz: domain.BizInk, BizId: 1, CreatedAt: now.Add(-time.Hour)},
+			{Id: 2, UserId: 1, Biz: domain.BizInk, BizId: 2, CreatedAt: now},
+			{Id: 3, UserId: 3, Biz: domain.BizInk, BizId: 3, CreatedAt: now},
+		},
+		push: []domain.Feed{
+			{Id: 4, UserId: 2, Biz: domain.BizInk, BizId: 2, CreatedAt: now},
+		},
+	}
+	followSvc := &fakeFollowService{
+		followers: map[int64]int64{1: 50, 3: 1000},
+		follows:   map[int64][]int64{2: {1}},
+	}
+	svc := NewFeedService(r, followSvc, &fakeActionService{}, 100)
+	feeds, err := svc.FollowFeedInkList(context.Background(), 2, 0, 0, 10)
+	require.NoError(t, err)
+	bizIds := make([]int64, 0, len(feeds))
+	for _, feed := range feeds {
+		bizIds = append(bizIds, feed.BizId)
+	}
+	// 推拉两侧重复的 feed 只保留一条, 切回推模型前只写入拉模型的 feed 依然可见
+	assert.Equal(t, []int64{2, 1}, bizIds)
+}
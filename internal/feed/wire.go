@@ -8,6 +8,7 @@ import (
 	"github.com/KNICEX/InkFlow/internal/relation"
 	"github.com/KNICEX/InkFlow/pkg/logx"
 	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"github.com/spf13/viper"
 	"gorm.io/gorm"
 )
 
@@ -23,10 +24,18 @@ func initRepo(db *gorm.DB, l logx.Logger) repo.FeedRepo {
 	node := snowflakex.NewNode(snowflakex.DefaultStartTime, 0)
 	pushDAO := dao.NewGormPushFeedDAO(db, node)
 	pullDAO := dao.NewGormFeedPullDAO(db, node)
-	r = repo.NewNoCacheFeedRepo(pushDAO, pullDAO, l)
+	authorDAO := dao.NewGormFeedAuthorDAO(db)
+	r = repo.NewNoCacheFeedRepo(pushDAO, pullDAO, authorDAO, l)
 	return r
 }
 
 func InitService(db *gorm.DB, followSvc relation.FollowService, actionSvc action.Service, l logx.Logger) Service {
-	return service.NewFeedService(initRepo(db, l), followSvc, actionSvc)
+	type Config struct {
+		PushThreshold int64 `mapstructure:"push_threshold"`
+	}
+	var cfg Config
+	if err := viper.UnmarshalKey("feed", &cfg); err != nil {
+		panic(err)
+	}
+	return service.NewFeedService(initRepo(db, l), followSvc, actionSvc, cfg.PushThreshold)
 }
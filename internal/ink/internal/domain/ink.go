@@ -2,6 +2,9 @@ package domain
 
 import (
 	"time"
+
+	"github.com/KNICEX/InkFlow/pkg/htmlx"
+	"github.com/KNICEX/InkFlow/pkg/stringx"
 )
 
 type Ink struct {
@@ -17,21 +20,38 @@ type Ink struct {
 	// 手动添加的标签
 	Tags []string
 	// ai生成的标签
-	AiTags    []string
-	Status    Status
-	Author    Author
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	AiTags []string
+	// WordCount 正文字数, 发布时统计
+	WordCount int
+	// ReadingTime 预计阅读时长, 单位分钟
+	ReadingTime int
+	Status      Status
+	Author      Author
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	// ScheduledAt 定时发布时间, 为零值表示立即发布
+	ScheduledAt time.Time
 }
 
-// Abstract 返回摘要(大约前50个字符)
+const abstractLen = 50
+
+// Abstract 返回摘要(正文纯文本的前50个字符)
 func (i Ink) Abstract() string {
-	// TODO 这里要去掉各种标记符号
-	if len(i.ContentHtml) > 50 {
-		// TODO 这里需要一个转换函数只提取文字内容
-		return i.ContentMeta[:50]
-	}
-	return i.ContentHtml
+	return htmlx.Abstract(i.ContentHtml, abstractLen)
+}
+
+// PlainText 返回去掉标记后的正文纯文本
+func (i Ink) PlainText() string {
+	return htmlx.Text(i.ContentHtml)
+}
+
+// readingSpeed 每分钟阅读的字数
+const readingSpeed = 300
+
+// ReadingStats 统计正文字数并估算阅读时长, 有内容时至少为 1 分钟
+func (i Ink) ReadingStats() (wordCount int, readingTime int) {
+	wordCount = stringx.WordCount(i.PlainText())
+	return wordCount, (wordCount + readingSpeed - 1) / readingSpeed
 }
 
 func (i Ink) CanPublish() bool {
@@ -55,7 +75,8 @@ const (
 	InkStatusPublished          // 已发布
 	InkStatusPrivate            // 私密
 
-	InKStatusDeleted // 已删除
+	InKStatusDeleted   // 已删除
+	InkStatusScheduled // 审核通过, 等待定时发布
 )
 
 type Author struct {
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/KNICEX/InkFlow/pkg/diffx"
+)
+
+// Revision 每次发布时保存的不可变版本
+type Revision struct {
+	Id int64
+	// Version 同一篇 ink 内从 1 开始递增
+	Version     int
+	InkId       int64
+	AuthorId    int64
+	Title       string
+	Cover       string
+	Summary     string
+	ContentHtml string
+	ContentMeta string
+	Tags        []string
+	AiTags      []string
+	Review      ReviewResult
+	CreatedAt   time.Time
+}
+
+type ReviewResult struct {
+	Passed bool
+	Reason string
+}
+
+// RevisionDiff 两个版本之间的差异, 正文按 markdown 源文逐行比较
+type RevisionDiff struct {
+	From int64
+	// To 为 0 时表示当前草稿
+	To      int64
+	Title   []diffx.Line
+	Content []diffx.Line
+	// ContentTooLarge 正文改动太大, 没有逐行比较, Content 为空
+	ContentTooLarge bool
+	AddedTags       []string
+	RemovedTags     []string
+}
@@ -16,10 +16,19 @@ type DraftDAO interface {
 	Update(ctx context.Context, d DraftInk) error
 	Delete(ctx context.Context, id int64, authorId int64, status ...int) error
 	UpdateStatus(ctx context.Context, inkId int64, authorId int64, status int) error
+	// Restore 用历史版本覆盖草稿内容, 空值同样会覆盖, 并置为待审核、清除定时.
+	// 只会覆盖处于 status 中的草稿, 没有匹配的草稿时返回 ErrDraftNotFound
+	Restore(ctx context.Context, d DraftInk, status ...int) error
+	// UpdateSchedule 同时更新定时发布时间和状态, scheduledAt 为零值时清除定时
+	UpdateSchedule(ctx context.Context, inkId int64, authorId int64, scheduledAt time.Time, status int) error
+	// UpdateScheduledAt 只更新处于 status 中的草稿的定时发布时间, 不改变状态
+	UpdateScheduledAt(ctx context.Context, inkId int64, authorId int64, scheduledAt time.Time, status ...int) error
 	FindById(ctx context.Context, id int64) (DraftInk, error)
 	FindByIdAndAuthorId(ctx context.Context, id int64, authorId int64, status ...int) (DraftInk, error)
 	FindByAuthorId(ctx context.Context, authorId int64, offset, limit int, status ...int) ([]DraftInk, error)
 	FindAll(ctx context.Context, maxId int64, limit int) ([]DraftInk, error)
+	// FindScheduledByAuthorId 查找设置了定时发布且尚未发布的草稿
+	FindScheduledByAuthorId(ctx context.Context, authorId int64, offset, limit int) ([]DraftInk, error)
 }
 
 var _ DraftDAO = (*draftDAO)(nil)
@@ -58,6 +67,30 @@ func (dao *draftDAO) Update(ctx context.Context, d DraftInk) error {
 	return nil
 }
 
+func (dao *draftDAO) Restore(ctx context.Context, d DraftInk, status ...int) error {
+	res := dao.db.WithContext(ctx).Model(&DraftInk{}).
+		Where("id = ? AND author_id = ? AND status IN ?", d.Id, d.AuthorId, status).
+		Updates(map[string]any{
+			"title":        d.Title,
+			"cover":        d.Cover,
+			"summary":      d.Summary,
+			"content_html": d.ContentHtml,
+			"content_meta": d.ContentMeta,
+			"tags":         d.Tags,
+			"ai_tags":      d.AiTags,
+			"status":       InkStatusPending,
+			"scheduled_at": time.Time{},
+			"updated_at":   time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrDraftNotFound
+	}
+	return nil
+}
+
 func (dao *draftDAO) UpdateStatus(ctx context.Context, inkId int64, authorId int64, status int) error {
 	err := dao.db.WithContext(ctx).Model(&DraftInk{}).Where("id = ? AND author_id = ?", inkId, authorId).Update("status", status).Error
 	if err != nil {
@@ -66,6 +99,23 @@ func (dao *draftDAO) UpdateStatus(ctx context.Context, inkId int64, authorId int
 	return nil
 }
 
+func (dao *draftDAO) UpdateSchedule(ctx context.Context, inkId int64, authorId int64, scheduledAt time.Time, status int) error {
+	return dao.db.WithContext(ctx).Model(&DraftInk{}).Where("id = ? AND author_id = ?", inkId, authorId).
+		Updates(map[string]any{
+			"scheduled_at": scheduledAt,
+			"status":       status,
+			"updated_at":   time.Now(),
+		}).Error
+}
+
+func (dao *draftDAO) UpdateScheduledAt(ctx context.Context, inkId int64, authorId int64, scheduledAt time.Time, status ...int) error {
+	return dao.db.WithContext(ctx).Model(&DraftInk{}).Where("id = ? AND author_id = ? AND status IN ?", inkId, authorId, status).
+		Updates(map[string]any{
+			"scheduled_at": scheduledAt,
+			"updated_at":   time.Now(),
+		}).Error
+}
+
 func (dao *draftDAO) FindById(ctx context.Context, id int64) (DraftInk, error) {
 	var d DraftInk
 	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&d).Error
@@ -120,3 +170,12 @@ func (dao *draftDAO) FindAll(ctx context.Context, maxId int64, limit int) ([]Dra
 	}
 	return drafts, nil
 }
+
+func (dao *draftDAO) FindScheduledByAuthorId(ctx context.Context, authorId int64, offset, limit int) ([]DraftInk, error) {
+	var drafts []DraftInk
+	err := dao.db.WithContext(ctx).
+		Where("author_id = ? AND status IN ? AND scheduled_at > ?", authorId,
+			[]int{InkStatusPending, InkStatusScheduled}, time.Time{}).
+		Order("scheduled_at asc").Offset(offset).Limit(limit).Find(&drafts).Error
+	return drafts, err
+}
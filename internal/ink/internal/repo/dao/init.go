@@ -3,7 +3,7 @@ package dao
 import "gorm.io/gorm"
 
 func InitTable(db *gorm.DB) error {
-	if err := db.AutoMigrate(&DraftInk{}, &LiveInk{}); err != nil {
+	if err := db.AutoMigrate(&DraftInk{}, &LiveInk{}, &InkRevision{}); err != nil {
 		return err
 	}
 	return nil
@@ -14,7 +14,10 @@ type Ink struct {
 	ContentHtml string
 	Tags        string
 	AiTags      string
+	WordCount   int
+	ReadingTime int
 	Status      int       `gorm:"type:int;default:0;index"`
+	ScheduledAt time.Time `gorm:"index"`
 	CreatedAt   time.Time `gorm:"index"`
 	UpdatedAt   time.Time `gorm:"index"`
 }
@@ -30,4 +33,6 @@ const (
 	InkStatusRejected        // 审核拒绝
 	InkStatusPublished       // 公开
 	InkStatusPrivate         // 私密
+	InkStatusDeleted         // 已删除
+	InkStatusScheduled       // 等待定时发布
 )
@@ -54,6 +54,8 @@ func (dao *liveDAO) Upsert(ctx context.Context, d LiveInk) (int64, error) {
 			"content_meta",
 			"tags",
 			"ai_tags",
+			"word_count",
+			"reading_time",
 			"updated_at",
 		}),
 	}).Create(&d).Error
@@ -0,0 +1,77 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"gorm.io/gorm"
+)
+
+var ErrRevisionNotFound = gorm.ErrRecordNotFound
+
+type InkRevision struct {
+	Id          int64
+	InkId       int64  `gorm:"uniqueIndex:ink_version"`
+	Version     int    `gorm:"uniqueIndex:ink_version"`
+	AuthorId    int64  `gorm:"index"`
+	Title       string `gorm:"type:varchar(100)"`
+	Cover       string
+	Summary     string
+	ContentMeta string
+	ContentHtml string
+	Tags        string
+	AiTags      string
+	Passed      bool
+	Reason      string
+	CreatedAt   time.Time
+}
+
+type RevisionDAO interface {
+	// Insert 插入新版本, 版本号自动递增
+	Insert(ctx context.Context, r InkRevision) (InkRevision, error)
+	FindById(ctx context.Context, id int64) (InkRevision, error)
+	FindByInkId(ctx context.Context, inkId, authorId int64, offset, limit int) ([]InkRevision, error)
+}
+
+type gormRevisionDAO struct {
+	db   *gorm.DB
+	node snowflakex.Node
+}
+
+func NewGormRevisionDAO(db *gorm.DB, node snowflakex.Node) RevisionDAO {
+	return &gormRevisionDAO{
+		db:   db,
+		node: node,
+	}
+}
+
+func (dao *gormRevisionDAO) Insert(ctx context.Context, r InkRevision) (InkRevision, error) {
+	r.Id = dao.node.NextID()
+	r.CreatedAt = time.Now()
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var version int
+		err := tx.Model(&InkRevision{}).Where("ink_id = ?", r.InkId).
+			Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+		if err != nil {
+			return err
+		}
+		// 并发发布时依赖唯一索引保证版本号不重复
+		r.Version = version + 1
+		return tx.Create(&r).Error
+	})
+	return r, err
+}
+
+func (dao *gormRevisionDAO) FindById(ctx context.Context, id int64) (InkRevision, error) {
+	var r InkRevision
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&r).Error
+	return r, err
+}
+
+func (dao *gormRevisionDAO) FindByInkId(ctx context.Context, inkId, authorId int64, offset, limit int) ([]InkRevision, error) {
+	var rs []InkRevision
+	err := dao.db.WithContext(ctx).Where("ink_id = ? AND author_id = ?", inkId, authorId).
+		Order("version desc").Offset(offset).Limit(limit).Find(&rs).Error
+	return rs, err
+}
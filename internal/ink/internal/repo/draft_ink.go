@@ -19,8 +19,14 @@ type DraftInkRepo interface {
 	FindByIdAndAuthorId(ctx context.Context, id, authorId int64, status ...domain.Status) (domain.Ink, error)
 	Update(ctx context.Context, ink domain.Ink) error
 	UpdateStatus(ctx context.Context, ink domain.Ink) error
+	UpdateSchedule(ctx context.Context, ink domain.Ink) error
+	// UpdateScheduledAt 只更新处于 status 中的草稿的定时发布时间
+	UpdateScheduledAt(ctx context.Context, ink domain.Ink, status ...domain.Status) error
+	// Restore 用 ink 的内容覆盖处于 status 中的草稿并置为待审核
+	Restore(ctx context.Context, ink domain.Ink, status ...domain.Status) error
 	FindByAuthorId(ctx context.Context, authorId int64, offset, limit int, status ...domain.Status) ([]domain.Ink, error)
 	FindAll(ctx context.Context, maxId int64, limit int) ([]domain.Ink, error)
+	FindScheduledByAuthorId(ctx context.Context, authorId int64, offset, limit int) ([]domain.Ink, error)
 }
 
 var _ DraftInkRepo = (*NoCacheDraftInkRepo)(nil)
@@ -79,6 +85,33 @@ func (repo *NoCacheDraftInkRepo) UpdateStatus(ctx context.Context, ink domain.In
 	return nil
 }
 
+func (repo *NoCacheDraftInkRepo) Restore(ctx context.Context, ink domain.Ink, status ...domain.Status) error {
+	return repo.dao.Restore(ctx, repo.domainToEntity(ink), lo.Map(status, func(item domain.Status, index int) int {
+		return item.ToInt()
+	})...)
+}
+
+// UpdateSchedule 更新定时发布时间和状态
+func (repo *NoCacheDraftInkRepo) UpdateSchedule(ctx context.Context, ink domain.Ink) error {
+	return repo.dao.UpdateSchedule(ctx, ink.Id, ink.Author.Id, ink.ScheduledAt, ink.Status.ToInt())
+}
+
+func (repo *NoCacheDraftInkRepo) UpdateScheduledAt(ctx context.Context, ink domain.Ink, status ...domain.Status) error {
+	return repo.dao.UpdateScheduledAt(ctx, ink.Id, ink.Author.Id, ink.ScheduledAt, lo.Map(status, func(item domain.Status, index int) int {
+		return item.ToInt()
+	})...)
+}
+
+func (repo *NoCacheDraftInkRepo) FindScheduledByAuthorId(ctx context.Context, authorId int64, offset, limit int) ([]domain.Ink, error) {
+	inks, err := repo.dao.FindScheduledByAuthorId(ctx, authorId, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(inks, func(item dao.DraftInk, index int) domain.Ink {
+		return repo.entityToDomain(item)
+	}), nil
+}
+
 func (repo *NoCacheDraftInkRepo) FindByAuthorId(ctx context.Context, authorId int64, offset, limit int, status ...domain.Status) ([]domain.Ink, error) {
 	inks, err := repo.dao.FindByAuthorId(ctx, authorId, offset, limit, lo.Map(status, func(item domain.Status, index int) int {
 		return item.ToInt()
@@ -118,6 +151,7 @@ func (repo *NoCacheDraftInkRepo) domainToEntity(ink domain.Ink) dao.DraftInk {
 		ContentHtml: ink.ContentHtml,
 		ContentMeta: ink.ContentMeta,
 		Status:      ink.Status.ToInt(),
+		ScheduledAt: ink.ScheduledAt,
 		CreatedAt:   ink.CreatedAt,
 		UpdatedAt:   ink.UpdatedAt,
 	}
@@ -140,6 +174,7 @@ func (repo *NoCacheDraftInkRepo) entityToDomain(ink dao.DraftInk) domain.Ink {
 		ContentHtml: ink.ContentHtml,
 		ContentMeta: ink.ContentMeta,
 		Status:      domain.Status(ink.Status),
+		ScheduledAt: ink.ScheduledAt,
 		CreatedAt:   ink.CreatedAt,
 		UpdatedAt:   ink.UpdatedAt,
 	}
@@ -305,6 +305,8 @@ func (repo *CachedLiveInkRepo) domainToEntity(ink domain.Ink) dao.LiveInk {
 		ContentType: ink.ContentType.ToInt(),
 		Tags:        strings.Join(ink.Tags, ","),
 		AiTags:      strings.Join(ink.AiTags, ","),
+		WordCount:   ink.WordCount,
+		ReadingTime: ink.ReadingTime,
 		ContentHtml: ink.ContentHtml,
 		ContentMeta: ink.ContentMeta,
 		Status:      int(ink.Status),
@@ -327,6 +329,8 @@ func (repo *CachedLiveInkRepo) entityToDomain(ink dao.LiveInk) domain.Ink {
 		ContentType: domain.ContentTypeFromInt(ink.ContentType),
 		Tags:        stringx.Split(ink.Tags, ","),
 		AiTags:      stringx.Split(ink.AiTags, ","),
+		WordCount:   ink.WordCount,
+		ReadingTime: ink.ReadingTime,
 		ContentHtml: ink.ContentHtml,
 		ContentMeta: ink.ContentMeta,
 		Status:      domain.Status(ink.Status),
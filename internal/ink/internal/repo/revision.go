@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/KNICEX/InkFlow/internal/ink/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/ink/internal/repo/dao"
+	"github.com/KNICEX/InkFlow/pkg/stringx"
+	"github.com/samber/lo"
+)
+
+var ErrRevisionNotFound = dao.ErrRevisionNotFound
+
+type RevisionRepo interface {
+	Create(ctx context.Context, r domain.Revision) (domain.Revision, error)
+	FindById(ctx context.Context, id int64) (domain.Revision, error)
+	FindByInkId(ctx context.Context, inkId, authorId int64, offset, limit int) ([]domain.Revision, error)
+}
+
+type NoCacheRevisionRepo struct {
+	dao dao.RevisionDAO
+}
+
+func NewNoCacheRevisionRepo(dao dao.RevisionDAO) RevisionRepo {
+	return &NoCacheRevisionRepo{
+		dao: dao,
+	}
+}
+
+func (repo *NoCacheRevisionRepo) Create(ctx context.Context, r domain.Revision) (domain.Revision, error) {
+	entity, err := repo.dao.Insert(ctx, repo.toEntity(r))
+	if err != nil {
+		return domain.Revision{}, err
+	}
+	return repo.toDomain(entity), nil
+}
+
+func (repo *NoCacheRevisionRepo) FindById(ctx context.Context, id int64) (domain.Revision, error) {
+	r, err := repo.dao.FindById(ctx, id)
+	if err != nil {
+		return domain.Revision{}, err
+	}
+	return repo.toDomain(r), nil
+}
+
+func (repo *NoCacheRevisionRepo) FindByInkId(ctx context.Context, inkId, authorId int64, offset, limit int) ([]domain.Revision, error) {
+	rs, err := repo.dao.FindByInkId(ctx, inkId, authorId, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(rs, func(item dao.InkRevision, index int) domain.Revision {
+		return repo.toDomain(item)
+	}), nil
+}
+
+func (repo *NoCacheRevisionRepo) toEntity(r domain.Revision) dao.InkRevision {
+	return dao.InkRevision{
+		Id:          r.Id,
+		InkId:       r.InkId,
+		Version:     r.Version,
+		AuthorId:    r.AuthorId,
+		Title:       r.Title,
+		Cover:       r.Cover,
+		Summary:     r.Summary,
+		ContentMeta: r.ContentMeta,
+		ContentHtml: r.ContentHtml,
+		Tags:        strings.Join(r.Tags, ","),
+		AiTags:      strings.Join(r.AiTags, ","),
+		Passed:      r.Review.Passed,
+		Reason:      r.Review.Reason,
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+func (repo *NoCacheRevisionRepo) toDomain(r dao.InkRevision) domain.Revision {
+	return domain.Revision{
+		Id:          r.Id,
+		Version:     r.Version,
+		InkId:       r.InkId,
+		AuthorId:    r.AuthorId,
+		Title:       r.Title,
+		Cover:       r.Cover,
+		Summary:     r.Summary,
+		ContentHtml: r.ContentHtml,
+		ContentMeta: r.ContentMeta,
+		Tags:        stringx.Split(r.Tags, ","),
+		AiTags:      stringx.Split(r.AiTags, ","),
+		Review: domain.ReviewResult{
+			Passed: r.Passed,
+			Reason: r.Reason,
+		},
+		CreatedAt: r.CreatedAt,
+	}
+}
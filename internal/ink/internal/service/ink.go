@@ -6,13 +6,17 @@ import (
 	"errors"
 	"github.com/KNICEX/InkFlow/internal/ink/internal/domain"
 	"github.com/KNICEX/InkFlow/internal/ink/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/htmlx"
 	"github.com/KNICEX/InkFlow/pkg/logx"
 	"github.com/yuin/goldmark"
+	"time"
 )
 
 var (
 	ErrNotFound     = errors.New("ink not found")
 	ErrNoPermission = errors.New("no permission")
+	// ErrInvalidSchedule 定时发布时间早于当前时间
+	ErrInvalidSchedule = errors.New("invalid schedule time")
 )
 
 // InkService
@@ -20,7 +24,11 @@ var (
 // 直接发布文章，前端先调用Save保存草稿，然后再调用Publish发布
 type InkService interface {
 	Save(ctx context.Context, ink domain.Ink) (int64, error) // 保存草稿
-	Publish(ctx context.Context, ink domain.Ink) error       // 发布
+	Publish(ctx context.Context, ink domain.Ink) error       // 发布, 设置了 ScheduledAt 时审核通过后定时发布
+
+	Schedule(ctx context.Context, id, authorId int64, publishAt time.Time) error   // 审核通过, 等待定时发布
+	CancelSchedule(ctx context.Context, id, authorId int64) error                  // 取消定时发布, 回到草稿
+	Reschedule(ctx context.Context, id, authorId int64, publishAt time.Time) error // 修改审核中或等待定时发布的 ink 的发布时间
 
 	UpdateLiveStatus(ctx context.Context, id int64, authorId int64, status domain.Status) error // 更新文章状态
 	UpdateDraftStatus(ctx context.Context, id int64, authorId int64, status domain.Status) error
@@ -34,6 +42,7 @@ type InkService interface {
 	FindLiveInk(ctx context.Context, id int64) (domain.Ink, error)            // 获取公开ink
 	FindDraftInk(ctx context.Context, id, authorId int64) (domain.Ink, error) // 获取草稿ink
 	FindPendingInk(ctx context.Context, id, authorId int64) (domain.Ink, error)
+	FindScheduledInk(ctx context.Context, id, authorId int64) (domain.Ink, error)
 	FindPrivateInk(ctx context.Context, id, authorId int64) (domain.Ink, error)  // 获取私有ink
 	FindRejectedInk(ctx context.Context, id, authorId int64) (domain.Ink, error) // 获取审核拒绝的ink
 	ListLiveByAuthorId(ctx context.Context, authorId int64, offset int, limit int) ([]domain.Ink, error)
@@ -41,6 +50,7 @@ type InkService interface {
 	ListReviewRejectedByAuthorId(ctx context.Context, authorId int64, offset int, limit int) ([]domain.Ink, error)
 	ListDraftByAuthorId(ctx context.Context, authorId int64, offset, limit int) ([]domain.Ink, error)
 	ListPrivateByAuthorId(ctx context.Context, authorId int64, offset, limit int) ([]domain.Ink, error)
+	ListScheduledByAuthorId(ctx context.Context, authorId int64, offset, limit int) ([]domain.Ink, error)
 
 	FirstPageByAuthorIds(ctx context.Context, authorIds []int64, n int) (map[int64][]domain.Ink, error)
 
@@ -59,6 +69,7 @@ func NewInkService(liveRepo repo.LiveInkRepo, draftRepo repo.DraftInkRepo, l log
 	return &inkService{
 		liveRepo:  liveRepo,
 		draftRepo: draftRepo,
+		l:         l,
 	}
 }
 
@@ -71,11 +82,23 @@ func (svc *inkService) Save(ctx context.Context, ink domain.Ink) (int64, error)
 	if err := goldmark.Convert([]byte(ink.ContentMeta), &buf); err != nil {
 		return 0, err
 	}
-	ink.ContentHtml = buf.String()
+	ink.ContentHtml = htmlx.Sanitize(buf.String())
 
 	if ink.Id == 0 {
 		return svc.draftRepo.Create(ctx, ink)
 	}
+	// 审核中或等待定时发布时草稿已绑定到发布流程, 不能被覆盖回未发布
+	draft, err := svc.draftRepo.FindByIdAndAuthorId(ctx, ink.Id, ink.Author.Id)
+	if err != nil {
+		return 0, svc.wrapNotFoundErr(err)
+	}
+	switch draft.Status {
+	case domain.InkStatusPending:
+		return 0, ErrInkUnderReview
+	case domain.InkStatusScheduled:
+		return 0, ErrInkScheduled
+	default:
+	}
 	return ink.Id, svc.draftRepo.Update(ctx, ink)
 }
 
@@ -86,22 +109,74 @@ func (svc *inkService) Publish(ctx context.Context, ink domain.Ink) error {
 	if err != nil {
 		return err
 	}
-	if draft.Status != domain.InkStatusUnPublished {
+	switch draft.Status {
+	case domain.InkStatusPending:
+		return ErrInkUnderReview
+	case domain.InkStatusScheduled:
+		return ErrInkScheduled
+	case domain.InkStatusUnPublished:
+	default:
 		svc.l.WithCtx(ctx).Error("InkService Publish  draft status error",
 			logx.Error(err), logx.Int64("inkId", ink.Id), logx.Any("status", draft.Status))
 		return errors.New("invalid status")
 	}
+	// 兼容未经过滤保存的旧草稿
+	if content := htmlx.Sanitize(draft.ContentHtml); content != draft.ContentHtml {
+		draft.ContentHtml = content
+		if err = svc.draftRepo.Update(ctx, draft); err != nil {
+			return err
+		}
+	}
 
-	// 修改草稿的状态为待审核
-	return svc.draftRepo.UpdateStatus(ctx, domain.Ink{
+	if !ink.ScheduledAt.IsZero() && ink.ScheduledAt.Before(time.Now()) {
+		return ErrInvalidSchedule
+	}
+
+	// 修改草稿的状态为待审核, 同时覆盖上一次的定时设置
+	return svc.draftRepo.UpdateSchedule(ctx, domain.Ink{
 		Id: draft.Id,
 		Author: domain.Author{
 			Id: draft.Author.Id,
 		},
-		Status: domain.InkStatusPending,
+		Status:      domain.InkStatusPending,
+		ScheduledAt: ink.ScheduledAt,
+	})
+}
+
+func (svc *inkService) Schedule(ctx context.Context, id, authorId int64, publishAt time.Time) error {
+	return svc.draftRepo.UpdateSchedule(ctx, domain.Ink{
+		Id: id,
+		Author: domain.Author{
+			Id: authorId,
+		},
+		Status:      domain.InkStatusScheduled,
+		ScheduledAt: publishAt,
 	})
 }
 
+func (svc *inkService) CancelSchedule(ctx context.Context, id, authorId int64) error {
+	return svc.draftRepo.UpdateSchedule(ctx, domain.Ink{
+		Id: id,
+		Author: domain.Author{
+			Id: authorId,
+		},
+		Status: domain.InkStatusUnPublished,
+	})
+}
+
+func (svc *inkService) Reschedule(ctx context.Context, id, authorId int64, publishAt time.Time) error {
+	if publishAt.Before(time.Now()) {
+		return ErrInvalidSchedule
+	}
+	return svc.draftRepo.UpdateScheduledAt(ctx, domain.Ink{
+		Id: id,
+		Author: domain.Author{
+			Id: authorId,
+		},
+		ScheduledAt: publishAt,
+	}, domain.InkStatusPending, domain.InkStatusScheduled)
+}
+
 // UpdateInkStatus
 // 此方法使用权不应该交给用户，仅内部服务可以调用
 func (svc *inkService) UpdateLiveStatus(ctx context.Context, id int64, authorId int64, status domain.Status) error {
@@ -125,6 +200,7 @@ func (svc *inkService) UpdateDraftStatus(ctx context.Context, id int64, authorId
 }
 
 func (svc *inkService) SyncToLive(ctx context.Context, ink domain.Ink) error {
+	ink.ContentHtml = htmlx.Sanitize(ink.ContentHtml)
 	_, err := svc.liveRepo.Save(ctx, ink)
 	return err
 }
@@ -210,9 +286,20 @@ func (svc *inkService) FindPendingInk(ctx context.Context, id, authorId int64) (
 	}
 	return ink, nil
 }
+func (svc *inkService) FindScheduledInk(ctx context.Context, id, authorId int64) (domain.Ink, error) {
+	ink, err := svc.draftRepo.FindByIdAndAuthorId(ctx, id, authorId, domain.InkStatusPending, domain.InkStatusScheduled)
+	if err != nil {
+		return domain.Ink{}, svc.wrapNotFoundErr(err)
+	}
+	if ink.ScheduledAt.IsZero() {
+		return domain.Ink{}, ErrNotFound
+	}
+	return ink, nil
+}
+
 func (svc *inkService) FindRejectedInk(ctx context.Context, id, authorId int64) (domain.Ink, error) {
-	//return svc.draftRepo.FindByIdAndAuthorId(ctx, id, authorId, domain.InkStatusRejected)
-	ink, err := svc.liveRepo.FindById(ctx, id, domain.InkStatusRejected)
+	// 审核拒绝后只会更新草稿状态, 不会同步到线上库
+	ink, err := svc.draftRepo.FindByIdAndAuthorId(ctx, id, authorId, domain.InkStatusRejected)
 	if err != nil {
 		return domain.Ink{}, svc.wrapNotFoundErr(err)
 	}
@@ -238,6 +325,10 @@ func (svc *inkService) ListPendingByAuthorId(ctx context.Context, authorId int64
 	return svc.draftRepo.FindByAuthorId(ctx, authorId, offset, limit, domain.InkStatusPending)
 }
 
+func (svc *inkService) ListScheduledByAuthorId(ctx context.Context, authorId int64, offset, limit int) ([]domain.Ink, error) {
+	return svc.draftRepo.FindScheduledByAuthorId(ctx, authorId, offset, limit)
+}
+
 func (svc *inkService) ListReviewRejectedByAuthorId(ctx context.Context, authorId int64, offset, limit int) ([]domain.Ink, error) {
 	return svc.draftRepo.FindByAuthorId(ctx, authorId, offset, limit, domain.InkStatusRejected)
 }
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/ink/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/ink/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/diffx"
+	"github.com/samber/lo"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrInkUnderReview   = errors.New("ink under review")
+	// ErrInkScheduled 等待定时发布的 ink 需要先取消定时才能修改
+	ErrInkScheduled = errors.New("ink scheduled")
+)
+
+type RevisionService interface {
+	// Create 保存一次发布的版本, 版本号自动递增
+	Create(ctx context.Context, r domain.Revision) (domain.Revision, error)
+	List(ctx context.Context, inkId, authorId int64, offset, limit int) ([]domain.Revision, error)
+	FindById(ctx context.Context, id, authorId int64) (domain.Revision, error)
+	// Diff 比较同一篇 ink 的两个版本, toId 为 0 时与当前草稿比较
+	Diff(ctx context.Context, authorId, fromId, toId int64) (domain.RevisionDiff, error)
+	// Rollback 用历史版本覆盖草稿并置为待审核, 调用方需要重新发起发布流程.
+	// 审核中或等待定时发布的 ink 已有发布流程在运行, 不能回滚
+	Rollback(ctx context.Context, id, authorId int64) (domain.Ink, error)
+}
+
+type revisionService struct {
+	repo      repo.RevisionRepo
+	draftRepo repo.DraftInkRepo
+}
+
+func NewRevisionService(repo repo.RevisionRepo, draftRepo repo.DraftInkRepo) RevisionService {
+	return &revisionService{
+		repo:      repo,
+		draftRepo: draftRepo,
+	}
+}
+
+func (svc *revisionService) Create(ctx context.Context, r domain.Revision) (domain.Revision, error) {
+	return svc.repo.Create(ctx, r)
+}
+
+func (svc *revisionService) List(ctx context.Context, inkId, authorId int64, offset, limit int) ([]domain.Revision, error) {
+	return svc.repo.FindByInkId(ctx, inkId, authorId, offset, limit)
+}
+
+func (svc *revisionService) FindById(ctx context.Context, id, authorId int64) (domain.Revision, error) {
+	r, err := svc.repo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repo.ErrRevisionNotFound) {
+			return domain.Revision{}, ErrRevisionNotFound
+		}
+		return domain.Revision{}, err
+	}
+	if r.AuthorId != authorId {
+		return domain.Revision{}, ErrNoPermission
+	}
+	return r, nil
+}
+
+func (svc *revisionService) Diff(ctx context.Context, authorId, fromId, toId int64) (domain.RevisionDiff, error) {
+	from, err := svc.FindById(ctx, fromId, authorId)
+	if err != nil {
+		return domain.RevisionDiff{}, err
+	}
+	var to domain.Revision
+	if toId == 0 {
+		draft, er := svc.draftRepo.FindByIdAndAuthorId(ctx, from.InkId, authorId)
+		if er != nil {
+			return domain.RevisionDiff{}, er
+		}
+		to = domain.Revision{
+			Title:       draft.Title,
+			ContentMeta: draft.ContentMeta,
+			Tags:        draft.Tags,
+		}
+	} else {
+		to, err = svc.FindById(ctx, toId, authorId)
+		if err != nil {
+			return domain.RevisionDiff{}, err
+		}
+		if to.InkId != from.InkId {
+			return domain.RevisionDiff{}, ErrRevisionNotFound
+		}
+	}
+
+	added, removed := lo.Difference(to.Tags, from.Tags)
+	title, err := diffx.Strings([]string{from.Title}, []string{to.Title})
+	if err != nil {
+		return domain.RevisionDiff{}, err
+	}
+	res := domain.RevisionDiff{
+		From:        fromId,
+		To:          toId,
+		Title:       title,
+		AddedTags:   added,
+		RemovedTags: removed,
+	}
+	res.Content, err = diffx.Lines(from.ContentMeta, to.ContentMeta)
+	if errors.Is(err, diffx.ErrTooLarge) {
+		// 改动太大时不返回逐行差异, 只告知调用方
+		res.ContentTooLarge = true
+		return res, nil
+	}
+	return res, err
+}
+
+func (svc *revisionService) Rollback(ctx context.Context, id, authorId int64) (domain.Ink, error) {
+	r, err := svc.FindById(ctx, id, authorId)
+	if err != nil {
+		return domain.Ink{}, err
+	}
+	draft, err := svc.draftRepo.FindByIdAndAuthorId(ctx, r.InkId, authorId)
+	if err != nil {
+		return domain.Ink{}, err
+	}
+	switch draft.Status {
+	case domain.InkStatusPending:
+		return domain.Ink{}, ErrInkUnderReview
+	case domain.InkStatusScheduled:
+		return domain.Ink{}, ErrInkScheduled
+	}
+
+	draft.Title = r.Title
+	draft.Cover = r.Cover
+	draft.Summary = r.Summary
+	draft.ContentHtml = r.ContentHtml
+	draft.ContentMeta = r.ContentMeta
+	draft.Tags = r.Tags
+	draft.AiTags = r.AiTags
+	// 回滚同样需要重新审核, 并且立即发布. 条件更新避免与并发的发布流程冲突
+	err = svc.draftRepo.Restore(ctx, draft, domain.InkStatusUnPublished, domain.InkStatusRejected,
+		domain.InkStatusPublished, domain.InkStatusPrivate)
+	if errors.Is(err, repo.ErrDraftNotFound) {
+		return domain.Ink{}, ErrInkUnderReview
+	}
+	if err != nil {
+		return domain.Ink{}, err
+	}
+	draft.Status = domain.InkStatusPending
+	draft.ScheduledAt = time.Time{}
+	return draft, nil
+}
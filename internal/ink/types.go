@@ -11,14 +11,27 @@ type Category = domain.Category
 
 type Ink = domain.Ink
 type TagStats = domain.TagStats
+type Revision = domain.Revision
+type RevisionDiff = domain.RevisionDiff
+type ReviewResult = domain.ReviewResult
 
 type Service = service.InkService
 type RankingService = service.RankingService
+type RevisionService = service.RevisionService
 
-var ErrNoPermission = service.ErrNoPermission
+var (
+	ErrNoPermission     = service.ErrNoPermission
+	ErrRevisionNotFound = service.ErrRevisionNotFound
+	ErrInkUnderReview   = service.ErrInkUnderReview
+	ErrInkScheduled     = service.ErrInkScheduled
+	ErrNotFound         = service.ErrNotFound
+	ErrInvalidSchedule  = service.ErrInvalidSchedule
+)
 
 const (
-	StatusPending   = domain.InkStatusPending
-	StatusPublished = domain.InkStatusPublished
-	StatusRejected  = domain.InkStatusRejected
+	StatusUnPublished = domain.InkStatusUnPublished
+	StatusPending     = domain.InkStatusPending
+	StatusPublished   = domain.InkStatusPublished
+	StatusRejected    = domain.InkStatusRejected
+	StatusScheduled   = domain.InkStatusScheduled
 )
@@ -49,6 +49,18 @@ func InitInkService(cmd redis.Cmdable, db *gorm.DB, l logx.Logger) Service {
 	return nil
 }
 
+func InitRevisionService(db *gorm.DB) RevisionService {
+	wire.Build(
+		initSnowflakeNode,
+		initDraftDAO,
+		dao.NewGormRevisionDAO,
+		repo.NewNoCacheDraftInkRepo,
+		repo.NewNoCacheRevisionRepo,
+		service.NewRevisionService,
+	)
+	return nil
+}
+
 func InitRankingService(cmd redis.Cmdable, db *gorm.DB, l logx.Logger, intrSvc interactive.Service) service.RankingService {
 	wire.Build(
 		initLiveRepo,
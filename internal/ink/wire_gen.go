@@ -29,6 +29,16 @@ func InitInkService(cmd redis.Cmdable, db *gorm.DB, l logx.Logger) service.InkSe
 	return inkService
 }
 
+func InitRevisionService(db *gorm.DB) service.RevisionService {
+	node := initSnowflakeNode()
+	revisionDAO := dao.NewGormRevisionDAO(db, node)
+	revisionRepo := repo.NewNoCacheRevisionRepo(revisionDAO)
+	draftDAO := initDraftDAO(db, node)
+	draftInkRepo := repo.NewNoCacheDraftInkRepo(draftDAO)
+	revisionService := service.NewRevisionService(revisionRepo, draftInkRepo)
+	return revisionService
+}
+
 func InitRankingService(cmd redis.Cmdable, db *gorm.DB, l logx.Logger, intrSvc interactive.Service) service.RankingService {
 	liveInkRepo := initLiveRepo(db, cmd, l)
 	rankingCache := cache.NewRedisRankingCache(cmd, l)
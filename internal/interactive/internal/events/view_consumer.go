@@ -17,13 +17,15 @@ const (
 type InkViewConsumer struct {
 	client sarama.Client
 	repo   repo.InteractiveRepo
+	retry  *saramax.RetryHandler
 	l      logx.Logger
 }
 
-func NewInkViewConsumer(client sarama.Client, repo repo.InteractiveRepo, l logx.Logger) *InkViewConsumer {
+func NewInkViewConsumer(client sarama.Client, repo repo.InteractiveRepo, retry *saramax.RetryHandler, l logx.Logger) *InkViewConsumer {
 	return &InkViewConsumer{
 		client: client,
 		repo:   repo,
+		retry:  retry.WithGroup(inkViewConsumerGroup),
 		l:      l,
 	}
 }
@@ -46,7 +48,8 @@ func (c *InkViewConsumer) Start() error {
 	}
 	go func() {
 		er := cg.Consume(context.Background(),
-			[]string{topicInkView}, saramax.NewBatchHandler(c.l, c))
+			saramax.WithRetryTopics(topicInkView),
+			saramax.NewBatchHandler(c.l, c, saramax.WithHandlerOptions[InkViewEvent](saramax.WithRetryHandler(c.retry))))
 		if er != nil {
 			c.l.Warn("consume quited", logx.Error(er))
 		}
@@ -10,6 +10,7 @@ import (
 	"github.com/KNICEX/InkFlow/internal/interactive/internal/repo/dao"
 	"github.com/KNICEX/InkFlow/internal/interactive/internal/service"
 	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
 	"github.com/KNICEX/InkFlow/pkg/snowflakex"
 	"github.com/google/wire"
 	"github.com/redis/go-redis/v9"
@@ -55,6 +56,6 @@ func InitInteractiveService(cmd redis.Cmdable, p sarama.SyncProducer, db *gorm.D
 	return nil
 }
 
-func InitInteractiveInkReadConsumer(client sarama.Client, l logx.Logger) *events.InkViewConsumer {
-	return events.NewInkViewConsumer(client, r, l)
+func InitInteractiveInkReadConsumer(client sarama.Client, retry *saramax.RetryHandler, l logx.Logger) *events.InkViewConsumer {
+	return events.NewInkViewConsumer(client, r, retry, l)
 }
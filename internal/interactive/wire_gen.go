@@ -14,6 +14,7 @@ import (
 	"github.com/KNICEX/InkFlow/internal/interactive/internal/repo/dao"
 	"github.com/KNICEX/InkFlow/internal/interactive/internal/service"
 	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
 	"github.com/KNICEX/InkFlow/pkg/snowflakex"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
@@ -61,6 +62,6 @@ func initProducer(p sarama.SyncProducer) events.InteractiveProducer {
 	return events.NewKafkaInteractiveProducer(p)
 }
 
-func InitInteractiveInkReadConsumer(client sarama.Client, l logx.Logger) *events.InkViewConsumer {
-	return events.NewInkViewConsumer(client, r, l)
+func InitInteractiveInkReadConsumer(client sarama.Client, retry *saramax.RetryHandler, l logx.Logger) *events.InkViewConsumer {
+	return events.NewInkViewConsumer(client, r, retry, l)
 }
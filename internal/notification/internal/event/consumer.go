@@ -21,14 +21,16 @@ type NotificationConsumer struct {
 	cli      sarama.Client
 	svc      service.NotificationService
 	handlers map[string]Handler
+	retry    *saramax.RetryHandler
 	l        logx.Logger
 }
 
-func NewNotificationConsumer(cli sarama.Client, svc service.NotificationService, l logx.Logger) *NotificationConsumer {
+func NewNotificationConsumer(cli sarama.Client, svc service.NotificationService, retry *saramax.RetryHandler, l logx.Logger) *NotificationConsumer {
 	return &NotificationConsumer{
 		cli:      cli,
 		svc:      svc,
 		handlers: make(map[string]Handler),
+		retry:    retry.WithGroup(notificationGroup),
 		l:        l,
 	}
 }
@@ -50,8 +52,8 @@ func (c *NotificationConsumer) Start() error {
 	}
 	go func() {
 		err = cg.Consume(context.Background(),
-			[]string{topicFollow, topicCommentReply, topicCommentLike, topicInkLike},
-			saramax.NewRawHandler(c.l, c))
+			saramax.WithRetryTopics(topicFollow, topicCommentReply, topicCommentLike, topicInkLike),
+			saramax.NewRawHandler(c.l, c, saramax.WithRetryHandler(c.retry)))
 		if err != nil {
 			c.l.Warn("notification consumer quit...", logx.Error(err))
 		}
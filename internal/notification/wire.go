@@ -11,6 +11,7 @@ import (
 	"github.com/KNICEX/InkFlow/internal/notification/internal/repo/dao"
 	"github.com/KNICEX/InkFlow/internal/notification/internal/service"
 	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
 	"github.com/KNICEX/InkFlow/pkg/snowflakex"
 	"github.com/google/wire"
 	"gorm.io/gorm"
@@ -37,8 +38,8 @@ func InitNotificationService(db *gorm.DB) Service {
 	return nil
 }
 
-func InitNotificationConsumer(cli sarama.Client, svc Service, inkSvc ink.Service, commentSvc comment.Service, l logx.Logger) *SyncConsumer {
-	consumer := event.NewNotificationConsumer(cli, svc, l)
+func InitNotificationConsumer(cli sarama.Client, svc Service, inkSvc ink.Service, commentSvc comment.Service, retry *saramax.RetryHandler, l logx.Logger) *SyncConsumer {
+	consumer := event.NewNotificationConsumer(cli, svc, retry, l)
 	userFollowHandler := event.NewFollowHandler(svc)
 	commentReplyHandler := event.NewReplyHandler(svc, commentSvc, inkSvc)
 	commentLikeHandler := event.NewCommentLikeHandler(svc, commentSvc)
@@ -15,6 +15,7 @@ import (
 	"github.com/KNICEX/InkFlow/internal/notification/internal/repo/dao"
 	"github.com/KNICEX/InkFlow/internal/notification/internal/service"
 	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
 	"github.com/KNICEX/InkFlow/pkg/snowflakex"
 	"gorm.io/gorm"
 )
@@ -42,8 +43,8 @@ func initDAO(db *gorm.DB, node snowflakex.Node) dao.NotificationDAO {
 	return dao.NewGormNotificationDAO(db, node)
 }
 
-func InitNotificationConsumer(cli sarama.Client, svc Service, inkSvc ink.Service, commentSvc comment.Service, l logx.Logger) *event.NotificationConsumer {
-	consumer := event.NewNotificationConsumer(cli, svc, l)
+func InitNotificationConsumer(cli sarama.Client, svc Service, inkSvc ink.Service, commentSvc comment.Service, retry *saramax.RetryHandler, l logx.Logger) *event.NotificationConsumer {
+	consumer := event.NewNotificationConsumer(cli, svc, retry, l)
 	userFollowHandler := event.NewFollowHandler(svc)
 	commentReplyHandler := event.NewReplyHandler(svc, commentSvc, inkSvc)
 	commentLikeHandler := event.NewCommentLikeHandler(svc, commentSvc)
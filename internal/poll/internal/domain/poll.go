@@ -8,11 +8,39 @@ type Poll struct {
 	BizType   string
 	BizId     int64
 	Title     string
+	// 最多可选择的选项数, 1为单选
+	MaxChoices int
+	// 截止时间, 零值表示不截止
+	EndAt     time.Time
 	Options   []PollOption
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
+// Closed 投票是否已经截止
+func (p Poll) Closed(now time.Time) bool {
+	return !p.EndAt.IsZero() && !now.Before(p.EndAt)
+}
+
+// Polled 当前查看用户是否已经投过票
+func (p Poll) Polled() bool {
+	for _, opt := range p.Options {
+		if opt.Polled {
+			return true
+		}
+	}
+	return false
+}
+
+// TotalCount 总投票数(多选时一人会被计多次)
+func (p Poll) TotalCount() int64 {
+	var total int64
+	for _, opt := range p.Options {
+		total += opt.Count
+	}
+	return total
+}
+
 type PollOption struct {
 	Id        int64
 	Title     string
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/KNICEX/InkFlow/internal/poll/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+var (
+	ErrKeyNotFound = redis.Nil
+)
+
+type PollCache interface {
+	// GetOptions 获取投票的选项和各选项的计数
+	GetOptions(ctx context.Context, pollId int64) ([]domain.PollOption, error)
+	// SetOptions 仅在选项未缓存时回填, 不会覆盖已有的计数
+	SetOptions(ctx context.Context, pollId int64, options []domain.PollOption) error
+	DelOptions(ctx context.Context, pollId int64) error
+}
+
+type RedisPollCache struct {
+	cmd        redis.Cmdable
+	expiration time.Duration
+}
+
+func NewRedisPollCache(cmd redis.Cmdable) PollCache {
+	return &RedisPollCache{
+		cmd:        cmd,
+		expiration: time.Minute * 10,
+	}
+}
+
+func (cache *RedisPollCache) optionsKey(pollId int64) string {
+	return fmt.Sprintf("poll:options:%d", pollId)
+}
+
+func (cache *RedisPollCache) GetOptions(ctx context.Context, pollId int64) ([]domain.PollOption, error) {
+	val, err := cache.cmd.Get(ctx, cache.optionsKey(pollId)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var options []domain.PollOption
+	err = json.Unmarshal(val, &options)
+	return options, err
+}
+
+func (cache *RedisPollCache) SetOptions(ctx context.Context, pollId int64, options []domain.PollOption) error {
+	if len(options) == 0 {
+		return nil
+	}
+	val, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	// 已经被其他请求回填时不覆盖
+	return cache.cmd.SetNX(ctx, cache.optionsKey(pollId), val, cache.expiration).Err()
+}
+
+func (cache *RedisPollCache) DelOptions(ctx context.Context, pollId int64) error {
+	return cache.cmd.Del(ctx, cache.optionsKey(pollId)).Err()
+}
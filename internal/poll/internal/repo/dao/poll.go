@@ -1,21 +1,38 @@
 package dao
 
-import "time"
+import (
+	"context"
+	"errors"
+	"github.com/KNICEX/InkFlow/pkg/gormx"
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var (
+	ErrRecordNotFound = gorm.ErrRecordNotFound
+	ErrPollExists     = errors.New("poll already exists")
+	ErrAlreadyPolled  = errors.New("already polled")
+	ErrInvalidOption  = errors.New("invalid poll option")
+)
 
 type Poll struct {
-	Id        int64
-	CreatorId int64
-	BizId     int64
-	BizType   string
-	Title     string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	Id         int64
+	CreatorId  int64  `gorm:"index"`
+	BizId      int64  `gorm:"uniqueIndex:biz_type_id"`
+	BizType    string `gorm:"type:varchar(32);uniqueIndex:biz_type_id"`
+	Title      string `gorm:"type:varchar(256)"`
+	MaxChoices int
+	EndAt      time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
 }
 
 type PollOption struct {
 	Id        int64
-	Title     string
-	PollId    int64
+	Title     string `gorm:"type:varchar(256)"`
+	PollId    int64  `gorm:"index"`
 	Count     int64
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -23,9 +40,134 @@ type PollOption struct {
 
 type PollRecord struct {
 	Id        int64
-	PollId    int64
-	OptionId  int64
-	UserId    int64
+	PollId    int64 `gorm:"uniqueIndex:poll_user_option"`
+	UserId    int64 `gorm:"uniqueIndex:poll_user_option"`
+	OptionId  int64 `gorm:"uniqueIndex:poll_user_option"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+type PollDAO interface {
+	Insert(ctx context.Context, p Poll, options []PollOption) (int64, error)
+	FindById(ctx context.Context, id int64) (Poll, error)
+	FindByBiz(ctx context.Context, bizType string, bizId int64) (Poll, error)
+	FindOptions(ctx context.Context, pollId int64) ([]PollOption, error)
+	FindUserRecords(ctx context.Context, pollId, uid int64) ([]PollRecord, error)
+	// InsertRecords 记录用户的投票并增加选项计数, 用户已经投过票时返回 ErrAlreadyPolled
+	InsertRecords(ctx context.Context, pollId, uid int64, optionIds []int64) error
+}
+
+type GormPollDAO struct {
+	db   *gorm.DB
+	node snowflakex.Node
+}
+
+func NewGormPollDAO(db *gorm.DB, node snowflakex.Node) PollDAO {
+	return &GormPollDAO{
+		db:   db,
+		node: node,
+	}
+}
+
+func (dao *GormPollDAO) Insert(ctx context.Context, p Poll, options []PollOption) (int64, error) {
+	now := time.Now()
+	p.Id = dao.node.NextID()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+	for i := range options {
+		options[i].Id = dao.node.NextID()
+		options[i].PollId = p.Id
+		options[i].Count = 0
+		options[i].CreatedAt = now
+		options[i].UpdatedAt = now
+	}
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&p).Error; err != nil {
+			return err
+		}
+		return tx.Create(&options).Error
+	})
+	if err, dup := gormx.CheckDuplicateErr(err); dup {
+		return 0, ErrPollExists
+	} else if err != nil {
+		return 0, err
+	}
+	return p.Id, nil
+}
+
+func (dao *GormPollDAO) FindById(ctx context.Context, id int64) (Poll, error) {
+	var p Poll
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&p).Error
+	return p, err
+}
+
+func (dao *GormPollDAO) FindByBiz(ctx context.Context, bizType string, bizId int64) (Poll, error) {
+	var p Poll
+	err := dao.db.WithContext(ctx).Where("biz_type = ? AND biz_id = ?", bizType, bizId).First(&p).Error
+	return p, err
+}
+
+func (dao *GormPollDAO) FindOptions(ctx context.Context, pollId int64) ([]PollOption, error) {
+	var options []PollOption
+	err := dao.db.WithContext(ctx).Where("poll_id = ?", pollId).Order("id ASC").Find(&options).Error
+	return options, err
+}
+
+func (dao *GormPollDAO) FindUserRecords(ctx context.Context, pollId, uid int64) ([]PollRecord, error) {
+	var records []PollRecord
+	err := dao.db.WithContext(ctx).Where("poll_id = ? AND user_id = ?", pollId, uid).Find(&records).Error
+	return records, err
+}
+
+func (dao *GormPollDAO) InsertRecords(ctx context.Context, pollId, uid int64, optionIds []int64) error {
+	now := time.Now()
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁住投票行, 保证同一用户并发投不同选项时只有一次能成功
+		var p Poll
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", pollId).First(&p).Error; err != nil {
+			return err
+		}
+		var polled int64
+		if err := tx.Model(&PollRecord{}).Where("poll_id = ? AND user_id = ?", pollId, uid).Count(&polled).Error; err != nil {
+			return err
+		}
+		if polled > 0 {
+			return ErrAlreadyPolled
+		}
+
+		records := make([]PollRecord, 0, len(optionIds))
+		for _, oid := range optionIds {
+			records = append(records, PollRecord{
+				Id:        dao.node.NextID(),
+				PollId:    pollId,
+				UserId:    uid,
+				OptionId:  oid,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+		}
+		if err := tx.Create(&records).Error; err != nil {
+			return err
+		}
+
+		res := tx.Model(&PollOption{}).Where("poll_id = ? AND id IN ?", pollId, optionIds).
+			UpdateColumns(map[string]any{
+				"count":      gorm.Expr("count + 1"),
+				"updated_at": now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != int64(len(optionIds)) {
+			// 选项不属于该投票, 回滚
+			return ErrInvalidOption
+		}
+		return nil
+	})
+	if err, dup := gormx.CheckDuplicateErr(err); dup {
+		return ErrAlreadyPolled
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/KNICEX/InkFlow/internal/poll/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/poll/internal/repo/cache"
+	"github.com/KNICEX/InkFlow/internal/poll/internal/repo/dao"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/samber/lo"
+)
+
+var (
+	ErrPollNotFound  = dao.ErrRecordNotFound
+	ErrPollExists    = dao.ErrPollExists
+	ErrAlreadyPolled = dao.ErrAlreadyPolled
+	ErrInvalidOption = dao.ErrInvalidOption
+)
+
+type PollRepo interface {
+	Create(ctx context.Context, p domain.Poll) (int64, error)
+	FindById(ctx context.Context, id int64) (domain.Poll, error)
+	FindByBiz(ctx context.Context, bizType string, bizId int64) (domain.Poll, error)
+	// FindPolledOptions 查找用户在该投票中选择的选项id
+	FindPolledOptions(ctx context.Context, pollId, uid int64) ([]int64, error)
+	Poll(ctx context.Context, pollId, uid int64, optionIds []int64) error
+}
+
+type CachedPollRepo struct {
+	dao   dao.PollDAO
+	cache cache.PollCache
+	l     logx.Logger
+}
+
+func NewCachedPollRepo(dao dao.PollDAO, cache cache.PollCache, l logx.Logger) PollRepo {
+	return &CachedPollRepo{
+		dao:   dao,
+		cache: cache,
+		l:     l,
+	}
+}
+
+func (repo *CachedPollRepo) Create(ctx context.Context, p domain.Poll) (int64, error) {
+	options := lo.Map(p.Options, func(item domain.PollOption, index int) dao.PollOption {
+		return dao.PollOption{
+			Title: item.Title,
+		}
+	})
+	return repo.dao.Insert(ctx, repo.toEntity(p), options)
+}
+
+func (repo *CachedPollRepo) FindById(ctx context.Context, id int64) (domain.Poll, error) {
+	p, err := repo.dao.FindById(ctx, id)
+	if err != nil {
+		return domain.Poll{}, err
+	}
+	return repo.withOptions(ctx, p)
+}
+
+func (repo *CachedPollRepo) FindByBiz(ctx context.Context, bizType string, bizId int64) (domain.Poll, error) {
+	p, err := repo.dao.FindByBiz(ctx, bizType, bizId)
+	if err != nil {
+		return domain.Poll{}, err
+	}
+	return repo.withOptions(ctx, p)
+}
+
+// withOptions 选项和计数一起缓存, 缓存命中时不需要读库
+func (repo *CachedPollRepo) withOptions(ctx context.Context, p dao.Poll) (domain.Poll, error) {
+	res := repo.toDomain(p)
+	options, err := repo.cache.GetOptions(ctx, p.Id)
+	if err == nil {
+		res.Options = options
+		return res, nil
+	}
+	if !errors.Is(err, cache.ErrKeyNotFound) {
+		repo.l.WithCtx(ctx).Error("get poll options cache error", logx.Error(err), logx.Int64("pollId", p.Id))
+	}
+
+	entities, err := repo.dao.FindOptions(ctx, p.Id)
+	if err != nil {
+		return domain.Poll{}, err
+	}
+	res.Options = lo.Map(entities, func(item dao.PollOption, index int) domain.PollOption {
+		return repo.optionToDomain(item)
+	})
+	// 同步回填缩短读库和写缓存之间的窗口, 投票时会删除缓存,
+	// 剩余的并发窗口内写入的旧值最多保留到缓存过期
+	if er := repo.cache.SetOptions(ctx, p.Id, res.Options); er != nil {
+		repo.l.WithCtx(ctx).Error("set poll options cache error", logx.Error(er), logx.Int64("pollId", p.Id))
+	}
+	return res, nil
+}
+
+func (repo *CachedPollRepo) FindPolledOptions(ctx context.Context, pollId, uid int64) ([]int64, error) {
+	records, err := repo.dao.FindUserRecords(ctx, pollId, uid)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(records, func(item dao.PollRecord, index int) int64 {
+		return item.OptionId
+	}), nil
+}
+
+func (repo *CachedPollRepo) Poll(ctx context.Context, pollId, uid int64, optionIds []int64) error {
+	if err := repo.dao.InsertRecords(ctx, pollId, uid, optionIds); err != nil {
+		return err
+	}
+	// 投票已经写入数据库, 删除缓存失败不影响投票结果
+	if err := repo.cache.DelOptions(ctx, pollId); err != nil {
+		repo.l.WithCtx(ctx).Error("del poll options cache error", logx.Error(err),
+			logx.Int64("pollId", pollId),
+			logx.Int64("uid", uid))
+	}
+	return nil
+}
+
+func (repo *CachedPollRepo) toEntity(p domain.Poll) dao.Poll {
+	return dao.Poll{
+		Id:         p.Id,
+		CreatorId:  p.CreatorId,
+		BizType:    p.BizType,
+		BizId:      p.BizId,
+		Title:      p.Title,
+		MaxChoices: p.MaxChoices,
+		EndAt:      p.EndAt,
+		CreatedAt:  p.CreatedAt,
+		UpdatedAt:  p.UpdatedAt,
+	}
+}
+
+func (repo *CachedPollRepo) toDomain(p dao.Poll) domain.Poll {
+	return domain.Poll{
+		Id:         p.Id,
+		CreatorId:  p.CreatorId,
+		BizType:    p.BizType,
+		BizId:      p.BizId,
+		Title:      p.Title,
+		MaxChoices: p.MaxChoices,
+		EndAt:      p.EndAt,
+		CreatedAt:  p.CreatedAt,
+		UpdatedAt:  p.UpdatedAt,
+	}
+}
+
+func (repo *CachedPollRepo) optionToDomain(o dao.PollOption) domain.PollOption {
+	return domain.PollOption{
+		Id:        o.Id,
+		Title:     o.Title,
+		Count:     o.Count,
+		CreatedAt: o.CreatedAt,
+		UpdatedAt: o.UpdatedAt,
+	}
+}
@@ -0,0 +1,146 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/KNICEX/InkFlow/internal/poll/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/poll/internal/repo/cache"
+	"github.com/KNICEX/InkFlow/internal/poll/internal/repo/dao"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePollDAO struct {
+	dao.PollDAO
+	options []dao.PollOption
+	voteErr error
+	reads   int
+}
+
+func (f *fakePollDAO) FindById(ctx context.Context, id int64) (dao.Poll, error) {
+	return dao.Poll{Id: id, MaxChoices: 1}, nil
+}
+
+func (f *fakePollDAO) FindOptions(ctx context.Context, pollId int64) ([]dao.PollOption, error) {
+	f.reads++
+	return f.options, nil
+}
+
+func (f *fakePollDAO) InsertRecords(ctx context.Context, pollId, uid int64, optionIds []int64) error {
+	if f.voteErr != nil {
+		return f.voteErr
+	}
+	for i, opt := range f.options {
+		for _, oid := range optionIds {
+			if opt.Id == oid {
+				f.options[i].Count++
+			}
+		}
+	}
+	return nil
+}
+
+// fakePollCache 行为与 SetNX 一致, 已缓存时不覆盖
+type fakePollCache struct {
+	options map[int64][]domain.PollOption
+	getErr  error
+}
+
+func (f *fakePollCache) GetOptions(ctx context.Context, pollId int64) ([]domain.PollOption, error) {
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	options, ok := f.options[pollId]
+	if !ok {
+		return nil, cache.ErrKeyNotFound
+	}
+	return options, nil
+}
+
+func (f *fakePollCache) SetOptions(ctx context.Context, pollId int64, options []domain.PollOption) error {
+	if _, ok := f.options[pollId]; !ok {
+		f.options[pollId] = options
+	}
+	return nil
+}
+
+func (f *fakePollCache) DelOptions(ctx context.Context, pollId int64) error {
+	delete(f.options, pollId)
+	return nil
+}
+
+func TestCachedPollRepo_Counts(t *testing.T) {
+	testCases := []struct {
+		name      string
+		cached    map[int64]map[int64]int64
+		getErr    error
+		vote      []int64
+		voteErr   error
+		wantCount map[int64]int64
+		wantReads int
+	}{
+		{
+			name:      "缓存未命中时使用数据库计数",
+			wantCount: map[int64]int64{1: 3, 2: 5},
+			wantReads: 1,
+		},
+		{
+			name:      "缓存命中",
+			cached:    map[int64]map[int64]int64{1: {1: 10, 2: 20}},
+			wantCount: map[int64]int64{1: 10, 2: 20},
+		},
+		{
+			name:      "投票后删除缓存, 重新读取数据库计数",
+			cached:    map[int64]map[int64]int64{1: {1: 3, 2: 5}},
+			vote:      []int64{2},
+			wantCount: map[int64]int64{1: 3, 2: 6},
+			wantReads: 1,
+		},
+		{
+			name:      "投票失败不删除缓存",
+			cached:    map[int64]map[int64]int64{1: {1: 10, 2: 20}},
+			vote:      []int64{2},
+			voteErr:   ErrAlreadyPolled,
+			wantCount: map[int64]int64{1: 10, 2: 20},
+		},
+		{
+			name:      "缓存出错时降级到数据库",
+			getErr:    errors.New("redis down"),
+			wantCount: map[int64]int64{1: 3, 2: 5},
+			wantReads: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &fakePollDAO{
+				options: []dao.PollOption{{Id: 1, PollId: 1, Count: 3}, {Id: 2, PollId: 1, Count: 5}},
+				voteErr: tc.voteErr,
+			}
+			c := &fakePollCache{options: map[int64][]domain.PollOption{}, getErr: tc.getErr}
+			for pid, counts := range tc.cached {
+				for oid, cnt := range counts {
+					c.options[pid] = append(c.options[pid], domain.PollOption{Id: oid, Count: cnt})
+				}
+			}
+			r := NewCachedPollRepo(d, c, logx.NewNopLogger())
+			ctx := context.Background()
+
+			if len(tc.vote) > 0 {
+				err := r.Poll(ctx, 1, 100, tc.vote)
+				assert.ErrorIs(t, err, tc.voteErr)
+			}
+			p, err := r.FindById(ctx, 1)
+			require.NoError(t, err)
+			counts := make(map[int64]int64, len(p.Options))
+			for _, opt := range p.Options {
+				counts[opt.Id] = opt.Count
+			}
+			assert.Equal(t, tc.wantCount, counts)
+			// 缓存命中时不读库
+			assert.Equal(t, tc.wantReads, d.reads)
+		})
+	}
+}
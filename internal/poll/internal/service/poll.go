@@ -2,11 +2,170 @@ package service
 
 import (
 	"context"
+	"errors"
+	"github.com/KNICEX/InkFlow/internal/comment"
+	"github.com/KNICEX/InkFlow/internal/ink"
 	"github.com/KNICEX/InkFlow/internal/poll/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/poll/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/samber/lo"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound       = errors.New("poll not found")
+	ErrNoPermission   = errors.New("no permission")
+	ErrInvalidPoll    = errors.New("invalid poll")
+	ErrPollExists     = repo.ErrPollExists
+	ErrPollClosed     = errors.New("poll closed")
+	ErrAlreadyPolled  = repo.ErrAlreadyPolled
+	ErrInvalidOption  = repo.ErrInvalidOption
+	ErrTooManyChoices = errors.New("too many choices")
+)
+
+const (
+	BizInk     = "ink"
+	BizComment = "comment"
+
+	minOptions = 2
+	maxOptions = 20
 )
 
 type PollService interface {
-	Create(ctx context.Context, vote domain.Poll) (int64, error)
-	FindById(ctx context.Context, id int64) (domain.Poll, error)
-	Poll(ctx context.Context, uid, vid, optionId int64) error
+	Create(ctx context.Context, poll domain.Poll) (int64, error)
+	// FindById 查找投票, uid 为当前查看的用户, 用于填充 Polled
+	FindById(ctx context.Context, id, uid int64) (domain.Poll, error)
+	FindByBiz(ctx context.Context, bizType string, bizId, uid int64) (domain.Poll, error)
+	Poll(ctx context.Context, uid, pid int64, optionIds []int64) error
+}
+
+type pollService struct {
+	repo       repo.PollRepo
+	inkSvc     ink.Service
+	commentSvc comment.Service
+	l          logx.Logger
+}
+
+func NewPollService(repo repo.PollRepo, inkSvc ink.Service, commentSvc comment.Service, l logx.Logger) PollService {
+	return &pollService{
+		repo:       repo,
+		inkSvc:     inkSvc,
+		commentSvc: commentSvc,
+		l:          l,
+	}
+}
+
+func (svc *pollService) Create(ctx context.Context, poll domain.Poll) (int64, error) {
+	poll.Title = strings.TrimSpace(poll.Title)
+	poll.Options = lo.Filter(poll.Options, func(item domain.PollOption, index int) bool {
+		return strings.TrimSpace(item.Title) != ""
+	})
+	if poll.Title == "" || len(poll.Options) < minOptions || len(poll.Options) > maxOptions {
+		return 0, ErrInvalidPoll
+	}
+	if poll.MaxChoices <= 0 {
+		poll.MaxChoices = 1
+	}
+	if poll.MaxChoices > len(poll.Options) {
+		poll.MaxChoices = len(poll.Options)
+	}
+	if !poll.EndAt.IsZero() && poll.EndAt.Before(time.Now()) {
+		return 0, ErrInvalidPoll
+	}
+
+	if err := svc.checkBizOwner(ctx, poll.BizType, poll.BizId, poll.CreatorId); err != nil {
+		return 0, err
+	}
+	return svc.repo.Create(ctx, poll)
+}
+
+// checkBizOwner 只有内容的作者才能给内容添加投票
+func (svc *pollService) checkBizOwner(ctx context.Context, bizType string, bizId, uid int64) error {
+	switch bizType {
+	case BizInk:
+		// 投票在编辑草稿时创建, 草稿能查到即说明是作者本人
+		_, err := svc.inkSvc.FindDraftInk(ctx, bizId, uid)
+		if err != nil {
+			return ErrNoPermission
+		}
+		return nil
+	case BizComment:
+		c, err := svc.commentSvc.FindById(ctx, bizId, uid)
+		if errors.Is(err, comment.ErrNotFound) {
+			// 评论不存在或对当前用户不可见
+			return ErrNoPermission
+		}
+		if err != nil {
+			return err
+		}
+		if c.Commentator.Id != uid {
+			return ErrNoPermission
+		}
+		return nil
+	default:
+		return ErrInvalidPoll
+	}
+}
+
+func (svc *pollService) FindById(ctx context.Context, id, uid int64) (domain.Poll, error) {
+	poll, err := svc.repo.FindById(ctx, id)
+	if err != nil {
+		return domain.Poll{}, svc.wrapNotFoundErr(err)
+	}
+	return svc.fillPolled(ctx, poll, uid)
+}
+
+func (svc *pollService) FindByBiz(ctx context.Context, bizType string, bizId, uid int64) (domain.Poll, error) {
+	poll, err := svc.repo.FindByBiz(ctx, bizType, bizId)
+	if err != nil {
+		return domain.Poll{}, svc.wrapNotFoundErr(err)
+	}
+	return svc.fillPolled(ctx, poll, uid)
+}
+
+func (svc *pollService) fillPolled(ctx context.Context, poll domain.Poll, uid int64) (domain.Poll, error) {
+	if uid == 0 {
+		return poll, nil
+	}
+	optionIds, err := svc.repo.FindPolledOptions(ctx, poll.Id, uid)
+	if err != nil {
+		return domain.Poll{}, err
+	}
+	for i, opt := range poll.Options {
+		poll.Options[i].Polled = lo.Contains(optionIds, opt.Id)
+	}
+	return poll, nil
+}
+
+func (svc *pollService) Poll(ctx context.Context, uid, pid int64, optionIds []int64) error {
+	optionIds = lo.Uniq(optionIds)
+	if len(optionIds) == 0 {
+		return ErrInvalidOption
+	}
+	poll, err := svc.repo.FindById(ctx, pid)
+	if err != nil {
+		return svc.wrapNotFoundErr(err)
+	}
+	if poll.Closed(time.Now()) {
+		return ErrPollClosed
+	}
+	if len(optionIds) > poll.MaxChoices {
+		return ErrTooManyChoices
+	}
+	for _, oid := range optionIds {
+		if !lo.ContainsBy(poll.Options, func(item domain.PollOption) bool {
+			return item.Id == oid
+		}) {
+			return ErrInvalidOption
+		}
+	}
+	return svc.repo.Poll(ctx, pid, uid, optionIds)
+}
+
+func (svc *pollService) wrapNotFoundErr(err error) error {
+	if errors.Is(err, repo.ErrPollNotFound) {
+		return ErrNotFound
+	}
+	return err
 }
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/comment"
+	"github.com/KNICEX/InkFlow/internal/poll/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/poll/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePollRepo struct {
+	repo.PollRepo
+	poll  domain.Poll
+	voted []int64
+}
+
+func (f *fakePollRepo) FindById(ctx context.Context, id int64) (domain.Poll, error) {
+	if id != f.poll.Id {
+		return domain.Poll{}, repo.ErrPollNotFound
+	}
+	return f.poll, nil
+}
+
+func (f *fakePollRepo) Poll(ctx context.Context, pollId, uid int64, optionIds []int64) error {
+	f.voted = optionIds
+	return nil
+}
+
+func TestPollService_Poll(t *testing.T) {
+	options := []domain.PollOption{{Id: 1}, {Id: 2}, {Id: 3}}
+	testCases := []struct {
+		name      string
+		poll      domain.Poll
+		pid       int64
+		optionIds []int64
+		wantErr   error
+		wantVoted []int64
+	}{
+		{
+			name:      "单选",
+			poll:      domain.Poll{Id: 1, MaxChoices: 1, Options: options},
+			pid:       1,
+			optionIds: []int64{2},
+			wantVoted: []int64{2},
+		},
+		{
+			name:      "重复选项去重",
+			poll:      domain.Poll{Id: 1, MaxChoices: 1, Options: options},
+			pid:       1,
+			optionIds: []int64{2, 2},
+			wantVoted: []int64{2},
+		},
+		{
+			name:      "超过最多可选数",
+			poll:      domain.Poll{Id: 1, MaxChoices: 2, Options: options},
+			pid:       1,
+			optionIds: []int64{1, 2, 3},
+			wantErr:   ErrTooManyChoices,
+		},
+		{
+			name:      "选项不属于该投票",
+			poll:      domain.Poll{Id: 1, MaxChoices: 1, Options: options},
+			pid:       1,
+			optionIds: []int64{4},
+			wantErr:   ErrInvalidOption,
+		},
+		{
+			name:    "未选择选项",
+			poll:    domain.Poll{Id: 1, MaxChoices: 1, Options: options},
+			pid:     1,
+			wantErr: ErrInvalidOption,
+		},
+		{
+			name:      "投票已截止",
+			poll:      domain.Poll{Id: 1, MaxChoices: 1, Options: options, EndAt: time.Now().Add(-time.Minute)},
+			pid:       1,
+			optionIds: []int64{1},
+			wantErr:   ErrPollClosed,
+		},
+		{
+			name:      "投票不存在",
+			poll:      domain.Poll{Id: 1, MaxChoices: 1, Options: options},
+			pid:       2,
+			optionIds: []int64{1},
+			wantErr:   ErrNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &fakePollRepo{poll: tc.poll}
+			svc := NewPollService(r, nil, nil, logx.NewNopLogger())
+			err := svc.Poll(context.Background(), 100, tc.pid, tc.optionIds)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantVoted, r.voted)
+		})
+	}
+}
+
+type fakeCommentService struct {
+	comment.Service
+	err error
+}
+
+func (f *fakeCommentService) FindById(ctx context.Context, commentId, uid int64) (comment.Comment, error) {
+	return comment.Comment{}, f.err
+}
+
+func TestPollService_CreateOnMissingComment(t *testing.T) {
+	svc := NewPollService(&fakePollRepo{}, nil, &fakeCommentService{err: comment.ErrNotFound}, logx.NewNopLogger())
+	_, err := svc.Create(context.Background(), domain.Poll{
+		BizType:   BizComment,
+		BizId:     1,
+		CreatorId: 100,
+		Title:     "poll",
+		Options:   []domain.PollOption{{Title: "a"}, {Title: "b"}},
+	})
+	// 评论不存在或不可见时不能返回 500
+	assert.ErrorIs(t, err, ErrNoPermission)
+}
@@ -0,0 +1,27 @@
+package poll
+
+import (
+	"github.com/KNICEX/InkFlow/internal/poll/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/poll/internal/service"
+)
+
+type Poll = domain.Poll
+type Option = domain.PollOption
+
+type Service = service.PollService
+
+const (
+	BizInk     = service.BizInk
+	BizComment = service.BizComment
+)
+
+var (
+	ErrNotFound       = service.ErrNotFound
+	ErrNoPermission   = service.ErrNoPermission
+	ErrInvalidPoll    = service.ErrInvalidPoll
+	ErrPollExists     = service.ErrPollExists
+	ErrPollClosed     = service.ErrPollClosed
+	ErrAlreadyPolled  = service.ErrAlreadyPolled
+	ErrInvalidOption  = service.ErrInvalidOption
+	ErrTooManyChoices = service.ErrTooManyChoices
+)
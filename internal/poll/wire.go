@@ -0,0 +1,39 @@
+//go:build wireinject
+
+package poll
+
+import (
+	"github.com/KNICEX/InkFlow/internal/comment"
+	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/internal/poll/internal/repo"
+	"github.com/KNICEX/InkFlow/internal/poll/internal/repo/cache"
+	"github.com/KNICEX/InkFlow/internal/poll/internal/repo/dao"
+	"github.com/KNICEX/InkFlow/internal/poll/internal/service"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"github.com/google/wire"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+func initSnowflakeNode() snowflakex.Node {
+	return snowflakex.NewNode(snowflakex.DefaultStartTime, 0)
+}
+
+func initDAO(db *gorm.DB, node snowflakex.Node) dao.PollDAO {
+	if err := dao.InitTables(db); err != nil {
+		panic(err)
+	}
+	return dao.NewGormPollDAO(db, node)
+}
+
+func InitPollService(db *gorm.DB, cmd redis.Cmdable, inkSvc ink.Service, commentSvc comment.Service, l logx.Logger) Service {
+	wire.Build(
+		initSnowflakeNode,
+		initDAO,
+		cache.NewRedisPollCache,
+		repo.NewCachedPollRepo,
+		service.NewPollService,
+	)
+	return nil
+}
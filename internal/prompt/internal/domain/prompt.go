@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// BuiltinVersion 代码内置的默认模板, 用途没有参与分配的版本时使用
+const BuiltinVersion = "builtin"
+
+// Prompt 提示词模板的一个版本, 模板使用 text/template 语法.
+// 同一用途下 Weight 大于 0 的版本按权重分配流量, 为 0 时只能指定版本使用, 比如离线评估
+type Prompt struct {
+	Id int64
+	// Purpose 用途, 比如 review.ink
+	Purpose   string
+	Version   string
+	Template  string
+	Weight    int
+	Remark    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Rendered 渲染后的提示词, Version 用于记录结果由哪个版本产生
+type Rendered struct {
+	Purpose string
+	Version string
+	Text    string
+}
@@ -0,0 +1,85 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRecordNotFound = gorm.ErrRecordNotFound
+	ErrDuplicateKey   = gorm.ErrDuplicatedKey
+)
+
+type PromptTemplate struct {
+	Id        int64
+	Purpose   string `gorm:"type:varchar(64);uniqueIndex:purpose_version"`
+	Version   string `gorm:"type:varchar(32);uniqueIndex:purpose_version"`
+	Template  string `gorm:"type:text"`
+	Weight    int
+	Remark    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type PromptDAO interface {
+	Insert(ctx context.Context, p PromptTemplate) (int64, error)
+	UpdateWeight(ctx context.Context, purpose, version string, weight int) error
+	FindAll(ctx context.Context) ([]PromptTemplate, error)
+	// FindByPurpose purpose 为空时查找所有用途
+	FindByPurpose(ctx context.Context, purpose string) ([]PromptTemplate, error)
+}
+
+type GormPromptDAO struct {
+	db   *gorm.DB
+	node snowflakex.Node
+}
+
+func NewGormPromptDAO(db *gorm.DB, node snowflakex.Node) PromptDAO {
+	return &GormPromptDAO{
+		db:   db,
+		node: node,
+	}
+}
+
+func (dao *GormPromptDAO) Insert(ctx context.Context, p PromptTemplate) (int64, error) {
+	now := time.Now()
+	p.Id = dao.node.NextID()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+	return p.Id, dao.db.WithContext(ctx).Create(&p).Error
+}
+
+func (dao *GormPromptDAO) UpdateWeight(ctx context.Context, purpose, version string, weight int) error {
+	res := dao.db.WithContext(ctx).Model(&PromptTemplate{}).
+		Where("purpose = ? AND version = ?", purpose, version).
+		Updates(map[string]any{
+			"weight":     weight,
+			"updated_at": time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (dao *GormPromptDAO) FindAll(ctx context.Context) ([]PromptTemplate, error) {
+	var prompts []PromptTemplate
+	err := dao.db.WithContext(ctx).Find(&prompts).Error
+	return prompts, err
+}
+
+func (dao *GormPromptDAO) FindByPurpose(ctx context.Context, purpose string) ([]PromptTemplate, error) {
+	var prompts []PromptTemplate
+	tx := dao.db.WithContext(ctx)
+	if purpose != "" {
+		tx = tx.Where("purpose = ?", purpose)
+	}
+	err := tx.Order("purpose, id desc").Find(&prompts).Error
+	return prompts, err
+}
@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/KNICEX/InkFlow/internal/prompt/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/prompt/internal/repo/dao"
+	"github.com/samber/lo"
+)
+
+var (
+	ErrPromptNotFound   = dao.ErrRecordNotFound
+	ErrDuplicateVersion = dao.ErrDuplicateKey
+)
+
+type PromptRepo interface {
+	Create(ctx context.Context, p domain.Prompt) (int64, error)
+	UpdateWeight(ctx context.Context, purpose, version string, weight int) error
+	FindAll(ctx context.Context) ([]domain.Prompt, error)
+	FindByPurpose(ctx context.Context, purpose string) ([]domain.Prompt, error)
+}
+
+type promptRepo struct {
+	dao dao.PromptDAO
+}
+
+func NewPromptRepo(dao dao.PromptDAO) PromptRepo {
+	return &promptRepo{
+		dao: dao,
+	}
+}
+
+func (repo *promptRepo) Create(ctx context.Context, p domain.Prompt) (int64, error) {
+	return repo.dao.Insert(ctx, dao.PromptTemplate{
+		Purpose:  p.Purpose,
+		Version:  p.Version,
+		Template: p.Template,
+		Weight:   p.Weight,
+		Remark:   p.Remark,
+	})
+}
+
+func (repo *promptRepo) UpdateWeight(ctx context.Context, purpose, version string, weight int) error {
+	return repo.dao.UpdateWeight(ctx, purpose, version, weight)
+}
+
+func (repo *promptRepo) FindAll(ctx context.Context) ([]domain.Prompt, error) {
+	prompts, err := repo.dao.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(prompts, func(item dao.PromptTemplate, index int) domain.Prompt {
+		return repo.toDomain(item)
+	}), nil
+}
+
+func (repo *promptRepo) FindByPurpose(ctx context.Context, purpose string) ([]domain.Prompt, error) {
+	prompts, err := repo.dao.FindByPurpose(ctx, purpose)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(prompts, func(item dao.PromptTemplate, index int) domain.Prompt {
+		return repo.toDomain(item)
+	}), nil
+}
+
+func (repo *promptRepo) toDomain(p dao.PromptTemplate) domain.Prompt {
+	return domain.Prompt{
+		Id:        p.Id,
+		Purpose:   p.Purpose,
+		Version:   p.Version,
+		Template:  p.Template,
+		Weight:    p.Weight,
+		Remark:    p.Remark,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
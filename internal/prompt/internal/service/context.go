@@ -0,0 +1,17 @@
+package service
+
+import "context"
+
+type versionKey struct {
+	purpose string
+}
+
+// WithVersion 指定 purpose 使用的版本, 不参与 A/B 分配, 用于离线评估候选版本
+func WithVersion(ctx context.Context, purpose, version string) context.Context {
+	return context.WithValue(ctx, versionKey{purpose: purpose}, version)
+}
+
+func versionFrom(ctx context.Context, purpose string) (string, bool) {
+	version, ok := ctx.Value(versionKey{purpose: purpose}).(string)
+	return version, ok && version != ""
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+
+	"github.com/KNICEX/InkFlow/internal/prompt/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/prompt/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+)
+
+var (
+	ErrPromptNotFound   = errors.New("prompt not found")
+	ErrInvalidPrompt    = errors.New("invalid prompt")
+	ErrDuplicateVersion = errors.New("duplicate prompt version")
+)
+
+const (
+	maxPurposeLen = 64
+	maxVersionLen = 32
+)
+
+type PromptService interface {
+	// Register 注册代码内置的模板, 模板无法解析时 panic, 应当在初始化时调用
+	Register(purpose, tpl string)
+	// Render 为 subject 选择一个版本并渲染. Weight 大于 0 的版本和内置模板按权重分配, 同一个 subject 总是分到同一个版本,
+	// 内置模板的权重由 Config.BuiltinWeights 配置, 默认为 0, 即库中有参与分配的版本时内置模板不再分到流量;
+	// 没有参与分配的版本时使用内置模板; 通过 WithVersion 指定了版本时使用指定的版本
+	Render(ctx context.Context, purpose string, subject int64, data any) (domain.Rendered, error)
+
+	// Create 新增一个版本, 模板无法解析时返回 ErrInvalidPrompt
+	Create(ctx context.Context, p domain.Prompt) (int64, error)
+	// SetWeight 调整版本的流量权重, 为 0 时停止分配
+	SetWeight(ctx context.Context, purpose, version string, weight int) error
+	// List purpose 为空时列出所有用途
+	List(ctx context.Context, purpose string) ([]domain.Prompt, error)
+
+	// Reload 重新加载所有版本, 无法解析的模板会被跳过
+	Reload(ctx context.Context) error
+}
+
+type Config struct {
+	// BuiltinWeights 内置模板参与分配的权重, key 为用途, 用于和库中的版本做对照实验
+	BuiltinWeights map[string]int
+}
+
+type compiled struct {
+	version string
+	weight  int
+	tpl     *template.Template
+}
+
+// registry 当前生效的版本, key 为用途, 构建后只读
+type registry map[string][]compiled
+
+type promptService struct {
+	repo repo.PromptRepo
+	cfg  Config
+	reg  atomic.Pointer[registry]
+
+	mu      sync.RWMutex
+	builtin map[string]*template.Template
+
+	l logx.Logger
+}
+
+func NewPromptService(repo repo.PromptRepo, cfg Config, l logx.Logger) PromptService {
+	return &promptService{
+		repo:    repo,
+		cfg:     cfg,
+		builtin: make(map[string]*template.Template),
+		l:       l,
+	}
+}
+
+func (svc *promptService) Register(purpose, tpl string) {
+	t := template.Must(template.New(purpose).Parse(tpl))
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.builtin[purpose] = t
+}
+
+func (svc *promptService) Render(ctx context.Context, purpose string, subject int64, data any) (domain.Rendered, error) {
+	version, tpl, err := svc.pick(ctx, purpose, subject)
+	if err != nil {
+		return domain.Rendered{}, err
+	}
+	var bs bytes.Buffer
+	if err = tpl.Execute(&bs, data); err != nil {
+		return domain.Rendered{}, err
+	}
+	return domain.Rendered{
+		Purpose: purpose,
+		Version: version,
+		Text:    bs.String(),
+	}, nil
+}
+
+func (svc *promptService) pick(ctx context.Context, purpose string, subject int64) (string, *template.Template, error) {
+	var versions []compiled
+	if reg := svc.reg.Load(); reg != nil {
+		versions = (*reg)[purpose]
+	}
+	if version, ok := versionFrom(ctx, purpose); ok {
+		if version != domain.BuiltinVersion {
+			for _, c := range versions {
+				if c.version == version {
+					return c.version, c.tpl, nil
+				}
+			}
+			return "", nil, fmt.Errorf("%w: %s %s", ErrPromptNotFound, purpose, version)
+		}
+	} else if c, ok := assign(purpose, subject, versions, svc.cfg.BuiltinWeights[purpose]); ok {
+		return c.version, c.tpl, nil
+	}
+
+	svc.mu.RLock()
+	tpl, ok := svc.builtin[purpose]
+	svc.mu.RUnlock()
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrPromptNotFound, purpose)
+	}
+	return domain.BuiltinVersion, tpl, nil
+}
+
+// assign 按 purpose 和 subject 的哈希在参与分配的版本中按权重选择, 不同用途之间的分配相互独立.
+// 内置模板排在所有版本之后, 分到内置模板或者没有参与分配的版本时返回 false
+func assign(purpose string, subject int64, versions []compiled, builtinWeight int) (compiled, bool) {
+	total := 0
+	for _, c := range versions {
+		total += max(c.weight, 0)
+	}
+	if total == 0 {
+		return compiled{}, false
+	}
+	total += max(builtinWeight, 0)
+	h := fnv.New32a()
+	h.Write([]byte(purpose))
+	h.Write(binary.BigEndian.AppendUint64(nil, uint64(subject)))
+	n := int(h.Sum32() % uint32(total))
+	for _, c := range versions {
+		if c.weight <= 0 {
+			continue
+		}
+		if n < c.weight {
+			return c, true
+		}
+		n -= c.weight
+	}
+	return compiled{}, false
+}
+
+func (svc *promptService) Create(ctx context.Context, p domain.Prompt) (int64, error) {
+	p.Purpose = strings.TrimSpace(p.Purpose)
+	p.Version = strings.TrimSpace(p.Version)
+	if p.Purpose == "" || len(p.Purpose) > maxPurposeLen || p.Version == "" || len(p.Version) > maxVersionLen ||
+		p.Version == domain.BuiltinVersion || p.Weight < 0 {
+		return 0, ErrInvalidPrompt
+	}
+	if _, err := template.New(p.Purpose).Parse(p.Template); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidPrompt, err)
+	}
+	id, err := svc.repo.Create(ctx, p)
+	if errors.Is(err, repo.ErrDuplicateVersion) {
+		return 0, ErrDuplicateVersion
+	}
+	if err != nil {
+		return 0, err
+	}
+	return id, svc.Reload(ctx)
+}
+
+func (svc *promptService) SetWeight(ctx context.Context, purpose, version string, weight int) error {
+	if weight < 0 {
+		return ErrInvalidPrompt
+	}
+	err := svc.repo.UpdateWeight(ctx, purpose, version, weight)
+	if errors.Is(err, repo.ErrPromptNotFound) {
+		return ErrPromptNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return svc.Reload(ctx)
+}
+
+func (svc *promptService) List(ctx context.Context, purpose string) ([]domain.Prompt, error) {
+	return svc.repo.FindByPurpose(ctx, purpose)
+}
+
+func (svc *promptService) Reload(ctx context.Context) error {
+	prompts, err := svc.repo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	reg := make(registry)
+	for _, p := range prompts {
+		tpl, er := template.New(p.Purpose).Parse(p.Template)
+		if er != nil {
+			svc.l.WithCtx(ctx).Warn("parse prompt template error", logx.String("purpose", p.Purpose),
+				logx.String("version", p.Version), logx.Error(er))
+			continue
+		}
+		reg[p.Purpose] = append(reg[p.Purpose], compiled{
+			version: p.Version,
+			weight:  p.Weight,
+			tpl:     tpl,
+		})
+	}
+	// 各实例按相同的顺序分配, 保证同一个 subject 在不同实例上分到同一个版本
+	for _, versions := range reg {
+		slices.SortFunc(versions, func(a, b compiled) int {
+			return strings.Compare(a.version, b.version)
+		})
+	}
+	svc.reg.Store(&reg)
+	return nil
+}
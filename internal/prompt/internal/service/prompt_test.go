@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KNICEX/InkFlow/internal/prompt/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/prompt/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePromptRepo struct {
+	repo.PromptRepo
+	prompts []domain.Prompt
+}
+
+func (r *fakePromptRepo) FindAll(ctx context.Context) ([]domain.Prompt, error) {
+	return r.prompts, nil
+}
+
+func TestPromptService_Render(t *testing.T) {
+	ctx := context.Background()
+	promptRepo := &fakePromptRepo{}
+	svc := NewPromptService(promptRepo, Config{}, logx.NewNopLogger())
+	svc.Register("review.ink", "builtin: {{.content}}")
+
+	// 没有加载过任何版本时使用内置模板
+	res, err := svc.Render(ctx, "review.ink", 1, map[string]any{"content": "花开了"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.Rendered{Purpose: "review.ink", Version: domain.BuiltinVersion, Text: "builtin: 花开了"}, res)
+	_, err = svc.Render(ctx, "unknown", 1, nil)
+	assert.ErrorIs(t, err, ErrPromptNotFound)
+
+	promptRepo.prompts = []domain.Prompt{
+		{Purpose: "review.ink", Version: "v1", Template: "v1: {{.content}}", Weight: 3},
+		{Purpose: "review.ink", Version: "v2", Template: "v2: {{.content}}", Weight: 1},
+		{Purpose: "review.ink", Version: "v3", Template: "v3: {{.content}}"},
+		{Purpose: "review.ink", Version: "bad", Template: "{{.content", Weight: 100},
+	}
+	require.NoError(t, svc.Reload(ctx))
+
+	cnt := map[string]int{}
+	for subject := range int64(4000) {
+		res, err = svc.Render(ctx, "review.ink", subject, map[string]any{"content": "x"})
+		require.NoError(t, err)
+		cnt[res.Version]++
+		// 同一个 subject 总是分到同一个版本
+		again, err := svc.Render(ctx, "review.ink", subject, map[string]any{"content": "x"})
+		require.NoError(t, err)
+		assert.Equal(t, res.Version, again.Version)
+	}
+	// 无法解析的模板被跳过, 权重为 0 的版本不参与分配
+	assert.Len(t, cnt, 2)
+	assert.InDelta(t, 3000, cnt["v1"], 200)
+	assert.InDelta(t, 1000, cnt["v2"], 200)
+
+	pinned := WithVersion(ctx, "review.ink", "v3")
+	res, err = svc.Render(pinned, "review.ink", 1, map[string]any{"content": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, "v3: x", res.Text)
+	res, err = svc.Render(WithVersion(ctx, "review.ink", domain.BuiltinVersion), "review.ink", 1, map[string]any{"content": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.BuiltinVersion, res.Version)
+	_, err = svc.Render(WithVersion(ctx, "review.ink", "v9"), "review.ink", 1, nil)
+	assert.ErrorIs(t, err, ErrPromptNotFound)
+}
+
+func TestPromptService_Create(t *testing.T) {
+	svc := NewPromptService(&fakePromptRepo{}, Config{}, logx.NewNopLogger())
+	_, err := svc.Create(context.Background(), domain.Prompt{Purpose: "review.ink", Version: "v1", Template: "{{.content"})
+	assert.ErrorIs(t, err, ErrInvalidPrompt)
+	_, err = svc.Create(context.Background(), domain.Prompt{Purpose: "review.ink", Version: domain.BuiltinVersion, Template: "x"})
+	assert.ErrorIs(t, err, ErrInvalidPrompt)
+}
+
+func TestPromptService_RenderBuiltinWeight(t *testing.T) {
+	ctx := context.Background()
+	promptRepo := &fakePromptRepo{prompts: []domain.Prompt{
+		{Purpose: "review.ink", Version: "v1", Template: "v1: {{.content}}", Weight: 1},
+	}}
+	svc := NewPromptService(promptRepo, Config{BuiltinWeights: map[string]int{"review.ink": 1}}, logx.NewNopLogger())
+	svc.Register("review.ink", "builtin: {{.content}}")
+	svc.Register("review.comment", "builtin: {{.content}}")
+	require.NoError(t, svc.Reload(ctx))
+
+	cnt := map[string]int{}
+	for subject := range int64(2000) {
+		res, err := svc.Render(ctx, "review.ink", subject, map[string]any{"content": "x"})
+		require.NoError(t, err)
+		cnt[res.Version]++
+	}
+	// 内置模板和 v1 各分到一半
+	assert.InDelta(t, 1000, cnt["v1"], 150)
+	assert.InDelta(t, 1000, cnt[domain.BuiltinVersion], 150)
+
+	// 没有配置权重的用途不受影响
+	res, err := svc.Render(ctx, "review.comment", 1, map[string]any{"content": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.BuiltinVersion, res.Version)
+}
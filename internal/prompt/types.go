@@ -0,0 +1,30 @@
+package prompt
+
+import (
+	"context"
+
+	"github.com/KNICEX/InkFlow/internal/prompt/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/prompt/internal/service"
+	"github.com/KNICEX/InkFlow/pkg/schedulex"
+)
+
+type Service = service.PromptService
+
+type Prompt = domain.Prompt
+type Rendered = domain.Rendered
+
+const BuiltinVersion = domain.BuiltinVersion
+
+var (
+	ErrPromptNotFound   = service.ErrPromptNotFound
+	ErrInvalidPrompt    = service.ErrInvalidPrompt
+	ErrDuplicateVersion = service.ErrDuplicateVersion
+)
+
+// WithVersion 见 service.WithVersion
+func WithVersion(ctx context.Context, purpose, version string) context.Context {
+	return service.WithVersion(ctx, purpose, version)
+}
+
+// ReloadScheduler 定时重新加载, 其他实例修改的版本在下次加载后生效
+type ReloadScheduler schedulex.Scheduler
@@ -0,0 +1,65 @@
+//go:build wireinject
+
+package prompt
+
+import (
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/prompt/internal/repo"
+	"github.com/KNICEX/InkFlow/internal/prompt/internal/repo/dao"
+	"github.com/KNICEX/InkFlow/internal/prompt/internal/service"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/schedulex"
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"github.com/google/wire"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+func initSnowflakeNode() snowflakex.Node {
+	return snowflakex.NewNode(snowflakex.DefaultStartTime, 0)
+}
+
+// initConfig 读取 prompt.builtin_weights 配置, 用途中包含 '.', 所以配置为列表
+func initConfig() service.Config {
+	type Weight struct {
+		Purpose string `mapstructure:"purpose"`
+		Weight  int    `mapstructure:"weight"`
+	}
+	var weights []Weight
+	if err := viper.UnmarshalKey("prompt.builtin_weights", &weights); err != nil {
+		panic(err)
+	}
+	cfg := service.Config{BuiltinWeights: make(map[string]int, len(weights))}
+	for _, w := range weights {
+		cfg.BuiltinWeights[w.Purpose] = w.Weight
+	}
+	return cfg
+}
+
+func initDAO(db *gorm.DB, node snowflakex.Node) dao.PromptDAO {
+	if err := dao.InitTables(db); err != nil {
+		panic(err)
+	}
+	return dao.NewGormPromptDAO(db, node)
+}
+
+func InitService(db *gorm.DB, l logx.Logger) Service {
+	wire.Build(
+		initSnowflakeNode,
+		initDAO,
+		initConfig,
+		repo.NewPromptRepo,
+		service.NewPromptService,
+	)
+	return nil
+}
+
+// InitReloadScheduler 加载间隔由 prompt.reload_interval 配置, 默认 1m
+func InitReloadScheduler(svc Service, l logx.Logger) ReloadScheduler {
+	interval := viper.GetDuration("prompt.reload_interval")
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return schedulex.NewIntervalScheduler("prompt-reload", interval, svc.Reload, l)
+}
@@ -17,6 +17,8 @@ const (
 	FeedbackTypeLike     FeedbackType = "like"
 	FeedbackUnLike       FeedbackType = "unlike"
 	FeedbackTypeFavorite FeedbackType = "favorite"
+	// FeedbackTypeSearchClick 从搜索结果中点击
+	FeedbackTypeSearchClick FeedbackType = "search_click"
 )
 
 func (t FeedbackType) ToString() string {
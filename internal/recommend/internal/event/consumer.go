@@ -13,20 +13,25 @@ const (
 	topicInkView       = "ink-view"
 	topicInkLike       = "ink-like"
 	topicInkCancelLike = "ink-cancel-like"
+	topicSearchClick   = "search-click"
 
 	recommendSyncGroup = "recommend-sync-group"
+
+	searchTypeInk = "ink"
 )
 
 type SyncConsumer struct {
 	cli      sarama.Client
 	handlers map[string]Handler
+	retry    *saramax.RetryHandler
 	l        logx.Logger
 }
 
-func NewSyncConsumer(cli sarama.Client, l logx.Logger) *SyncConsumer {
+func NewSyncConsumer(cli sarama.Client, retry *saramax.RetryHandler, l logx.Logger) *SyncConsumer {
 	return &SyncConsumer{
 		cli:      cli,
 		handlers: make(map[string]Handler),
+		retry:    retry.WithGroup(recommendSyncGroup),
 		l:        l,
 	}
 }
@@ -37,7 +42,9 @@ func (s *SyncConsumer) Start() error {
 		return err
 	}
 	go func() {
-		err = cg.Consume(context.Background(), []string{topicUserCreate, topicInkLike, topicInkCancelLike}, saramax.NewRawHandler(s.l, s))
+		err = cg.Consume(context.Background(),
+			saramax.WithRetryTopics(topicUserCreate, topicInkLike, topicInkCancelLike, topicSearchClick),
+			saramax.NewRawHandler(s.l, s, saramax.WithRetryHandler(s.retry)))
 		if err != nil {
 			s.l.Warn("recommend sync consumer quit...", logx.Error(err))
 		}
@@ -28,3 +28,11 @@ type InkCancelLikeEvent struct {
 	UserId    int64     `json:"userId"`
 	CreatedAt time.Time `json:"createdAt"`
 }
+
+type SearchClickEvent struct {
+	SearchId  int64     `json:"searchId"`
+	Uid       int64     `json:"uid"`
+	Type      string    `json:"type"`
+	ResultId  int64     `json:"resultId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -118,3 +118,34 @@ func (h *InkCancelLikeHandler) HandleMessage(ctx context.Context, msg *sarama.Co
 		FeedbackType: domain.FeedbackTypeLike,
 	})
 }
+
+// SearchClickHandler 用户从搜索结果中点击 ink 作为正反馈, 未登录用户和其他搜索类型的点击忽略
+type SearchClickHandler struct {
+	svc service.SyncService
+}
+
+func NewSearchClickHandler(svc service.SyncService) Handler {
+	return &SearchClickHandler{
+		svc: svc,
+	}
+}
+
+func (h *SearchClickHandler) Topic() string {
+	return topicSearchClick
+}
+
+func (h *SearchClickHandler) HandleMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	var evt SearchClickEvent
+	if err := json.Unmarshal(msg.Value, &evt); err != nil {
+		return err
+	}
+	if evt.Type != searchTypeInk || evt.Uid == 0 {
+		return nil
+	}
+	return h.svc.InputFeedback(ctx, domain.Feedback{
+		UserId:       evt.Uid,
+		InkId:        evt.ResultId,
+		FeedbackType: domain.FeedbackTypeSearchClick,
+		CreatedAt:    evt.CreatedAt,
+	})
+}
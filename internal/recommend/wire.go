@@ -8,19 +8,22 @@ import (
 	"github.com/KNICEX/InkFlow/internal/relation"
 	"github.com/KNICEX/InkFlow/pkg/gorsex"
 	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
 )
 
 func InitSyncService(cli *gorsex.Client) SyncService {
 	return gorse.NewSyncService(cli)
 }
 
-func InitSyncConsumer(cli sarama.Client, svc SyncService, l logx.Logger) *SyncConsumer {
+func InitSyncConsumer(cli sarama.Client, svc SyncService, retry *saramax.RetryHandler, l logx.Logger) *SyncConsumer {
 	userCreateHandler := event.NewUserCreateHandler(svc)
 	inkViewHandler := event.NewInkViewHandler(svc)
 	inkLikeHandler := event.NewInkLikeHandler(svc)
 	inkCancelLikeHandler := event.NewInkCancelLikeHandler(svc)
-	consumer := event.NewSyncConsumer(cli, l)
-	if err := consumer.RegisterHandler(userCreateHandler, inkViewHandler, inkLikeHandler, inkCancelLikeHandler); err != nil {
+	searchClickHandler := event.NewSearchClickHandler(svc)
+	consumer := event.NewSyncConsumer(cli, retry, l)
+	if err := consumer.RegisterHandler(userCreateHandler, inkViewHandler, inkLikeHandler, inkCancelLikeHandler,
+		searchClickHandler); err != nil {
 		panic(err)
 	}
 	return consumer
@@ -3,6 +3,7 @@ package domain
 import "time"
 
 type FollowRelation struct {
+	Id         int64
 	FollowerId int64
 	FolloweeId int64
 	CreatedAt  time.Time
@@ -25,6 +25,8 @@ type FollowRepo interface {
 
 	GetFollowingIds(ctx context.Context, uid int64, maxId int64, limit int) ([]int64, error)
 	GetFollowerIds(ctx context.Context, uid int64, maxId int64, limit int) ([]int64, error)
+	GetFollowingRelations(ctx context.Context, uid int64, maxId int64, limit int) ([]domain.FollowRelation, error)
+	GetFollowerRelations(ctx context.Context, uid int64, maxId int64, limit int) ([]domain.FollowRelation, error)
 
 	GetMostPopular(ctx context.Context, offset, limit int, viewUid int64) ([]domain.FollowStatistic, error)
 }
@@ -331,6 +333,26 @@ func (repo *CachedFollowRepo) GetFollowerIds(ctx context.Context, uid int64, max
 	}), nil
 }
 
+func (repo *CachedFollowRepo) GetFollowingRelations(ctx context.Context, uid int64, maxId int64, limit int) ([]domain.FollowRelation, error) {
+	followings, err := repo.dao.FollowList(ctx, uid, maxId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(followings, func(item dao.UserFollow, index int) domain.FollowRelation {
+		return repo.toDomain(item)
+	}), nil
+}
+
+func (repo *CachedFollowRepo) GetFollowerRelations(ctx context.Context, uid int64, maxId int64, limit int) ([]domain.FollowRelation, error) {
+	followers, err := repo.dao.FollowerList(ctx, uid, maxId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(followers, func(item dao.UserFollow, index int) domain.FollowRelation {
+		return repo.toDomain(item)
+	}), nil
+}
+
 func (repo *CachedFollowRepo) GetMostPopular(ctx context.Context, offset, limit int, viewUid int64) ([]domain.FollowStatistic, error) {
 	followStats, err := repo.dao.FindMostPopular(ctx, offset, limit)
 	if err != nil {
@@ -367,6 +389,7 @@ func (repo *CachedFollowRepo) statsToDomain(stats dao.FollowStats) domain.Follow
 
 func (repo *CachedFollowRepo) toDomain(follow dao.UserFollow) domain.FollowRelation {
 	return domain.FollowRelation{
+		Id:         follow.Id,
 		FollowerId: follow.FollowerId,
 		FolloweeId: follow.FolloweeId,
 		CreatedAt:  follow.CreatedAt,
@@ -19,6 +19,10 @@ type FollowService interface {
 	FollowerList(ctx context.Context, uid, viewUid int64, maxId int64, limit int) ([]domain.FollowStatistic, error)
 	FollowingIds(ctx context.Context, uid int64, maxId int64, limit int) ([]int64, error)
 	FollowerIds(ctx context.Context, uid int64, maxId int64, limit int) ([]int64, error)
+	// FollowingRelations 返回关注关系, 可使用最后一条的 Id 作为下一页的 maxId
+	FollowingRelations(ctx context.Context, uid int64, maxId int64, limit int) ([]domain.FollowRelation, error)
+	// FollowerRelations 返回粉丝关系, 可使用最后一条的 Id 作为下一页的 maxId
+	FollowerRelations(ctx context.Context, uid int64, maxId int64, limit int) ([]domain.FollowRelation, error)
 	FindFollowStats(ctx context.Context, uid, viewUid int64) (domain.FollowStatistic, error)
 	FindFollowStatsBatch(ctx context.Context, uids []int64, viewUid int64) (map[int64]domain.FollowStatistic, error)
 	FindMostPopular(ctx context.Context, offset, limit int, viewUid int64) ([]domain.FollowStatistic, error)
@@ -93,6 +97,14 @@ func (svc *followService) FollowerIds(ctx context.Context, uid int64, maxId int6
 	return svc.repo.GetFollowerIds(ctx, uid, maxId, limit)
 }
 
+func (svc *followService) FollowingRelations(ctx context.Context, uid int64, maxId int64, limit int) ([]domain.FollowRelation, error) {
+	return svc.repo.GetFollowingRelations(ctx, uid, maxId, limit)
+}
+
+func (svc *followService) FollowerRelations(ctx context.Context, uid int64, maxId int64, limit int) ([]domain.FollowRelation, error) {
+	return svc.repo.GetFollowerRelations(ctx, uid, maxId, limit)
+}
+
 func (svc *followService) FindMostPopular(ctx context.Context, offset, limit int, viewUid int64) ([]domain.FollowStatistic, error) {
 	return svc.repo.GetMostPopular(ctx, offset, limit, viewUid)
 }
@@ -8,3 +8,4 @@ import (
 type FollowService = service.FollowService
 
 type FollowStatistic = domain.FollowStatistic
+type FollowRelation = domain.FollowRelation
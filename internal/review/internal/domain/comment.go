@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+type Comment struct {
+	Id        int64
+	Biz       string
+	BizId     int64
+	AuthorId  int64
+	Content   string
+	Images    []string
+	CreatedAt time.Time
+}
@@ -14,5 +14,6 @@ type FailReview struct {
 type ReviewType string
 
 const (
-	ReviewTypeInk ReviewType = "ink"
+	ReviewTypeInk     ReviewType = "ink"
+	ReviewTypeComment ReviewType = "comment"
 )
@@ -10,6 +10,8 @@ type Ink struct {
 	Content   string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// Images 正文中的图片链接, 不包括封面
+	Images []string
 }
 
 type ReviewResult struct {
@@ -17,4 +19,22 @@ type ReviewResult struct {
 	Reason      string   `json:"reason"`
 	ReviewScore int64    `json:"reviewScore"`
 	ReviewTags  []string `json:"reviewTags"`
+	// Confidence 审核结论的置信度 0-100
+	Confidence int64 `json:"confidence"`
+	// NeedsHuman 置信度过低需要人工审核, 此时 Passed 无意义
+	NeedsHuman bool `json:"needsHuman"`
+	// Images 每张图片的审核结论, 任意图片不通过时整体不通过
+	Images []ImageResult `json:"images"`
+	// PromptVersion 产生该结论的审核提示词版本
+	PromptVersion string `json:"promptVersion"`
+	// ImagePromptVersion 图片审核提示词版本, 没有审核图片时为空
+	ImagePromptVersion string `json:"imagePromptVersion,omitempty"`
+	// TagPromptVersion 打标签提示词版本, 没有打标签时为空
+	TagPromptVersion string `json:"tagPromptVersion,omitempty"`
+}
+
+type ImageResult struct {
+	URL    string `json:"url"`
+	Passed bool   `json:"passed"`
+	Reason string `json:"reason"`
 }
@@ -0,0 +1,73 @@
+package domain
+
+import "time"
+
+type ModerationStatus string
+
+const (
+	ModerationStatusPending  ModerationStatus = "pending"
+	ModerationStatusClaimed  ModerationStatus = "claimed"
+	ModerationStatusApproved ModerationStatus = "approved"
+	ModerationStatusRejected ModerationStatus = "rejected"
+)
+
+type ModerationSource string
+
+const (
+	// ModerationSourceLLM LLM 审核置信度不足转人工
+	ModerationSourceLLM ModerationSource = "llm"
+	// ModerationSourceAppeal 作者对拒绝结果的申诉
+	ModerationSourceAppeal ModerationSource = "appeal"
+)
+
+// ModerationTask 人工审核任务, 审核结果通过 WorkflowId 对应的流程继续处理
+type ModerationTask struct {
+	Id         int64
+	Type       ReviewType
+	BizId      int64
+	AuthorId   int64
+	WorkflowId string
+	Title      string
+	Cover      string
+	Content    string
+	Source     ModerationSource
+	// AppealReason 申诉理由
+	AppealReason string
+	// LLMResult 转人工前的 LLM 审核结果
+	LLMResult   ReviewResult
+	Status      ModerationStatus
+	ModeratorId int64
+	// Reason 审核员给出的理由
+	Reason    string
+	ClaimedAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type AuditAction string
+
+const (
+	AuditActionLLMPass   AuditAction = "llm_pass"
+	AuditActionLLMReject AuditAction = "llm_reject"
+	AuditActionEscalate  AuditAction = "escalate"
+	AuditActionAppeal    AuditAction = "appeal"
+	AuditActionClaim     AuditAction = "claim"
+	AuditActionApprove   AuditAction = "approve"
+	AuditActionReject    AuditAction = "reject"
+)
+
+// AuditLog 审核决策记录, OperatorId 为 0 表示系统
+type AuditLog struct {
+	Id         int64
+	TaskId     int64
+	Type       ReviewType
+	BizId      int64
+	OperatorId int64
+	Action     AuditAction
+	Reason     string
+	// PromptVersion LLM 审核结论对应的提示词版本, 其余两个为同一次审核中图片审核和打标签的版本
+	PromptVersion      string
+	ImagePromptVersion string
+	TagPromptVersion   string
+	CreatedAt          time.Time
+}
@@ -0,0 +1,90 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/internal/review/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/review/internal/service"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
+)
+
+// CommentReviewConsumer 审核带图片的评论, 失败时交给重试 topic 处理
+type CommentReviewConsumer struct {
+	svc           service.Service
+	moderationSvc service.ModerationService
+	producer      ReviewProducer
+	saramaCli     sarama.Client
+	retry         *saramax.RetryHandler
+	l             logx.Logger
+}
+
+func NewCommentReviewConsumer(svc service.Service, moderationSvc service.ModerationService, producer ReviewProducer,
+	saramaCli sarama.Client, retry *saramax.RetryHandler, l logx.Logger) *CommentReviewConsumer {
+	return &CommentReviewConsumer{
+		svc:           svc,
+		moderationSvc: moderationSvc,
+		producer:      producer,
+		saramaCli:     saramaCli,
+		retry:         retry.WithGroup(commentReviewGroup),
+		l:             l,
+	}
+}
+
+func (c *CommentReviewConsumer) Start() error {
+	group, err := sarama.NewConsumerGroupFromClient(commentReviewGroup, c.saramaCli)
+	if err != nil {
+		return err
+	}
+	go func() {
+		er := group.Consume(context.Background(),
+			saramax.WithRetryTopics(commentReviewTopic),
+			saramax.NewHandler[ReviewCommentEvent](c, c.l, saramax.WithRetryHandler(c.retry)))
+		if er != nil {
+			c.l.Warn("comment review consumer quit...", logx.Error(er))
+		}
+	}()
+	return nil
+}
+
+func (c *CommentReviewConsumer) Consume(msg *sarama.ConsumerMessage, event ReviewCommentEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	result, err := c.svc.ReviewComment(ctx, event.Comment)
+	if err != nil {
+		return err
+	}
+	if result.NeedsHuman && len(result.Images) < len(event.Comment.Images) {
+		// 评论没有人工审核流程, 有图片没有经过审核时直接隐藏, 不能让未审核的图片公开
+		result.Passed = false
+	}
+	c.audit(ctx, event.Comment.Id, result)
+	return c.producer.ProduceCommentReviewed(ctx, CommentReviewedEvent{
+		CommentId: event.Comment.Id,
+		AuthorId:  event.Comment.AuthorId,
+		// 只有文本置信度不足时按 LLM 结论处理
+		Passed: result.Passed,
+		Reason: result.Reason,
+	})
+}
+
+// audit 记录 LLM 的结论和提示词版本, 失败时不影响评论的审核结果
+func (c *CommentReviewConsumer) audit(ctx context.Context, commentId int64, result domain.ReviewResult) {
+	action := domain.AuditActionLLMReject
+	if result.Passed {
+		action = domain.AuditActionLLMPass
+	}
+	err := c.moderationSvc.Audit(ctx, domain.AuditLog{
+		Type:               domain.ReviewTypeComment,
+		BizId:              commentId,
+		Action:             action,
+		Reason:             result.Reason,
+		PromptVersion:      result.PromptVersion,
+		ImagePromptVersion: result.ImagePromptVersion,
+	})
+	if err != nil {
+		c.l.WithCtx(ctx).Warn("audit comment review error", logx.Error(err), logx.Int64("commentId", commentId))
+	}
+}
@@ -0,0 +1,92 @@
+package event
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KNICEX/InkFlow/internal/review/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/review/internal/service"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeReviewService struct {
+	service.Service
+	result domain.ReviewResult
+}
+
+func (s *fakeReviewService) ReviewComment(ctx context.Context, comment domain.Comment) (domain.ReviewResult, error) {
+	return s.result, nil
+}
+
+type fakeModerationService struct {
+	service.ModerationService
+	audits []domain.AuditLog
+}
+
+func (s *fakeModerationService) Audit(ctx context.Context, log domain.AuditLog) error {
+	s.audits = append(s.audits, log)
+	return nil
+}
+
+type fakeReviewProducer struct {
+	ReviewProducer
+	reviewed []CommentReviewedEvent
+}
+
+func (p *fakeReviewProducer) ProduceCommentReviewed(ctx context.Context, event CommentReviewedEvent) error {
+	p.reviewed = append(p.reviewed, event)
+	return nil
+}
+
+func TestCommentReviewConsumer_Consume(t *testing.T) {
+	testCases := []struct {
+		name       string
+		images     []string
+		result     domain.ReviewResult
+		wantPassed bool
+		wantAction domain.AuditAction
+	}{
+		{
+			name:       "图片无法获取时隐藏",
+			images:     []string{"http://127.0.0.1/a.png"},
+			result:     domain.ReviewResult{Passed: true, NeedsHuman: true, Reason: "图片无法自动审核"},
+			wantAction: domain.AuditActionLLMReject,
+		},
+		{
+			name:   "图片超过上限时隐藏",
+			images: []string{"a", "b"},
+			result: domain.ReviewResult{Passed: true, NeedsHuman: true, Reason: "图片超过1张",
+				Images: []domain.ImageResult{{Passed: true}}},
+			wantAction: domain.AuditActionLLMReject,
+		},
+		{
+			name:   "只有文本置信度不足时按 LLM 结论处理",
+			images: []string{"a"},
+			result: domain.ReviewResult{Passed: true, NeedsHuman: true,
+				Images: []domain.ImageResult{{Passed: true}}},
+			wantPassed: true,
+			wantAction: domain.AuditActionLLMPass,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			moderationSvc := &fakeModerationService{}
+			producer := &fakeReviewProducer{}
+			c := &CommentReviewConsumer{
+				svc:           &fakeReviewService{result: tc.result},
+				moderationSvc: moderationSvc,
+				producer:      producer,
+				l:             logx.NewNopLogger(),
+			}
+			err := c.Consume(nil, ReviewCommentEvent{Comment: domain.Comment{Id: 1, AuthorId: 2, Images: tc.images}})
+			require.NoError(t, err)
+			require.Len(t, producer.reviewed, 1)
+			assert.Equal(t, tc.wantPassed, producer.reviewed[0].Passed)
+			assert.Equal(t, tc.result.Reason, producer.reviewed[0].Reason)
+			require.Len(t, moderationSvc.audits, 1)
+			assert.Equal(t, tc.wantAction, moderationSvc.audits[0].Action)
+		})
+	}
+}
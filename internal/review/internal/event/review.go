@@ -3,8 +3,10 @@ package event
 import (
 	"context"
 	"encoding/json"
+	"errors"
 
 	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/internal/ai"
 	"github.com/KNICEX/InkFlow/internal/review/internal/consts"
 	"github.com/KNICEX/InkFlow/internal/review/internal/domain"
 	"github.com/KNICEX/InkFlow/internal/review/internal/service"
@@ -16,13 +18,32 @@ import (
 const inkReviewTopic = "ink-review"
 const inkReviewGroup = "ink-review-group"
 
+const commentReviewTopic = "comment-review"
+const commentReviewGroup = "comment-review-group"
+
+// commentReviewedTopic 评论审核结果, 由评论服务消费
+const commentReviewedTopic = "comment-reviewed"
+
 type ReviewInkEvent struct {
 	WorkflowId string
 	Ink        domain.Ink
 }
 
+type ReviewCommentEvent struct {
+	Comment domain.Comment `json:"comment"`
+}
+
+type CommentReviewedEvent struct {
+	CommentId int64  `json:"commentId"`
+	AuthorId  int64  `json:"authorId"`
+	Passed    bool   `json:"passed"`
+	Reason    string `json:"reason"`
+}
+
 type ReviewProducer interface {
 	Produce(ctx context.Context, event ReviewInkEvent) error
+	ProduceComment(ctx context.Context, event ReviewCommentEvent) error
+	ProduceCommentReviewed(ctx context.Context, event CommentReviewedEvent) error
 }
 
 type KafkaReviewProducer struct {
@@ -36,12 +57,24 @@ func NewKafkaReviewProducer(producer sarama.SyncProducer) ReviewProducer {
 }
 
 func (p *KafkaReviewProducer) Produce(ctx context.Context, event ReviewInkEvent) error {
+	return p.produce(inkReviewTopic, event)
+}
+
+func (p *KafkaReviewProducer) ProduceComment(ctx context.Context, event ReviewCommentEvent) error {
+	return p.produce(commentReviewTopic, event)
+}
+
+func (p *KafkaReviewProducer) ProduceCommentReviewed(ctx context.Context, event CommentReviewedEvent) error {
+	return p.produce(commentReviewedTopic, event)
+}
+
+func (p *KafkaReviewProducer) produce(topic string, event any) error {
 	data, err := json.Marshal(event)
 	if err != nil {
 		return err
 	}
 	_, _, err = p.producer.SendMessage(&sarama.ProducerMessage{
-		Topic: inkReviewTopic,
+		Topic: topic,
 		Value: sarama.ByteEncoder(data),
 	})
 	return err
@@ -87,7 +120,8 @@ func (c *ReviewConsumer) Consume(msg *sarama.ConsumerMessage, event ReviewInkEve
 
 	for i := 0; i < maxRetry; i++ {
 		result, err = c.svc.ReviewInk(ctx, event.Ink)
-		if err == nil {
+		if err == nil || errors.Is(err, ai.ErrBudgetExhausted) {
+			// 额度用完时重试也没有用, 直接进入失败队列等待定时重试
 			break
 		}
 		c.l.Warn("review ink failed, will retry", logx.Any("retry", i+1), logx.Error(err))
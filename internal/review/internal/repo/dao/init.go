@@ -3,5 +3,5 @@ package dao
 import "gorm.io/gorm"
 
 func InitTables(db *gorm.DB) error {
-	return db.AutoMigrate(&ReviewFail{})
+	return db.AutoMigrate(&ReviewFail{}, &ModerationTask{}, &AuditLog{})
 }
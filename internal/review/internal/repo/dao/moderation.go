@@ -0,0 +1,178 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTaskNotFound = gorm.ErrRecordNotFound
+	// ErrTaskConflict 任务状态已经变化, 比如被其他审核员领取
+	ErrTaskConflict = errors.New("moderation task conflict")
+)
+
+const (
+	TaskStatusPending  = "pending"
+	TaskStatusClaimed  = "claimed"
+	TaskStatusApproved = "approved"
+	TaskStatusRejected = "rejected"
+)
+
+type ModerationTask struct {
+	Id           int64
+	Type         string `gorm:"index:type_biz"`
+	BizId        int64  `gorm:"index:type_biz"`
+	AuthorId     int64  `gorm:"index"`
+	WorkflowId   string
+	Title        string
+	Cover        string
+	Content      string
+	Source       string
+	AppealReason string
+	// LLMResult json 格式的 LLM 审核结果
+	LLMResult   string
+	Status      string `gorm:"index"`
+	ModeratorId int64  `gorm:"index"`
+	Reason      string
+	ClaimedAt   time.Time
+	CreatedAt   time.Time `gorm:"index"`
+	UpdatedAt   time.Time
+}
+
+type AuditLog struct {
+	Id                 int64
+	TaskId             int64  `gorm:"index"`
+	Type               string `gorm:"index:audit_type_biz"`
+	BizId              int64  `gorm:"index:audit_type_biz"`
+	OperatorId         int64  `gorm:"index"`
+	Action             string
+	Reason             string
+	PromptVersion      string    `gorm:"type:varchar(32)"`
+	ImagePromptVersion string    `gorm:"type:varchar(32)"`
+	TagPromptVersion   string    `gorm:"type:varchar(32)"`
+	CreatedAt          time.Time `gorm:"index"`
+}
+
+type ModerationDAO interface {
+	// InsertTask 创建任务并记录审计日志
+	InsertTask(ctx context.Context, task ModerationTask, audit AuditLog) (int64, error)
+	// Claim 领取待审核或者领取超时的任务
+	Claim(ctx context.Context, id, moderatorId int64, expiredBefore time.Time, audit AuditLog) error
+	// Decide 审核员对自己领取的任务给出结论
+	Decide(ctx context.Context, id, moderatorId int64, status, reason string, audit AuditLog) error
+	FindById(ctx context.Context, id int64) (ModerationTask, error)
+	FindByStatus(ctx context.Context, status string, offset, limit int) ([]ModerationTask, error)
+	CountBySource(ctx context.Context, typ string, bizId int64, source string) (int64, error)
+	InsertAudit(ctx context.Context, audit AuditLog) error
+	FindAudits(ctx context.Context, typ string, bizId int64) ([]AuditLog, error)
+}
+
+type GormModerationDAO struct {
+	db   *gorm.DB
+	node snowflakex.Node
+}
+
+func NewGormModerationDAO(db *gorm.DB, node snowflakex.Node) ModerationDAO {
+	return &GormModerationDAO{
+		db:   db,
+		node: node,
+	}
+}
+
+func (g *GormModerationDAO) InsertTask(ctx context.Context, task ModerationTask, audit AuditLog) (int64, error) {
+	now := time.Now()
+	task.Id = g.node.NextID()
+	task.Status = TaskStatusPending
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		audit.TaskId = task.Id
+		return g.insertAudit(tx, audit)
+	})
+	return task.Id, err
+}
+
+func (g *GormModerationDAO) Claim(ctx context.Context, id, moderatorId int64, expiredBefore time.Time, audit AuditLog) error {
+	now := time.Now()
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&ModerationTask{}).
+			Where("id = ? AND (status = ? OR (status = ? AND claimed_at < ?))",
+				id, TaskStatusPending, TaskStatusClaimed, expiredBefore).
+			Updates(map[string]any{
+				"status":       TaskStatusClaimed,
+				"moderator_id": moderatorId,
+				"claimed_at":   now,
+				"updated_at":   now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrTaskConflict
+		}
+		return g.insertAudit(tx, audit)
+	})
+}
+
+func (g *GormModerationDAO) Decide(ctx context.Context, id, moderatorId int64, status, reason string, audit AuditLog) error {
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&ModerationTask{}).
+			Where("id = ? AND status = ? AND moderator_id = ?", id, TaskStatusClaimed, moderatorId).
+			Updates(map[string]any{
+				"status":     status,
+				"reason":     reason,
+				"updated_at": time.Now(),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrTaskConflict
+		}
+		return g.insertAudit(tx, audit)
+	})
+}
+
+func (g *GormModerationDAO) FindById(ctx context.Context, id int64) (ModerationTask, error) {
+	var task ModerationTask
+	err := g.db.WithContext(ctx).Where("id = ?", id).First(&task).Error
+	return task, err
+}
+
+func (g *GormModerationDAO) FindByStatus(ctx context.Context, status string, offset, limit int) ([]ModerationTask, error) {
+	var tasks []ModerationTask
+	err := g.db.WithContext(ctx).Where("status = ?", status).
+		Order("created_at asc").Offset(offset).Limit(limit).Find(&tasks).Error
+	return tasks, err
+}
+
+func (g *GormModerationDAO) CountBySource(ctx context.Context, typ string, bizId int64, source string) (int64, error) {
+	var cnt int64
+	err := g.db.WithContext(ctx).Model(&ModerationTask{}).
+		Where("type = ? AND biz_id = ? AND source = ?", typ, bizId, source).Count(&cnt).Error
+	return cnt, err
+}
+
+func (g *GormModerationDAO) InsertAudit(ctx context.Context, audit AuditLog) error {
+	return g.insertAudit(g.db.WithContext(ctx), audit)
+}
+
+func (g *GormModerationDAO) insertAudit(tx *gorm.DB, audit AuditLog) error {
+	audit.Id = g.node.NextID()
+	audit.CreatedAt = time.Now()
+	return tx.Create(&audit).Error
+}
+
+func (g *GormModerationDAO) FindAudits(ctx context.Context, typ string, bizId int64) ([]AuditLog, error) {
+	var audits []AuditLog
+	err := g.db.WithContext(ctx).Where("type = ? AND biz_id = ?", typ, bizId).
+		Order("created_at asc").Find(&audits).Error
+	return audits, err
+}
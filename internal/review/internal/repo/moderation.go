@@ -0,0 +1,164 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/review/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/review/internal/repo/dao"
+	"github.com/samber/lo"
+)
+
+var (
+	ErrTaskNotFound = dao.ErrTaskNotFound
+	ErrTaskConflict = dao.ErrTaskConflict
+)
+
+type ModerationRepo interface {
+	CreateTask(ctx context.Context, task domain.ModerationTask, audit domain.AuditLog) (int64, error)
+	Claim(ctx context.Context, id, moderatorId int64, expiredBefore time.Time, audit domain.AuditLog) error
+	Decide(ctx context.Context, id, moderatorId int64, status domain.ModerationStatus, reason string, audit domain.AuditLog) error
+	FindById(ctx context.Context, id int64) (domain.ModerationTask, error)
+	FindByStatus(ctx context.Context, status domain.ModerationStatus, offset, limit int) ([]domain.ModerationTask, error)
+	CountBySource(ctx context.Context, typ domain.ReviewType, bizId int64, source domain.ModerationSource) (int64, error)
+	CreateAudit(ctx context.Context, audit domain.AuditLog) error
+	FindAudits(ctx context.Context, typ domain.ReviewType, bizId int64) ([]domain.AuditLog, error)
+}
+
+type moderationRepo struct {
+	dao dao.ModerationDAO
+}
+
+func NewModerationRepo(dao dao.ModerationDAO) ModerationRepo {
+	return &moderationRepo{
+		dao: dao,
+	}
+}
+
+func (r *moderationRepo) CreateTask(ctx context.Context, task domain.ModerationTask, audit domain.AuditLog) (int64, error) {
+	entity, err := r.taskToEntity(task)
+	if err != nil {
+		return 0, err
+	}
+	return r.dao.InsertTask(ctx, entity, r.auditToEntity(audit))
+}
+
+func (r *moderationRepo) Claim(ctx context.Context, id, moderatorId int64, expiredBefore time.Time, audit domain.AuditLog) error {
+	return r.dao.Claim(ctx, id, moderatorId, expiredBefore, r.auditToEntity(audit))
+}
+
+func (r *moderationRepo) Decide(ctx context.Context, id, moderatorId int64, status domain.ModerationStatus, reason string, audit domain.AuditLog) error {
+	return r.dao.Decide(ctx, id, moderatorId, string(status), reason, r.auditToEntity(audit))
+}
+
+func (r *moderationRepo) FindById(ctx context.Context, id int64) (domain.ModerationTask, error) {
+	task, err := r.dao.FindById(ctx, id)
+	if err != nil {
+		return domain.ModerationTask{}, err
+	}
+	return r.taskToDomain(task), nil
+}
+
+func (r *moderationRepo) FindByStatus(ctx context.Context, status domain.ModerationStatus, offset, limit int) ([]domain.ModerationTask, error) {
+	tasks, err := r.dao.FindByStatus(ctx, string(status), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(tasks, func(item dao.ModerationTask, index int) domain.ModerationTask {
+		return r.taskToDomain(item)
+	}), nil
+}
+
+func (r *moderationRepo) CountBySource(ctx context.Context, typ domain.ReviewType, bizId int64, source domain.ModerationSource) (int64, error) {
+	return r.dao.CountBySource(ctx, string(typ), bizId, string(source))
+}
+
+func (r *moderationRepo) CreateAudit(ctx context.Context, audit domain.AuditLog) error {
+	return r.dao.InsertAudit(ctx, r.auditToEntity(audit))
+}
+
+func (r *moderationRepo) FindAudits(ctx context.Context, typ domain.ReviewType, bizId int64) ([]domain.AuditLog, error) {
+	audits, err := r.dao.FindAudits(ctx, string(typ), bizId)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(audits, func(item dao.AuditLog, index int) domain.AuditLog {
+		return domain.AuditLog{
+			Id:                 item.Id,
+			TaskId:             item.TaskId,
+			Type:               domain.ReviewType(item.Type),
+			BizId:              item.BizId,
+			OperatorId:         item.OperatorId,
+			Action:             domain.AuditAction(item.Action),
+			Reason:             item.Reason,
+			PromptVersion:      item.PromptVersion,
+			ImagePromptVersion: item.ImagePromptVersion,
+			TagPromptVersion:   item.TagPromptVersion,
+			CreatedAt:          item.CreatedAt,
+		}
+	}), nil
+}
+
+func (r *moderationRepo) taskToEntity(task domain.ModerationTask) (dao.ModerationTask, error) {
+	llmResult, err := json.Marshal(task.LLMResult)
+	if err != nil {
+		return dao.ModerationTask{}, err
+	}
+	return dao.ModerationTask{
+		Id:           task.Id,
+		Type:         string(task.Type),
+		BizId:        task.BizId,
+		AuthorId:     task.AuthorId,
+		WorkflowId:   task.WorkflowId,
+		Title:        task.Title,
+		Cover:        task.Cover,
+		Content:      task.Content,
+		Source:       string(task.Source),
+		AppealReason: task.AppealReason,
+		LLMResult:    string(llmResult),
+		Status:       string(task.Status),
+		ModeratorId:  task.ModeratorId,
+		Reason:       task.Reason,
+		ClaimedAt:    task.ClaimedAt,
+	}, nil
+}
+
+func (r *moderationRepo) taskToDomain(task dao.ModerationTask) domain.ModerationTask {
+	var llmResult domain.ReviewResult
+	// 解析失败时只丢失 LLM 的参考结果, 不影响人工审核
+	_ = json.Unmarshal([]byte(task.LLMResult), &llmResult)
+	return domain.ModerationTask{
+		Id:           task.Id,
+		Type:         domain.ReviewType(task.Type),
+		BizId:        task.BizId,
+		AuthorId:     task.AuthorId,
+		WorkflowId:   task.WorkflowId,
+		Title:        task.Title,
+		Cover:        task.Cover,
+		Content:      task.Content,
+		Source:       domain.ModerationSource(task.Source),
+		AppealReason: task.AppealReason,
+		LLMResult:    llmResult,
+		Status:       domain.ModerationStatus(task.Status),
+		ModeratorId:  task.ModeratorId,
+		Reason:       task.Reason,
+		ClaimedAt:    task.ClaimedAt,
+		CreatedAt:    task.CreatedAt,
+		UpdatedAt:    task.UpdatedAt,
+	}
+}
+
+func (r *moderationRepo) auditToEntity(audit domain.AuditLog) dao.AuditLog {
+	return dao.AuditLog{
+		TaskId:             audit.TaskId,
+		Type:               string(audit.Type),
+		BizId:              audit.BizId,
+		OperatorId:         audit.OperatorId,
+		Action:             string(audit.Action),
+		Reason:             audit.Reason,
+		PromptVersion:      audit.PromptVersion,
+		ImagePromptVersion: audit.ImagePromptVersion,
+		TagPromptVersion:   audit.TagPromptVersion,
+	}
+}
@@ -24,3 +24,9 @@ func (a *AsyncWorkflowService) SubmitInk(ctx context.Context, ink domain.Ink) er
 		WorkflowId: activity.GetInfo(ctx).WorkflowExecution.ID,
 	})
 }
+
+func (a *AsyncWorkflowService) SubmitComment(ctx context.Context, comment domain.Comment) error {
+	return a.producer.ProduceComment(ctx, event.ReviewCommentEvent{
+		Comment: comment,
+	})
+}
@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/KNICEX/InkFlow/internal/ai"
+	"github.com/KNICEX/InkFlow/internal/prompt"
+	"github.com/KNICEX/InkFlow/internal/review/internal/domain"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePrompts struct {
+	prompt.Service
+}
+
+func (fakePrompts) Register(purpose, tpl string) {}
+
+func (fakePrompts) Render(ctx context.Context, purpose string, subject int64, data any) (prompt.Rendered, error) {
+	return prompt.Rendered{Purpose: purpose, Version: "v1", Text: purpose}, nil
+}
+
+// fakeLLM 文本审核通过, 图片按 failImage 的下标不通过, imageErr 不为空时图片审核直接返回该错误
+type fakeLLM struct {
+	ai.LLMService
+	failImage int
+	imageErr  error
+	tagErr    error
+	images    int
+}
+
+func (f *fakeLLM) Generate(ctx context.Context, req ai.Request) (ai.Resp, error) {
+	var out any
+	switch {
+	case len(req.Images) > 0:
+		if f.imageErr != nil {
+			return ai.Resp{}, f.imageErr
+		}
+		f.images = len(req.Images)
+		var res imageReviewOutput
+		res.Images = make([]struct {
+			Passed bool   `json:"passed"`
+			Reason string `json:"reason" description:"如不通过，请说明原因；如通过，为空"`
+		}, len(req.Images))
+		for i := range res.Images {
+			res.Images[i].Passed = i != f.failImage
+			if !res.Images[i].Passed {
+				res.Images[i].Reason = "色情"
+			}
+		}
+		out = res
+	case req.Question == PromptInkTagging:
+		if f.tagErr != nil {
+			return ai.Resp{}, f.tagErr
+		}
+		out = tagOutput{ReviewTags: []string{"科技"}}
+	default:
+		out = inkReviewOutput{Passed: true, ReviewScore: 80, Confidence: 90}
+	}
+	data, _ := json.Marshal(out)
+	return ai.Resp{Content: string(data)}, nil
+}
+
+func TestService_ReviewInkImages(t *testing.T) {
+	urls := func(n int) []string {
+		res := make([]string, 0, n)
+		for i := range n {
+			res = append(res, fmt.Sprintf("https://cdn.inkflow.com/%d.png", i))
+		}
+		return res
+	}
+	testCases := []struct {
+		name       string
+		llm        *fakeLLM
+		images     []string
+		wantPassed bool
+		wantHuman  bool
+		wantImages int
+	}{
+		{
+			name:       "图片全部通过",
+			llm:        &fakeLLM{failImage: -1},
+			images:     urls(2),
+			wantPassed: true,
+			wantImages: 3,
+		},
+		{
+			name:       "图片违规",
+			llm:        &fakeLLM{failImage: 1},
+			images:     urls(2),
+			wantImages: 3,
+		},
+		{
+			name:       "图片无法下载转人工",
+			llm:        &fakeLLM{failImage: -1, imageErr: fmt.Errorf("%w: 404", ai.ErrImageUnavailable)},
+			images:     urls(2),
+			wantPassed: true,
+			wantHuman:  true,
+		},
+		{
+			name:       "图片超过上限转人工",
+			llm:        &fakeLLM{failImage: -1},
+			images:     urls(maxReviewImages),
+			wantPassed: true,
+			wantHuman:  true,
+			wantImages: maxReviewImages,
+		},
+		{
+			name:       "超过上限但已有图片违规",
+			llm:        &fakeLLM{failImage: 0},
+			images:     urls(maxReviewImages),
+			wantImages: maxReviewImages,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{llm: tc.llm, prompts: fakePrompts{}, l: logx.NewNopLogger()}
+			res, err := svc.ReviewInk(context.Background(), domain.Ink{
+				Id:      1,
+				Cover:   "https://cdn.inkflow.com/cover.png",
+				Content: "content",
+				Images:  tc.images,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantPassed, res.Passed)
+			assert.Equal(t, tc.wantHuman, res.NeedsHuman)
+			assert.Equal(t, tc.wantImages, tc.llm.images)
+		})
+	}
+}
+
+func TestService_ReviewInkTagError(t *testing.T) {
+	for _, tagErr := range []error{ai.ErrBudgetExhausted, errors.New("timeout")} {
+		svc := &Service{llm: &fakeLLM{tagErr: tagErr}, prompts: fakePrompts{}, l: logx.NewNopLogger()}
+		res, err := svc.ReviewInk(context.Background(), domain.Ink{Id: 1, Content: "content"})
+		// 打标签失败不影响审核结论
+		require.NoError(t, err)
+		assert.True(t, res.Passed)
+		assert.Empty(t, res.ReviewTags)
+	}
+}
@@ -1,82 +1,275 @@
 package llm
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/KNICEX/InkFlow/internal/ai"
+	"github.com/KNICEX/InkFlow/internal/prompt"
 	"github.com/KNICEX/InkFlow/internal/review/internal/domain"
 	"github.com/KNICEX/InkFlow/internal/review/internal/service"
-	"strings"
-	"text/template"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+)
+
+// 提示词的用途, 运行时从提示词库加载, 库中没有可用版本时使用下面的内置模板
+const (
+	PromptInkReview     = "review.ink"
+	PromptInkTagging    = "review.ink_tagging"
+	PromptCommentReview = "review.comment"
+	PromptImageReview   = "review.image"
 )
 
 const reviewPrompt = `
 你是一个内容审核助手，专注于社交平台的内容合规性判断。
 请根据以下标准判断文本是否符合社区规范（例如：不得包含暴力、色情、歧视、诈骗、明显广告推销[可以接受商品推荐]等内容）。
 你的任务是判断是否通过审核，如果不通过,给出简洁明了的理由(reason)。
-如果通过,你还需要给内容打一个0-100的分数(reviewScore)，表示内容的充实度, 
-并且为文章内容打上标签(reviewTags),要求从大分类到小分类尽量全面, 10个左右, 例如：科技->AI->ChatGPT。
-请按照json格式输入,如下：
-{  
-	"passed": true | false,  
-	"reason": "如不通过，请说明原因；如通过，为空",
-	"reviewScore": 0-100,
-	"reviewTags": ["tag1", "tag2"...]
+如果通过,你还需要给内容打一个0-100的分数(reviewScore)，表示内容的充实度。
+同时给出你对审核结论的把握程度(confidence), 0-100, 内容处于违规边缘或者难以判断时给出较低的值。
 输出必须是合法 JSON，不要添加额外解释，不要有多余文本。
 
 内容：{{.content}}
 `
 
+const tagPrompt = `
+你是一个内容分类助手，请为以下文章内容打上标签(reviewTags)，要求从大分类到小分类尽量全面, 10个左右, 例如：科技->AI->ChatGPT。
+输出必须是合法 JSON，不要添加额外解释，不要有多余文本。
+
+内容：{{.content}}
+`
+
+const commentReviewPrompt = `
+你是一个内容审核助手，专注于社交平台评论的合规性判断。
+请判断评论是否符合社区规范（例如：不得包含暴力、色情、歧视、诈骗、广告引流、人身攻击等内容）。
+如果不通过,给出简洁明了的理由(reason)，并给出你对审核结论的把握程度(confidence), 0-100。
+输出必须是合法 JSON，不要添加额外解释，不要有多余文本。
+
+评论：{{.content}}
+`
+
+const imageReviewPrompt = `
+你是一个图片审核助手，专注于社交平台图片的合规性判断。
+以上共有 {{.count}} 张图片，请按顺序逐张判断是否包含色情、暴力血腥、违法违禁、歧视、诈骗或广告引流二维码等内容。
+如果不通过,给出简洁明了的理由(reason)。结果放在 images 数组中, 顺序与图片顺序一致。
+输出必须是合法 JSON，不要添加额外解释，不要有多余文本。
+`
+
+// humanReviewConfidence 低于该置信度的结果需要人工审核
+const humanReviewConfidence = 60
+
+// maxReviewImages 单次审核的最多图片数, 超出时转人工审核
+const maxReviewImages = 10
+
+// inkReviewOutput 模型的输出结构, 用于生成 schema
+type inkReviewOutput struct {
+	Passed      bool   `json:"passed"`
+	Reason      string `json:"reason" description:"如不通过，请说明原因；如通过，为空"`
+	ReviewScore int64  `json:"reviewScore" minimum:"0" maximum:"100" description:"内容的充实度"`
+	Confidence  int64  `json:"confidence" minimum:"0" maximum:"100" description:"对审核结论的把握程度"`
+}
+
+type tagOutput struct {
+	ReviewTags []string `json:"reviewTags" description:"从大分类到小分类的标签, 例如 科技->AI->ChatGPT"`
+}
+
+type commentReviewOutput struct {
+	Passed     bool   `json:"passed"`
+	Reason     string `json:"reason" description:"如不通过，请说明原因；如通过，为空"`
+	Confidence int64  `json:"confidence" minimum:"0" maximum:"100" description:"对审核结论的把握程度"`
+}
+
+type imageReviewOutput struct {
+	Images []struct {
+		Passed bool   `json:"passed"`
+		Reason string `json:"reason" description:"如不通过，请说明原因；如通过，为空"`
+	} `json:"images"`
+}
+
 type Service struct {
-	llm      ai.LLMService
-	template *template.Template
+	llm     ai.LLMService
+	prompts prompt.Service
+	l       logx.Logger
 }
 
-func NewLLMService(llm ai.LLMService) service.Service {
+func NewLLMService(llm ai.LLMService, prompts prompt.Service, l logx.Logger) service.Service {
+	prompts.Register(PromptInkReview, reviewPrompt)
+	prompts.Register(PromptInkTagging, tagPrompt)
+	prompts.Register(PromptCommentReview, commentReviewPrompt)
+	prompts.Register(PromptImageReview, imageReviewPrompt)
 	return &Service{
-		llm:      llm,
-		template: template.Must(template.New("review").Parse(reviewPrompt)),
+		llm:     llm,
+		prompts: prompts,
+		l:       l,
 	}
 }
 
-func (s *Service) trimJsonResp(content string) string {
-	content = strings.Trim("\n", content)
-	lines := strings.Split(content, "\n")
-	if len(lines) > 3 {
-		lines = lines[1 : len(lines)-1]
+func (s *Service) ReviewInk(ctx context.Context, ink domain.Ink) (domain.ReviewResult, error) {
+	ctx = ai.WithUser(ai.WithPurpose(ctx, ai.PurposeReview), ink.AuthorId)
+	question, err := s.prompts.Render(ctx, PromptInkReview, ink.Id, map[string]any{
+		"content": ink.Content,
+	})
+	if err != nil {
+		return domain.ReviewResult{}, err
+	}
+
+	out, _, err := ai.Structured[inkReviewOutput](ctx, s.llm, ai.Request{Question: question.Text})
+	if err != nil {
+		return domain.ReviewResult{}, err
+	}
+
+	result := domain.ReviewResult{
+		Passed:        out.Passed,
+		Reason:        out.Reason,
+		ReviewScore:   out.ReviewScore,
+		Confidence:    out.Confidence,
+		PromptVersion: question.Version,
 	}
-	return strings.Join(lines, "\n")
+	// 置信度不足(包括没有给出置信度)时转人工审核
+	result.NeedsHuman = result.Confidence < humanReviewConfidence
+
+	images := ink.Images
+	if ink.Cover != "" {
+		images = append([]string{ink.Cover}, images...)
+	}
+	result, err = s.mergeImages(ctx, ink.Id, result, images)
+	if err != nil || (!result.Passed && !result.NeedsHuman) {
+		return result, err
+	}
+	tags, version, err := s.tagInk(ctx, ink)
+	if err != nil {
+		// 标签不影响审核结论, 失败时不打标签
+		if !errors.Is(err, ai.ErrBudgetExhausted) {
+			s.l.WithCtx(ctx).Warn("tag ink error", logx.Error(err), logx.Int64("inkId", ink.Id))
+		}
+		return result, nil
+	}
+	result.ReviewTags = tags
+	result.TagPromptVersion = version
+	return result, nil
 }
 
-func (s *Service) ReviewInk(ctx context.Context, ink domain.Ink) (domain.ReviewResult, error) {
-	var bs bytes.Buffer
-	if err := s.template.Execute(&bs, map[string]any{
+// tagInk 返回标签和使用的提示词版本
+func (s *Service) tagInk(ctx context.Context, ink domain.Ink) ([]string, string, error) {
+	question, err := s.prompts.Render(ctx, PromptInkTagging, ink.Id, map[string]any{
 		"content": ink.Content,
-	}); err != nil {
-		return domain.ReviewResult{}, err
+	})
+	if err != nil {
+		return nil, "", err
 	}
+	out, _, err := ai.Structured[tagOutput](ai.WithPurpose(ctx, ai.PurposeTagging), s.llm, ai.Request{Question: question.Text})
+	if err != nil {
+		return nil, "", err
+	}
+	return out.ReviewTags, question.Version, nil
+}
 
-	resp, err := s.llm.AskOnce(ctx, bs.String())
+func (s *Service) ReviewComment(ctx context.Context, comment domain.Comment) (domain.ReviewResult, error) {
+	ctx = ai.WithUser(ai.WithPurpose(ctx, ai.PurposeReview), comment.AuthorId)
+	question, err := s.prompts.Render(ctx, PromptCommentReview, comment.Id, map[string]any{
+		"content": comment.Content,
+	})
 	if err != nil {
 		return domain.ReviewResult{}, err
 	}
 
-	var result domain.ReviewResult
-	err = json.Unmarshal([]byte(s.trimMarkdown(resp.Content)), &result)
+	out, _, err := ai.Structured[commentReviewOutput](ctx, s.llm, ai.Request{Question: question.Text})
 	if err != nil {
 		return domain.ReviewResult{}, err
 	}
 
-	result.Reason = strings.Trim(result.Reason, "\"")
+	result := domain.ReviewResult{
+		Passed:        out.Passed,
+		Reason:        out.Reason,
+		Confidence:    out.Confidence,
+		PromptVersion: question.Version,
+	}
+	result.NeedsHuman = result.Confidence < humanReviewConfidence
+
+	return s.mergeImages(ctx, comment.Id, result, comment.Images)
+}
 
+// mergeImages 审核图片并合并到文本的审核结果中, subject 为图片所属内容的 id
+func (s *Service) mergeImages(ctx context.Context, subject int64, result domain.ReviewResult, images []string) (domain.ReviewResult, error) {
+	if len(images) == 0 {
+		return result, nil
+	}
+	// 超出上限的图片没有经过自动审核, 其余图片通过时也需要人工复核
+	overflow := len(images) > maxReviewImages
+	if overflow {
+		images = images[:maxReviewImages]
+	}
+	imageResults, version, err := s.reviewImages(ctx, subject, images)
+	result.ImagePromptVersion = version
+	if errors.Is(err, ai.ErrImageUnavailable) {
+		return needsHuman(result, "图片无法自动审核"), nil
+	}
+	if err != nil {
+		return domain.ReviewResult{}, err
+	}
+	result.Images = imageResults
+	for i, item := range imageResults {
+		if item.Passed {
+			continue
+		}
+		// 图片违规时不再需要人工复核文本
+		result.Passed = false
+		result.NeedsHuman = false
+		reason := fmt.Sprintf("第%d张图片: %s", i+1, item.Reason)
+		if result.Reason == "" {
+			result.Reason = reason
+		} else {
+			result.Reason += "; " + reason
+		}
+	}
+	if overflow {
+		result = needsHuman(result, fmt.Sprintf("图片超过%d张", maxReviewImages))
+	}
 	return result, nil
 }
 
-func (s *Service) trimMarkdown(content string) string {
-	lines := strings.Split(content, "\n")
-	if len(lines) > 3 {
-		lines = lines[1 : len(lines)-1]
+// needsHuman 无法自动给出结论时转人工审核, 已经确定不通过的结论保持不变
+func needsHuman(result domain.ReviewResult, reason string) domain.ReviewResult {
+	if !result.Passed && !result.NeedsHuman {
+		return result
+	}
+	result.NeedsHuman = true
+	if result.Reason == "" {
+		result.Reason = reason
+	} else {
+		result.Reason += "; " + reason
+	}
+	return result
+}
+
+// reviewImages 返回每张图片的结论和使用的提示词版本
+func (s *Service) reviewImages(ctx context.Context, subject int64, urls []string) ([]domain.ImageResult, string, error) {
+	question, err := s.prompts.Render(ctx, PromptImageReview, subject, map[string]any{
+		"count": len(urls),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	images := make([]ai.Image, 0, len(urls))
+	for _, url := range urls {
+		images = append(images, ai.Image{URL: url})
+	}
+
+	out, _, err := ai.Structured[imageReviewOutput](ctx, s.llm, ai.Request{
+		Question: question.Text,
+		Images:   images,
+	})
+	if err != nil {
+		return nil, question.Version, err
+	}
+	if len(out.Images) != len(urls) {
+		return nil, question.Version, fmt.Errorf("image review result count mismatch, want %d, got %d", len(urls), len(out.Images))
+	}
+	results := make([]domain.ImageResult, 0, len(urls))
+	for i, item := range out.Images {
+		results = append(results, domain.ImageResult{
+			URL:    urls[i],
+			Passed: item.Passed,
+			Reason: item.Reason,
+		})
 	}
-	return strings.Join(lines, "\n")
+	return results, question.Version, nil
 }
@@ -0,0 +1,146 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/review/internal/consts"
+	"github.com/KNICEX/InkFlow/internal/review/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/review/internal/repo"
+	"github.com/KNICEX/InkFlow/internal/review/internal/service"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"go.temporal.io/sdk/client"
+)
+
+var (
+	ErrTaskNotFound = errors.New("moderation task not found")
+	// ErrTaskConflict 任务已被其他审核员领取, 或者未领取就提交结论
+	ErrTaskConflict = errors.New("moderation task conflict")
+)
+
+// claimTimeout 领取后超过该时间未处理, 其他审核员可以重新领取
+const claimTimeout = time.Minute * 30
+
+type Service struct {
+	repo        repo.ModerationRepo
+	workflowCli client.Client
+	l           logx.Logger
+}
+
+func NewService(repo repo.ModerationRepo, workflowCli client.Client, l logx.Logger) service.ModerationService {
+	return &Service{
+		repo:        repo,
+		workflowCli: workflowCli,
+		l:           l,
+	}
+}
+
+func (s *Service) Submit(ctx context.Context, task domain.ModerationTask) (int64, error) {
+	action := domain.AuditActionEscalate
+	reason := task.LLMResult.Reason
+	if task.Source == domain.ModerationSourceAppeal {
+		action = domain.AuditActionAppeal
+		reason = task.AppealReason
+	}
+	return s.repo.CreateTask(ctx, task, domain.AuditLog{
+		Type:               task.Type,
+		BizId:              task.BizId,
+		OperatorId:         task.AuthorId,
+		Action:             action,
+		Reason:             reason,
+		PromptVersion:      task.LLMResult.PromptVersion,
+		ImagePromptVersion: task.LLMResult.ImagePromptVersion,
+		TagPromptVersion:   task.LLMResult.TagPromptVersion,
+	})
+}
+
+func (s *Service) List(ctx context.Context, status domain.ModerationStatus, offset, limit int) ([]domain.ModerationTask, error) {
+	return s.repo.FindByStatus(ctx, status, offset, limit)
+}
+
+func (s *Service) Claim(ctx context.Context, id, moderatorId int64) error {
+	task, err := s.findTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = s.repo.Claim(ctx, id, moderatorId, time.Now().Add(-claimTimeout), domain.AuditLog{
+		TaskId:     id,
+		Type:       task.Type,
+		BizId:      task.BizId,
+		OperatorId: moderatorId,
+		Action:     domain.AuditActionClaim,
+	})
+	return s.wrapErr(err)
+}
+
+func (s *Service) Approve(ctx context.Context, id, moderatorId int64, reason string) error {
+	return s.decide(ctx, id, moderatorId, true, reason)
+}
+
+func (s *Service) Reject(ctx context.Context, id, moderatorId int64, reason string) error {
+	return s.decide(ctx, id, moderatorId, false, reason)
+}
+
+// decide 先通知流程再落库, 落库失败时审核员可以重试, 流程只会处理第一次的结论
+func (s *Service) decide(ctx context.Context, id, moderatorId int64, passed bool, reason string) error {
+	task, err := s.findTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	if task.Status != domain.ModerationStatusClaimed || task.ModeratorId != moderatorId {
+		return ErrTaskConflict
+	}
+
+	// 保留 LLM 给出的评分和标签
+	result := task.LLMResult
+	result.Passed = passed
+	result.Reason = reason
+	result.NeedsHuman = false
+	err = s.workflowCli.SignalWorkflow(ctx, task.WorkflowId, "", consts.ReviewSignal, result)
+	if err != nil {
+		return err
+	}
+
+	status, action := domain.ModerationStatusApproved, domain.AuditActionApprove
+	if !passed {
+		status, action = domain.ModerationStatusRejected, domain.AuditActionReject
+	}
+	err = s.repo.Decide(ctx, id, moderatorId, status, reason, domain.AuditLog{
+		TaskId:     id,
+		Type:       task.Type,
+		BizId:      task.BizId,
+		OperatorId: moderatorId,
+		Action:     action,
+		Reason:     reason,
+	})
+	return s.wrapErr(err)
+}
+
+func (s *Service) CountAppeals(ctx context.Context, typ domain.ReviewType, bizId int64) (int64, error) {
+	return s.repo.CountBySource(ctx, typ, bizId, domain.ModerationSourceAppeal)
+}
+
+func (s *Service) Audit(ctx context.Context, log domain.AuditLog) error {
+	return s.repo.CreateAudit(ctx, log)
+}
+
+func (s *Service) ListAudits(ctx context.Context, typ domain.ReviewType, bizId int64) ([]domain.AuditLog, error) {
+	return s.repo.FindAudits(ctx, typ, bizId)
+}
+
+func (s *Service) findTask(ctx context.Context, id int64) (domain.ModerationTask, error) {
+	task, err := s.repo.FindById(ctx, id)
+	return task, s.wrapErr(err)
+}
+
+func (s *Service) wrapErr(err error) error {
+	switch {
+	case errors.Is(err, repo.ErrTaskNotFound):
+		return ErrTaskNotFound
+	case errors.Is(err, repo.ErrTaskConflict):
+		return ErrTaskConflict
+	default:
+		return err
+	}
+}
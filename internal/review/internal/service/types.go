@@ -7,11 +7,29 @@ import (
 
 type AsyncService interface {
 	SubmitInk(ctx context.Context, ink domain.Ink) error
+	// SubmitComment 异步审核评论, 结果通过 comment-reviewed 事件通知评论服务
+	SubmitComment(ctx context.Context, comment domain.Comment) error
 }
 type Service interface {
 	ReviewInk(ctx context.Context, ink domain.Ink) (domain.ReviewResult, error)
+	ReviewComment(ctx context.Context, comment domain.Comment) (domain.ReviewResult, error)
 }
 type FailoverService interface {
 	RetryFail(ctx context.Context) error
 	Create(ctx context.Context, typ domain.ReviewType, evt any, er error) error
 }
+
+// ModerationService 人工审核队列, 审核结论通过 ReviewSignal 通知对应的发布流程
+type ModerationService interface {
+	// Submit 创建人工审核任务, 来源为 LLM 转人工或者作者申诉
+	Submit(ctx context.Context, task domain.ModerationTask) (int64, error)
+	List(ctx context.Context, status domain.ModerationStatus, offset, limit int) ([]domain.ModerationTask, error)
+	Claim(ctx context.Context, id, moderatorId int64) error
+	Approve(ctx context.Context, id, moderatorId int64, reason string) error
+	Reject(ctx context.Context, id, moderatorId int64, reason string) error
+	// CountAppeals 统计内容被申诉的次数
+	CountAppeals(ctx context.Context, typ domain.ReviewType, bizId int64) (int64, error)
+	// Audit 记录非人工审核任务产生的决策, 比如 LLM 的审核结果
+	Audit(ctx context.Context, log domain.AuditLog) error
+	ListAudits(ctx context.Context, typ domain.ReviewType, bizId int64) ([]domain.AuditLog, error)
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/KNICEX/InkFlow/internal/review/internal/domain"
 	"github.com/KNICEX/InkFlow/internal/review/internal/event"
 	"github.com/KNICEX/InkFlow/internal/review/internal/service"
+	"github.com/KNICEX/InkFlow/internal/review/internal/service/llm"
+	"github.com/KNICEX/InkFlow/internal/review/internal/service/moderation"
 )
 
 type Service = service.Service
@@ -14,6 +16,48 @@ type FailoverService = service.FailoverService
 
 type Consumer = event.ReviewConsumer
 
+type CommentConsumer = event.CommentReviewConsumer
+
 type Ink = domain.Ink
 
+type Comment = domain.Comment
+
 type Result = domain.ReviewResult
+type ImageResult = domain.ImageResult
+
+type ModerationService = service.ModerationService
+type ModerationTask = domain.ModerationTask
+type ModerationStatus = domain.ModerationStatus
+type ModerationSource = domain.ModerationSource
+type AuditLog = domain.AuditLog
+type AuditAction = domain.AuditAction
+type ReviewType = domain.ReviewType
+
+const (
+	TypeInk     = domain.ReviewTypeInk
+	TypeComment = domain.ReviewTypeComment
+
+	ModerationStatusPending  = domain.ModerationStatusPending
+	ModerationStatusClaimed  = domain.ModerationStatusClaimed
+	ModerationStatusApproved = domain.ModerationStatusApproved
+	ModerationStatusRejected = domain.ModerationStatusRejected
+
+	ModerationSourceLLM    = domain.ModerationSourceLLM
+	ModerationSourceAppeal = domain.ModerationSourceAppeal
+
+	AuditActionLLMPass   = domain.AuditActionLLMPass
+	AuditActionLLMReject = domain.AuditActionLLMReject
+)
+
+// 审核使用的提示词用途, 用于在提示词库中管理版本
+const (
+	PromptInkReview     = llm.PromptInkReview
+	PromptInkTagging    = llm.PromptInkTagging
+	PromptCommentReview = llm.PromptCommentReview
+	PromptImageReview   = llm.PromptImageReview
+)
+
+var (
+	ErrTaskNotFound = moderation.ErrTaskNotFound
+	ErrTaskConflict = moderation.ErrTaskConflict
+)
@@ -5,12 +5,15 @@ package review
 import (
 	"github.com/IBM/sarama"
 	"github.com/KNICEX/InkFlow/internal/ai"
+	"github.com/KNICEX/InkFlow/internal/prompt"
 	"github.com/KNICEX/InkFlow/internal/review/internal/event"
 	"github.com/KNICEX/InkFlow/internal/review/internal/repo"
 	"github.com/KNICEX/InkFlow/internal/review/internal/repo/dao"
 	"github.com/KNICEX/InkFlow/internal/review/internal/service/failover"
 	"github.com/KNICEX/InkFlow/internal/review/internal/service/llm"
+	"github.com/KNICEX/InkFlow/internal/review/internal/service/moderation"
 	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
 	"github.com/KNICEX/InkFlow/pkg/snowflakex"
 	"github.com/google/wire"
 	"go.temporal.io/sdk/client"
@@ -25,8 +28,8 @@ func InitAsyncService(producer sarama.SyncProducer, l logx.Logger) AsyncService
 	return nil
 }
 
-func InitService(llmSvc ai.LLMService) Service {
-	return llm.NewLLMService(llmSvc)
+func InitService(llmSvc ai.LLMService, prompts prompt.Service, l logx.Logger) Service {
+	return llm.NewLLMService(llmSvc, prompts, l)
 }
 
 func InitReviewConsumer(workflowCli client.Client, saramaCli sarama.Client, service Service, failoverSvc FailoverService, l logx.Logger) *event.ReviewConsumer {
@@ -36,6 +39,15 @@ func InitReviewConsumer(workflowCli client.Client, saramaCli sarama.Client, serv
 	return nil
 }
 
+func InitCommentReviewConsumer(saramaCli sarama.Client, producer sarama.SyncProducer, service Service,
+	moderationSvc ModerationService, retry *saramax.RetryHandler, l logx.Logger) *event.CommentReviewConsumer {
+	wire.Build(
+		event.NewKafkaReviewProducer,
+		event.NewCommentReviewConsumer,
+	)
+	return nil
+}
+
 func initSnowflakeNode() snowflakex.Node {
 	return snowflakex.NewNode(snowflakex.DefaultStartTime, 0)
 }
@@ -48,6 +60,22 @@ func initFailoverDao(db *gorm.DB) dao.ReviewFailDAO {
 	return dao.NewGormReviewFailDAO(db, initSnowflakeNode())
 }
 
+func initModerationDAO(db *gorm.DB) dao.ModerationDAO {
+	if err := dao.InitTables(db); err != nil {
+		panic(err)
+	}
+	return dao.NewGormModerationDAO(db, initSnowflakeNode())
+}
+
+func InitModerationService(workflowCli client.Client, db *gorm.DB, l logx.Logger) ModerationService {
+	wire.Build(
+		initModerationDAO,
+		repo.NewModerationRepo,
+		moderation.NewService,
+	)
+	return nil
+}
+
 func InitFailoverService(workflowCli client.Client, svc Service, db *gorm.DB, l logx.Logger) FailoverService {
 	wire.Build(
 		initFailoverDao,
@@ -9,13 +9,16 @@ package review
 import (
 	"github.com/IBM/sarama"
 	"github.com/KNICEX/InkFlow/internal/ai"
+	"github.com/KNICEX/InkFlow/internal/prompt"
 	"github.com/KNICEX/InkFlow/internal/review/internal/event"
 	"github.com/KNICEX/InkFlow/internal/review/internal/repo"
 	"github.com/KNICEX/InkFlow/internal/review/internal/repo/dao"
 	"github.com/KNICEX/InkFlow/internal/review/internal/service"
 	"github.com/KNICEX/InkFlow/internal/review/internal/service/failover"
 	"github.com/KNICEX/InkFlow/internal/review/internal/service/llm"
+	"github.com/KNICEX/InkFlow/internal/review/internal/service/moderation"
 	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
 	"github.com/KNICEX/InkFlow/pkg/snowflakex"
 	"go.temporal.io/sdk/client"
 	"gorm.io/gorm"
@@ -34,6 +37,19 @@ func InitReviewConsumer(workflowCli client.Client, saramaCli sarama.Client, serv
 	return reviewConsumer
 }
 
+func InitCommentReviewConsumer(saramaCli sarama.Client, producer sarama.SyncProducer, service2 service.Service, moderationSvc service.ModerationService, retry *saramax.RetryHandler, l logx.Logger) *event.CommentReviewConsumer {
+	reviewProducer := event.NewKafkaReviewProducer(producer)
+	commentReviewConsumer := event.NewCommentReviewConsumer(service2, moderationSvc, reviewProducer, saramaCli, retry, l)
+	return commentReviewConsumer
+}
+
+func InitModerationService(workflowCli client.Client, db *gorm.DB, l logx.Logger) service.ModerationService {
+	moderationDAO := initModerationDAO(db)
+	moderationRepo := repo.NewModerationRepo(moderationDAO)
+	moderationService := moderation.NewService(moderationRepo, workflowCli, l)
+	return moderationService
+}
+
 func InitFailoverService(workflowCli client.Client, svc service.Service, db *gorm.DB, l logx.Logger) service.FailoverService {
 	reviewFailDAO := initFailoverDao(db)
 	reviewFailRepo := repo.NewReviewFailRepo(reviewFailDAO)
@@ -43,8 +59,8 @@ func InitFailoverService(workflowCli client.Client, svc service.Service, db *gor
 
 // wire.go:
 
-func InitService(llmSvc ai.LLMService) Service {
-	return llm.NewLLMService(llmSvc)
+func InitService(llmSvc ai.LLMService, prompts prompt.Service, l logx.Logger) Service {
+	return llm.NewLLMService(llmSvc, prompts, l)
 }
 
 func initSnowflakeNode() snowflakex.Node {
@@ -58,3 +74,10 @@ func initFailoverDao(db *gorm.DB) dao.ReviewFailDAO {
 
 	return dao.NewGormReviewFailDAO(db, initSnowflakeNode())
 }
+
+func initModerationDAO(db *gorm.DB) dao.ModerationDAO {
+	if err := dao.InitTables(db); err != nil {
+		panic(err)
+	}
+	return dao.NewGormModerationDAO(db, initSnowflakeNode())
+}
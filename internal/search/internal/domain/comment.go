@@ -12,6 +12,8 @@ type Comment struct {
 	Images      []string
 	Commentator User
 	CreatedAt   time.Time
+	// Highlights 见 Ink.Highlights
+	Highlights map[string]string
 }
 
 const BizInk = "ink"
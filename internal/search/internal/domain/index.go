@@ -0,0 +1,17 @@
+package domain
+
+// IndexCheckResult 索引与数据库的一致性检查结果,
+// Missing 为索引中缺失的文档, Stale 为内容与数据库不一致的文档,
+// Orphan 为数据库中已经删除或不可见, 但仍在索引中的文档
+type IndexCheckResult struct {
+	Type     SearchType
+	Checked  int
+	Missing  []int64
+	Stale    []int64
+	Orphan   []int64
+	Repaired bool
+}
+
+func (r IndexCheckResult) Consistent() bool {
+	return len(r.Missing) == 0 && len(r.Stale) == 0 && len(r.Orphan) == 0
+}
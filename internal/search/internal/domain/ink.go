@@ -6,13 +6,28 @@ type Ink struct {
 	Id          int64
 	Author      User
 	Title       string
+	Summary     string
 	Content     string
 	Tags        []string
 	AiTags      []string
 	Cover       string
+	ContentType int
+	CategoryId  int64
 	ViewCnt     int64
 	LikeCnt     int64
 	FavoriteCnt int64
+	WordCount   int
+	// ReadingTime 预计阅读时长, 单位分钟
+	ReadingTime int
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	// Highlights 搜索结果的高亮片段, 字段 -> 片段, 命中的词用 <em> 包裹
+	Highlights map[string]string
+}
+
+// InkStats 作品的互动计数, 用于按点赞数排序
+type InkStats struct {
+	Id      int64
+	ViewCnt int64
+	LikeCnt int64
 }
@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+type SearchType string
+
+const (
+	SearchTypeInk     SearchType = "ink"
+	SearchTypeUser    SearchType = "user"
+	SearchTypeComment SearchType = "comment"
+)
+
+// SearchLog 一次搜索的记录, ClickedId 为用户从结果中点击的第一个内容, 未点击时为 0
+type SearchLog struct {
+	Id        int64
+	Uid       int64
+	Query     string
+	Type      SearchType
+	ResultCnt int64
+	ClickedId int64
+	// ResultIds 返回给用户的结果, 只用于校验之后上报的点击, 不写入搜索记录
+	ResultIds []int64
+	CreatedAt time.Time
+}
+
+// QueryStats 搜索词在一段时间内的统计, UserCnt 为每小时搜索过的登录用户数之和
+type QueryStats struct {
+	Query         string
+	Type          SearchType
+	SearchCnt     int64
+	ZeroResultCnt int64
+	ClickCnt      int64
+	UserCnt       int64
+}
+
+// SearchClick 用户点击了某次搜索结果中的内容
+type SearchClick struct {
+	SearchId  int64
+	Uid       int64
+	Type      SearchType
+	ResultId  int64
+	CreatedAt time.Time
+}
@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+type InkSort string
+
+const (
+	InkSortRelevance InkSort = "relevance"
+	InkSortNewest    InkSort = "newest"
+	// InkSortMostLiked 按点赞数排序, 点赞数相同时按浏览数
+	InkSortMostLiked InkSort = "most_liked"
+)
+
+func (s InkSort) Valid() bool {
+	switch s {
+	case InkSortRelevance, InkSortNewest, InkSortMostLiked:
+		return true
+	default:
+		return false
+	}
+}
+
+// 返回分面统计的字段
+const (
+	FacetTags        = "tags"
+	FacetContentType = "content_type"
+	FacetCategory    = "category_id"
+)
+
+// InkQuery 各过滤条件之间是且的关系, 零值表示不过滤
+type InkQuery struct {
+	Keyword string
+	// Tags 需要同时包含所有标签
+	Tags          []string
+	AuthorId      int64
+	ContentType   int
+	CategoryId    int64
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Sort          InkSort
+	Offset        int
+	Limit         int
+}
+
+type InkSearchResult struct {
+	Inks []Ink
+	// Total 命中总数的估计值
+	Total int64
+	// Facets 字段 -> 取值 -> 命中数量
+	Facets map[string]map[string]int64
+}
@@ -0,0 +1,15 @@
+package domain
+
+type SuggestionType string
+
+const (
+	SuggestionQuery SuggestionType = "query"
+	SuggestionTag   SuggestionType = "tag"
+	SuggestionUser  SuggestionType = "user"
+)
+
+// Suggestion 搜索框的输入补全, 来源为热门搜索词, 热门标签和用户账号
+type Suggestion struct {
+	Text string
+	Type SuggestionType
+}
@@ -37,3 +37,25 @@ type UserUpdateEvent struct {
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
+
+type SearchLogEvent struct {
+	Id        int64     `json:"id"`
+	Uid       int64     `json:"uid"`
+	Query     string    `json:"query"`
+	Type      string    `json:"type"`
+	ResultCnt int64     `json:"resultCnt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type SearchClickEvent struct {
+	SearchId  int64     `json:"searchId"`
+	Uid       int64     `json:"uid"`
+	Type      string    `json:"type"`
+	ResultId  int64     `json:"resultId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// InkInteractiveEvent 浏览, 点赞和取消点赞事件, 只关心作品 id
+type InkInteractiveEvent struct {
+	InkId int64 `json:"inkId"`
+}
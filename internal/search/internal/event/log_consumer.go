@@ -0,0 +1,107 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
+	"github.com/samber/lo"
+	"time"
+)
+
+const (
+	searchLogGroup   = "search-log-group"
+	searchClickGroup = "search-click-group"
+)
+
+// clickLogWait 搜索记录最晚多久写入, 远大于批量写入的等待时间
+const clickLogWait = time.Minute
+
+// LogConsumer 批量写入搜索记录, 并把点击回填到对应的搜索记录上
+type LogConsumer struct {
+	cli        sarama.Client
+	repo       repo.SearchLogRepo
+	logRetry   *saramax.RetryHandler
+	clickRetry *saramax.RetryHandler
+	l          logx.Logger
+}
+
+func NewLogConsumer(cli sarama.Client, repo repo.SearchLogRepo, retry *saramax.RetryHandler, l logx.Logger) *LogConsumer {
+	return &LogConsumer{
+		cli:        cli,
+		repo:       repo,
+		logRetry:   retry.WithGroup(searchLogGroup),
+		clickRetry: retry.WithGroup(searchClickGroup),
+		l:          l,
+	}
+}
+
+func (c *LogConsumer) Start() error {
+	logCg, err := sarama.NewConsumerGroupFromClient(searchLogGroup, c.cli)
+	if err != nil {
+		return err
+	}
+	clickCg, err := sarama.NewConsumerGroupFromClient(searchClickGroup, c.cli)
+	if err != nil {
+		return err
+	}
+	go func() {
+		er := logCg.Consume(context.Background(),
+			saramax.WithRetryTopics(topicSearchLog),
+			saramax.NewBatchHandler[SearchLogEvent](c.l, searchLogBatch{c},
+				saramax.WithBatchSize[SearchLogEvent](100),
+				saramax.WithHandlerOptions[SearchLogEvent](saramax.WithRetryHandler(c.logRetry))))
+		if er != nil {
+			c.l.Warn("search log consumer quit...", logx.Error(er))
+		}
+	}()
+	go func() {
+		er := clickCg.Consume(context.Background(),
+			saramax.WithRetryTopics(topicSearchClick),
+			saramax.NewHandler[SearchClickEvent](searchClick{c}, c.l, saramax.WithRetryHandler(c.clickRetry)))
+		if er != nil {
+			c.l.Warn("search click consumer quit...", logx.Error(er))
+		}
+	}()
+	return nil
+}
+
+type searchLogBatch struct {
+	*LogConsumer
+}
+
+func (c searchLogBatch) Consume(msgs []*sarama.ConsumerMessage, ts []SearchLogEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	return c.repo.InsertLogs(ctx, lo.Map(ts, func(item SearchLogEvent, index int) domain.SearchLog {
+		return domain.SearchLog{
+			Id:        item.Id,
+			Uid:       item.Uid,
+			Query:     item.Query,
+			Type:      domain.SearchType(item.Type),
+			ResultCnt: item.ResultCnt,
+			CreatedAt: item.CreatedAt,
+		}
+	}))
+}
+
+type searchClick struct {
+	*LogConsumer
+}
+
+// Consume 搜索记录是批量写入的, 点击可能先到, 此时返回错误交由重试处理.
+// 超过 clickLogWait 仍然找不到搜索记录时不再重试, 搜索记录已经丢失, 重试也不会成功
+func (c searchClick) Consume(msg *sarama.ConsumerMessage, evt SearchClickEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	err := c.repo.SetClicked(ctx, evt.SearchId, evt.ResultId)
+	if errors.Is(err, repo.ErrSearchLogNotFound) && time.Since(evt.CreatedAt) > clickLogWait {
+		c.l.WithCtx(ctx).Warn("search log of click not found, dropped",
+			logx.Int64("searchId", evt.SearchId), logx.Int64("resultId", evt.ResultId))
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/search/internal/service"
+)
+
+const (
+	topicSearchLog   = "search-log"
+	topicSearchClick = "search-click"
+)
+
+type KafkaLogProducer struct {
+	producer sarama.SyncProducer
+}
+
+func NewKafkaLogProducer(producer sarama.SyncProducer) service.LogProducer {
+	return &KafkaLogProducer{
+		producer: producer,
+	}
+}
+
+func (p *KafkaLogProducer) produce(topic string, evt any) error {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	_, _, err = p.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.ByteEncoder(data),
+	})
+	return err
+}
+
+func (p *KafkaLogProducer) ProduceSearchLog(ctx context.Context, log domain.SearchLog) error {
+	return p.produce(topicSearchLog, SearchLogEvent{
+		Id:        log.Id,
+		Uid:       log.Uid,
+		Query:     log.Query,
+		Type:      string(log.Type),
+		ResultCnt: log.ResultCnt,
+		CreatedAt: log.CreatedAt,
+	})
+}
+
+func (p *KafkaLogProducer) ProduceSearchClick(ctx context.Context, click domain.SearchClick) error {
+	return p.produce(topicSearchClick, SearchClickEvent{
+		SearchId:  click.SearchId,
+		Uid:       click.Uid,
+		Type:      string(click.Type),
+		ResultId:  click.ResultId,
+		CreatedAt: click.CreatedAt,
+	})
+}
@@ -0,0 +1,82 @@
+package event
+
+import (
+	"context"
+	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/internal/interactive"
+	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/search/internal/service"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
+	"github.com/samber/lo"
+	"time"
+)
+
+const (
+	inkStatsGroup = "search-ink-stats-group"
+)
+
+const (
+	topicInkView       = "ink-view"
+	topicInkLike       = "ink-like"
+	topicInkCancelLike = "ink-cancel-like"
+)
+
+// InkStatsConsumer 把作品的浏览和点赞数同步到索引, 用于按点赞数排序.
+// 事件只用来确定哪些作品有变化, 计数以互动服务为准, 重复消费或乱序都不影响结果.
+// 浏览数由互动服务异步累加, 索引中的浏览数可能落后一批, 下一次事件时会追上
+type InkStatsConsumer struct {
+	cli     sarama.Client
+	svc     service.SyncService
+	intrSvc interactive.Service
+	retry   *saramax.RetryHandler
+	l       logx.Logger
+}
+
+func NewInkStatsConsumer(cli sarama.Client, svc service.SyncService, intrSvc interactive.Service,
+	retry *saramax.RetryHandler, l logx.Logger) *InkStatsConsumer {
+	return &InkStatsConsumer{
+		cli:     cli,
+		svc:     svc,
+		intrSvc: intrSvc,
+		retry:   retry.WithGroup(inkStatsGroup),
+		l:       l,
+	}
+}
+
+func (c *InkStatsConsumer) Start() error {
+	cg, err := sarama.NewConsumerGroupFromClient(inkStatsGroup, c.cli)
+	if err != nil {
+		return err
+	}
+	go func() {
+		er := cg.Consume(context.Background(),
+			saramax.WithRetryTopics(topicInkView, topicInkLike, topicInkCancelLike),
+			saramax.NewBatchHandler[InkInteractiveEvent](c.l, c,
+				saramax.WithBatchSize[InkInteractiveEvent](100),
+				saramax.WithHandlerOptions[InkInteractiveEvent](saramax.WithRetryHandler(c.retry))))
+		if er != nil {
+			c.l.Warn("search ink stats consumer quit...", logx.Error(er))
+		}
+	}()
+	return nil
+}
+
+func (c *InkStatsConsumer) Consume(msgs []*sarama.ConsumerMessage, evts []InkInteractiveEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	inkIds := lo.Uniq(lo.Map(evts, func(item InkInteractiveEvent, index int) int64 {
+		return item.InkId
+	}))
+	intrs, err := c.intrSvc.GetMulti(ctx, domain.BizInk, inkIds, 0)
+	if err != nil {
+		return err
+	}
+	return c.svc.UpdateInkStats(ctx, lo.MapToSlice(intrs, func(key int64, value interactive.Interactive) domain.InkStats {
+		return domain.InkStats{
+			Id:      key,
+			ViewCnt: value.ViewCnt,
+			LikeCnt: value.LikeCnt,
+		}
+	}))
+}
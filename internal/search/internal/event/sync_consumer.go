@@ -23,14 +23,16 @@ type SyncConsumer struct {
 	svc      service.SyncService
 	cli      sarama.Client
 	handlers map[string]Handler
+	retry    *saramax.RetryHandler
 	l        logx.Logger
 }
 
-func NewSyncConsumer(cli sarama.Client, svc service.SyncService, l logx.Logger) *SyncConsumer {
+func NewSyncConsumer(cli sarama.Client, svc service.SyncService, retry *saramax.RetryHandler, l logx.Logger) *SyncConsumer {
 	return &SyncConsumer{
 		cli:      cli,
 		svc:      svc,
 		handlers: make(map[string]Handler),
+		retry:    retry.WithGroup(searchSyncGroup),
 		l:        l,
 	}
 }
@@ -52,8 +54,8 @@ func (s *SyncConsumer) Start() error {
 	}
 	go func() {
 		err = cg.Consume(context.Background(),
-			[]string{topicCommentReply, topicUserCreate, topicUserUpdate},
-			saramax.NewRawHandler(s.l, s))
+			saramax.WithRetryTopics(topicCommentReply, topicUserCreate, topicUserUpdate),
+			saramax.NewRawHandler(s.l, s, saramax.WithRetryHandler(s.retry)))
 		if err != nil {
 			s.l.Warn("search sync consumer quit...", logx.Error(err))
 		}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+)
+
+// QueryCache 缓存由搜索记录汇总出的热门搜索词, 补全时直接读取
+type QueryCache interface {
+	// SetPopular 整体替换热门搜索词, 按热度从高到低排列
+	SetPopular(ctx context.Context, queries []string) error
+	Popular(ctx context.Context, limit int) ([]string, error)
+}
+
+type RedisQueryCache struct {
+	cmd redis.Cmdable
+}
+
+func NewRedisQueryCache(cmd redis.Cmdable) QueryCache {
+	return &RedisQueryCache{
+		cmd: cmd,
+	}
+}
+
+func (r *RedisQueryCache) SetPopular(ctx context.Context, queries []string) error {
+	pipeline := r.cmd.TxPipeline()
+	pipeline.Del(ctx, r.popularKey())
+	if len(queries) > 0 {
+		pipeline.RPush(ctx, r.popularKey(), queries)
+	}
+	_, err := pipeline.Exec(ctx)
+	return err
+}
+
+func (r *RedisQueryCache) Popular(ctx context.Context, limit int) ([]string, error) {
+	return r.cmd.LRange(ctx, r.popularKey(), 0, int64(limit-1)).Result()
+}
+
+func (r *RedisQueryCache) popularKey() string {
+	return "search:query:popular"
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// rebuildExpiration 大于重建索引的最长耗时, 重建流程异常退出时不会一直双写
+const rebuildExpiration = time.Hour * 24
+
+// RebuildCache 记录正在重建的索引, 以及重建期间被修改过的数据 id
+type RebuildCache interface {
+	SetTarget(ctx context.Context, typ, index string) error
+	// Target 没有正在重建的索引时返回空字符串
+	Target(ctx context.Context, typ string) (string, error)
+	// ClearTarget 同时清除记录的 id
+	ClearTarget(ctx context.Context, typ string) error
+	Touch(ctx context.Context, typ string, ids []int64) error
+	Touched(ctx context.Context, typ string) ([]int64, error)
+	Untouch(ctx context.Context, typ string, ids []int64) error
+}
+
+type RedisRebuildCache struct {
+	cmd redis.Cmdable
+}
+
+func NewRedisRebuildCache(cmd redis.Cmdable) RebuildCache {
+	return &RedisRebuildCache{
+		cmd: cmd,
+	}
+}
+
+func (r *RedisRebuildCache) SetTarget(ctx context.Context, typ, index string) error {
+	pipeline := r.cmd.TxPipeline()
+	pipeline.Del(ctx, r.touchedKey(typ))
+	pipeline.Set(ctx, r.targetKey(typ), index, rebuildExpiration)
+	_, err := pipeline.Exec(ctx)
+	return err
+}
+
+func (r *RedisRebuildCache) Target(ctx context.Context, typ string) (string, error) {
+	index, err := r.cmd.Get(ctx, r.targetKey(typ)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return index, err
+}
+
+func (r *RedisRebuildCache) ClearTarget(ctx context.Context, typ string) error {
+	return r.cmd.Del(ctx, r.targetKey(typ), r.touchedKey(typ)).Err()
+}
+
+func (r *RedisRebuildCache) Touch(ctx context.Context, typ string, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	pipeline := r.cmd.TxPipeline()
+	pipeline.SAdd(ctx, r.touchedKey(typ), r.members(ids)...)
+	pipeline.Expire(ctx, r.touchedKey(typ), rebuildExpiration)
+	_, err := pipeline.Exec(ctx)
+	return err
+}
+
+func (r *RedisRebuildCache) Touched(ctx context.Context, typ string) ([]int64, error) {
+	members, err := r.cmd.SMembers(ctx, r.touchedKey(typ)).Result()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (r *RedisRebuildCache) Untouch(ctx context.Context, typ string, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.cmd.SRem(ctx, r.touchedKey(typ), r.members(ids)...).Err()
+}
+
+func (r *RedisRebuildCache) members(ids []int64) []any {
+	members := make([]any, 0, len(ids))
+	for _, id := range ids {
+		members = append(members, id)
+	}
+	return members
+}
+
+func (r *RedisRebuildCache) targetKey(typ string) string {
+	return fmt.Sprintf("search:rebuild:%s:target", typ)
+}
+
+func (r *RedisRebuildCache) touchedKey(typ string) string {
+	return fmt.Sprintf("search:rebuild:%s:touched", typ)
+}
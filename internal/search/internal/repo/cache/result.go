@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// resultExpiration 搜索结果保留多久, 超过之后的点击不再记录
+const resultExpiration = time.Hour
+
+// ResultCache 记录每次搜索返回给用户的结果, 上报点击时校验搜索属于该用户并且点击的内容在结果中
+type ResultCache interface {
+	Set(ctx context.Context, searchId, uid int64, resultIds []int64) error
+	// Append 翻页时追加结果, 搜索不存在或者不属于 uid 时返回 false
+	Append(ctx context.Context, searchId, uid int64, resultIds []int64) (bool, error)
+	Contains(ctx context.Context, searchId, uid, resultId int64) (bool, error)
+}
+
+type RedisResultCache struct {
+	cmd redis.Cmdable
+}
+
+func NewRedisResultCache(cmd redis.Cmdable) ResultCache {
+	return &RedisResultCache{
+		cmd: cmd,
+	}
+}
+
+func (r *RedisResultCache) Set(ctx context.Context, searchId, uid int64, resultIds []int64) error {
+	if len(resultIds) == 0 {
+		return nil
+	}
+	pipeline := r.cmd.TxPipeline()
+	pipeline.SAdd(ctx, r.key(searchId, uid), r.members(resultIds)...)
+	pipeline.Expire(ctx, r.key(searchId, uid), resultExpiration)
+	_, err := pipeline.Exec(ctx)
+	return err
+}
+
+func (r *RedisResultCache) Append(ctx context.Context, searchId, uid int64, resultIds []int64) (bool, error) {
+	// 只能追加到自己已有的搜索上, 否则可以借用别人的 searchId 伪造点击
+	n, err := r.cmd.Exists(ctx, r.key(searchId, uid)).Result()
+	if err != nil || n == 0 {
+		return false, err
+	}
+	if len(resultIds) == 0 {
+		return true, nil
+	}
+	return true, r.cmd.SAdd(ctx, r.key(searchId, uid), r.members(resultIds)...).Err()
+}
+
+func (r *RedisResultCache) Contains(ctx context.Context, searchId, uid, resultId int64) (bool, error) {
+	return r.cmd.SIsMember(ctx, r.key(searchId, uid), resultId).Result()
+}
+
+func (r *RedisResultCache) members(ids []int64) []any {
+	members := make([]any, 0, len(ids))
+	for _, id := range ids {
+		members = append(members, id)
+	}
+	return members
+}
+
+// key uid 是 key 的一部分, 其他用户的 searchId 查不到结果
+func (r *RedisResultCache) key(searchId, uid int64) string {
+	return fmt.Sprintf("search:result:%d:%d", searchId, uid)
+}
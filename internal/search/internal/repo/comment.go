@@ -10,6 +10,8 @@ import (
 
 type CommentRepo interface {
 	Search(ctx context.Context, query string, offset, limit int) ([]domain.Comment, error)
+	// FindByIds 直接返回索引中的文档, 不填充评论者信息
+	FindByIds(ctx context.Context, ids []int64) (map[int64]domain.Comment, error)
 	InputComment(ctx context.Context, comments []domain.Comment) error
 	DeleteComment(ctx context.Context, commentId int64) error
 	DeleteByBiz(ctx context.Context, biz string, bizId int64) error
@@ -46,6 +48,16 @@ func (repo *commentRepo) Search(ctx context.Context, query string, offset, limit
 	}), nil
 }
 
+func (repo *commentRepo) FindByIds(ctx context.Context, ids []int64) (map[int64]domain.Comment, error) {
+	comments, err := repo.dao.FindByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return lo.MapValues(comments, func(value dao.Comment, key int64) domain.Comment {
+		return repo.entityToDomain(value)
+	}), nil
+}
+
 func (repo *commentRepo) InputComment(ctx context.Context, comments []domain.Comment) error {
 	err := repo.dao.Input(ctx, lo.Map(comments, func(item domain.Comment, index int) dao.Comment {
 		return repo.domainToEntity(item)
@@ -92,8 +104,9 @@ func (repo *commentRepo) entityToDomain(c dao.Comment) domain.Comment {
 		Commentator: domain.User{
 			Id: c.CommentatorId,
 		},
-		Content:   c.Content,
-		Images:    strings.Split(c.Images, ","),
-		CreatedAt: c.CreatedAt,
+		Content:    c.Content,
+		Images:     strings.Split(c.Images, ","),
+		CreatedAt:  c.CreatedAt,
+		Highlights: c.Highlights,
 	}
 }
@@ -0,0 +1,68 @@
+package dao
+
+import (
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/meilisearch/meilisearch-go"
+	"maps"
+)
+
+// Backend 同一个搜索引擎下的全部 DAO, Init 负责创建索引并同步设置
+type Backend struct {
+	User    UserDAO
+	Ink     InkDAO
+	Comment CommentDAO
+	Init    func() error
+	Indexes IndexManager
+	// WithIndex 返回把 name 换成 index 的 Backend, 用于向重建中的新索引写入
+	WithIndex func(name, index string) Backend
+}
+
+func NewMeiliBackend(cli meilisearch.ServiceManager, tuning map[string]IndexTuning) Backend {
+	return newMeiliBackend(cli, tuning, map[string]string{})
+}
+
+func newMeiliBackend(cli meilisearch.ServiceManager, tuning map[string]IndexTuning, indexes map[string]string) Backend {
+	return Backend{
+		User:    &MeiliUserDAO{cli: cli, index: indexOf(indexes, UserIndexName)},
+		Ink:     &MeiliInkDAO{cli: cli, index: indexOf(indexes, InkIndexName)},
+		Comment: &MeiliCommentDAO{cli: cli, index: indexOf(indexes, CommentIndexName)},
+		Init: func() error {
+			return InitMeili(cli, tuning)
+		},
+		Indexes: &meiliIndexManager{cli: cli, tuning: tuning},
+		WithIndex: func(name, index string) Backend {
+			indexes := maps.Clone(indexes)
+			indexes[name] = index
+			return newMeiliBackend(cli, tuning, indexes)
+		},
+	}
+}
+
+// NewEsBackend analyzer 为 ik 或 smartcn, 停用词和拼写容错只对 meilisearch 生效
+func NewEsBackend(cli *elasticsearch.Client, analyzer string) Backend {
+	return newEsBackend(cli, analyzer, map[string]string{})
+}
+
+func newEsBackend(cli *elasticsearch.Client, analyzer string, indexes map[string]string) Backend {
+	return Backend{
+		User:    &EsUserDAO{cli: cli, index: indexOf(indexes, UserIndexName)},
+		Ink:     &EsInkDAO{cli: cli, index: indexOf(indexes, InkIndexName)},
+		Comment: &EsCommentDAO{cli: cli, index: indexOf(indexes, CommentIndexName)},
+		Init: func() error {
+			return InitEs(cli, analyzer)
+		},
+		Indexes: &esIndexManager{cli: cli, analyzer: analyzer},
+		WithIndex: func(name, index string) Backend {
+			indexes := maps.Clone(indexes)
+			indexes[name] = index
+			return newEsBackend(cli, analyzer, indexes)
+		},
+	}
+}
+
+func indexOf(indexes map[string]string, name string) string {
+	if index, ok := indexes[name]; ok {
+		return index
+	}
+	return name
+}
@@ -20,10 +20,14 @@ type Comment struct {
 	Content       string    `json:"content" mapstructure:"content"`
 	Images        string    `json:"images" mapstructure:"images"`
 	CreatedAt     time.Time `json:"created_at" mapstructure:"created_at"`
+	// Formatted 搜索时返回的高亮结果, 不写入索引
+	Formatted  map[string]any    `json:"-" mapstructure:"_formatted"`
+	Highlights map[string]string `json:"-" mapstructure:"-"`
 }
 
 type CommentDAO interface {
 	Search(ctx context.Context, keyword string, offset, limit int) ([]Comment, error)
+	FindByIds(ctx context.Context, ids []int64) (map[int64]Comment, error)
 	Input(ctx context.Context, comments []Comment) error
 	DeleteByIds(ctx context.Context, ids []int64) error
 	DeleteChildComments(ctx context.Context, id int64) error
@@ -31,17 +35,24 @@ type CommentDAO interface {
 }
 
 type MeiliCommentDAO struct {
-	cli meilisearch.ServiceManager
+	cli   meilisearch.ServiceManager
+	index string
 }
 
 func NewMeiliCommentDAO(cli meilisearch.ServiceManager) CommentDAO {
-	return &MeiliCommentDAO{cli: cli}
+	return &MeiliCommentDAO{cli: cli, index: CommentIndexName}
 }
 
 func (m *MeiliCommentDAO) Search(ctx context.Context, keyword string, offset, limit int) ([]Comment, error) {
-	res, err := m.cli.Index(commentIndexName).SearchWithContext(ctx, keyword, &meilisearch.SearchRequest{
+	res, err := m.cli.Index(m.index).SearchWithContext(ctx, keyword, &meilisearch.SearchRequest{
 		Limit:  int64(limit),
 		Offset: int64(offset),
+
+		AttributesToHighlight: []string{"content"},
+		AttributesToCrop:      []string{"content"},
+		CropLength:            highlightCropLength,
+		HighlightPreTag:       highlightPreTag,
+		HighlightPostTag:      highlightPostTag,
 	})
 	if err != nil {
 		return nil, err
@@ -50,30 +61,52 @@ func (m *MeiliCommentDAO) Search(ctx context.Context, keyword string, offset, li
 		return nil, nil
 	}
 	var comments []Comment
-	err = mapstructurex.Decode(res.Hits, &comments)
-	return comments, err
+	if err = mapstructurex.Decode(res.Hits, &comments); err != nil {
+		return nil, err
+	}
+	for i := range comments {
+		comments[i].Highlights = highlights(comments[i].Formatted, "content")
+	}
+	return comments, nil
+}
+
+func (m *MeiliCommentDAO) FindByIds(ctx context.Context, ids []int64) (map[int64]Comment, error) {
+	res, err := m.cli.Index(m.index).SearchWithContext(ctx, "", &meilisearch.SearchRequest{
+		Filter: idsFilter(ids),
+		Limit:  int64(len(ids)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var comments []Comment
+	if err = mapstructurex.Decode(res.Hits, &comments); err != nil {
+		return nil, err
+	}
+	return lo.SliceToMap(comments, func(item Comment) (int64, Comment) {
+		return item.Id, item
+	}), nil
 }
 
 func (m *MeiliCommentDAO) Input(ctx context.Context, comments []Comment) error {
-	_, err := m.cli.Index(commentIndexName).AddDocumentsWithContext(ctx, comments)
+	_, err := m.cli.Index(m.index).AddDocumentsWithContext(ctx, comments)
 	return err
 }
 
 func (m *MeiliCommentDAO) DeleteByIds(ctx context.Context, ids []int64) error {
-	_, err := m.cli.Index(commentIndexName).DeleteDocumentsWithContext(ctx, lo.Map(ids, func(item int64, index int) string {
+	_, err := m.cli.Index(m.index).DeleteDocumentsWithContext(ctx, lo.Map(ids, func(item int64, index int) string {
 		return strconv.FormatInt(item, 10)
 	}))
 	return err
 }
 
 func (m *MeiliCommentDAO) DeleteChildComments(ctx context.Context, id int64) error {
-	_, err := m.cli.Index(commentIndexName).DeleteDocumentsByFilterWithContext(ctx,
+	_, err := m.cli.Index(m.index).DeleteDocumentsByFilterWithContext(ctx,
 		fmt.Sprintf("root_id=%d OR parent_id=%d", id, id))
 	return err
 }
 
 func (m *MeiliCommentDAO) DeleteByBiz(ctx context.Context, biz string, bizId int64) error {
-	_, err := m.cli.Index(commentIndexName).DeleteDocumentsByFilterWithContext(ctx,
+	_, err := m.cli.Index(m.index).DeleteDocumentsByFilterWithContext(ctx,
 		fmt.Sprintf("biz=%s AND biz_id=%d", biz, bizId))
 	return err
 }
@@ -0,0 +1,367 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"github.com/elastic/go-elasticsearch/v8/esutil"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// esFacetSize 每个分面最多返回的取值个数
+	esFacetSize = 50
+	// esFragmentSize es 按字符计算片段长度
+	esFragmentSize = 100
+)
+
+// esDo 检查响应状态, out 不为空时解析响应体
+func esDo(res *esapi.Response, err error, out any) error {
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("es: %s %s", res.Status(), body)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+type esHit[T any] struct {
+	Source    T                   `json:"_source"`
+	Highlight map[string][]string `json:"highlight"`
+}
+
+type esBucket struct {
+	Key      json.RawMessage `json:"key"`
+	DocCount int64           `json:"doc_count"`
+}
+
+type esSearchResponse[T any] struct {
+	Hits struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []esHit[T] `json:"hits"`
+	} `json:"hits"`
+	Aggregations map[string]struct {
+		Buckets []esBucket `json:"buckets"`
+	} `json:"aggregations"`
+}
+
+func esSearch[T any](ctx context.Context, cli *elasticsearch.Client, index string, body map[string]any) (esSearchResponse[T], error) {
+	var resp esSearchResponse[T]
+	res, err := cli.Search(
+		cli.Search.WithContext(ctx),
+		cli.Search.WithIndex(index),
+		cli.Search.WithBody(esutil.NewJSONReader(body)),
+	)
+	err = esDo(res, err, &resp)
+	return resp, err
+}
+
+// facets 把 terms 聚合转成与 meilisearch 一致的分面格式, 取值统一为字符串
+func (r esSearchResponse[T]) facets() map[string]map[string]int64 {
+	if len(r.Aggregations) == 0 {
+		return nil
+	}
+	res := make(map[string]map[string]int64, len(r.Aggregations))
+	for name, agg := range r.Aggregations {
+		values := make(map[string]int64, len(agg.Buckets))
+		for _, bucket := range agg.Buckets {
+			key := string(bucket.Key)
+			if unquoted, err := strconv.Unquote(key); err == nil {
+				key = unquoted
+			}
+			values[key] = bucket.DocCount
+		}
+		res[name] = values
+	}
+	return res
+}
+
+// esHighlights 高亮片段使用与 meilisearch 相同的标记, 多个片段用省略号连接
+func esHighlights(hl map[string][]string, fields ...string) map[string]string {
+	if len(hl) == 0 {
+		return nil
+	}
+	formatted := make(map[string]any, len(hl))
+	for field, fragments := range hl {
+		formatted[field] = strings.Join(fragments, "…")
+	}
+	return highlights(formatted, fields...)
+}
+
+func esHighlight(fields map[string]any) map[string]any {
+	return map[string]any{
+		"pre_tags":  []string{highlightPreTag},
+		"post_tags": []string{highlightPostTag},
+		"fields":    fields,
+	}
+}
+
+// esSort 把 meilisearch 的 field:direction 排序规则转成 es 格式
+func esSort(rules []string) []map[string]string {
+	if len(rules) == 0 {
+		return nil
+	}
+	res := make([]map[string]string, 0, len(rules))
+	for _, rule := range rules {
+		field, direction, _ := strings.Cut(rule, ":")
+		res = append(res, map[string]string{field: direction})
+	}
+	return res
+}
+
+func esTerm(field string, value any) map[string]any {
+	return map[string]any{"term": map[string]any{field: value}}
+}
+
+func esIdsQuery(ids []int64) map[string]any {
+	return map[string]any{
+		"size":  len(ids),
+		"query": map[string]any{"terms": map[string]any{"id": ids}},
+	}
+}
+
+func esBulkIndex[T any](ctx context.Context, cli *elasticsearch.Client, index string, docs []T, id func(T) int64) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	for _, doc := range docs {
+		if err := enc.Encode(map[string]any{"index": map[string]any{"_id": strconv.FormatInt(id(doc), 10)}}); err != nil {
+			return err
+		}
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+	}
+	return esBulk(ctx, cli, index, buf)
+}
+
+// esBulkUpdate 只更新 docs 中的字段, 文档不存在时该条更新失败
+func esBulkUpdate[T any](ctx context.Context, cli *elasticsearch.Client, index string, docs []T, id func(T) int64) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	for _, doc := range docs {
+		if err := enc.Encode(map[string]any{"update": map[string]any{"_id": strconv.FormatInt(id(doc), 10)}}); err != nil {
+			return err
+		}
+		if err := enc.Encode(map[string]any{"doc": doc}); err != nil {
+			return err
+		}
+	}
+	return esBulk(ctx, cli, index, buf)
+}
+
+func esBulkDelete(ctx context.Context, cli *elasticsearch.Client, index string, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	for _, id := range ids {
+		if err := enc.Encode(map[string]any{"delete": map[string]any{"_id": strconv.FormatInt(id, 10)}}); err != nil {
+			return err
+		}
+	}
+	return esBulk(ctx, cli, index, buf)
+}
+
+// esBulk bulk 请求部分失败时状态码依然是 200, 需要检查 errors
+func esBulk(ctx context.Context, cli *elasticsearch.Client, index string, body io.Reader) error {
+	var resp struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	res, err := cli.Bulk(body,
+		cli.Bulk.WithContext(ctx),
+		cli.Bulk.WithIndex(index),
+	)
+	if err = esDo(res, err, &resp); err != nil || !resp.Errors {
+		return err
+	}
+	for _, item := range resp.Items {
+		for action, res := range item {
+			if len(res.Error) > 0 {
+				return fmt.Errorf("es: bulk %s failed, status %d: %s", action, res.Status, res.Error)
+			}
+		}
+	}
+	return errors.New("es: bulk failed")
+}
+
+func esDeleteByQuery(ctx context.Context, cli *elasticsearch.Client, index string, query map[string]any) error {
+	res, err := cli.DeleteByQuery([]string{index},
+		esutil.NewJSONReader(map[string]any{"query": query}),
+		cli.DeleteByQuery.WithContext(ctx),
+		cli.DeleteByQuery.WithConflicts("proceed"),
+	)
+	return esDo(res, err, nil)
+}
+
+// esAnalyzer 定义文件默认使用 ik 分词, 没有安装 ik 插件时可以换成 smartcn
+func esAnalyzer(body, analyzer string) string {
+	if analyzer != "smartcn" {
+		return body
+	}
+	return strings.NewReplacer(`"ik_max_word"`, `"smartcn"`, `"ik_smart"`, `"smartcn"`).Replace(body)
+}
+
+// esIndexName 实际的索引名带上定义内容的摘要, 读写都通过别名,
+// 全量重建时会在后面再加上时间戳, 定义相同的索引都以它为前缀
+func esIndexName(alias, body string) string {
+	sum := sha1.Sum([]byte(body))
+	return alias + "_" + hex.EncodeToString(sum[:4])
+}
+
+// ensureEsIndex 定义变化时新建索引, 把旧索引的数据 reindex 过去后原子地切换别名,
+// 切换前读写都走旧索引. 切换后再补一次 reindex, 只创建新索引中不存在的文档,
+// 补上第一次 reindex 期间写入旧索引的数据, 最后删除旧索引
+func ensureEsIndex(ctx context.Context, cli *elasticsearch.Client, alias, body string) error {
+	index := esIndexName(alias, body)
+	olds, err := esAliasIndexes(ctx, cli, alias)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(olds, func(old string) bool {
+		return strings.HasPrefix(old, index)
+	}) {
+		return nil
+	}
+	if len(olds) == 0 {
+		// 早期版本直接用别名作为索引名创建
+		exists, err := esIndexExists(ctx, cli, alias)
+		if err != nil {
+			return err
+		}
+		if exists {
+			olds = []string{alias}
+		}
+	}
+
+	exists, err := esIndexExists(ctx, cli, index)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		res, err := cli.Indices.Create(index,
+			cli.Indices.Create.WithContext(ctx),
+			cli.Indices.Create.WithBody(strings.NewReader(body)),
+		)
+		if err = esDo(res, err, nil); err != nil {
+			return err
+		}
+	}
+	for _, old := range olds {
+		if err = esReindex(ctx, cli, old, index, false); err != nil {
+			return err
+		}
+	}
+
+	actions := []map[string]any{{"add": map[string]any{"index": index, "alias": alias}}}
+	for _, old := range olds {
+		if old == alias {
+			// 同名索引需要在添加别名的同一个请求中删除
+			actions = append(actions, map[string]any{"remove_index": map[string]any{"index": old}})
+		} else {
+			actions = append(actions, map[string]any{"remove": map[string]any{"index": old, "alias": alias}})
+		}
+	}
+	res, err := cli.Indices.UpdateAliases(esutil.NewJSONReader(map[string]any{"actions": actions}),
+		cli.Indices.UpdateAliases.WithContext(ctx),
+	)
+	if err = esDo(res, err, nil); err != nil {
+		return err
+	}
+
+	for _, old := range olds {
+		if old == alias {
+			continue
+		}
+		if err = esReindex(ctx, cli, old, index, true); err != nil {
+			return err
+		}
+		res, err := cli.Indices.Delete([]string{old}, cli.Indices.Delete.WithContext(ctx))
+		if err = esDo(res, err, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// esReindex onlyMissing 时不覆盖新索引中已有的文档
+func esReindex(ctx context.Context, cli *elasticsearch.Client, src, dest string, onlyMissing bool) error {
+	destBody := map[string]any{"index": dest}
+	body := map[string]any{"source": map[string]any{"index": src}, "dest": destBody}
+	if onlyMissing {
+		destBody["op_type"] = "create"
+		body["conflicts"] = "proceed"
+	}
+	res, err := cli.Reindex(esutil.NewJSONReader(body),
+		cli.Reindex.WithContext(ctx),
+		cli.Reindex.WithWaitForCompletion(true),
+	)
+	return esDo(res, err, nil)
+}
+
+func esAliasIndexes(ctx context.Context, cli *elasticsearch.Client, alias string) ([]string, error) {
+	res, err := cli.Indices.GetAlias(
+		cli.Indices.GetAlias.WithContext(ctx),
+		cli.Indices.GetAlias.WithName(alias),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, nil
+	}
+	var resp map[string]json.RawMessage
+	if err = esDo(res, nil, &resp); err != nil {
+		return nil, err
+	}
+	indexes := make([]string, 0, len(resp))
+	for index := range resp {
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+func esIndexExists(ctx context.Context, cli *elasticsearch.Client, index string) (bool, error) {
+	res, err := cli.Indices.Exists([]string{index}, cli.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("es: check index %s: %s", index, res.Status())
+	}
+}
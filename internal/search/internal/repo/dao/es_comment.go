@@ -0,0 +1,77 @@
+package dao
+
+import (
+	"context"
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+type EsCommentDAO struct {
+	cli   *elasticsearch.Client
+	index string
+}
+
+func NewEsCommentDAO(cli *elasticsearch.Client) CommentDAO {
+	return &EsCommentDAO{cli: cli, index: CommentIndexName}
+}
+
+func (e *EsCommentDAO) Search(ctx context.Context, keyword string, offset, limit int) ([]Comment, error) {
+	resp, err := esSearch[Comment](ctx, e.cli, e.index, map[string]any{
+		"from":  offset,
+		"size":  limit,
+		"query": map[string]any{"match": map[string]any{"content": keyword}},
+		"highlight": esHighlight(map[string]any{
+			"content": map[string]any{"fragment_size": esFragmentSize, "number_of_fragments": 1},
+		}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Hits.Hits) == 0 {
+		return nil, nil
+	}
+	comments := make([]Comment, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		comment := hit.Source
+		comment.Highlights = esHighlights(hit.Highlight, "content")
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+func (e *EsCommentDAO) FindByIds(ctx context.Context, ids []int64) (map[int64]Comment, error) {
+	resp, err := esSearch[Comment](ctx, e.cli, e.index, esIdsQuery(ids))
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int64]Comment, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		res[hit.Source.Id] = hit.Source
+	}
+	return res, nil
+}
+
+func (e *EsCommentDAO) Input(ctx context.Context, comments []Comment) error {
+	return esBulkIndex(ctx, e.cli, e.index, comments, func(comment Comment) int64 {
+		return comment.Id
+	})
+}
+
+func (e *EsCommentDAO) DeleteByIds(ctx context.Context, ids []int64) error {
+	return esBulkDelete(ctx, e.cli, e.index, ids)
+}
+
+func (e *EsCommentDAO) DeleteChildComments(ctx context.Context, id int64) error {
+	return esDeleteByQuery(ctx, e.cli, e.index, map[string]any{
+		"bool": map[string]any{
+			"should": []map[string]any{esTerm("root_id", id), esTerm("parent_id", id)},
+		},
+	})
+}
+
+func (e *EsCommentDAO) DeleteByBiz(ctx context.Context, biz string, bizId int64) error {
+	return esDeleteByQuery(ctx, e.cli, e.index, map[string]any{
+		"bool": map[string]any{
+			"filter": []map[string]any{esTerm("biz", biz), esTerm("biz_id", bizId)},
+		},
+	})
+}
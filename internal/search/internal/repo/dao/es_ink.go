@@ -0,0 +1,118 @@
+package dao
+
+import (
+	"context"
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+type EsInkDAO struct {
+	cli   *elasticsearch.Client
+	index string
+}
+
+func NewEsInkDAO(cli *elasticsearch.Client) InkDAO {
+	return &EsInkDAO{cli: cli, index: InkIndexName}
+}
+
+func (e *EsInkDAO) Search(ctx context.Context, query InkQuery) (InkSearchResult, error) {
+	resp, err := esSearch[Ink](ctx, e.cli, e.index, esInkQuery(query))
+	if err != nil {
+		return InkSearchResult{}, err
+	}
+	result := InkSearchResult{
+		Total:  resp.Hits.Total.Value,
+		Facets: resp.facets(),
+	}
+	for _, hit := range resp.Hits.Hits {
+		ink := hit.Source
+		ink.Highlights = esHighlights(hit.Highlight, "title", "content")
+		result.Inks = append(result.Inks, ink)
+	}
+	return result, nil
+}
+
+// esInkQuery 过滤、排序和分面与 meilisearch 的实现保持一致
+func esInkQuery(query InkQuery) map[string]any {
+	filters := make([]map[string]any, 0)
+	for _, tag := range query.Tags {
+		filters = append(filters, esTerm("tags", tag))
+	}
+	if query.AuthorId > 0 {
+		filters = append(filters, esTerm("author_id", query.AuthorId))
+	}
+	if query.ContentType > 0 {
+		filters = append(filters, esTerm("content_type", query.ContentType))
+	}
+	if query.CategoryId > 0 {
+		filters = append(filters, esTerm("category_id", query.CategoryId))
+	}
+	createdRange := map[string]any{}
+	if query.CreatedAfter > 0 {
+		createdRange["gte"] = query.CreatedAfter
+	}
+	if query.CreatedBefore > 0 {
+		createdRange["lt"] = query.CreatedBefore
+	}
+	if len(createdRange) > 0 {
+		filters = append(filters, map[string]any{"range": map[string]any{"created_ts": createdRange}})
+	}
+
+	boolQuery := map[string]any{"filter": filters}
+	if query.Keyword != "" {
+		boolQuery["must"] = map[string]any{
+			"multi_match": map[string]any{
+				"query":  query.Keyword,
+				"fields": []string{"title^3", "summary^2", "content", "tags^2", "ai_tags"},
+			},
+		}
+	}
+	body := map[string]any{
+		"from":             query.Offset,
+		"size":             query.Limit,
+		"track_total_hits": true,
+		"query":            map[string]any{"bool": boolQuery},
+		"highlight": esHighlight(map[string]any{
+			"title":   map[string]any{"number_of_fragments": 0},
+			"content": map[string]any{"fragment_size": esFragmentSize, "number_of_fragments": 1},
+		}),
+	}
+	if sort := esSort(inkSortRules(query.Sort)); sort != nil {
+		body["sort"] = sort
+	}
+	if len(query.Facets) > 0 {
+		aggs := make(map[string]any, len(query.Facets))
+		for _, facet := range query.Facets {
+			aggs[facet] = map[string]any{"terms": map[string]any{"field": facet, "size": esFacetSize}}
+		}
+		body["aggs"] = aggs
+	}
+	return body
+}
+
+func (e *EsInkDAO) FindByIds(ctx context.Context, ids []int64) (map[int64]Ink, error) {
+	resp, err := esSearch[Ink](ctx, e.cli, e.index, esIdsQuery(ids))
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int64]Ink, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		res[hit.Source.Id] = hit.Source
+	}
+	return res, nil
+}
+
+func (e *EsInkDAO) InputInk(ctx context.Context, inks []Ink) error {
+	return esBulkIndex(ctx, e.cli, e.index, inks, func(ink Ink) int64 {
+		return ink.Id
+	})
+}
+
+func (e *EsInkDAO) UpdateStats(ctx context.Context, stats []InkStats) error {
+	return esBulkUpdate(ctx, e.cli, e.index, stats, func(item InkStats) int64 {
+		return item.Id
+	})
+}
+
+func (e *EsInkDAO) DeleteInk(ctx context.Context, ids []int64) error {
+	return esBulkDelete(ctx, e.cli, e.index, ids)
+}
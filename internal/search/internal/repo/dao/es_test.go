@@ -35,9 +35,11 @@ func (s *EsTestSuite) SetupTest() {
 func (s *EsTestSuite) TestCreateIndex() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	def, err := parseIndexDefinition(userIndexMapping)
+	require.NoError(s.T(), err)
 	res, err := s.es.Indices.Create("user_idx",
 		s.es.Indices.Create.WithContext(ctx),
-		s.es.Indices.Create.WithBody(strings.NewReader(userIndexMapping)),
+		s.es.Indices.Create.WithBody(strings.NewReader(def.esBody())),
 	)
 	require.NoError(s.T(), err)
 	require.NotNil(s.T(), res)
@@ -0,0 +1,66 @@
+package dao
+
+import (
+	"context"
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+type EsUserDAO struct {
+	cli   *elasticsearch.Client
+	index string
+}
+
+func NewEsUserDAO(cli *elasticsearch.Client) UserDAO {
+	return &EsUserDAO{cli: cli, index: UserIndexName}
+}
+
+// Search 最后一个词按前缀匹配, 与 meilisearch 输入即搜索的效果接近
+func (e *EsUserDAO) Search(ctx context.Context, query string, offset, limit int) ([]User, error) {
+	resp, err := esSearch[User](ctx, e.cli, e.index, map[string]any{
+		"from": offset,
+		"size": limit,
+		"query": map[string]any{
+			"multi_match": map[string]any{
+				"query":  query,
+				"type":   "bool_prefix",
+				"fields": []string{"account^2", "username^2", "about_me"},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Hits.Hits) == 0 {
+		return nil, nil
+	}
+	users := make([]User, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		users = append(users, hit.Source)
+	}
+	return users, nil
+}
+
+func (e *EsUserDAO) SearchByIds(ctx context.Context, ids []int64) (map[int64]User, error) {
+	resp, err := esSearch[User](ctx, e.cli, e.index, esIdsQuery(ids))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Hits.Hits) == 0 {
+		return nil, nil
+	}
+	userMap := make(map[int64]User, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		userMap[hit.Source.Id] = hit.Source
+	}
+	return userMap, nil
+}
+
+func (e *EsUserDAO) InputUser(ctx context.Context, users []User) error {
+	return esBulkIndex(ctx, e.cli, e.index, users, func(user User) int64 {
+		return user.Id
+	})
+}
+
+func (e *EsUserDAO) DeleteUser(ctx context.Context, userIds []int64) error {
+	return esBulkDelete(ctx, e.cli, e.index, userIds)
+}
@@ -0,0 +1,33 @@
+package dao
+
+import (
+	"html"
+	"strings"
+)
+
+// meilisearch 不会转义高亮片段中的 html, 先用不可见字符标记命中的词,
+// 转义后再替换为 <em>, 避免索引内容中的 html 被前端渲染
+const (
+	highlightPreTag  = "\x02"
+	highlightPostTag = "\x03"
+	// highlightCropLength 正文片段保留的词数
+	highlightCropLength = 30
+)
+
+// highlights 从 meilisearch 返回的 _formatted 中取出指定字段的高亮片段
+func highlights(formatted map[string]any, fields ...string) map[string]string {
+	if len(formatted) == 0 {
+		return nil
+	}
+	res := make(map[string]string, len(fields))
+	for _, field := range fields {
+		fragment, ok := formatted[field].(string)
+		if !ok || !strings.Contains(fragment, highlightPreTag) {
+			continue
+		}
+		fragment = html.EscapeString(fragment)
+		fragment = strings.ReplaceAll(fragment, highlightPreTag, "<em>")
+		res[field] = strings.ReplaceAll(fragment, highlightPostTag, "</em>")
+	}
+	return res
+}
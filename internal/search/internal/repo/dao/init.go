@@ -3,11 +3,12 @@ package dao
 import (
 	"context"
 	_ "embed"
+	"encoding/json"
+	"fmt"
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/meilisearch/meilisearch-go"
 	"golang.org/x/sync/errgroup"
-	"net/http"
-	"strings"
+	"gorm.io/gorm"
 	"time"
 )
 
@@ -16,102 +17,146 @@ var (
 	userIndexMapping string
 	//go:embed ink_index.json
 	inkIndexMapping string
+	//go:embed comment_index.json
+	commentIndexMapping string
 )
 
-const (
-	userIndexName    = "user_index"
-	commentIndexName = "comment_index"
-	inkIndexName     = "ink_index"
-)
+// indexDefinition 索引定义文件, mappings 用于创建 es 索引, meili 为 meilisearch 的索引设置,
+// 每次启动时都会同步到 meilisearch, 修改可搜索/过滤/排序字段只需要改定义文件
+type indexDefinition struct {
+	Mappings json.RawMessage      `json:"mappings"`
+	Meili    meilisearch.Settings `json:"meili"`
+}
 
-func InitEs(client *elasticsearch.Client) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	eg := errgroup.Group{}
-	eg.Go(func() error {
-		return tryCreateIndex(ctx, client, userIndexName, userIndexMapping)
-	})
-	eg.Go(func() error {
-		return tryCreateIndex(ctx, client, inkIndexName, inkIndexMapping)
+// findIndexDefinition name 为索引名或别名
+func findIndexDefinition(name string) (indexDefinition, error) {
+	for _, idx := range indexDefinitions {
+		if idx.name == name {
+			return parseIndexDefinition(idx.definition)
+		}
+	}
+	return indexDefinition{}, fmt.Errorf("unknown index %s", name)
+}
+
+func parseIndexDefinition(raw string) (indexDefinition, error) {
+	var def indexDefinition
+	err := json.Unmarshal([]byte(raw), &def)
+	return def, err
+}
+
+// esBody 只保留 es 能识别的部分
+func (d indexDefinition) esBody() string {
+	body, _ := json.Marshal(map[string]json.RawMessage{
+		"mappings": d.Mappings,
 	})
-	return eg.Wait()
+	return string(body)
+}
+
+// IndexTuning 通过配置覆盖定义文件中的拼写容错和停用词设置, 为空的项不覆盖
+type IndexTuning struct {
+	StopWords     []string             `mapstructure:"stop_words"`
+	TypoTolerance *TypoToleranceTuning `mapstructure:"typo_tolerance"`
 }
 
-func tryCreateIndex(ctx context.Context,
-	client *elasticsearch.Client,
-	indexName string,
-	indexMap string) error {
-	resp, err := client.Indices.Exists([]string{indexName})
-	if err != nil {
-		return err
+// TypoToleranceTuning 在定义文件的基础上合并, 未配置的项保持原样.
+// Enabled 为指针, 只配置词长时不会把拼写容错关掉
+type TypoToleranceTuning struct {
+	Enabled *bool `mapstructure:"enabled"`
+	// OneTypo 允许一个拼写错误的最小词长, TwoTypos 同理
+	OneTypo             int64    `mapstructure:"one_typo"`
+	TwoTypos            int64    `mapstructure:"two_typos"`
+	DisableOnAttributes []string `mapstructure:"disable_on_attributes"`
+}
+
+func (t IndexTuning) apply(settings *meilisearch.Settings) {
+	if len(t.StopWords) > 0 {
+		settings.StopWords = t.StopWords
 	}
-	if resp.StatusCode == http.StatusOK {
-		return nil
+	if t.TypoTolerance == nil {
+		return
 	}
-
-	_, err = client.Indices.Create(indexName,
-		client.Indices.Create.WithContext(ctx),
-		client.Indices.Create.WithBody(strings.NewReader(indexMap)),
-	)
-	if err != nil {
-		return err
+	typo := settings.TypoTolerance
+	if typo == nil {
+		// 定义文件没有设置时和 meilisearch 的默认值一致
+		typo = &meilisearch.TypoTolerance{Enabled: true}
+		settings.TypoTolerance = typo
+	}
+	if t.TypoTolerance.Enabled != nil {
+		typo.Enabled = *t.TypoTolerance.Enabled
+	}
+	if t.TypoTolerance.OneTypo > 0 {
+		typo.MinWordSizeForTypos.OneTypo = t.TypoTolerance.OneTypo
+	}
+	if t.TypoTolerance.TwoTypos > 0 {
+		typo.MinWordSizeForTypos.TwoTypos = t.TypoTolerance.TwoTypos
+	}
+	if len(t.TypoTolerance.DisableOnAttributes) > 0 {
+		typo.DisableOnAttributes = t.TypoTolerance.DisableOnAttributes
 	}
-	return nil
 }
 
-func InitMeili(cli meilisearch.ServiceManager) error {
-	_, err := cli.CreateIndex(&meilisearch.IndexConfig{
-		Uid:        userIndexName,
-		PrimaryKey: "id",
-	})
-	if err != nil {
-		return err
+const (
+	UserIndexName    = "user_index"
+	CommentIndexName = "comment_index"
+	InkIndexName     = "ink_index"
+)
+
+var indexDefinitions = []struct {
+	name       string
+	definition string
+}{
+	{name: UserIndexName, definition: userIndexMapping},
+	{name: InkIndexName, definition: inkIndexMapping},
+	{name: CommentIndexName, definition: commentIndexMapping},
+}
+
+// InitEs 索引通过别名访问, 定义变化时需要 reindex, 超时时间放宽
+func InitEs(client *elasticsearch.Client, analyzer string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	eg := errgroup.Group{}
+	for _, idx := range indexDefinitions {
+		eg.Go(func() error {
+			def, err := parseIndexDefinition(idx.definition)
+			if err != nil {
+				return err
+			}
+			return ensureEsIndex(ctx, client, idx.name, esAnalyzer(def.esBody(), analyzer))
+		})
 	}
-	_, err = cli.CreateIndex(&meilisearch.IndexConfig{
-		Uid:        inkIndexName,
-		PrimaryKey: "id",
-	})
-	if err != nil {
-		return err
+	return eg.Wait()
+}
+
+// InitMeili tuning 以索引名为 key
+func InitMeili(cli meilisearch.ServiceManager, tuning map[string]IndexTuning) error {
+	for _, idx := range indexDefinitions {
+		_, err := cli.CreateIndex(&meilisearch.IndexConfig{
+			Uid:        idx.name,
+			PrimaryKey: "id",
+		})
+		if err != nil {
+			return err
+		}
 	}
 
 	time.Sleep(time.Second * 3)
-	return initIndexSetting(cli)
+	return initIndexSetting(cli, tuning)
 }
 
-func initIndexSetting(cli meilisearch.ServiceManager) error {
-	// 设置可搜索属性
-	_, err := cli.Index(userIndexName).UpdateSearchableAttributes(&[]string{
-		"account",
-		"username",
-		"about_me",
-	})
-	if err != nil {
-		return err
-	}
-	// 设置可过滤属性, 通过类sql语法进行过滤
-	_, err = cli.Index(userIndexName).UpdateFilterableAttributes(&[]string{
-		"id",
-		"account",
-	})
-	if err != nil {
-		return err
-	}
-	_, err = cli.Index(inkIndexName).UpdateSearchableAttributes(&[]string{
-		"title",
-		"content",
-		"tags",
-		"ai_tags",
-	})
-	if err != nil {
-		return err
+func initIndexSetting(cli meilisearch.ServiceManager, tuning map[string]IndexTuning) error {
+	for _, idx := range indexDefinitions {
+		def, err := parseIndexDefinition(idx.definition)
+		if err != nil {
+			return err
+		}
+		tuning[idx.name].apply(&def.Meili)
+		if _, err = cli.Index(idx.name).UpdateSettings(&def.Meili); err != nil {
+			return err
+		}
 	}
-	_, err = cli.Index(inkIndexName).UpdateFilterableAttributes(&[]string{
-		"id",
-		"tags",
-	})
-	_, err = cli.Index(commentIndexName).UpdateFilterableAttributes(&[]string{
-		"content",
-	})
-	return err
+	return nil
+}
+
+func InitTables(db *gorm.DB) error {
+	return db.AutoMigrate(&SearchLog{}, &SearchQueryHourly{})
 }
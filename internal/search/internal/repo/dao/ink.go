@@ -2,62 +2,193 @@ package dao
 
 import (
 	"context"
+	"fmt"
 	"github.com/KNICEX/InkFlow/pkg/mapstructurex"
 	"github.com/meilisearch/meilisearch-go"
 	"github.com/samber/lo"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// Ink CreatedTs 为创建时间的秒级时间戳, 用于按时间过滤和排序
 type Ink struct {
-	Id        int64     `json:"id" mapstructure:"id"`
-	Title     string    `json:"title" mapstructure:"title"`
-	Cover     string    `json:"cover" mapstructure:"cover"`
-	AuthorId  int64     `json:"author_id" mapstructure:"author_id"`
-	Content   string    `json:"content" mapstructure:"content"`
-	Tags      []string  `json:"tags" mapstructure:"tags"`
-	AiTags    []string  `json:"ai_tags" mapstructure:"ai_tags"`
-	CreatedAt time.Time `json:"created_at" mapstructure:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" mapstructure:"updated_at"`
+	Id          int64     `json:"id" mapstructure:"id"`
+	Title       string    `json:"title" mapstructure:"title"`
+	Cover       string    `json:"cover" mapstructure:"cover"`
+	AuthorId    int64     `json:"author_id" mapstructure:"author_id"`
+	Summary     string    `json:"summary" mapstructure:"summary"`
+	Content     string    `json:"content" mapstructure:"content"`
+	Tags        []string  `json:"tags" mapstructure:"tags"`
+	AiTags      []string  `json:"ai_tags" mapstructure:"ai_tags"`
+	ContentType int       `json:"content_type" mapstructure:"content_type"`
+	CategoryId  int64     `json:"category_id" mapstructure:"category_id"`
+	ViewCnt     int64     `json:"view_cnt" mapstructure:"view_cnt"`
+	LikeCnt     int64     `json:"like_cnt" mapstructure:"like_cnt"`
+	WordCount   int       `json:"word_count" mapstructure:"word_count"`
+	ReadingTime int       `json:"reading_time" mapstructure:"reading_time"`
+	CreatedTs   int64     `json:"created_ts" mapstructure:"created_ts"`
+	CreatedAt   time.Time `json:"created_at" mapstructure:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" mapstructure:"updated_at"`
+	// Formatted 搜索时返回的高亮结果, 不写入索引
+	Formatted  map[string]any    `json:"-" mapstructure:"_formatted"`
+	Highlights map[string]string `json:"-" mapstructure:"-"`
+}
+
+// InkStats 互动计数, 更新时只写这几个字段, 不影响文档的其他内容
+type InkStats struct {
+	Id      int64 `json:"id"`
+	ViewCnt int64 `json:"view_cnt"`
+	LikeCnt int64 `json:"like_cnt"`
+}
+
+// InkQuery 见 domain.InkQuery, 时间范围为秒级时间戳, 0 表示不限制
+type InkQuery struct {
+	Keyword       string
+	Tags          []string
+	AuthorId      int64
+	ContentType   int
+	CategoryId    int64
+	CreatedAfter  int64
+	CreatedBefore int64
+	Sort          string
+	Facets        []string
+	Offset        int
+	Limit         int
+}
+
+type InkSearchResult struct {
+	Inks   []Ink
+	Total  int64
+	Facets map[string]map[string]int64
 }
 
 type InkDAO interface {
-	Search(ctx context.Context, key string, offset int, limit int) ([]Ink, error)
+	Search(ctx context.Context, query InkQuery) (InkSearchResult, error)
+	FindByIds(ctx context.Context, ids []int64) (map[int64]Ink, error)
 	InputInk(ctx context.Context, inks []Ink) error
+	// UpdateStats 部分更新, 文档不存在时 meilisearch 会新建只有这几个字段的文档, 调用方需要先过滤
+	UpdateStats(ctx context.Context, stats []InkStats) error
 	DeleteInk(ctx context.Context, ids []int64) error
 }
 
 type MeiliInkDAO struct {
-	cli meilisearch.ServiceManager
+	cli   meilisearch.ServiceManager
+	index string
 }
 
 func NewMeiliInkDAO(cli meilisearch.ServiceManager) InkDAO {
-	return &MeiliInkDAO{cli: cli}
+	return &MeiliInkDAO{cli: cli, index: InkIndexName}
 }
 
-func (m *MeiliInkDAO) Search(ctx context.Context, key string, offset int, limit int) ([]Ink, error) {
-	res, err := m.cli.Index(inkIndexName).SearchWithContext(ctx, key, &meilisearch.SearchRequest{
-		Limit:  int64(limit),
-		Offset: int64(offset),
-	})
+func (m *MeiliInkDAO) Search(ctx context.Context, query InkQuery) (InkSearchResult, error) {
+	req := &meilisearch.SearchRequest{
+		Limit:  int64(query.Limit),
+		Offset: int64(query.Offset),
+		Facets: query.Facets,
+		Sort:   inkSortRules(query.Sort),
+
+		AttributesToHighlight: []string{"title", "content"},
+		AttributesToCrop:      []string{"content"},
+		CropLength:            highlightCropLength,
+		HighlightPreTag:       highlightPreTag,
+		HighlightPostTag:      highlightPostTag,
+	}
+	if filter := inkFilter(query); filter != "" {
+		req.Filter = filter
+	}
+	res, err := m.cli.Index(m.index).SearchWithContext(ctx, query.Keyword, req)
 	if err != nil {
-		return nil, err
+		return InkSearchResult{}, err
+	}
+	result := InkSearchResult{Total: res.EstimatedTotalHits}
+	if res.FacetDistribution != nil {
+		if err = mapstructurex.Decode(res.FacetDistribution, &result.Facets); err != nil {
+			return InkSearchResult{}, err
+		}
 	}
 	if len(res.Hits) == 0 {
-		return nil, nil
+		return result, nil
+	}
+	if err = mapstructurex.Decode(res.Hits, &result.Inks); err != nil {
+		return InkSearchResult{}, err
+	}
+	for i := range result.Inks {
+		result.Inks[i].Highlights = highlights(result.Inks[i].Formatted, "title", "content")
+	}
+	return result, nil
+}
+
+// inkSortRules 字段需要在 ink_index.json 中声明为可排序, 相关性排序不需要额外规则
+func inkSortRules(sort string) []string {
+	switch sort {
+	case "newest":
+		return []string{"created_ts:desc"}
+	case "most_liked":
+		return []string{"like_cnt:desc", "view_cnt:desc"}
+	default:
+		return nil
+	}
+}
+
+// inkFilter 拼接 meilisearch 的过滤表达式, 字段需要在 ink_index.json 中声明为可过滤
+func inkFilter(query InkQuery) string {
+	var conds []string
+	for _, tag := range query.Tags {
+		conds = append(conds, "tags = "+quoteFilterValue(tag))
+	}
+	if query.AuthorId > 0 {
+		conds = append(conds, fmt.Sprintf("author_id = %d", query.AuthorId))
+	}
+	if query.ContentType > 0 {
+		conds = append(conds, fmt.Sprintf("content_type = %d", query.ContentType))
+	}
+	if query.CategoryId > 0 {
+		conds = append(conds, fmt.Sprintf("category_id = %d", query.CategoryId))
+	}
+	if query.CreatedAfter > 0 {
+		conds = append(conds, fmt.Sprintf("created_ts >= %d", query.CreatedAfter))
+	}
+	if query.CreatedBefore > 0 {
+		conds = append(conds, fmt.Sprintf("created_ts < %d", query.CreatedBefore))
+	}
+	return strings.Join(conds, " AND ")
+}
+
+func quoteFilterValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	return `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+}
+
+func (m *MeiliInkDAO) FindByIds(ctx context.Context, ids []int64) (map[int64]Ink, error) {
+	res, err := m.cli.Index(m.index).SearchWithContext(ctx, "", &meilisearch.SearchRequest{
+		Filter: idsFilter(ids),
+		Limit:  int64(len(ids)),
+	})
+	if err != nil {
+		return nil, err
 	}
 	var inks []Ink
-	err = mapstructurex.Decode(res.Hits, &inks)
-	return inks, err
+	if err = mapstructurex.Decode(res.Hits, &inks); err != nil {
+		return nil, err
+	}
+	return lo.SliceToMap(inks, func(item Ink) (int64, Ink) {
+		return item.Id, item
+	}), nil
 }
 
 func (m *MeiliInkDAO) InputInk(ctx context.Context, inks []Ink) error {
-	_, err := m.cli.Index(inkIndexName).AddDocumentsWithContext(ctx, inks)
+	_, err := m.cli.Index(m.index).AddDocumentsWithContext(ctx, inks)
+	return err
+}
+
+func (m *MeiliInkDAO) UpdateStats(ctx context.Context, stats []InkStats) error {
+	_, err := m.cli.Index(m.index).UpdateDocumentsWithContext(ctx, stats)
 	return err
 }
 
 func (m *MeiliInkDAO) DeleteInk(ctx context.Context, ids []int64) error {
-	_, err := m.cli.Index(inkIndexName).DeleteDocumentsWithContext(ctx, lo.Map(ids, func(item int64, index int) string {
+	_, err := m.cli.Index(m.index).DeleteDocumentsWithContext(ctx, lo.Map(ids, func(item int64, index int) string {
 		return strconv.FormatInt(item, 10)
 	}))
 	return err
@@ -0,0 +1,113 @@
+package dao
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInkFilter(t *testing.T) {
+	assert.Equal(t, "", inkFilter(InkQuery{Keyword: "go"}))
+	assert.Equal(t, `tags = "go" AND tags = "say \"hi\"" AND author_id = 1 AND content_type = 2 AND category_id = 3 AND created_ts >= 100 AND created_ts < 200`,
+		inkFilter(InkQuery{
+			Tags:          []string{"go", `say "hi"`},
+			AuthorId:      1,
+			ContentType:   2,
+			CategoryId:    3,
+			CreatedAfter:  100,
+			CreatedBefore: 200,
+		}))
+}
+
+func TestInkIndexDefinition(t *testing.T) {
+	def, err := parseIndexDefinition(inkIndexMapping)
+	require.NoError(t, err)
+	assert.NotEmpty(t, def.Mappings)
+	// 过滤和排序用到的字段必须在定义文件中声明
+	assert.Subset(t, def.Meili.FilterableAttributes, []string{"tags", "author_id", "content_type", "category_id", "created_ts"})
+	for _, sort := range []string{"newest", "most_liked"} {
+		for _, rule := range inkSortRules(sort) {
+			field := rule[:len(rule)-len(":desc")]
+			assert.Contains(t, def.Meili.SortableAttributes, field)
+		}
+	}
+	assert.Nil(t, inkSortRules("relevance"))
+}
+
+func TestIndexTuning_Apply(t *testing.T) {
+	def, err := parseIndexDefinition(inkIndexMapping)
+	require.NoError(t, err)
+	// 只配置词长时保留定义文件中的开关和属性
+	IndexTuning{TypoTolerance: &TypoToleranceTuning{OneTypo: 5, TwoTypos: 9}}.apply(&def.Meili)
+	require.NotNil(t, def.Meili.TypoTolerance)
+	assert.True(t, def.Meili.TypoTolerance.Enabled)
+	assert.Equal(t, int64(5), def.Meili.TypoTolerance.MinWordSizeForTypos.OneTypo)
+	assert.Equal(t, int64(9), def.Meili.TypoTolerance.MinWordSizeForTypos.TwoTypos)
+	assert.Equal(t, []string{"tags", "ai_tags"}, def.Meili.TypoTolerance.DisableOnAttributes)
+
+	disabled := false
+	IndexTuning{TypoTolerance: &TypoToleranceTuning{Enabled: &disabled}}.apply(&def.Meili)
+	assert.False(t, def.Meili.TypoTolerance.Enabled)
+	assert.Equal(t, int64(5), def.Meili.TypoTolerance.MinWordSizeForTypos.OneTypo)
+}
+
+func TestHighlights(t *testing.T) {
+	assert.Nil(t, highlights(nil, "title"))
+	res := highlights(map[string]any{
+		"title":   "学习 \x02Go\x03 <script>",
+		"content": "没有命中",
+		"tags":    []any{"go"},
+	}, "title", "content", "tags")
+	assert.Equal(t, map[string]string{"title": "学习 <em>Go</em> &lt;script&gt;"}, res)
+}
+
+func TestEsInkQuery(t *testing.T) {
+	body := esInkQuery(InkQuery{
+		Keyword:      "go",
+		Tags:         []string{"go"},
+		AuthorId:     1,
+		CreatedAfter: 100,
+		Sort:         "most_liked",
+		Facets:       []string{"tags"},
+		Offset:       10,
+		Limit:        20,
+	})
+	bs, err := json.Marshal(body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"from": 10,
+		"size": 20,
+		"track_total_hits": true,
+		"query": {"bool": {
+			"filter": [
+				{"term": {"tags": "go"}},
+				{"term": {"author_id": 1}},
+				{"range": {"created_ts": {"gte": 100}}}
+			],
+			"must": {"multi_match": {"query": "go", "fields": ["title^3", "summary^2", "content", "tags^2", "ai_tags"]}}
+		}},
+		"highlight": {
+			"pre_tags": ["\u0002"],
+			"post_tags": ["\u0003"],
+			"fields": {
+				"title": {"number_of_fragments": 0},
+				"content": {"fragment_size": 100, "number_of_fragments": 1}
+			}
+		},
+		"sort": [{"like_cnt": "desc"}, {"view_cnt": "desc"}],
+		"aggs": {"tags": {"terms": {"field": "tags", "size": 50}}}
+	}`, string(bs))
+}
+
+func TestEsIndexName(t *testing.T) {
+	def, err := parseIndexDefinition(inkIndexMapping)
+	require.NoError(t, err)
+	body := def.esBody()
+	assert.Equal(t, esIndexName(InkIndexName, body), esIndexName(InkIndexName, body))
+	// 换分词器相当于修改定义, 需要新建索引
+	smartcn := esAnalyzer(body, "smartcn")
+	assert.NotContains(t, smartcn, "ik_")
+	assert.NotEqual(t, esIndexName(InkIndexName, body), esIndexName(InkIndexName, smartcn))
+}
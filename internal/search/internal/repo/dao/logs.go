@@ -1,5 +1,144 @@
 package dao
 
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var ErrRecordNotFound = gorm.ErrRecordNotFound
+
 type SearchLog struct {
-	Id int64
+	Id        int64
+	Uid       int64  `gorm:"index"`
+	Query     string `gorm:"type:varchar(128)"`
+	Type      string `gorm:"type:varchar(16)"`
+	ResultCnt int64
+	ClickedId int64
+	CreatedAt time.Time `gorm:"index"`
+}
+
+// SearchQueryHourly 按小时汇总的搜索词统计, Hour 为整点时间.
+// UserCnt 为这一小时内搜索过的登录用户数, 未登录的搜索不计入
+type SearchQueryHourly struct {
+	Id            int64
+	Hour          time.Time `gorm:"uniqueIndex:hour_query_type"`
+	Query         string    `gorm:"type:varchar(128);uniqueIndex:hour_query_type"`
+	Type          string    `gorm:"type:varchar(16);uniqueIndex:hour_query_type"`
+	SearchCnt     int64
+	ZeroResultCnt int64
+	ClickCnt      int64
+	UserCnt       int64
+}
+
+// QueryStats 汇总查询的结果, UserCnt 为各小时用户数之和, 同一个用户每小时最多计一次
+type QueryStats struct {
+	Query         string
+	Type          string
+	SearchCnt     int64
+	ZeroResultCnt int64
+	ClickCnt      int64
+	UserCnt       int64
+}
+
+const queryStatsSums = "SUM(search_cnt) AS search_cnt, SUM(zero_result_cnt) AS zero_result_cnt, " +
+	"SUM(click_cnt) AS click_cnt, SUM(user_cnt) AS user_cnt"
+
+const queryStatsColumns = "query, type, " + queryStatsSums
+
+type SearchLogDAO interface {
+	InsertBatch(ctx context.Context, logs []SearchLog) error
+	// SetClicked 只记录第一次点击, 搜索记录还未写入时返回 ErrRecordNotFound
+	SetClicked(ctx context.Context, id int64, clickedId int64) error
+	// AggregateHour 重新汇总 [hour, hour+1h) 的搜索记录, 重复执行结果相同
+	AggregateHour(ctx context.Context, hour time.Time) error
+	// FindTopQueries typ 为空时按搜索词合并各类型的统计, 只返回用户数不少于 minUsers 的搜索词
+	FindTopQueries(ctx context.Context, typ string, since time.Time, minUsers int64, limit int) ([]QueryStats, error)
+	FindZeroResultQueries(ctx context.Context, since time.Time, offset, limit int) ([]QueryStats, error)
+}
+
+type GormSearchLogDAO struct {
+	db *gorm.DB
+}
+
+func NewGormSearchLogDAO(db *gorm.DB) SearchLogDAO {
+	return &GormSearchLogDAO{
+		db: db,
+	}
+}
+
+func (dao *GormSearchLogDAO) InsertBatch(ctx context.Context, logs []SearchLog) error {
+	// 消息重复投递时忽略已写入的记录
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&logs).Error
+}
+
+func (dao *GormSearchLogDAO) SetClicked(ctx context.Context, id int64, clickedId int64) error {
+	res := dao.db.WithContext(ctx).Model(&SearchLog{}).
+		Where("id = ? AND clicked_id = 0", id).
+		Update("clicked_id", clickedId)
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.Error
+	}
+	var cnt int64
+	if err := dao.db.WithContext(ctx).Model(&SearchLog{}).Where("id = ?", id).Count(&cnt).Error; err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (dao *GormSearchLogDAO) AggregateHour(ctx context.Context, hour time.Time) error {
+	var rows []SearchQueryHourly
+	err := dao.db.WithContext(ctx).Model(&SearchLog{}).
+		Select("query, type, COUNT(*) AS search_cnt, "+
+			"SUM(CASE WHEN result_cnt = 0 THEN 1 ELSE 0 END) AS zero_result_cnt, "+
+			"SUM(CASE WHEN clicked_id <> 0 THEN 1 ELSE 0 END) AS click_cnt, "+
+			"COUNT(DISTINCT CASE WHEN uid <> 0 THEN uid END) AS user_cnt").
+		Where("created_at >= ? AND created_at < ?", hour, hour.Add(time.Hour)).
+		Group("query, type").
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+	for i := range rows {
+		rows[i].Hour = hour
+	}
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "hour"}, {Name: "query"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"search_cnt", "zero_result_cnt", "click_cnt", "user_cnt"}),
+	}).CreateInBatches(&rows, 500).Error
+}
+
+func (dao *GormSearchLogDAO) FindTopQueries(ctx context.Context, typ string, since time.Time, minUsers int64, limit int) ([]QueryStats, error) {
+	var res []QueryStats
+	tx := dao.db.WithContext(ctx).Model(&SearchQueryHourly{}).
+		Where("hour >= ?", since)
+	if typ != "" {
+		tx = tx.Select(queryStatsColumns).Where("type = ?", typ).Group("query, type")
+	} else {
+		// 用户数跨类型累计后再与 minUsers 比较
+		tx = tx.Select("query, '' AS type, " + queryStatsSums).Group("query")
+	}
+	err := tx.Having("SUM(user_cnt) >= ?", minUsers).
+		Order("search_cnt DESC").
+		Limit(limit).
+		Scan(&res).Error
+	return res, err
+}
+
+func (dao *GormSearchLogDAO) FindZeroResultQueries(ctx context.Context, since time.Time, offset, limit int) ([]QueryStats, error) {
+	var res []QueryStats
+	err := dao.db.WithContext(ctx).Model(&SearchQueryHourly{}).
+		Select(queryStatsColumns).
+		Where("hour >= ?", since).
+		Group("query, type").
+		Having("SUM(zero_result_cnt) > 0").
+		Order("zero_result_cnt DESC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&res).Error
+	return res, err
 }
@@ -0,0 +1,230 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/KNICEX/InkFlow/pkg/mapstructurex"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esutil"
+	"github.com/meilisearch/meilisearch-go"
+	"github.com/samber/lo"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IndexManager 全量重建索引时先把数据写入新索引, 写完后原子地替换正在使用的索引
+type IndexManager interface {
+	// Create 按 name 的定义创建一个空索引, 返回新索引名
+	Create(ctx context.Context, name string) (string, error)
+	// Wait 等待 index 上的写入全部生效, 有写入失败时返回 ErrIndexTaskFailed
+	Wait(ctx context.Context, index string) error
+	// Swap 让 name 指向 index, 返回交换后不再使用的旧索引, 由调用方删除, 为空时不需要删除
+	Swap(ctx context.Context, name, index string) (string, error)
+	// Drop 删除不再使用的索引, 正在使用的索引不会被删除
+	Drop(ctx context.Context, index string) error
+	// Ids 按 id 倒序返回 name 中小于 maxId 的文档 id, 用于抽查索引中多余的文档, maxId 为 0 时不限制
+	Ids(ctx context.Context, name string, maxId int64, limit int) ([]int64, error)
+}
+
+// docId 抽查时只取文档 id
+type docId struct {
+	Id int64 `json:"id" mapstructure:"id"`
+}
+
+// ErrIndexTaskFailed 写入任务执行失败, 重试等待也不会成功
+var ErrIndexTaskFailed = errors.New("index task failed")
+
+func rebuildIndexName(name string) string {
+	return name + "_" + strconv.FormatInt(time.Now().Unix(), 10)
+}
+
+type meiliIndexManager struct {
+	cli    meilisearch.ServiceManager
+	tuning map[string]IndexTuning
+}
+
+func (m *meiliIndexManager) Create(ctx context.Context, name string) (string, error) {
+	def, err := findIndexDefinition(name)
+	if err != nil {
+		return "", err
+	}
+	index := rebuildIndexName(name)
+	_, err = m.cli.CreateIndexWithContext(ctx, &meilisearch.IndexConfig{
+		Uid:        index,
+		PrimaryKey: "id",
+	})
+	if err != nil {
+		return "", err
+	}
+	m.tuning[name].apply(&def.Meili)
+	// meilisearch 按顺序执行同一个索引的任务, 之后写入的文档会使用新的设置
+	_, err = m.cli.Index(index).UpdateSettingsWithContext(ctx, &def.Meili)
+	return index, err
+}
+
+// Wait 写入文档只是提交任务, 等最后一个任务执行完, 再检查是否有失败的任务.
+// 同一个索引的任务按顺序执行, 最后一个任务完成时之前的任务也都完成了
+func (m *meiliIndexManager) Wait(ctx context.Context, index string) error {
+	res, err := m.cli.GetTasksWithContext(ctx, &meilisearch.TasksQuery{
+		IndexUIDS: []string{index},
+		Limit:     1,
+	})
+	if err != nil || len(res.Results) == 0 {
+		return err
+	}
+	if _, err = m.cli.WaitForTaskWithContext(ctx, res.Results[0].UID, time.Second); err != nil {
+		return err
+	}
+	res, err = m.cli.GetTasksWithContext(ctx, &meilisearch.TasksQuery{
+		IndexUIDS: []string{index},
+		Statuses:  []meilisearch.TaskStatus{meilisearch.TaskStatusFailed, meilisearch.TaskStatusCanceled},
+		Limit:     1,
+	})
+	if err != nil || len(res.Results) == 0 {
+		return err
+	}
+	task := res.Results[0]
+	return fmt.Errorf("%w: %s task %d on %s %s: %s", ErrIndexTaskFailed, task.Type, task.UID, index, task.Status, task.Error.Message)
+}
+
+// Swap 调用前已经等待写入完成, 交换任务不会排在大量写入任务之后.
+// 交换后 index 中是旧数据, 重建期间的双写可能还在写入它, 由调用方稍后删除
+func (m *meiliIndexManager) Swap(ctx context.Context, name, index string) (string, error) {
+	info, err := m.cli.SwapIndexesWithContext(ctx, []*meilisearch.SwapIndexesParams{
+		{Indexes: []string{name, index}},
+	})
+	if err != nil {
+		return "", err
+	}
+	task, err := m.cli.WaitForTaskWithContext(ctx, info.TaskUID, time.Second)
+	if err != nil {
+		return "", err
+	}
+	if task.Status != meilisearch.TaskStatusSucceeded {
+		return "", fmt.Errorf("swap index %s with %s %s: %s", name, index, task.Status, task.Error.Message)
+	}
+	return index, nil
+}
+
+// Drop meilisearch 通过交换使用新索引, 正在使用的索引名固定, 重建的索引名不会被使用
+func (m *meiliIndexManager) Drop(ctx context.Context, index string) error {
+	if isIndexName(index) {
+		return fmt.Errorf("index %s is in use", index)
+	}
+	_, err := m.cli.DeleteIndexWithContext(ctx, index)
+	return err
+}
+
+type esIndexManager struct {
+	cli      *elasticsearch.Client
+	analyzer string
+}
+
+func (e *esIndexManager) Create(ctx context.Context, name string) (string, error) {
+	def, err := findIndexDefinition(name)
+	if err != nil {
+		return "", err
+	}
+	body := esAnalyzer(def.esBody(), e.analyzer)
+	index := rebuildIndexName(esIndexName(name, body))
+	res, err := e.cli.Indices.Create(index,
+		e.cli.Indices.Create.WithContext(ctx),
+		e.cli.Indices.Create.WithBody(strings.NewReader(body)),
+	)
+	return index, esDo(res, err, nil)
+}
+
+// Wait bulk 写入是同步的, 失败时已经返回错误, 这里只需要刷新让文档可以被搜索到
+func (e *esIndexManager) Wait(ctx context.Context, index string) error {
+	res, err := e.cli.Indices.Refresh(
+		e.cli.Indices.Refresh.WithContext(ctx),
+		e.cli.Indices.Refresh.WithIndex(index),
+	)
+	return esDo(res, err, nil)
+}
+
+func (m *meiliIndexManager) Ids(ctx context.Context, name string, maxId int64, limit int) ([]int64, error) {
+	req := &meilisearch.SearchRequest{
+		AttributesToRetrieve: []string{"id"},
+		Sort:                 []string{"id:desc"},
+		Limit:                int64(limit),
+	}
+	if maxId > 0 {
+		req.Filter = "id < " + strconv.FormatInt(maxId, 10)
+	}
+	res, err := m.cli.Index(name).SearchWithContext(ctx, "", req)
+	if err != nil {
+		return nil, err
+	}
+	var docs []docId
+	if err = mapstructurex.Decode(res.Hits, &docs); err != nil {
+		return nil, err
+	}
+	return lo.Map(docs, func(item docId, index int) int64 {
+		return item.Id
+	}), nil
+}
+
+// Swap name 为别名, 切换别名和删除旧索引在同一个请求中完成, 不需要调用方再删除
+func (e *esIndexManager) Swap(ctx context.Context, name, index string) (string, error) {
+	olds, err := esAliasIndexes(ctx, e.cli, name)
+	if err != nil {
+		return "", err
+	}
+	actions := []map[string]any{{"add": map[string]any{"index": index, "alias": name}}}
+	for _, old := range olds {
+		if old != index {
+			actions = append(actions, map[string]any{"remove_index": map[string]any{"index": old}})
+		}
+	}
+	res, err := e.cli.Indices.UpdateAliases(esutil.NewJSONReader(map[string]any{"actions": actions}),
+		e.cli.Indices.UpdateAliases.WithContext(ctx),
+	)
+	return "", esDo(res, err, nil)
+}
+
+// Drop 交换成功但调用方没有收到结果时, index 已经是别名指向的索引, 不能删除
+func (e *esIndexManager) Drop(ctx context.Context, index string) error {
+	for _, def := range indexDefinitions {
+		indexes, err := esAliasIndexes(ctx, e.cli, def.name)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(indexes, index) {
+			return fmt.Errorf("index %s is in use by %s", index, def.name)
+		}
+	}
+	res, err := e.cli.Indices.Delete([]string{index}, e.cli.Indices.Delete.WithContext(ctx))
+	return esDo(res, err, nil)
+}
+
+func (e *esIndexManager) Ids(ctx context.Context, name string, maxId int64, limit int) ([]int64, error) {
+	query := map[string]any{"match_all": map[string]any{}}
+	if maxId > 0 {
+		query = map[string]any{"range": map[string]any{"id": map[string]any{"lt": maxId}}}
+	}
+	resp, err := esSearch[docId](ctx, e.cli, name, map[string]any{
+		"size":    limit,
+		"_source": []string{"id"},
+		"sort":    esSort([]string{"id:desc"}),
+		"query":   query,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(resp.Hits.Hits, func(item esHit[docId], index int) int64 {
+		return item.Source.Id
+	}), nil
+}
+
+func isIndexName(index string) bool {
+	for _, def := range indexDefinitions {
+		if def.name == index {
+			return true
+		}
+	}
+	return false
+}
@@ -28,15 +28,16 @@ type UserDAO interface {
 }
 
 type MeiliUserDAO struct {
-	cli meilisearch.ServiceManager
+	cli   meilisearch.ServiceManager
+	index string
 }
 
 func NewMeiliUserDAO(cli meilisearch.ServiceManager) UserDAO {
-	return &MeiliUserDAO{cli: cli}
+	return &MeiliUserDAO{cli: cli, index: UserIndexName}
 }
 
 func (dao *MeiliUserDAO) Search(ctx context.Context, query string, offset, limit int) ([]User, error) {
-	res, err := dao.cli.Index(userIndexName).SearchWithContext(ctx, query, &meilisearch.SearchRequest{
+	res, err := dao.cli.Index(dao.index).SearchWithContext(ctx, query, &meilisearch.SearchRequest{
 		Offset: int64(offset),
 		Limit:  int64(limit),
 	})
@@ -52,15 +53,9 @@ func (dao *MeiliUserDAO) Search(ctx context.Context, query string, offset, limit
 }
 
 func (dao *MeiliUserDAO) SearchByIds(ctx context.Context, ids []int64) (map[int64]User, error) {
-	idsStr := strings.Builder{}
-	for i, id := range ids {
-		if i > 0 {
-			idsStr.WriteString(",")
-		}
-		idsStr.WriteString(strconv.FormatInt(id, 10))
-	}
-	res, err := dao.cli.Index(userIndexName).SearchWithContext(ctx, "", &meilisearch.SearchRequest{
-		Filter: []string{"id IN [" + idsStr.String() + "]"},
+	res, err := dao.cli.Index(dao.index).SearchWithContext(ctx, "", &meilisearch.SearchRequest{
+		Filter: idsFilter(ids),
+		Limit:  int64(len(ids)),
 	})
 	if err != nil {
 		return nil, err
@@ -80,13 +75,25 @@ func (dao *MeiliUserDAO) SearchByIds(ctx context.Context, ids []int64) (map[int6
 	return userMap, nil
 }
 
+// idsFilter id 需要在索引定义文件中声明为可过滤
+func idsFilter(ids []int64) string {
+	idsStr := strings.Builder{}
+	for i, id := range ids {
+		if i > 0 {
+			idsStr.WriteString(",")
+		}
+		idsStr.WriteString(strconv.FormatInt(id, 10))
+	}
+	return "id IN [" + idsStr.String() + "]"
+}
+
 func (dao *MeiliUserDAO) InputUser(ctx context.Context, users []User) error {
-	_, err := dao.cli.Index(userIndexName).AddDocumentsWithContext(ctx, users)
+	_, err := dao.cli.Index(dao.index).AddDocumentsWithContext(ctx, users)
 	return err
 }
 
 func (dao *MeiliUserDAO) DeleteUser(ctx context.Context, userIds []int64) error {
-	_, err := dao.cli.Index(userIndexName).DeleteDocumentsWithContext(ctx, lo.Map(userIds, func(item int64, index int) string {
+	_, err := dao.cli.Index(dao.index).DeleteDocumentsWithContext(ctx, lo.Map(userIds, func(item int64, index int) string {
 		return strconv.FormatInt(item, 10)
 	}))
 	return err
@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo/cache"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo/dao"
+)
+
+var ErrIndexTaskFailed = dao.ErrIndexTaskFailed
+
+// IndexRepo 全量重建索引, 按搜索类型找到对应的索引
+type IndexRepo interface {
+	Create(ctx context.Context, typ domain.SearchType) (string, error)
+	Wait(ctx context.Context, index string) error
+	// Swap 返回交换后不再使用的旧索引, 为空时不需要删除
+	Swap(ctx context.Context, typ domain.SearchType, index string) (string, error)
+	Drop(ctx context.Context, index string) error
+	// Ids 按 id 倒序返回正在使用的索引中小于 maxId 的文档 id, maxId 为 0 时不限制
+	Ids(ctx context.Context, typ domain.SearchType, maxId int64, limit int) ([]int64, error)
+
+	// SetRebuilding 记录 typ 正在重建到 index, 重建期间的增量同步同时写入 index
+	SetRebuilding(ctx context.Context, typ domain.SearchType, index string) error
+	// Rebuilding 没有正在重建的索引时返回空字符串
+	Rebuilding(ctx context.Context, typ domain.SearchType) (string, error)
+	ClearRebuilding(ctx context.Context, typ domain.SearchType) error
+	// Touch 记录重建期间被修改过的 id, 新索引写完后按数据库重新同步
+	Touch(ctx context.Context, typ domain.SearchType, ids []int64) error
+	Touched(ctx context.Context, typ domain.SearchType) ([]int64, error)
+	Untouch(ctx context.Context, typ domain.SearchType, ids []int64) error
+	// Target 返回把 typ 类型的数据写入 index 的 repo, 其它类型不受影响
+	Target(typ domain.SearchType, index string) (UserRepo, InkRepo, CommentRepo, error)
+}
+
+type indexRepo struct {
+	backend dao.Backend
+	cache   cache.RebuildCache
+}
+
+func NewIndexRepo(backend dao.Backend, cache cache.RebuildCache) IndexRepo {
+	return &indexRepo{
+		backend: backend,
+		cache:   cache,
+	}
+}
+
+func (repo *indexRepo) Create(ctx context.Context, typ domain.SearchType) (string, error) {
+	name, err := indexName(typ)
+	if err != nil {
+		return "", err
+	}
+	return repo.backend.Indexes.Create(ctx, name)
+}
+
+func (repo *indexRepo) Wait(ctx context.Context, index string) error {
+	return repo.backend.Indexes.Wait(ctx, index)
+}
+
+func (repo *indexRepo) Swap(ctx context.Context, typ domain.SearchType, index string) (string, error) {
+	name, err := indexName(typ)
+	if err != nil {
+		return "", err
+	}
+	return repo.backend.Indexes.Swap(ctx, name, index)
+}
+
+func (repo *indexRepo) Drop(ctx context.Context, index string) error {
+	return repo.backend.Indexes.Drop(ctx, index)
+}
+
+func (repo *indexRepo) Ids(ctx context.Context, typ domain.SearchType, maxId int64, limit int) ([]int64, error) {
+	name, err := indexName(typ)
+	if err != nil {
+		return nil, err
+	}
+	return repo.backend.Indexes.Ids(ctx, name, maxId, limit)
+}
+
+func (repo *indexRepo) SetRebuilding(ctx context.Context, typ domain.SearchType, index string) error {
+	return repo.cache.SetTarget(ctx, string(typ), index)
+}
+
+func (repo *indexRepo) Rebuilding(ctx context.Context, typ domain.SearchType) (string, error) {
+	return repo.cache.Target(ctx, string(typ))
+}
+
+func (repo *indexRepo) ClearRebuilding(ctx context.Context, typ domain.SearchType) error {
+	return repo.cache.ClearTarget(ctx, string(typ))
+}
+
+func (repo *indexRepo) Touch(ctx context.Context, typ domain.SearchType, ids []int64) error {
+	return repo.cache.Touch(ctx, string(typ), ids)
+}
+
+func (repo *indexRepo) Touched(ctx context.Context, typ domain.SearchType) ([]int64, error) {
+	return repo.cache.Touched(ctx, string(typ))
+}
+
+func (repo *indexRepo) Untouch(ctx context.Context, typ domain.SearchType, ids []int64) error {
+	return repo.cache.Untouch(ctx, string(typ), ids)
+}
+
+func (repo *indexRepo) Target(typ domain.SearchType, index string) (UserRepo, InkRepo, CommentRepo, error) {
+	name, err := indexName(typ)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	backend := repo.backend.WithIndex(name, index)
+	return NewUserRepo(backend.User), NewInkRepo(backend.Ink, backend.User),
+		NewCommentRepo(backend.Comment, backend.User), nil
+}
+
+func indexName(typ domain.SearchType) (string, error) {
+	switch typ {
+	case domain.SearchTypeUser:
+		return dao.UserIndexName, nil
+	case domain.SearchTypeInk:
+		return dao.InkIndexName, nil
+	case domain.SearchTypeComment:
+		return dao.CommentIndexName, nil
+	default:
+		return "", fmt.Errorf("unknown search type %s", typ)
+	}
+}
@@ -8,8 +8,12 @@ import (
 )
 
 type InkRepo interface {
-	SearchInk(ctx context.Context, query string, offset, limit int) ([]domain.Ink, error)
+	SearchInk(ctx context.Context, query domain.InkQuery) (domain.InkSearchResult, error)
+	// FindByIds 直接返回索引中的文档, 不填充作者信息
+	FindByIds(ctx context.Context, ids []int64) (map[int64]domain.Ink, error)
 	InputInk(ctx context.Context, inks []domain.Ink) error
+	// UpdateStats 只更新索引中已有的作品, 未发布或已删除的作品直接忽略
+	UpdateStats(ctx context.Context, stats []domain.InkStats) error
 	DeleteInk(ctx context.Context, inkIds []int64) error
 }
 
@@ -26,21 +30,39 @@ func NewInkRepo(dao dao.InkDAO, userDao dao.UserDAO) InkRepo {
 	}
 }
 
-func (repo *inkRepo) SearchInk(ctx context.Context, query string, offset, limit int) ([]domain.Ink, error) {
-	inkList, err := repo.dao.Search(ctx, query, offset, limit)
-	if err != nil || len(inkList) == 0 {
-		return nil, err
+func (repo *inkRepo) SearchInk(ctx context.Context, query domain.InkQuery) (domain.InkSearchResult, error) {
+	res, err := repo.dao.Search(ctx, repo.queryToEntity(query))
+	if err != nil {
+		return domain.InkSearchResult{}, err
+	}
+	result := domain.InkSearchResult{
+		Total:  res.Total,
+		Facets: res.Facets,
 	}
-	authorIds := lo.Map(inkList, func(item dao.Ink, index int) int64 {
+	if len(res.Inks) == 0 {
+		return result, nil
+	}
+	authorIds := lo.Map(res.Inks, func(item dao.Ink, index int) int64 {
 		return item.AuthorId
 	})
 	authors, err := repo.userDAO.SearchByIds(ctx, authorIds)
-	return lo.Map(inkList, func(item dao.Ink, index int) domain.Ink {
+	result.Inks = lo.Map(res.Inks, func(item dao.Ink, index int) domain.Ink {
 		ink := repo.entityToDomain(item)
 		if author, ok := authors[item.AuthorId]; ok {
 			ink.Author = repo.userParser.entityToDomain(author)
 		}
 		return ink
+	})
+	return result, nil
+}
+
+func (repo *inkRepo) FindByIds(ctx context.Context, ids []int64) (map[int64]domain.Ink, error) {
+	inks, err := repo.dao.FindByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return lo.MapValues(inks, func(value dao.Ink, key int64) domain.Ink {
+		return repo.entityToDomain(value)
 	}), nil
 }
 
@@ -54,21 +76,71 @@ func (repo *inkRepo) InputInk(ctx context.Context, inks []domain.Ink) error {
 	return nil
 }
 
+func (repo *inkRepo) UpdateStats(ctx context.Context, stats []domain.InkStats) error {
+	docs, err := repo.dao.FindByIds(ctx, lo.Map(stats, func(item domain.InkStats, index int) int64 {
+		return item.Id
+	}))
+	if err != nil {
+		return err
+	}
+	entities := lo.FilterMap(stats, func(item domain.InkStats, index int) (dao.InkStats, bool) {
+		_, ok := docs[item.Id]
+		return dao.InkStats{
+			Id:      item.Id,
+			ViewCnt: item.ViewCnt,
+			LikeCnt: item.LikeCnt,
+		}, ok
+	})
+	if len(entities) == 0 {
+		return nil
+	}
+	return repo.dao.UpdateStats(ctx, entities)
+}
+
 func (repo *inkRepo) DeleteInk(ctx context.Context, inkIds []int64) error {
 	return repo.dao.DeleteInk(ctx, inkIds)
 }
 
+func (repo *inkRepo) queryToEntity(query domain.InkQuery) dao.InkQuery {
+	q := dao.InkQuery{
+		Keyword:     query.Keyword,
+		Tags:        query.Tags,
+		AuthorId:    query.AuthorId,
+		ContentType: query.ContentType,
+		CategoryId:  query.CategoryId,
+		Sort:        string(query.Sort),
+		Facets:      []string{domain.FacetTags, domain.FacetContentType, domain.FacetCategory},
+		Offset:      query.Offset,
+		Limit:       query.Limit,
+	}
+	if !query.CreatedAfter.IsZero() {
+		q.CreatedAfter = query.CreatedAfter.Unix()
+	}
+	if !query.CreatedBefore.IsZero() {
+		q.CreatedBefore = query.CreatedBefore.Unix()
+	}
+	return q
+}
+
 func (repo *inkRepo) domainToEntity(ink domain.Ink) dao.Ink {
 	return dao.Ink{
-		Id:        ink.Id,
-		Title:     ink.Title,
-		AuthorId:  ink.Author.Id,
-		Cover:     ink.Cover,
-		Content:   ink.Content,
-		Tags:      ink.Tags,
-		AiTags:    ink.AiTags,
-		CreatedAt: ink.CreatedAt,
-		UpdatedAt: ink.UpdatedAt,
+		Id:          ink.Id,
+		Title:       ink.Title,
+		AuthorId:    ink.Author.Id,
+		Cover:       ink.Cover,
+		Summary:     ink.Summary,
+		Content:     ink.Content,
+		Tags:        ink.Tags,
+		AiTags:      ink.AiTags,
+		ContentType: ink.ContentType,
+		CategoryId:  ink.CategoryId,
+		ViewCnt:     ink.ViewCnt,
+		LikeCnt:     ink.LikeCnt,
+		WordCount:   ink.WordCount,
+		ReadingTime: ink.ReadingTime,
+		CreatedTs:   ink.CreatedAt.Unix(),
+		CreatedAt:   ink.CreatedAt,
+		UpdatedAt:   ink.UpdatedAt,
 	}
 }
 
@@ -79,11 +151,19 @@ func (repo *inkRepo) entityToDomain(ink dao.Ink) domain.Ink {
 		Author: domain.User{
 			Id: ink.AuthorId,
 		},
-		Cover:     ink.Cover,
-		Content:   ink.Content,
-		Tags:      ink.Tags,
-		AiTags:    ink.AiTags,
-		CreatedAt: ink.CreatedAt,
-		UpdatedAt: ink.UpdatedAt,
+		Cover:       ink.Cover,
+		Summary:     ink.Summary,
+		Content:     ink.Content,
+		Tags:        ink.Tags,
+		AiTags:      ink.AiTags,
+		ContentType: ink.ContentType,
+		CategoryId:  ink.CategoryId,
+		ViewCnt:     ink.ViewCnt,
+		LikeCnt:     ink.LikeCnt,
+		WordCount:   ink.WordCount,
+		ReadingTime: ink.ReadingTime,
+		CreatedAt:   ink.CreatedAt,
+		UpdatedAt:   ink.UpdatedAt,
+		Highlights:  ink.Highlights,
 	}
 }
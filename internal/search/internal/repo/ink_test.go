@@ -0,0 +1,44 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo/dao"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeInkDAO struct {
+	dao.InkDAO
+	docs    map[int64]dao.Ink
+	updated []dao.InkStats
+}
+
+func (d *fakeInkDAO) FindByIds(ctx context.Context, ids []int64) (map[int64]dao.Ink, error) {
+	return d.docs, nil
+}
+
+func (d *fakeInkDAO) UpdateStats(ctx context.Context, stats []dao.InkStats) error {
+	d.updated = append(d.updated, stats...)
+	return nil
+}
+
+func TestInkRepo_UpdateStats(t *testing.T) {
+	inkDAO := &fakeInkDAO{docs: map[int64]dao.Ink{1: {Id: 1, Title: "go"}}}
+	repo := NewInkRepo(inkDAO, nil)
+
+	err := repo.UpdateStats(context.Background(), []domain.InkStats{
+		{Id: 1, ViewCnt: 10, LikeCnt: 2},
+		// 不在索引中的作品不能写入只有计数的文档
+		{Id: 2, ViewCnt: 5, LikeCnt: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []dao.InkStats{{Id: 1, ViewCnt: 10, LikeCnt: 2}}, inkDAO.updated)
+
+	inkDAO.updated = nil
+	inkDAO.docs = nil
+	require.NoError(t, repo.UpdateStats(context.Background(), []domain.InkStats{{Id: 2}}))
+	assert.Empty(t, inkDAO.updated)
+}
@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"context"
+	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo/dao"
+	"github.com/samber/lo"
+	"time"
+)
+
+var ErrSearchLogNotFound = dao.ErrRecordNotFound
+
+type SearchLogRepo interface {
+	InsertLogs(ctx context.Context, logs []domain.SearchLog) error
+	SetClicked(ctx context.Context, id int64, clickedId int64) error
+	AggregateHour(ctx context.Context, hour time.Time) error
+	FindTopQueries(ctx context.Context, typ domain.SearchType, since time.Time, minUsers int64, limit int) ([]domain.QueryStats, error)
+	FindZeroResultQueries(ctx context.Context, since time.Time, offset, limit int) ([]domain.QueryStats, error)
+}
+
+type searchLogRepo struct {
+	dao dao.SearchLogDAO
+}
+
+func NewSearchLogRepo(dao dao.SearchLogDAO) SearchLogRepo {
+	return &searchLogRepo{
+		dao: dao,
+	}
+}
+
+func (repo *searchLogRepo) InsertLogs(ctx context.Context, logs []domain.SearchLog) error {
+	return repo.dao.InsertBatch(ctx, lo.Map(logs, func(item domain.SearchLog, index int) dao.SearchLog {
+		return dao.SearchLog{
+			Id:        item.Id,
+			Uid:       item.Uid,
+			Query:     item.Query,
+			Type:      string(item.Type),
+			ResultCnt: item.ResultCnt,
+			ClickedId: item.ClickedId,
+			CreatedAt: item.CreatedAt,
+		}
+	}))
+}
+
+func (repo *searchLogRepo) SetClicked(ctx context.Context, id int64, clickedId int64) error {
+	return repo.dao.SetClicked(ctx, id, clickedId)
+}
+
+func (repo *searchLogRepo) AggregateHour(ctx context.Context, hour time.Time) error {
+	return repo.dao.AggregateHour(ctx, hour)
+}
+
+func (repo *searchLogRepo) FindTopQueries(ctx context.Context, typ domain.SearchType, since time.Time, minUsers int64, limit int) ([]domain.QueryStats, error) {
+	stats, err := repo.dao.FindTopQueries(ctx, string(typ), since, minUsers, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(stats, repo.statsToDomain), nil
+}
+
+func (repo *searchLogRepo) FindZeroResultQueries(ctx context.Context, since time.Time, offset, limit int) ([]domain.QueryStats, error) {
+	stats, err := repo.dao.FindZeroResultQueries(ctx, since, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(stats, repo.statsToDomain), nil
+}
+
+func (repo *searchLogRepo) statsToDomain(s dao.QueryStats, _ int) domain.QueryStats {
+	return domain.QueryStats{
+		Query:         s.Query,
+		Type:          domain.SearchType(s.Type),
+		SearchCnt:     s.SearchCnt,
+		ZeroResultCnt: s.ZeroResultCnt,
+		ClickCnt:      s.ClickCnt,
+		UserCnt:       s.UserCnt,
+	}
+}
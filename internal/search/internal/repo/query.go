@@ -0,0 +1,29 @@
+package repo
+
+import (
+	"context"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo/cache"
+)
+
+type QueryRepo interface {
+	SetPopularQueries(ctx context.Context, queries []string) error
+	PopularQueries(ctx context.Context, limit int) ([]string, error)
+}
+
+type queryRepo struct {
+	cache cache.QueryCache
+}
+
+func NewQueryRepo(cache cache.QueryCache) QueryRepo {
+	return &queryRepo{
+		cache: cache,
+	}
+}
+
+func (repo *queryRepo) SetPopularQueries(ctx context.Context, queries []string) error {
+	return repo.cache.SetPopular(ctx, queries)
+}
+
+func (repo *queryRepo) PopularQueries(ctx context.Context, limit int) ([]string, error) {
+	return repo.cache.Popular(ctx, limit)
+}
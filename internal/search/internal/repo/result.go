@@ -0,0 +1,35 @@
+package repo
+
+import (
+	"context"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo/cache"
+)
+
+// ResultRepo 见 cache.ResultCache
+type ResultRepo interface {
+	SetResults(ctx context.Context, searchId, uid int64, resultIds []int64) error
+	AppendResults(ctx context.Context, searchId, uid int64, resultIds []int64) (bool, error)
+	InResults(ctx context.Context, searchId, uid, resultId int64) (bool, error)
+}
+
+type resultRepo struct {
+	cache cache.ResultCache
+}
+
+func NewResultRepo(cache cache.ResultCache) ResultRepo {
+	return &resultRepo{
+		cache: cache,
+	}
+}
+
+func (repo *resultRepo) SetResults(ctx context.Context, searchId, uid int64, resultIds []int64) error {
+	return repo.cache.Set(ctx, searchId, uid, resultIds)
+}
+
+func (repo *resultRepo) AppendResults(ctx context.Context, searchId, uid int64, resultIds []int64) (bool, error) {
+	return repo.cache.Append(ctx, searchId, uid, resultIds)
+}
+
+func (repo *resultRepo) InResults(ctx context.Context, searchId, uid, resultId int64) (bool, error) {
+	return repo.cache.Contains(ctx, searchId, uid, resultId)
+}
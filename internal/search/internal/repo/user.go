@@ -9,6 +9,7 @@ import (
 
 type UserRepo interface {
 	Search(ctx context.Context, query string, offset, limit int) ([]domain.User, error)
+	FindByIds(ctx context.Context, ids []int64) (map[int64]domain.User, error)
 	InputUser(ctx context.Context, users []domain.User) error
 	DeleteUser(ctx context.Context, userId []int64) error
 }
@@ -33,6 +34,16 @@ func (repo *userRepo) Search(ctx context.Context, query string, offset, limit in
 	}), nil
 }
 
+func (repo *userRepo) FindByIds(ctx context.Context, ids []int64) (map[int64]domain.User, error) {
+	users, err := repo.dao.SearchByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return lo.MapValues(users, func(value dao.User, key int64) domain.User {
+		return repo.entityToDomain(value)
+	}), nil
+}
+
 func (repo *userRepo) InputUser(ctx context.Context, users []domain.User) error {
 	err := repo.dao.InputUser(ctx, lo.Map(users, func(item domain.User, index int) dao.User {
 		return repo.domainToEntity(item)
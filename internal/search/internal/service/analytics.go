@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// trendingWindow 热搜和补全的热门搜索词统计最近多长时间的搜索
+	trendingWindow = 24 * time.Hour
+	// minTrendingUsers 至少有这么多登录用户搜索过才能进入热搜和补全, 防止个别用户刷词
+	minTrendingUsers = 10
+	// maxLogQueryLen 与 search_logs.query 的列宽一致
+	maxLogQueryLen = 128
+)
+
+// ErrInvalidClick 搜索不属于当前用户, 已经过期, 或者点击的内容不在搜索结果中
+var ErrInvalidClick = errors.New("invalid search click")
+
+// LogProducer 搜索记录和点击通过消息异步写入
+type LogProducer interface {
+	ProduceSearchLog(ctx context.Context, log domain.SearchLog) error
+	ProduceSearchClick(ctx context.Context, click domain.SearchClick) error
+}
+
+type AnalyticsService interface {
+	// RecordSearch 异步记录一次搜索, 返回的 id 用于关联之后的点击, 搜索词为空时不记录, 返回 0.
+	// log.ResultIds 会保留一段时间, 用于校验点击
+	RecordSearch(ctx context.Context, log domain.SearchLog) (int64, error)
+	// AppendResults 翻页时把这一页的结果加入第一页的搜索, 搜索不属于 uid 或已过期时返回 ErrInvalidClick
+	AppendResults(ctx context.Context, searchId, uid int64, resultIds []int64) error
+	// RecordClick 只记录点击当前用户搜索结果中的内容, 否则返回 ErrInvalidClick
+	RecordClick(ctx context.Context, click domain.SearchClick) error
+	// Aggregate 汇总 hour 所在小时的搜索记录, 并刷新补全使用的热门搜索词, 可以重复执行
+	Aggregate(ctx context.Context, hour time.Time) error
+	// Trending 最近 24 小时搜索次数最多的搜索词, typ 为空时不区分搜索类型,
+	// 搜索过的登录用户太少的搜索词不返回. 搜索词未经审核, 展示前需要过滤
+	Trending(ctx context.Context, typ domain.SearchType, limit int) ([]domain.QueryStats, error)
+	// ZeroResultQueries since 之后没有搜索结果的搜索词, 按无结果次数排序
+	ZeroResultQueries(ctx context.Context, since time.Time, offset, limit int) ([]domain.QueryStats, error)
+}
+
+type analyticsService struct {
+	repo       repo.SearchLogRepo
+	resultRepo repo.ResultRepo
+	queryRepo  repo.QueryRepo
+	producer   LogProducer
+	// node 搜索记录是异步写入的, 需要先生成 id 返回给调用方
+	node snowflakex.Node
+	l    logx.Logger
+}
+
+func NewAnalyticsService(repo repo.SearchLogRepo, resultRepo repo.ResultRepo, queryRepo repo.QueryRepo,
+	producer LogProducer, node snowflakex.Node, l logx.Logger) AnalyticsService {
+	return &analyticsService{
+		repo:       repo,
+		resultRepo: resultRepo,
+		queryRepo:  queryRepo,
+		producer:   producer,
+		node:       node,
+		l:          l,
+	}
+}
+
+func (s *analyticsService) RecordSearch(ctx context.Context, log domain.SearchLog) (int64, error) {
+	log.Query = normalizeQuery(log.Query)
+	if log.Query == "" {
+		return 0, nil
+	}
+	if utf8.RuneCountInString(log.Query) > maxLogQueryLen {
+		log.Query = string([]rune(log.Query)[:maxLogQueryLen])
+	}
+	log.Id = s.node.NextID()
+	log.CreatedAt = time.Now()
+	if err := s.resultRepo.SetResults(ctx, log.Id, log.Uid, log.ResultIds); err != nil {
+		return 0, err
+	}
+	if err := s.producer.ProduceSearchLog(ctx, log); err != nil {
+		return 0, err
+	}
+	return log.Id, nil
+}
+
+func (s *analyticsService) AppendResults(ctx context.Context, searchId, uid int64, resultIds []int64) error {
+	ok, err := s.resultRepo.AppendResults(ctx, searchId, uid, resultIds)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidClick
+	}
+	return nil
+}
+
+func (s *analyticsService) RecordClick(ctx context.Context, click domain.SearchClick) error {
+	ok, err := s.resultRepo.InResults(ctx, click.SearchId, click.Uid, click.ResultId)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidClick
+	}
+	click.CreatedAt = time.Now()
+	return s.producer.ProduceSearchClick(ctx, click)
+}
+
+// Aggregate 热门搜索词和热搜同样来自汇总结果, 刷新失败不影响汇总
+func (s *analyticsService) Aggregate(ctx context.Context, hour time.Time) error {
+	if err := s.repo.AggregateHour(ctx, hour.Truncate(time.Hour)); err != nil {
+		return err
+	}
+	stats, err := s.repo.FindTopQueries(ctx, "", s.trendingSince(), minTrendingUsers, suggestCandidates)
+	if err == nil {
+		err = s.queryRepo.SetPopularQueries(ctx, popularQueries(stats))
+	}
+	if err != nil {
+		s.l.WithCtx(ctx).Warn("refresh popular queries error", logx.Error(err))
+	}
+	return nil
+}
+
+func (s *analyticsService) Trending(ctx context.Context, typ domain.SearchType, limit int) ([]domain.QueryStats, error) {
+	return s.repo.FindTopQueries(ctx, typ, s.trendingSince(), minTrendingUsers, limit)
+}
+
+func (s *analyticsService) trendingSince() time.Time {
+	return time.Now().Add(-trendingWindow).Truncate(time.Hour)
+}
+
+// popularQueries 不同类型的同一个搜索词只保留一次, 过长的搜索词通常是粘贴的整段文字, 不用于补全
+func popularQueries(stats []domain.QueryStats) []string {
+	queries := make([]string, 0, len(stats))
+	seen := make(map[string]struct{}, len(stats))
+	for _, stat := range stats {
+		if _, ok := seen[stat.Query]; ok || utf8.RuneCountInString(stat.Query) > maxQueryLen {
+			continue
+		}
+		seen[stat.Query] = struct{}{}
+		queries = append(queries, stat.Query)
+	}
+	return queries
+}
+
+func (s *analyticsService) ZeroResultQueries(ctx context.Context, since time.Time, offset, limit int) ([]domain.QueryStats, error) {
+	return s.repo.FindZeroResultQueries(ctx, since.Truncate(time.Hour), offset, limit)
+}
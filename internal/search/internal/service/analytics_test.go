@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLogProducer struct {
+	logs   []domain.SearchLog
+	clicks []domain.SearchClick
+}
+
+func (p *fakeLogProducer) ProduceSearchLog(ctx context.Context, log domain.SearchLog) error {
+	p.logs = append(p.logs, log)
+	return nil
+}
+
+func (p *fakeLogProducer) ProduceSearchClick(ctx context.Context, click domain.SearchClick) error {
+	p.clicks = append(p.clicks, click)
+	return nil
+}
+
+type fakeSearchLogRepo struct {
+	repo.SearchLogRepo
+	since    time.Time
+	minUsers int64
+	top      []domain.QueryStats
+}
+
+func (r *fakeSearchLogRepo) AggregateHour(ctx context.Context, hour time.Time) error {
+	return nil
+}
+
+func (r *fakeSearchLogRepo) FindTopQueries(ctx context.Context, typ domain.SearchType, since time.Time, minUsers int64, limit int) ([]domain.QueryStats, error) {
+	r.since = since
+	r.minUsers = minUsers
+	return r.top, nil
+}
+
+type fakeResultRepo struct {
+	repo.ResultRepo
+	results map[int64]map[int64][]int64
+}
+
+func (r *fakeResultRepo) SetResults(ctx context.Context, searchId, uid int64, resultIds []int64) error {
+	if r.results == nil {
+		r.results = make(map[int64]map[int64][]int64)
+	}
+	r.results[searchId] = map[int64][]int64{uid: resultIds}
+	return nil
+}
+
+func (r *fakeResultRepo) AppendResults(ctx context.Context, searchId, uid int64, resultIds []int64) (bool, error) {
+	ids, ok := r.results[searchId][uid]
+	if !ok {
+		return false, nil
+	}
+	r.results[searchId][uid] = append(ids, resultIds...)
+	return true, nil
+}
+
+func (r *fakeResultRepo) InResults(ctx context.Context, searchId, uid, resultId int64) (bool, error) {
+	return slices.Contains(r.results[searchId][uid], resultId), nil
+}
+
+func newTestAnalyticsService(logRepo repo.SearchLogRepo, queryRepo repo.QueryRepo, producer LogProducer) AnalyticsService {
+	return NewAnalyticsService(logRepo, &fakeResultRepo{}, queryRepo, producer,
+		snowflakex.NewNode(snowflakex.DefaultStartTime, 0), logx.NewNopLogger())
+}
+
+func TestAnalyticsService_RecordSearch(t *testing.T) {
+	producer := &fakeLogProducer{}
+	svc := newTestAnalyticsService(&fakeSearchLogRepo{}, nil, producer)
+
+	id, err := svc.RecordSearch(context.Background(), domain.SearchLog{Uid: 1, Query: "  Go   并发 ", Type: domain.SearchTypeInk})
+	require.NoError(t, err)
+	assert.NotZero(t, id)
+	require.Len(t, producer.logs, 1)
+	assert.Equal(t, id, producer.logs[0].Id)
+	assert.Equal(t, "go 并发", producer.logs[0].Query)
+	assert.False(t, producer.logs[0].CreatedAt.IsZero())
+
+	// 空搜索词不记录
+	id, err = svc.RecordSearch(context.Background(), domain.SearchLog{Query: "  "})
+	require.NoError(t, err)
+	assert.Zero(t, id)
+
+	// 超长的搜索词截断到列宽
+	_, err = svc.RecordSearch(context.Background(), domain.SearchLog{Query: strings.Repeat("字", 200)})
+	require.NoError(t, err)
+	assert.Equal(t, maxLogQueryLen, len([]rune(producer.logs[1].Query)))
+}
+
+func TestAnalyticsService_Trending(t *testing.T) {
+	logRepo := &fakeSearchLogRepo{}
+	svc := newTestAnalyticsService(logRepo, nil, &fakeLogProducer{})
+	_, err := svc.Trending(context.Background(), domain.SearchTypeInk, 10)
+	require.NoError(t, err)
+	assert.Equal(t, logRepo.since, logRepo.since.Truncate(time.Hour))
+	assert.WithinDuration(t, time.Now().Add(-trendingWindow), logRepo.since, time.Hour)
+	assert.Equal(t, int64(minTrendingUsers), logRepo.minUsers)
+}
+
+func TestAnalyticsService_RecordClick(t *testing.T) {
+	producer := &fakeLogProducer{}
+	svc := newTestAnalyticsService(&fakeSearchLogRepo{}, nil, producer)
+	ctx := context.Background()
+	id, err := svc.RecordSearch(ctx, domain.SearchLog{Uid: 1, Query: "go", Type: domain.SearchTypeInk, ResultIds: []int64{10, 11}})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RecordClick(ctx, domain.SearchClick{SearchId: id, Uid: 1, ResultId: 11}))
+	// 不在结果中的内容和其他用户的搜索都不记录
+	assert.ErrorIs(t, svc.RecordClick(ctx, domain.SearchClick{SearchId: id, Uid: 1, ResultId: 99}), ErrInvalidClick)
+	assert.ErrorIs(t, svc.RecordClick(ctx, domain.SearchClick{SearchId: id, Uid: 2, ResultId: 11}), ErrInvalidClick)
+
+	// 翻页的结果加入第一页的搜索后可以点击
+	assert.ErrorIs(t, svc.AppendResults(ctx, id, 2, []int64{12}), ErrInvalidClick)
+	require.NoError(t, svc.AppendResults(ctx, id, 1, []int64{12}))
+	require.NoError(t, svc.RecordClick(ctx, domain.SearchClick{SearchId: id, Uid: 1, ResultId: 12}))
+	assert.Len(t, producer.clicks, 2)
+}
+
+func TestAnalyticsService_Aggregate(t *testing.T) {
+	logRepo := &fakeSearchLogRepo{top: []domain.QueryStats{
+		{Query: "go", Type: domain.SearchTypeInk},
+		{Query: "go", Type: domain.SearchTypeUser},
+		{Query: strings.Repeat("长", maxQueryLen+1), Type: domain.SearchTypeInk},
+		{Query: "rust", Type: domain.SearchTypeInk},
+	}}
+	queryRepo := &fakeQueryRepo{}
+	svc := newTestAnalyticsService(logRepo, queryRepo, &fakeLogProducer{})
+	require.NoError(t, svc.Aggregate(context.Background(), time.Now()))
+	// 补全和热搜使用同样的汇总结果和用户数门槛
+	assert.Equal(t, int64(minTrendingUsers), logRepo.minUsers)
+	assert.Equal(t, []string{"go", "rust"}, queryRepo.popular)
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo"
+	"github.com/samber/lo"
+	"slices"
+	"strings"
+)
+
+var ErrIndexTaskFailed = repo.ErrIndexTaskFailed
+
+// IndexService 全量重建索引, 以及检查索引和数据库是否一致
+type IndexService interface {
+	// BeginRebuild 创建一个空的新索引, 返回新索引名, 之后的增量同步会同时写入新索引
+	BeginRebuild(ctx context.Context, typ domain.SearchType) (string, error)
+	// RebuildSync 返回把 typ 类型的数据写入新索引的 SyncService
+	RebuildSync(typ domain.SearchType, index string) (SyncService, error)
+	// RebuildTouched 返回重建期间增量同步修改过的 id, 需要按数据库重新写入新索引
+	RebuildTouched(ctx context.Context, typ domain.SearchType) ([]int64, error)
+	// ClearTouched 重新写入后清除这些 id
+	ClearTouched(ctx context.Context, typ domain.SearchType, ids []int64) error
+	// WaitRebuild 等待写入新索引的数据全部生效, 有写入失败时返回 ErrIndexTaskFailed
+	WaitRebuild(ctx context.Context, index string) error
+	// CommitRebuild 用新索引原子地替换正在使用的索引并停止双写,
+	// 返回不再使用的旧索引, 可能还有进行中的双写, 由调用方稍后通过 DropIndex 删除
+	CommitRebuild(ctx context.Context, typ domain.SearchType, index string) (string, error)
+	// AbortRebuild 停止双写, 新索引由调用方稍后通过 DropIndex 删除
+	AbortRebuild(ctx context.Context, typ domain.SearchType) error
+	// DropIndex 删除不再使用的索引, 正在使用的索引不会被删除
+	DropIndex(ctx context.Context, index string) error
+
+	// SampleIds 按 id 倒序返回正在使用的索引中小于 maxId 的文档 id, 用于查找 orphans
+	SampleIds(ctx context.Context, typ domain.SearchType, maxId int64, limit int) ([]int64, error)
+	// CheckInks 传入数据库中的数据, 以及索引中有但数据库中已经删除或不可见的 id,
+	// repair 为 true 时把缺失和过期的文档重新写入索引, 并删除 orphans
+	CheckInks(ctx context.Context, inks []domain.Ink, orphans []int64, repair bool) (domain.IndexCheckResult, error)
+	CheckUsers(ctx context.Context, users []domain.User, orphans []int64, repair bool) (domain.IndexCheckResult, error)
+	CheckComments(ctx context.Context, comments []domain.Comment, orphans []int64, repair bool) (domain.IndexCheckResult, error)
+}
+
+type indexService struct {
+	indexRepo   repo.IndexRepo
+	userRepo    repo.UserRepo
+	inkRepo     repo.InkRepo
+	commentRepo repo.CommentRepo
+	syncSvc     SyncService
+}
+
+func NewIndexService(indexRepo repo.IndexRepo, userRepo repo.UserRepo, inkRepo repo.InkRepo,
+	commentRepo repo.CommentRepo, syncSvc SyncService) IndexService {
+	return &indexService{
+		indexRepo:   indexRepo,
+		userRepo:    userRepo,
+		inkRepo:     inkRepo,
+		commentRepo: commentRepo,
+		syncSvc:     syncSvc,
+	}
+}
+
+func (s *indexService) BeginRebuild(ctx context.Context, typ domain.SearchType) (string, error) {
+	index, err := s.indexRepo.Create(ctx, typ)
+	if err != nil {
+		return "", err
+	}
+	return index, s.indexRepo.SetRebuilding(ctx, typ, index)
+}
+
+func (s *indexService) RebuildSync(typ domain.SearchType, index string) (SyncService, error) {
+	userRepo, inkRepo, commentRepo, err := s.indexRepo.Target(typ, index)
+	if err != nil {
+		return nil, err
+	}
+	return NewSyncService(userRepo, inkRepo, commentRepo), nil
+}
+
+func (s *indexService) WaitRebuild(ctx context.Context, index string) error {
+	return s.indexRepo.Wait(ctx, index)
+}
+
+func (s *indexService) RebuildTouched(ctx context.Context, typ domain.SearchType) ([]int64, error) {
+	return s.indexRepo.Touched(ctx, typ)
+}
+
+func (s *indexService) ClearTouched(ctx context.Context, typ domain.SearchType, ids []int64) error {
+	return s.indexRepo.Untouch(ctx, typ, ids)
+}
+
+// CommitRebuild 交换后再停止双写, 交换前后的增量同步都会写入正在使用的索引
+func (s *indexService) CommitRebuild(ctx context.Context, typ domain.SearchType, index string) (string, error) {
+	retired, err := s.indexRepo.Swap(ctx, typ, index)
+	if err != nil {
+		return "", err
+	}
+	return retired, s.indexRepo.ClearRebuilding(ctx, typ)
+}
+
+func (s *indexService) AbortRebuild(ctx context.Context, typ domain.SearchType) error {
+	return s.indexRepo.ClearRebuilding(ctx, typ)
+}
+
+func (s *indexService) DropIndex(ctx context.Context, index string) error {
+	return s.indexRepo.Drop(ctx, index)
+}
+
+func (s *indexService) SampleIds(ctx context.Context, typ domain.SearchType, maxId int64, limit int) ([]int64, error) {
+	return s.indexRepo.Ids(ctx, typ, maxId, limit)
+}
+
+func (s *indexService) CheckInks(ctx context.Context, inks []domain.Ink, orphans []int64, repair bool) (domain.IndexCheckResult, error) {
+	docs, err := s.inkRepo.FindByIds(ctx, lo.Map(inks, func(item domain.Ink, index int) int64 {
+		return item.Id
+	}))
+	if err != nil {
+		return domain.IndexCheckResult{}, err
+	}
+	res, broken := checkDocs(domain.SearchTypeInk, inks, docs, orphans, func(ink domain.Ink) int64 {
+		return ink.Id
+	}, func(ink, doc domain.Ink) bool {
+		return ink.Title == doc.Title && ink.Summary == doc.Summary &&
+			slices.Equal(ink.Tags, doc.Tags) && ink.CategoryId == doc.CategoryId &&
+			ink.ContentType == doc.ContentType && ink.UpdatedAt.Unix() == doc.UpdatedAt.Unix()
+	})
+	if !repair || res.Consistent() {
+		return res, nil
+	}
+	if len(broken) > 0 {
+		if err = s.syncSvc.InputInk(ctx, broken); err != nil {
+			return res, err
+		}
+	}
+	if err = deleteOrphans(ctx, orphans, s.syncSvc.DeleteInk); err != nil {
+		return res, err
+	}
+	res.Repaired = true
+	return res, nil
+}
+
+func (s *indexService) CheckUsers(ctx context.Context, users []domain.User, orphans []int64, repair bool) (domain.IndexCheckResult, error) {
+	docs, err := s.userRepo.FindByIds(ctx, lo.Map(users, func(item domain.User, index int) int64 {
+		return item.Id
+	}))
+	if err != nil {
+		return domain.IndexCheckResult{}, err
+	}
+	res, broken := checkDocs(domain.SearchTypeUser, users, docs, orphans, func(user domain.User) int64 {
+		return user.Id
+	}, func(user, doc domain.User) bool {
+		return user.Account == doc.Account && user.Username == doc.Username &&
+			user.AboutMe == doc.AboutMe && user.Avatar == doc.Avatar
+	})
+	if !repair || res.Consistent() {
+		return res, nil
+	}
+	if len(broken) > 0 {
+		if err = s.syncSvc.InputUser(ctx, broken); err != nil {
+			return res, err
+		}
+	}
+	if err = deleteOrphans(ctx, orphans, s.syncSvc.DeleteUser); err != nil {
+		return res, err
+	}
+	res.Repaired = true
+	return res, nil
+}
+
+// CheckComments 比较写入索引的全部字段, 隐藏和删除的评论不在传入的数据中, 通过 orphans 删除
+func (s *indexService) CheckComments(ctx context.Context, comments []domain.Comment, orphans []int64, repair bool) (domain.IndexCheckResult, error) {
+	docs, err := s.commentRepo.FindByIds(ctx, lo.Map(comments, func(item domain.Comment, index int) int64 {
+		return item.Id
+	}))
+	if err != nil {
+		return domain.IndexCheckResult{}, err
+	}
+	res, broken := checkDocs(domain.SearchTypeComment, comments, docs, orphans, func(comment domain.Comment) int64 {
+		return comment.Id
+	}, func(comment, doc domain.Comment) bool {
+		// 索引中的图片以逗号拼接, 没有图片时读出来是一个空字符串
+		return comment.Content == doc.Content && strings.Join(comment.Images, ",") == strings.Join(doc.Images, ",") &&
+			comment.Biz == doc.Biz && comment.BizId == doc.BizId &&
+			comment.RootId == doc.RootId && comment.ParentId == doc.ParentId &&
+			comment.Commentator.Id == doc.Commentator.Id
+	})
+	if !repair || res.Consistent() {
+		return res, nil
+	}
+	if len(broken) > 0 {
+		if err = s.syncSvc.InputComment(ctx, broken); err != nil {
+			return res, err
+		}
+	}
+	if err = deleteOrphans(ctx, orphans, s.syncSvc.DeleteComment); err != nil {
+		return res, err
+	}
+	res.Repaired = true
+	return res, nil
+}
+
+func deleteOrphans(ctx context.Context, orphans []int64, del func(ctx context.Context, id int64) error) error {
+	for _, id := range orphans {
+		if err := del(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDocs 返回检查结果和需要重新写入的数据
+func checkDocs[T any](typ domain.SearchType, items []T, docs map[int64]T, orphans []int64,
+	id func(T) int64, same func(item, doc T) bool) (domain.IndexCheckResult, []T) {
+	res := domain.IndexCheckResult{Type: typ, Checked: len(items) + len(orphans), Orphan: orphans}
+	var broken []T
+	for _, item := range items {
+		doc, ok := docs[id(item)]
+		switch {
+		case !ok:
+			res.Missing = append(res.Missing, id(item))
+		case !same(item, doc):
+			res.Stale = append(res.Stale, id(item))
+		default:
+			continue
+		}
+		broken = append(broken, item)
+	}
+	return res, broken
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeInkRepo struct {
+	repo.InkRepo
+	docs    map[int64]domain.Ink
+	input   []domain.Ink
+	deleted []int64
+}
+
+func (r *fakeInkRepo) FindByIds(ctx context.Context, ids []int64) (map[int64]domain.Ink, error) {
+	return r.docs, nil
+}
+
+func (r *fakeInkRepo) InputInk(ctx context.Context, inks []domain.Ink) error {
+	r.input = append(r.input, inks...)
+	return nil
+}
+
+func (r *fakeInkRepo) DeleteInk(ctx context.Context, inkIds []int64) error {
+	r.deleted = append(r.deleted, inkIds...)
+	return nil
+}
+
+type fakeCommentRepo struct {
+	repo.CommentRepo
+}
+
+func (r *fakeCommentRepo) DeleteByBiz(ctx context.Context, biz string, bizId int64) error {
+	return nil
+}
+
+func TestIndexService_CheckInks(t *testing.T) {
+	now := time.Now()
+	inkRepo := &fakeInkRepo{docs: map[int64]domain.Ink{
+		1: {Id: 1, Title: "go", Tags: []string{"go"}, UpdatedAt: now},
+		2: {Id: 2, Title: "old", UpdatedAt: now.Add(-time.Hour)},
+	}}
+	svc := NewIndexService(nil, nil, inkRepo, nil, NewSyncService(nil, inkRepo, &fakeCommentRepo{}))
+	inks := []domain.Ink{
+		{Id: 1, Title: "go", Tags: []string{"go"}, UpdatedAt: now},
+		{Id: 2, Title: "new", UpdatedAt: now},
+		{Id: 3, Title: "missing", Content: "<p>hi</p>", UpdatedAt: now},
+	}
+
+	// 4 已经删除或不可见, 但还在索引中
+	orphans := []int64{4}
+
+	res, err := svc.CheckInks(context.Background(), inks, orphans, false)
+	require.NoError(t, err)
+	assert.Equal(t, domain.IndexCheckResult{
+		Type:    domain.SearchTypeInk,
+		Checked: 4,
+		Missing: []int64{3},
+		Stale:   []int64{2},
+		Orphan:  []int64{4},
+	}, res)
+	assert.Empty(t, inkRepo.input)
+	assert.Empty(t, inkRepo.deleted)
+
+	res, err = svc.CheckInks(context.Background(), inks, orphans, true)
+	require.NoError(t, err)
+	assert.True(t, res.Repaired)
+	require.Len(t, inkRepo.input, 2)
+	assert.Equal(t, int64(2), inkRepo.input[0].Id)
+	// 修复时和正常同步一样只写入纯文本
+	assert.Equal(t, "hi", inkRepo.input[1].Content)
+	assert.Equal(t, []int64{4}, inkRepo.deleted)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo"
+	"github.com/samber/lo"
+	"slices"
+)
+
+// rebuildSyncService 重建索引期间把增量同步同时写入新索引, 并记录被修改的 id.
+// 全量写入读到的数据可能比增量同步旧, 新索引写完后由重建流程按数据库重新同步这些 id
+type rebuildSyncService struct {
+	SyncService
+	indexRepo repo.IndexRepo
+}
+
+func NewRebuildSyncService(svc SyncService, indexRepo repo.IndexRepo) SyncService {
+	return &rebuildSyncService{
+		SyncService: svc,
+		indexRepo:   indexRepo,
+	}
+}
+
+// dualWrite 没有正在重建的 typ 索引时什么也不做
+func (s *rebuildSyncService) dualWrite(ctx context.Context, typ domain.SearchType, ids []int64,
+	write func(target SyncService) error) error {
+	index, err := s.indexRepo.Rebuilding(ctx, typ)
+	if err != nil || index == "" {
+		return err
+	}
+	if err = s.indexRepo.Touch(ctx, typ, ids); err != nil {
+		return err
+	}
+	userRepo, inkRepo, commentRepo, err := s.indexRepo.Target(typ, index)
+	if err != nil {
+		return err
+	}
+	return write(NewSyncService(userRepo, inkRepo, commentRepo))
+}
+
+func (s *rebuildSyncService) InputUser(ctx context.Context, users []domain.User) error {
+	if err := s.SyncService.InputUser(ctx, users); err != nil {
+		return err
+	}
+	return s.dualWrite(ctx, domain.SearchTypeUser, lo.Map(users, func(item domain.User, index int) int64 {
+		return item.Id
+	}), func(target SyncService) error {
+		return target.InputUser(ctx, users)
+	})
+}
+
+// InputInk 写入时会把正文转成纯文本, 双写使用写入前的副本
+func (s *rebuildSyncService) InputInk(ctx context.Context, inks []domain.Ink) error {
+	origin := slices.Clone(inks)
+	if err := s.SyncService.InputInk(ctx, inks); err != nil {
+		return err
+	}
+	return s.dualWrite(ctx, domain.SearchTypeInk, lo.Map(origin, func(item domain.Ink, index int) int64 {
+		return item.Id
+	}), func(target SyncService) error {
+		return target.InputInk(ctx, origin)
+	})
+}
+
+func (s *rebuildSyncService) InputComment(ctx context.Context, comments []domain.Comment) error {
+	if err := s.SyncService.InputComment(ctx, comments); err != nil {
+		return err
+	}
+	return s.dualWrite(ctx, domain.SearchTypeComment, lo.Map(comments, func(item domain.Comment, index int) int64 {
+		return item.Id
+	}), func(target SyncService) error {
+		return target.InputComment(ctx, comments)
+	})
+}
+
+func (s *rebuildSyncService) UpdateInkStats(ctx context.Context, stats []domain.InkStats) error {
+	if err := s.SyncService.UpdateInkStats(ctx, stats); err != nil {
+		return err
+	}
+	return s.dualWrite(ctx, domain.SearchTypeInk, lo.Map(stats, func(item domain.InkStats, index int) int64 {
+		return item.Id
+	}), func(target SyncService) error {
+		return target.UpdateInkStats(ctx, stats)
+	})
+}
+
+// DeleteInk 同时删除 ink 下的评论, 评论索引在重建时也要删除
+func (s *rebuildSyncService) DeleteInk(ctx context.Context, inkId int64) error {
+	if err := s.SyncService.DeleteInk(ctx, inkId); err != nil {
+		return err
+	}
+	write := func(target SyncService) error {
+		return target.DeleteInk(ctx, inkId)
+	}
+	if err := s.dualWrite(ctx, domain.SearchTypeInk, []int64{inkId}, write); err != nil {
+		return err
+	}
+	return s.dualWrite(ctx, domain.SearchTypeComment, nil, write)
+}
+
+func (s *rebuildSyncService) DeleteUser(ctx context.Context, userId int64) error {
+	if err := s.SyncService.DeleteUser(ctx, userId); err != nil {
+		return err
+	}
+	return s.dualWrite(ctx, domain.SearchTypeUser, []int64{userId}, func(target SyncService) error {
+		return target.DeleteUser(ctx, userId)
+	})
+}
+
+func (s *rebuildSyncService) DeleteComment(ctx context.Context, commentId int64) error {
+	if err := s.SyncService.DeleteComment(ctx, commentId); err != nil {
+		return err
+	}
+	return s.dualWrite(ctx, domain.SearchTypeComment, []int64{commentId}, func(target SyncService) error {
+		return target.DeleteComment(ctx, commentId)
+	})
+}
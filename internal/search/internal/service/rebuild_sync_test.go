@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeIndexRepo struct {
+	repo.IndexRepo
+	rebuilding string
+	touched    []int64
+	target     *fakeInkRepo
+}
+
+func (r *fakeIndexRepo) Rebuilding(ctx context.Context, typ domain.SearchType) (string, error) {
+	return r.rebuilding, nil
+}
+
+func (r *fakeIndexRepo) Touch(ctx context.Context, typ domain.SearchType, ids []int64) error {
+	r.touched = append(r.touched, ids...)
+	return nil
+}
+
+func (r *fakeIndexRepo) Target(typ domain.SearchType, index string) (repo.UserRepo, repo.InkRepo, repo.CommentRepo, error) {
+	return nil, r.target, nil, nil
+}
+
+func TestRebuildSyncService_InputInk(t *testing.T) {
+	live, target := &fakeInkRepo{}, &fakeInkRepo{}
+	indexRepo := &fakeIndexRepo{target: target}
+	svc := NewRebuildSyncService(NewSyncService(nil, live, nil), indexRepo)
+
+	err := svc.InputInk(context.Background(), []domain.Ink{{Id: 1, Content: "<p>hi</p>"}})
+	require.NoError(t, err)
+	assert.Len(t, live.input, 1)
+	// 没有正在重建的索引时只写入正在使用的索引
+	assert.Empty(t, target.input)
+	assert.Empty(t, indexRepo.touched)
+
+	indexRepo.rebuilding = "ink_index_1"
+	err = svc.InputInk(context.Background(), []domain.Ink{{Id: 2, Content: "<p>hi</p>"}})
+	require.NoError(t, err)
+	assert.Len(t, live.input, 2)
+	require.Len(t, target.input, 1)
+	assert.Equal(t, "hi", target.input[0].Content)
+	assert.Equal(t, []int64{2}, indexRepo.touched)
+}
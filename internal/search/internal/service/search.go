@@ -2,27 +2,38 @@ package service
 
 import (
 	"context"
+	"github.com/KNICEX/InkFlow/internal/ink"
 	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
 	"github.com/KNICEX/InkFlow/internal/search/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/logx"
 )
 
 type SearchService interface {
 	SearchUser(ctx context.Context, query string, offset, limit int) ([]domain.User, error)
-	SearchInk(ctx context.Context, query string, offset, limit int) ([]domain.Ink, error)
+	SearchInk(ctx context.Context, query domain.InkQuery) (domain.InkSearchResult, error)
 	SearchComment(ctx context.Context, query string, offset, limit int) ([]domain.Comment, error)
+	// Suggest 按前缀补全搜索词, 依次取热门搜索词, 热门标签和用户账号, 去重后最多返回 limit 个
+	Suggest(ctx context.Context, prefix string, limit int) ([]domain.Suggestion, error)
 }
 
 type searchService struct {
 	userRepo    repo.UserRepo
 	inkRepo     repo.InkRepo
 	commentRepo repo.CommentRepo
+	queryRepo   repo.QueryRepo
+	rankingSvc  ink.RankingService
+	l           logx.Logger
 }
 
-func NewSearchService(userRepo repo.UserRepo, inkRepo repo.InkRepo, commentRepo repo.CommentRepo) SearchService {
+func NewSearchService(userRepo repo.UserRepo, inkRepo repo.InkRepo, commentRepo repo.CommentRepo,
+	queryRepo repo.QueryRepo, rankingSvc ink.RankingService, l logx.Logger) SearchService {
 	return &searchService{
 		userRepo:    userRepo,
 		inkRepo:     inkRepo,
 		commentRepo: commentRepo,
+		queryRepo:   queryRepo,
+		rankingSvc:  rankingSvc,
+		l:           l,
 	}
 }
 
@@ -34,12 +45,12 @@ func (s *searchService) SearchUser(ctx context.Context, query string, offset, li
 	return users, nil
 }
 
-func (s *searchService) SearchInk(ctx context.Context, query string, offset, limit int) ([]domain.Ink, error) {
-	inks, err := s.inkRepo.SearchInk(ctx, query, offset, limit)
-	if err != nil {
-		return nil, err
+// SearchInk 未指定或无法识别的排序方式按相关性排序
+func (s *searchService) SearchInk(ctx context.Context, query domain.InkQuery) (domain.InkSearchResult, error) {
+	if !query.Sort.Valid() {
+		query.Sort = domain.InkSortRelevance
 	}
-	return inks, nil
+	return s.inkRepo.SearchInk(ctx, query)
 }
 
 func (s *searchService) SearchComment(ctx context.Context, query string, offset, limit int) ([]domain.Comment, error) {
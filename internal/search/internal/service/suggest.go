@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"golang.org/x/sync/errgroup"
+	"strings"
+)
+
+const (
+	// suggestCandidates 每个来源取多少个候选再按前缀过滤
+	suggestCandidates = 500
+	// maxQueryLen 超过该长度的搜索词不计入热门, 通常是粘贴的整段文字
+	maxQueryLen = 32
+)
+
+func normalizeQuery(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+// Suggest 热门搜索词由 AnalyticsService 汇总搜索记录后写入, 单个来源出错时跳过该来源, 不影响其他来源的结果
+func (s *searchService) Suggest(ctx context.Context, prefix string, limit int) ([]domain.Suggestion, error) {
+	prefix = normalizeQuery(prefix)
+	if prefix == "" || limit <= 0 {
+		return nil, nil
+	}
+	var queries, tags, accounts []string
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		res, err := s.queryRepo.PopularQueries(egCtx, suggestCandidates)
+		if err != nil {
+			s.l.WithCtx(ctx).Warn("find popular queries error", logx.Error(err))
+			return nil
+		}
+		queries = res
+		return nil
+	})
+	eg.Go(func() error {
+		res, err := s.rankingSvc.FindTopNTag(egCtx, 0, suggestCandidates)
+		if err != nil {
+			s.l.WithCtx(ctx).Warn("find top tags error", logx.Error(err))
+			return nil
+		}
+		for _, tag := range res {
+			tags = append(tags, tag.Name)
+		}
+		return nil
+	})
+	eg.Go(func() error {
+		res, err := s.userRepo.Search(egCtx, prefix, 0, limit)
+		if err != nil {
+			s.l.WithCtx(ctx).Warn("search user for suggestion error", logx.Error(err))
+			return nil
+		}
+		for _, u := range res {
+			accounts = append(accounts, u.Account)
+		}
+		return nil
+	})
+	_ = eg.Wait()
+
+	suggestions := make([]domain.Suggestion, 0, limit)
+	seen := make(map[string]struct{})
+	appendMatched := func(texts []string, typ domain.SuggestionType) {
+		for _, text := range texts {
+			if len(suggestions) >= limit {
+				return
+			}
+			key := strings.ToLower(text)
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			suggestions = append(suggestions, domain.Suggestion{Text: text, Type: typ})
+		}
+	}
+	appendMatched(queries, domain.SuggestionQuery)
+	appendMatched(tags, domain.SuggestionTag)
+	appendMatched(accounts, domain.SuggestionUser)
+	return suggestions, nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeQueryRepo struct {
+	repo.QueryRepo
+	popular []string
+}
+
+func (r *fakeQueryRepo) SetPopularQueries(ctx context.Context, queries []string) error {
+	r.popular = queries
+	return nil
+}
+
+func (r *fakeQueryRepo) PopularQueries(ctx context.Context, limit int) ([]string, error) {
+	return r.popular, nil
+}
+
+type fakeRankingService struct {
+	ink.RankingService
+	tags []ink.TagStats
+}
+
+func (s *fakeRankingService) FindTopNTag(ctx context.Context, offset int, limit int) ([]ink.TagStats, error) {
+	return s.tags, nil
+}
+
+type fakeUserRepo struct {
+	repo.UserRepo
+	users []domain.User
+	err   error
+}
+
+func (r *fakeUserRepo) Search(ctx context.Context, query string, offset, limit int) ([]domain.User, error) {
+	return r.users, r.err
+}
+
+func TestSearchService_Suggest(t *testing.T) {
+	queryRepo := &fakeQueryRepo{popular: []string{"golang 入门", "rust", "go 并发"}}
+	rankingSvc := &fakeRankingService{tags: []ink.TagStats{{Name: "Go"}, {Name: "golang 入门"}, {Name: "Java"}}}
+	userRepo := &fakeUserRepo{users: []domain.User{{Account: "gopher"}, {Account: "alice"}}}
+	svc := NewSearchService(userRepo, nil, nil, queryRepo, rankingSvc, logx.NewNopLogger())
+
+	res, err := svc.Suggest(context.Background(), " GO ", 10)
+	require.NoError(t, err)
+	// 热门搜索词优先, 与已有建议重复的标签不再返回, 不匹配前缀的账号被过滤
+	assert.Equal(t, []domain.Suggestion{
+		{Text: "golang 入门", Type: domain.SuggestionQuery},
+		{Text: "go 并发", Type: domain.SuggestionQuery},
+		{Text: "Go", Type: domain.SuggestionTag},
+		{Text: "gopher", Type: domain.SuggestionUser},
+	}, res)
+
+	res, err = svc.Suggest(context.Background(), "go", 2)
+	require.NoError(t, err)
+	assert.Len(t, res, 2)
+
+	// 单个来源出错不影响其他来源
+	userRepo.err = errors.New("meili unavailable")
+	res, err = svc.Suggest(context.Background(), "go", 10)
+	require.NoError(t, err)
+	assert.Len(t, res, 3)
+}
@@ -4,13 +4,15 @@ import (
 	"context"
 	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
 	"github.com/KNICEX/InkFlow/internal/search/internal/repo"
-	strip "github.com/grokify/html-strip-tags-go"
+	"github.com/KNICEX/InkFlow/pkg/htmlx"
 )
 
 type SyncService interface {
 	InputUser(ctx context.Context, users []domain.User) error
 	InputInk(ctx context.Context, inks []domain.Ink) error
 	InputComment(ctx context.Context, comments []domain.Comment) error
+	// UpdateInkStats 更新作品的浏览和点赞数, 不影响索引中的其他字段
+	UpdateInkStats(ctx context.Context, stats []domain.InkStats) error
 	DeleteInk(ctx context.Context, inkId int64) error
 	DeleteUser(ctx context.Context, userId int64) error
 	DeleteComment(ctx context.Context, commentId int64) error
@@ -36,7 +38,8 @@ func (s *syncService) InputUser(ctx context.Context, users []domain.User) error
 
 func (s *syncService) InputInk(ctx context.Context, inks []domain.Ink) error {
 	for i, _ := range inks {
-		inks[i].Content = strip.StripTags(inks[i].Content)
+		// 搜索只索引正文纯文本
+		inks[i].Content = htmlx.Text(inks[i].Content)
 	}
 	return s.inkRepo.InputInk(ctx, inks)
 }
@@ -45,6 +48,13 @@ func (s *syncService) InputComment(ctx context.Context, comments []domain.Commen
 	return s.commentRepo.InputComment(ctx, comments)
 }
 
+func (s *syncService) UpdateInkStats(ctx context.Context, stats []domain.InkStats) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	return s.inkRepo.UpdateStats(ctx, stats)
+}
+
 func (s *syncService) DeleteInk(ctx context.Context, inkId int64) error {
 	err := s.inkRepo.DeleteInk(ctx, []int64{inkId})
 	if err != nil {
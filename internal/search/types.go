@@ -3,14 +3,62 @@ package search
 import (
 	"github.com/KNICEX/InkFlow/internal/search/internal/domain"
 	"github.com/KNICEX/InkFlow/internal/search/internal/event"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo/dao"
 	"github.com/KNICEX/InkFlow/internal/search/internal/service"
 )
 
 type SyncService = service.SyncService
 type Service = service.SearchService
+type AnalyticsService = service.AnalyticsService
+type IndexService = service.IndexService
+
+var (
+	ErrInvalidClick    = service.ErrInvalidClick
+	ErrIndexTaskFailed = service.ErrIndexTaskFailed
+)
 
 type SyncConsumer = event.SyncConsumer
+type LogConsumer = event.LogConsumer
+type InkStatsConsumer = event.InkStatsConsumer
+
+// Backend 搜索引擎后端, 由 search.backend 配置选择
+type Backend = dao.Backend
 
 type Ink = domain.Ink
+type InkStats = domain.InkStats
+type InkQuery = domain.InkQuery
+type InkSort = domain.InkSort
+type InkSearchResult = domain.InkSearchResult
 type Comment = domain.Comment
 type User = domain.User
+type SearchType = domain.SearchType
+type SearchLog = domain.SearchLog
+type SearchClick = domain.SearchClick
+type QueryStats = domain.QueryStats
+type IndexCheckResult = domain.IndexCheckResult
+type Suggestion = domain.Suggestion
+type SuggestionType = domain.SuggestionType
+
+const (
+	InkSortRelevance = domain.InkSortRelevance
+	InkSortNewest    = domain.InkSortNewest
+	InkSortMostLiked = domain.InkSortMostLiked
+)
+
+const (
+	SearchTypeInk     = domain.SearchTypeInk
+	SearchTypeUser    = domain.SearchTypeUser
+	SearchTypeComment = domain.SearchTypeComment
+)
+
+const (
+	SuggestionQuery = domain.SuggestionQuery
+	SuggestionTag   = domain.SuggestionTag
+	SuggestionUser  = domain.SuggestionUser
+)
+
+const (
+	FacetTags        = domain.FacetTags
+	FacetContentType = domain.FacetContentType
+	FacetCategory    = domain.FacetCategory
+)
@@ -2,12 +2,21 @@ package search
 
 import (
 	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/internal/interactive"
 	"github.com/KNICEX/InkFlow/internal/search/internal/event"
 	"github.com/KNICEX/InkFlow/internal/search/internal/repo"
+	"github.com/KNICEX/InkFlow/internal/search/internal/repo/cache"
 	"github.com/KNICEX/InkFlow/internal/search/internal/repo/dao"
 	"github.com/KNICEX/InkFlow/internal/search/internal/service"
 	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/saramax"
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/meilisearch/meilisearch-go"
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
 	"sync"
 )
 
@@ -15,39 +24,90 @@ var (
 	inkRepo     repo.InkRepo
 	commentRepo repo.CommentRepo
 	userRepo    repo.UserRepo
+	indexRepo   repo.IndexRepo
 	once        sync.Once
+
+	logRepo repo.SearchLogRepo
+	logOnce sync.Once
 )
 
-func initRepo(meili meilisearch.ServiceManager) {
+// InitMeiliBackend 索引的停用词和拼写容错可以通过 search.indexes.<索引名> 配置覆盖
+func InitMeiliBackend(meili meilisearch.ServiceManager) Backend {
+	var tuning map[string]dao.IndexTuning
+	if err := viper.UnmarshalKey("search.indexes", &tuning); err != nil {
+		panic(err)
+	}
+	return dao.NewMeiliBackend(meili, tuning)
+}
+
+// InitEsBackend 中文分词器通过 search.es.analyzer 配置, 默认 ik
+func InitEsBackend(es *elasticsearch.Client) Backend {
+	return dao.NewEsBackend(es, viper.GetString("search.es.analyzer"))
+}
+
+func initRepo(backend Backend, cmd redis.Cmdable) {
 	once.Do(func() {
-		if err := dao.InitMeili(meili); err != nil {
+		if err := backend.Init(); err != nil {
 			panic(err)
 		}
-		userDao := dao.NewMeiliUserDAO(meili)
-		inkRepo = repo.NewInkRepo(dao.NewMeiliInkDAO(meili), userDao)
-		commentRepo = repo.NewCommentRepo(dao.NewMeiliCommentDAO(meili), userDao)
-		userRepo = repo.NewUserRepo(userDao)
+		inkRepo = repo.NewInkRepo(backend.Ink, backend.User)
+		commentRepo = repo.NewCommentRepo(backend.Comment, backend.User)
+		userRepo = repo.NewUserRepo(backend.User)
+		indexRepo = repo.NewIndexRepo(backend, cache.NewRedisRebuildCache(cmd))
 	})
 }
 
-func InitSearchService(meili meilisearch.ServiceManager) Service {
-	initRepo(meili)
-	return service.NewSearchService(userRepo, inkRepo, commentRepo)
+func InitSearchService(backend Backend, cmd redis.Cmdable, rankingSvc ink.RankingService, l logx.Logger) Service {
+	initRepo(backend, cmd)
+	queryRepo := repo.NewQueryRepo(cache.NewRedisQueryCache(cmd))
+	return service.NewSearchService(userRepo, inkRepo, commentRepo, queryRepo, rankingSvc, l)
 }
 
-func InitSyncConsumer(svc SyncService, cli sarama.Client, l logx.Logger) *SyncConsumer {
+func InitSyncConsumer(svc SyncService, cli sarama.Client, retry *saramax.RetryHandler, l logx.Logger) *SyncConsumer {
 	replyHandler := event.NewReplyHandler(svc)
 	userCreateHandler := event.NewUserCreateHandler(svc)
 	userUpdateHandler := event.NewUserUpdateHandler(svc)
 
-	consumer := event.NewSyncConsumer(cli, svc, l)
+	consumer := event.NewSyncConsumer(cli, svc, retry, l)
 	if err := consumer.RegisterHandler(replyHandler, userCreateHandler, userUpdateHandler); err != nil {
 		panic(err)
 	}
 	return consumer
 }
 
-func InitSyncService(meili meilisearch.ServiceManager) SyncService {
-	initRepo(meili)
-	return service.NewSyncService(userRepo, inkRepo, commentRepo)
+func InitInkStatsConsumer(svc SyncService, intrSvc interactive.Service, cli sarama.Client,
+	retry *saramax.RetryHandler, l logx.Logger) *InkStatsConsumer {
+	return event.NewInkStatsConsumer(cli, svc, intrSvc, retry, l)
+}
+
+func InitSyncService(backend Backend, cmd redis.Cmdable) SyncService {
+	initRepo(backend, cmd)
+	return service.NewRebuildSyncService(service.NewSyncService(userRepo, inkRepo, commentRepo), indexRepo)
+}
+
+func InitIndexService(backend Backend, cmd redis.Cmdable) IndexService {
+	initRepo(backend, cmd)
+	syncSvc := service.NewRebuildSyncService(service.NewSyncService(userRepo, inkRepo, commentRepo), indexRepo)
+	return service.NewIndexService(indexRepo, userRepo, inkRepo, commentRepo, syncSvc)
+}
+
+func initLogRepo(db *gorm.DB) repo.SearchLogRepo {
+	logOnce.Do(func() {
+		if err := dao.InitTables(db); err != nil {
+			panic(err)
+		}
+		logRepo = repo.NewSearchLogRepo(dao.NewGormSearchLogDAO(db))
+	})
+	return logRepo
+}
+
+func InitAnalyticsService(db *gorm.DB, cmd redis.Cmdable, producer sarama.SyncProducer, l logx.Logger) AnalyticsService {
+	node := snowflakex.NewNode(snowflakex.DefaultStartTime, 0)
+	resultRepo := repo.NewResultRepo(cache.NewRedisResultCache(cmd))
+	queryRepo := repo.NewQueryRepo(cache.NewRedisQueryCache(cmd))
+	return service.NewAnalyticsService(initLogRepo(db), resultRepo, queryRepo, event.NewKafkaLogProducer(producer), node, l)
+}
+
+func InitLogConsumer(db *gorm.DB, cli sarama.Client, retry *saramax.RetryHandler, l logx.Logger) *LogConsumer {
+	return event.NewLogConsumer(cli, initLogRepo(db), retry, l)
 }
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/KNICEX/InkFlow/pkg/ahocorasick"
+)
+
+// Word 敏感词, Category 为分类, 比如 政治、色情、广告
+type Word struct {
+	Id        int64
+	Word      string
+	Category  string
+	CreatedAt time.Time
+}
+
+// Match 一次命中, Start 和 End 为 rune 下标, 左闭右开
+type Match = ahocorasick.Match
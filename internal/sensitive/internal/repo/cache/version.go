@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const versionKey = "sensitive:words:version"
+
+// VersionCache 词库版本号, 每次修改词库后自增, 各实例发现版本变化后重新加载
+type VersionCache interface {
+	// Version 未设置过时返回 0
+	Version(ctx context.Context) (int64, error)
+	Incr(ctx context.Context) (int64, error)
+}
+
+type RedisVersionCache struct {
+	cmd redis.Cmdable
+}
+
+func NewRedisVersionCache(cmd redis.Cmdable) VersionCache {
+	return &RedisVersionCache{
+		cmd: cmd,
+	}
+}
+
+func (cache *RedisVersionCache) Version(ctx context.Context) (int64, error) {
+	v, err := cache.cmd.Get(ctx, versionKey).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return v, err
+}
+
+func (cache *RedisVersionCache) Incr(ctx context.Context) (int64, error) {
+	return cache.cmd.Incr(ctx, versionKey).Result()
+}
@@ -0,0 +1,75 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SensitiveWord struct {
+	Id        int64
+	Word      string `gorm:"type:varchar(64);uniqueIndex:word_category"`
+	Category  string `gorm:"type:varchar(32);uniqueIndex:word_category;index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type WordDAO interface {
+	// Insert 批量插入, 已存在的词会被忽略
+	Insert(ctx context.Context, words []SensitiveWord) error
+	Delete(ctx context.Context, ids []int64) error
+	FindAll(ctx context.Context) ([]SensitiveWord, error)
+	// FindByCategory category 为空时查找所有分类
+	FindByCategory(ctx context.Context, category string, offset, limit int) ([]SensitiveWord, error)
+}
+
+type GormWordDAO struct {
+	db   *gorm.DB
+	node snowflakex.Node
+}
+
+func NewGormWordDAO(db *gorm.DB, node snowflakex.Node) WordDAO {
+	return &GormWordDAO{
+		db:   db,
+		node: node,
+	}
+}
+
+func (dao *GormWordDAO) Insert(ctx context.Context, words []SensitiveWord) error {
+	if len(words) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for i := range words {
+		words[i].Id = dao.node.NextID()
+		words[i].CreatedAt = now
+		words[i].UpdatedAt = now
+	}
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&words).Error
+}
+
+func (dao *GormWordDAO) Delete(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return dao.db.WithContext(ctx).Where("id IN ?", ids).Delete(&SensitiveWord{}).Error
+}
+
+func (dao *GormWordDAO) FindAll(ctx context.Context) ([]SensitiveWord, error) {
+	var words []SensitiveWord
+	err := dao.db.WithContext(ctx).Find(&words).Error
+	return words, err
+}
+
+func (dao *GormWordDAO) FindByCategory(ctx context.Context, category string, offset, limit int) ([]SensitiveWord, error) {
+	var words []SensitiveWord
+	tx := dao.db.WithContext(ctx)
+	if category != "" {
+		tx = tx.Where("category = ?", category)
+	}
+	err := tx.Order("id desc").Offset(offset).Limit(limit).Find(&words).Error
+	return words, err
+}
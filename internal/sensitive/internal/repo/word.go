@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/KNICEX/InkFlow/internal/sensitive/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/sensitive/internal/repo/cache"
+	"github.com/KNICEX/InkFlow/internal/sensitive/internal/repo/dao"
+	"github.com/samber/lo"
+)
+
+type WordRepo interface {
+	// Create 和 Delete 修改词库后更新版本号
+	Create(ctx context.Context, words []domain.Word) error
+	Delete(ctx context.Context, ids []int64) error
+	FindAll(ctx context.Context) ([]domain.Word, error)
+	FindByCategory(ctx context.Context, category string, offset, limit int) ([]domain.Word, error)
+	Version(ctx context.Context) (int64, error)
+}
+
+type CachedWordRepo struct {
+	dao   dao.WordDAO
+	cache cache.VersionCache
+}
+
+func NewCachedWordRepo(dao dao.WordDAO, cache cache.VersionCache) WordRepo {
+	return &CachedWordRepo{
+		dao:   dao,
+		cache: cache,
+	}
+}
+
+func (repo *CachedWordRepo) Create(ctx context.Context, words []domain.Word) error {
+	err := repo.dao.Insert(ctx, lo.Map(words, func(item domain.Word, index int) dao.SensitiveWord {
+		return repo.toEntity(item)
+	}))
+	if err != nil {
+		return err
+	}
+	_, err = repo.cache.Incr(ctx)
+	return err
+}
+
+func (repo *CachedWordRepo) Delete(ctx context.Context, ids []int64) error {
+	if err := repo.dao.Delete(ctx, ids); err != nil {
+		return err
+	}
+	_, err := repo.cache.Incr(ctx)
+	return err
+}
+
+func (repo *CachedWordRepo) FindAll(ctx context.Context) ([]domain.Word, error) {
+	words, err := repo.dao.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(words, func(item dao.SensitiveWord, index int) domain.Word {
+		return repo.toDomain(item)
+	}), nil
+}
+
+func (repo *CachedWordRepo) FindByCategory(ctx context.Context, category string, offset, limit int) ([]domain.Word, error) {
+	words, err := repo.dao.FindByCategory(ctx, category, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(words, func(item dao.SensitiveWord, index int) domain.Word {
+		return repo.toDomain(item)
+	}), nil
+}
+
+func (repo *CachedWordRepo) Version(ctx context.Context) (int64, error) {
+	return repo.cache.Version(ctx)
+}
+
+func (repo *CachedWordRepo) toEntity(w domain.Word) dao.SensitiveWord {
+	return dao.SensitiveWord{
+		Id:       w.Id,
+		Word:     w.Word,
+		Category: w.Category,
+	}
+}
+
+func (repo *CachedWordRepo) toDomain(w dao.SensitiveWord) domain.Word {
+	return domain.Word{
+		Id:        w.Id,
+		Word:      w.Word,
+		Category:  w.Category,
+		CreatedAt: w.CreatedAt,
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/KNICEX/InkFlow/internal/sensitive/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/sensitive/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/ahocorasick"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/samber/lo"
+)
+
+var ErrInvalidWord = errors.New("invalid sensitive word")
+
+const (
+	maskRune = '*'
+
+	maxWordLen     = 64
+	maxCategoryLen = 32
+)
+
+type SensitiveService interface {
+	// Match 返回所有命中的敏感词及其位置, 词库未加载时不命中任何词
+	Match(ctx context.Context, text string) []domain.Match
+	Contains(ctx context.Context, text string) bool
+	// Mask 将命中的敏感词替换为 *
+	Mask(ctx context.Context, text string) string
+
+	AddWords(ctx context.Context, words []domain.Word) error
+	DelWords(ctx context.Context, ids []int64) error
+	// ListWords category 为空时列出所有分类
+	ListWords(ctx context.Context, category string, offset, limit int) ([]domain.Word, error)
+
+	// Reload 词库版本或者配置中的词变化时重新构建自动机
+	Reload(ctx context.Context) error
+}
+
+// ConfigWords 配置文件中的词, 配置文件修改后由 viper 热更新, 下次 Reload 时生效
+type ConfigWords func() []domain.Word
+
+// dictionary 当前生效的词库, 构建后只读
+type dictionary struct {
+	version int64
+	extra   []domain.Word
+	matcher *ahocorasick.Matcher
+}
+
+type sensitiveService struct {
+	repo        repo.WordRepo
+	configWords ConfigWords
+	dict        atomic.Pointer[dictionary]
+	l           logx.Logger
+}
+
+func NewSensitiveService(repo repo.WordRepo, configWords ConfigWords, l logx.Logger) SensitiveService {
+	return &sensitiveService{
+		repo:        repo,
+		configWords: configWords,
+		l:           l,
+	}
+}
+
+func (svc *sensitiveService) Match(ctx context.Context, text string) []domain.Match {
+	dict := svc.dict.Load()
+	if dict == nil {
+		return nil
+	}
+	return dict.matcher.FindAll(text)
+}
+
+func (svc *sensitiveService) Contains(ctx context.Context, text string) bool {
+	dict := svc.dict.Load()
+	if dict == nil {
+		return false
+	}
+	return dict.matcher.Contains(text)
+}
+
+func (svc *sensitiveService) Mask(ctx context.Context, text string) string {
+	dict := svc.dict.Load()
+	if dict == nil {
+		return text
+	}
+	return dict.matcher.Mask(text, maskRune)
+}
+
+func (svc *sensitiveService) AddWords(ctx context.Context, words []domain.Word) error {
+	for i, w := range words {
+		w.Word = strings.TrimSpace(w.Word)
+		w.Category = strings.TrimSpace(w.Category)
+		if w.Word == "" || len([]rune(w.Word)) > maxWordLen || len([]rune(w.Category)) > maxCategoryLen {
+			return ErrInvalidWord
+		}
+		words[i] = w
+	}
+	if err := svc.repo.Create(ctx, words); err != nil {
+		return err
+	}
+	return svc.Reload(ctx)
+}
+
+func (svc *sensitiveService) DelWords(ctx context.Context, ids []int64) error {
+	if err := svc.repo.Delete(ctx, ids); err != nil {
+		return err
+	}
+	return svc.Reload(ctx)
+}
+
+func (svc *sensitiveService) ListWords(ctx context.Context, category string, offset, limit int) ([]domain.Word, error) {
+	return svc.repo.FindByCategory(ctx, category, offset, limit)
+}
+
+func (svc *sensitiveService) Reload(ctx context.Context) error {
+	version, err := svc.repo.Version(ctx)
+	if err != nil {
+		return err
+	}
+	extra := svc.configWords()
+	old := svc.dict.Load()
+	if old != nil && old.version == version && slices.Equal(old.extra, extra) {
+		return nil
+	}
+
+	words, err := svc.repo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	patterns := lo.Map(append(words, extra...), func(item domain.Word, index int) ahocorasick.Pattern {
+		return ahocorasick.Pattern{
+			Word:     item.Word,
+			Category: item.Category,
+		}
+	})
+	matcher := ahocorasick.New(patterns)
+	svc.dict.Store(&dictionary{
+		version: version,
+		extra:   extra,
+		matcher: matcher,
+	})
+	svc.l.WithCtx(ctx).Info("sensitive words reloaded", logx.Int64("version", version),
+		logx.Int64("count", int64(matcher.Len())))
+	return nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KNICEX/InkFlow/internal/sensitive/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/sensitive/internal/repo"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWordRepo struct {
+	repo.WordRepo
+	words   []domain.Word
+	version int64
+	loadCnt int
+}
+
+func (r *fakeWordRepo) FindAll(ctx context.Context) ([]domain.Word, error) {
+	r.loadCnt++
+	return append([]domain.Word(nil), r.words...), nil
+}
+
+func (r *fakeWordRepo) Version(ctx context.Context) (int64, error) {
+	return r.version, nil
+}
+
+func TestSensitiveServiceReload(t *testing.T) {
+	ctx := context.Background()
+	wordRepo := &fakeWordRepo{words: []domain.Word{{Word: "赌博", Category: "gamble"}}}
+	extra := []domain.Word{{Word: "spam", Category: "ad"}}
+	svc := NewSensitiveService(wordRepo, func() []domain.Word {
+		return extra
+	}, logx.NewNopLogger())
+
+	assert.False(t, svc.Contains(ctx, "网络赌博"), "未加载时不命中")
+
+	require.NoError(t, svc.Reload(ctx))
+	assert.Equal(t, []domain.Match{
+		{Word: "赌博", Category: "gamble", Start: 2, End: 4},
+		{Word: "spam", Category: "ad", Start: 6, End: 10},
+	}, svc.Match(ctx, "网络赌博, SPAM"))
+	assert.Equal(t, "网络**, ****", svc.Mask(ctx, "网络赌博, SPAM"))
+
+	// 版本和配置都没有变化时不重新加载
+	require.NoError(t, svc.Reload(ctx))
+	assert.Equal(t, 1, wordRepo.loadCnt)
+
+	wordRepo.version++
+	wordRepo.words = append(wordRepo.words, domain.Word{Word: "代开发票", Category: "ad"})
+	require.NoError(t, svc.Reload(ctx))
+	assert.Equal(t, 2, wordRepo.loadCnt)
+	assert.True(t, svc.Contains(ctx, "代开发票联系我"))
+
+	extra = nil
+	require.NoError(t, svc.Reload(ctx))
+	assert.Equal(t, 3, wordRepo.loadCnt)
+	assert.False(t, svc.Contains(ctx, "spam"))
+}
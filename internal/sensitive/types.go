@@ -0,0 +1,17 @@
+package sensitive
+
+import (
+	"github.com/KNICEX/InkFlow/internal/sensitive/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/sensitive/internal/service"
+	"github.com/KNICEX/InkFlow/pkg/schedulex"
+)
+
+type Service = service.SensitiveService
+
+type Word = domain.Word
+type Match = domain.Match
+
+var ErrInvalidWord = service.ErrInvalidWord
+
+// ReloadScheduler 定时检查词库版本, 实现热更新
+type ReloadScheduler schedulex.Scheduler
@@ -0,0 +1,74 @@
+//go:build wireinject
+
+package sensitive
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/sensitive/internal/domain"
+	"github.com/KNICEX/InkFlow/internal/sensitive/internal/repo"
+	"github.com/KNICEX/InkFlow/internal/sensitive/internal/repo/cache"
+	"github.com/KNICEX/InkFlow/internal/sensitive/internal/repo/dao"
+	"github.com/KNICEX/InkFlow/internal/sensitive/internal/service"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/KNICEX/InkFlow/pkg/schedulex"
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"github.com/google/wire"
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+func initSnowflakeNode() snowflakex.Node {
+	return snowflakex.NewNode(snowflakex.DefaultStartTime, 0)
+}
+
+func initDAO(db *gorm.DB, node snowflakex.Node) dao.WordDAO {
+	if err := dao.InitTables(db); err != nil {
+		panic(err)
+	}
+	return dao.NewGormWordDAO(db, node)
+}
+
+// initConfigWords 读取 sensitive.words 配置, key 为分类, 每次调用都读取最新的配置
+func initConfigWords() service.ConfigWords {
+	return func() []domain.Word {
+		var words []domain.Word
+		for category, list := range viper.GetStringMapStringSlice("sensitive.words") {
+			for _, w := range list {
+				if w = strings.TrimSpace(w); w != "" {
+					words = append(words, domain.Word{Word: w, Category: category})
+				}
+			}
+		}
+		// map 无序, 排序后才能和上次的配置比较
+		slices.SortFunc(words, func(a, b domain.Word) int {
+			return cmp.Or(strings.Compare(a.Category, b.Category), strings.Compare(a.Word, b.Word))
+		})
+		return words
+	}
+}
+
+func InitService(db *gorm.DB, cmd redis.Cmdable, l logx.Logger) Service {
+	wire.Build(
+		initSnowflakeNode,
+		initDAO,
+		cache.NewRedisVersionCache,
+		repo.NewCachedWordRepo,
+		initConfigWords,
+		service.NewSensitiveService,
+	)
+	return nil
+}
+
+// InitReloadScheduler 检查间隔由 sensitive.reload_interval 配置, 默认 30s
+func InitReloadScheduler(svc Service, l logx.Logger) ReloadScheduler {
+	interval := viper.GetDuration("sensitive.reload_interval")
+	if interval <= 0 {
+		interval = time.Second * 30
+	}
+	return schedulex.NewIntervalScheduler("sensitive-reload", interval, svc.Reload, l)
+}
@@ -26,6 +26,8 @@ type UserDAO interface {
 	UpdateById(ctx context.Context, u User) error
 	FindByGithubId(ctx context.Context, id int64) (User, error)
 	FindByAccountName(ctx context.Context, account string) (User, error)
+	// FindAll 按 id 倒序分页遍历全部用户, maxId 为 0 时从最新的开始
+	FindAll(ctx context.Context, maxId int64, limit int) ([]User, error)
 }
 
 type User struct {
@@ -148,3 +150,13 @@ func (dao *GormUserDAO) FindByAccountName(ctx context.Context, account string) (
 	}
 	return u, nil
 }
+
+func (dao *GormUserDAO) FindAll(ctx context.Context, maxId int64, limit int) ([]User, error) {
+	var users []User
+	tx := dao.db.WithContext(ctx)
+	if maxId != 0 {
+		tx = tx.Where("id < ?", maxId)
+	}
+	err := tx.Order("id DESC").Limit(limit).Find(&users).Error
+	return users, err
+}
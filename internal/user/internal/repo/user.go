@@ -26,6 +26,7 @@ type UserRepo interface {
 	FindByIds(ctx context.Context, ids []int64) (map[int64]domain.User, error)
 	FindByGithubId(ctx context.Context, id int64) (domain.User, error)
 	UpdateNonZeroFields(ctx context.Context, u domain.User) error
+	FindAll(ctx context.Context, maxId int64, limit int) ([]domain.User, error)
 }
 
 var _ UserRepo = (*CachedUserRepo)(nil)
@@ -179,6 +180,16 @@ func (r *CachedUserRepo) UpdateNonZeroFields(ctx context.Context, u domain.User)
 	return nil
 }
 
+func (r *CachedUserRepo) FindAll(ctx context.Context, maxId int64, limit int) ([]domain.User, error) {
+	users, err := r.dao.FindAll(ctx, maxId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(users, func(item dao.User, index int) domain.User {
+		return r.entityToDomain(item)
+	}), nil
+}
+
 func (r *CachedUserRepo) FindByAccount(ctx context.Context, accountName string) (domain.User, error) {
 	user, err := r.cache.GetByAccount(ctx, accountName)
 	if err == nil {
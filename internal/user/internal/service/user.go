@@ -37,6 +37,9 @@ type UserService interface {
 
 	ResetPwd(ctx context.Context, uid int64, newPwd string) error
 	ChangePwd(ctx context.Context, uid int64, oldPwd, newPwd string) error
+
+	// ListAll 按 id 倒序遍历全部用户, 用于重建搜索索引
+	ListAll(ctx context.Context, maxId int64, limit int) ([]domain.User, error)
 }
 
 type userService struct {
@@ -250,3 +253,8 @@ func (svc *userService) ChangePwd(ctx context.Context, uid int64, oldPwd, newPwd
 		Password: newPwd,
 	})
 }
+
+// ListAll 不要暴露给用户
+func (svc *userService) ListAll(ctx context.Context, maxId int64, limit int) ([]domain.User, error) {
+	return svc.repo.FindAll(ctx, maxId, limit)
+}
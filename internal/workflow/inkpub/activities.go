@@ -2,14 +2,45 @@ package inkpub
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/KNICEX/InkFlow/internal/action"
+	"github.com/KNICEX/InkFlow/internal/ai"
 	"github.com/KNICEX/InkFlow/internal/feed"
 	"github.com/KNICEX/InkFlow/internal/ink"
 	"github.com/KNICEX/InkFlow/internal/interactive"
 	"github.com/KNICEX/InkFlow/internal/notification"
+	"github.com/KNICEX/InkFlow/internal/prompt"
 	"github.com/KNICEX/InkFlow/internal/recommend"
 	"github.com/KNICEX/InkFlow/internal/review"
 	"github.com/KNICEX/InkFlow/internal/search"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
+	"github.com/KNICEX/InkFlow/internal/workflow/searchindex"
+	"github.com/KNICEX/InkFlow/pkg/stringx"
+	"github.com/samber/lo"
+	"go.temporal.io/sdk/activity"
+	"strings"
+	"time"
+)
+
+// promptInkSummary 摘要提示词在提示词库中的用途
+const promptInkSummary = "ink.summary"
+
+const summaryPrompt = `请为以下文章写一段 100 字以内的摘要，概括文章的主要内容和观点，语言简洁，不要以"本文"开头。
+
+标题：{{.title}}
+正文：{{.content}}`
+
+type summaryOutput struct {
+	Summary string `json:"summary" description:"100 字以内的文章摘要"`
+}
+
+const (
+	// minSummaryWords 字数少于该值的短文不生成摘要, 直接展示正文开头
+	minSummaryWords = 200
+	// maxSummaryInput 生成摘要时最多使用的正文字数
+	maxSummaryInput = 8000
+	maxSummaryLen   = 200
 )
 
 type Activities struct {
@@ -20,6 +51,12 @@ type Activities struct {
 	notificationSvc  notification.Service
 	recommendSyncSvc recommend.SyncService
 	feedSvc          feed.Service
+	actionSvc        action.Service
+	revisionSvc      ink.RevisionService
+	moderationSvc    review.ModerationService
+	sensitiveSvc     sensitive.Service
+	llmSvc           ai.LLMService
+	prompts          prompt.Service
 }
 
 func NewActivities(
@@ -30,7 +67,14 @@ func NewActivities(
 	recommendSyncSvc recommend.SyncService,
 	notificationSvc notification.Service,
 	feedSvc feed.Service,
+	actionSvc action.Service,
+	revisionSvc ink.RevisionService,
+	moderationSvc review.ModerationService,
+	sensitiveSvc sensitive.Service,
+	llmSvc ai.LLMService,
+	prompts prompt.Service,
 ) *Activities {
+	prompts.Register(promptInkSummary, summaryPrompt)
 	return &Activities{
 		inkSvc:           inkSvc,
 		intrSvc:          intrSvc,
@@ -39,12 +83,57 @@ func NewActivities(
 		recommendSyncSvc: recommendSyncSvc,
 		notificationSvc:  notificationSvc,
 		feedSvc:          feedSvc,
+		actionSvc:        actionSvc,
+		revisionSvc:      revisionSvc,
+		moderationSvc:    moderationSvc,
+		sensitiveSvc:     sensitiveSvc,
+		llmSvc:           llmSvc,
+		prompts:          prompts,
 	}
 }
 func (a *Activities) FindInkInfo(ctx context.Context, inkId, uid int64) (ink.Ink, error) {
 	return a.inkSvc.FindPendingInk(ctx, inkId, uid)
 }
 
+// CheckSensitive 检查标题、摘要和正文中的敏感词, 命中时返回拒绝原因
+func (a *Activities) CheckSensitive(ctx context.Context, inkInfo ink.Ink) (string, error) {
+	text := strings.Join([]string{inkInfo.Title, inkInfo.Summary, inkInfo.PlainText()}, "\n")
+	matches := a.sensitiveSvc.Match(ctx, text)
+	if len(matches) == 0 {
+		return "", nil
+	}
+	words := lo.Uniq(lo.Map(matches, func(item sensitive.Match, index int) string {
+		return item.Word
+	}))
+	return fmt.Sprintf("包含敏感词: %s", strings.Join(words, ", ")), nil
+}
+
+// EnrichInk 统计字数和阅读时长, 作者没有填写摘要时由 LLM 生成.
+// 摘要只是锦上添花, 生成失败或者额度用完时跳过, 不影响发布
+func (a *Activities) EnrichInk(ctx context.Context, inkInfo ink.Ink) (ink.Ink, error) {
+	inkInfo.WordCount, inkInfo.ReadingTime = inkInfo.ReadingStats()
+	if strings.TrimSpace(inkInfo.Summary) != "" || inkInfo.WordCount < minSummaryWords {
+		return inkInfo, nil
+	}
+	ctx = ai.WithUser(ai.WithPurpose(ctx, ai.PurposeSummary), inkInfo.Author.Id)
+	question, err := a.prompts.Render(ctx, promptInkSummary, inkInfo.Id, map[string]any{
+		"title":   inkInfo.Title,
+		"content": stringx.Truncate(inkInfo.PlainText(), maxSummaryInput),
+	})
+	if err != nil {
+		return inkInfo, err
+	}
+	out, _, err := ai.Structured[summaryOutput](ctx, a.llmSvc, ai.Request{Question: question.Text})
+	if err != nil {
+		if !errors.Is(err, ai.ErrBudgetExhausted) {
+			activity.GetLogger(ctx).Warn("generate ink summary error", "error", err, "inkId", inkInfo.Id)
+		}
+		return inkInfo, nil
+	}
+	inkInfo.Summary = stringx.Truncate(strings.TrimSpace(out.Summary), maxSummaryLen)
+	return inkInfo, nil
+}
+
 func (a *Activities) SubmitReview(ctx context.Context, ink review.Ink) error {
 	return a.reviewSvc.SubmitInk(ctx, ink)
 }
@@ -57,6 +146,14 @@ func (a *Activities) UpdateToPublished(ctx context.Context, inkId, uid int64) er
 	return a.inkSvc.UpdateDraftStatus(ctx, inkId, uid, ink.StatusPublished)
 }
 
+func (a *Activities) ScheduleInk(ctx context.Context, inkId, uid int64, publishAt time.Time) error {
+	return a.inkSvc.Schedule(ctx, inkId, uid, publishAt)
+}
+
+func (a *Activities) CancelSchedule(ctx context.Context, inkId, uid int64) error {
+	return a.inkSvc.CancelSchedule(ctx, inkId, uid)
+}
+
 func (a *Activities) UpdateInkToRejected(ctx context.Context, inkId int64, uid int64) error {
 	return a.inkSvc.UpdateDraftStatus(ctx, inkId, uid, ink.StatusRejected)
 }
@@ -66,22 +163,13 @@ func (a *Activities) SyncToLive(ctx context.Context, inkInfo ink.Ink) error {
 	return a.inkSvc.SyncToLive(ctx, inkInfo)
 }
 
+// SyncToSearch 修改后重新发布时保留已有的浏览和点赞数
 func (a *Activities) SyncToSearch(ctx context.Context, ink ink.Ink) error {
-	return a.searchSyncSvc.InputInk(ctx, []search.Ink{
-		{
-			Id:    ink.Id,
-			Title: ink.Title,
-			Author: search.User{
-				Id: ink.Author.Id,
-			},
-			Content:   ink.ContentHtml,
-			Cover:     ink.Cover,
-			Tags:      ink.Tags,
-			AiTags:    ink.AiTags,
-			CreatedAt: ink.CreatedAt,
-			UpdatedAt: ink.UpdatedAt,
-		},
-	})
+	intr, err := a.intrSvc.Get(ctx, bizInk, ink.Id, 0)
+	if err != nil {
+		return err
+	}
+	return a.searchSyncSvc.InputInk(ctx, []search.Ink{searchindex.SearchInk(ink, intr)})
 }
 
 func (a *Activities) SyncToRecommend(ctx context.Context, ink ink.Ink) error {
@@ -115,14 +203,91 @@ func (a *Activities) SyncToFeed(ctx context.Context, ink ink.Ink) error {
 		Biz:    bizInk,
 		BizId:  ink.Id,
 		Content: feed.Ink{
-			InkId:     ink.Id,
-			Title:     ink.Title,
-			AuthorId:  ink.Author.Id,
-			Cover:     ink.Cover,
-			Abstract:  "",
-			CreatedAt: ink.CreatedAt,
+			InkId:       ink.Id,
+			Title:       ink.Title,
+			AuthorId:    ink.Author.Id,
+			Cover:       ink.Cover,
+			Summary:     ink.Summary,
+			Abstract:    ink.Abstract(),
+			WordCount:   ink.WordCount,
+			ReadingTime: ink.ReadingTime,
+			CreatedAt:   ink.CreatedAt,
+		},
+	})
+}
+
+func (a *Activities) RecordPublishAction(ctx context.Context, ink ink.Ink) error {
+	return a.actionSvc.CreateAction(ctx, action.Action{
+		UserId:     ink.Author.Id,
+		TargetType: action.TargetTypeInk,
+		TargetId:   ink.Id,
+		ActionType: action.ActionTypePublish,
+	})
+}
+
+// SaveRevision 保存本次发布的版本及审核结果
+func (a *Activities) SaveRevision(ctx context.Context, inkInfo ink.Ink, result review.Result) error {
+	aiTags := inkInfo.AiTags
+	if result.Passed && len(result.ReviewTags) > 0 {
+		aiTags = result.ReviewTags
+	}
+	_, err := a.revisionSvc.Create(ctx, ink.Revision{
+		InkId:       inkInfo.Id,
+		AuthorId:    inkInfo.Author.Id,
+		Title:       inkInfo.Title,
+		Cover:       inkInfo.Cover,
+		Summary:     inkInfo.Summary,
+		ContentHtml: inkInfo.ContentHtml,
+		ContentMeta: inkInfo.ContentMeta,
+		Tags:        inkInfo.Tags,
+		AiTags:      aiTags,
+		Review: ink.ReviewResult{
+			Passed: result.Passed,
+			Reason: result.Reason,
 		},
 	})
+	return err
+}
+
+// SubmitModeration 提交人工审核, appealReason 不为空时为作者申诉
+func (a *Activities) SubmitModeration(ctx context.Context, inkInfo ink.Ink, result review.Result, appealReason string) error {
+	source := review.ModerationSourceLLM
+	if appealReason != "" {
+		source = review.ModerationSourceAppeal
+	}
+	_, err := a.moderationSvc.Submit(ctx, review.ModerationTask{
+		Type:         review.TypeInk,
+		BizId:        inkInfo.Id,
+		AuthorId:     inkInfo.Author.Id,
+		WorkflowId:   activity.GetInfo(ctx).WorkflowExecution.ID,
+		Title:        inkInfo.Title,
+		Cover:        inkInfo.Cover,
+		Content:      inkInfo.PlainText(),
+		Source:       source,
+		AppealReason: appealReason,
+		LLMResult:    result,
+	})
+	return err
+}
+
+// AuditReview 记录 LLM 给出的审核结论, 转人工的结果由人工审核队列记录
+func (a *Activities) AuditReview(ctx context.Context, inkInfo ink.Ink, result review.Result) error {
+	if result.NeedsHuman {
+		return nil
+	}
+	action := review.AuditActionLLMReject
+	if result.Passed {
+		action = review.AuditActionLLMPass
+	}
+	return a.moderationSvc.Audit(ctx, review.AuditLog{
+		Type:               review.TypeInk,
+		BizId:              inkInfo.Id,
+		Action:             action,
+		Reason:             result.Reason,
+		PromptVersion:      result.PromptVersion,
+		ImagePromptVersion: result.ImagePromptVersion,
+		TagPromptVersion:   result.TagPromptVersion,
+	})
 }
 
 func (a *Activities) NotifyRejected(ctx context.Context, ink ink.Ink, reason string) error {
@@ -0,0 +1,84 @@
+package inkpub
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/KNICEX/InkFlow/internal/ai"
+	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/internal/prompt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+)
+
+type fakeLLM struct {
+	ai.LLMService
+	err   error
+	calls int
+}
+
+func (l *fakeLLM) Generate(ctx context.Context, req ai.Request) (ai.Resp, error) {
+	l.calls++
+	return ai.Resp{Content: `{"summary": " 春天来了，花都开了。 "}`, Token: 10}, l.err
+}
+
+type fakePrompts struct {
+	prompt.Service
+}
+
+func (p fakePrompts) Render(ctx context.Context, purpose string, subject int64, data any) (prompt.Rendered, error) {
+	return prompt.Rendered{Purpose: purpose, Version: prompt.BuiltinVersion, Text: "summary"}, nil
+}
+
+func TestActivities_EnrichInk(t *testing.T) {
+	longText := "<p>" + strings.Repeat("花开了", 200) + "</p>"
+	testCases := []struct {
+		name        string
+		inkInfo     ink.Ink
+		err         error
+		wantSummary string
+		wantCalls   int
+	}{
+		{
+			name:        "生成摘要",
+			inkInfo:     ink.Ink{Id: 1, ContentHtml: longText},
+			wantSummary: "春天来了，花都开了。",
+			wantCalls:   1,
+		},
+		{
+			name:        "作者已填写摘要",
+			inkInfo:     ink.Ink{Id: 1, Summary: "作者的摘要", ContentHtml: longText},
+			wantSummary: "作者的摘要",
+		},
+		{
+			name:    "短文不生成摘要",
+			inkInfo: ink.Ink{Id: 1, ContentHtml: "<p>花开了</p>"},
+		},
+		{
+			name:      "额度用完时跳过",
+			inkInfo:   ink.Ink{Id: 1, ContentHtml: longText},
+			err:       ai.ErrBudgetExhausted,
+			wantCalls: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			llm := &fakeLLM{err: tc.err}
+			activities := &Activities{llmSvc: llm, prompts: fakePrompts{}}
+			env.RegisterActivity(activities)
+			val, err := env.ExecuteActivity(activities.EnrichInk, tc.inkInfo)
+			require.NoError(t, err)
+			var res ink.Ink
+			require.NoError(t, val.Get(&res))
+			assert.Equal(t, tc.wantSummary, res.Summary)
+			assert.Equal(t, tc.wantCalls, llm.calls)
+			wantWords, wantTime := tc.inkInfo.ReadingStats()
+			assert.Equal(t, wantWords, res.WordCount)
+			assert.Equal(t, wantTime, res.ReadingTime)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/KNICEX/InkFlow/internal/ink"
 	"github.com/KNICEX/InkFlow/internal/review"
+	"github.com/KNICEX/InkFlow/pkg/htmlx"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 	"time"
@@ -11,8 +12,20 @@ import (
 
 const ReviewSignal = "review-signal"
 
+// ScheduleSignal 修改或取消定时发布
+const ScheduleSignal = "schedule-signal"
+
 const bizInk = "ink"
 
+// 流程上线后加入的步骤用 workflow.GetVersion 区分, 升级前启动的流程回放时仍走原来的分支
+const (
+	versionCheckSensitive  = "check-sensitive"
+	versionHumanModeration = "human-moderation"
+	versionSaveRevision    = "save-revision"
+	versionEnrichInk       = "enrich-ink"
+	versionSchedulePublish = "schedule-publish"
+)
+
 func InkPublish(ctx workflow.Context, inkId int64, uid int64) error {
 	ao := workflow.ActivityOptions{
 		StartToCloseTimeout: time.Minute * 3,
@@ -33,31 +46,142 @@ func InkPublish(ctx workflow.Context, inkId int64, uid int64) error {
 		return err
 	}
 
+	if workflow.GetVersion(ctx, versionCheckSensitive, workflow.DefaultVersion, 1) == 1 {
+		// 命中敏感词时直接拒绝, 不再交给 LLM 审核, 作者可以修改后重新发布或者申诉
+		var hitReason string
+		err = workflow.ExecuteActivity(ctx, activities.CheckSensitive, inkInfo).Get(ctx, &hitReason)
+		if err != nil {
+			l.Error("check ink sensitive words error", "error", err, "inkId", inkId)
+		}
+		if hitReason != "" {
+			return finishReview(ctx, inkInfo, review.Result{Passed: false, Reason: hitReason})
+		}
+	}
+
 	err = workflow.ExecuteActivity(ctx, activities.SubmitReview, review.Ink{
 		Id:        inkId,
 		AuthorId:  inkInfo.Author.Id,
 		Cover:     inkInfo.Cover,
 		Title:     inkInfo.Title,
-		Content:   inkInfo.ContentHtml,
+		Content:   inkInfo.PlainText(),
 		CreatedAt: inkInfo.CreatedAt,
 		UpdatedAt: inkInfo.UpdatedAt,
+		Images:    htmlx.Images(inkInfo.ContentHtml),
 	}).Get(ctx, &inkInfo)
 	if err != nil {
 		l.Error("ink publish workflow error", "error", err, "inkId", inkId)
 		return err
 	}
 
-	selector := workflow.NewSelector(ctx)
+	reviewResult := receiveReview(ctx)
+
+	if workflow.GetVersion(ctx, versionHumanModeration, workflow.DefaultVersion, 1) == 1 {
+		// 记录 LLM 的审核结论, 失败不影响发布
+		err = workflow.ExecuteActivity(ctx, activities.AuditReview, inkInfo, reviewResult).Get(ctx, nil)
+		if err != nil {
+			l.Error("audit ink review error", "error", err, "inkId", inkInfo.Id)
+		}
+
+		if reviewResult.NeedsHuman {
+			// 置信度不足, 转人工审核并等待审核员的结论
+			err = workflow.ExecuteActivity(ctx, activities.SubmitModeration, inkInfo, reviewResult, "").Get(ctx, nil)
+			if err != nil {
+				l.Error("submit ink moderation error", "error", err, "inkId", inkInfo.Id)
+				return err
+			}
+			reviewResult = receiveReview(ctx)
+		}
+	}
+
+	return finishReview(ctx, inkInfo, reviewResult)
+}
+
+// InkAppeal 作者对被拒绝的 ink 发起申诉, 直接进入人工审核
+func InkAppeal(ctx workflow.Context, inkId int64, uid int64, reason string) error {
+	ao := workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute * 3,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: time.Second,
+			MaximumAttempts: 5,
+		},
+	}
+	ctx = workflow.WithActivityOptions(ctx, ao)
+	var activities *Activities
+
+	var inkInfo ink.Ink
+	l := workflow.GetLogger(ctx)
+
+	err := workflow.ExecuteActivity(ctx, activities.FindInkInfo, inkId, uid).
+		Get(ctx, &inkInfo)
+	if err != nil {
+		return err
+	}
+
+	err = workflow.ExecuteActivity(ctx, activities.SubmitModeration, inkInfo, review.Result{}, reason).Get(ctx, nil)
+	if err != nil {
+		l.Error("submit ink appeal error", "error", err, "inkId", inkId)
+		return err
+	}
+
+	return finishReview(ctx, inkInfo, receiveReview(ctx))
+}
+
+// receiveReview 等待审核结果, 来自 LLM 或者审核员
+func receiveReview(ctx workflow.Context) review.Result {
 	var reviewResult review.Result
+	selector := workflow.NewSelector(ctx)
 	selector.AddReceive(workflow.GetSignalChannel(ctx, ReviewSignal), func(c workflow.ReceiveChannel, more bool) {
 		c.Receive(ctx, &reviewResult)
-		l.Info("review result received", "result", reviewResult)
+		workflow.GetLogger(ctx).Info("review result received", "result", reviewResult)
 	})
 	selector.Select(ctx)
+	return reviewResult
+}
+
+// finishReview 根据最终的审核结果发布或者拒绝
+func finishReview(ctx workflow.Context, inkInfo ink.Ink, reviewResult review.Result) error {
+	var activities *Activities
+	l := workflow.GetLogger(ctx)
+	revisionVersion := workflow.GetVersion(ctx, versionSaveRevision, workflow.DefaultVersion, 1)
+	// 版本 2 起先补充摘要和字数再保存版本, 使版本记录与发布的内容一致
+	enrichVersion := workflow.GetVersion(ctx, versionEnrichInk, workflow.DefaultVersion, 2)
+	scheduleVersion := workflow.GetVersion(ctx, versionSchedulePublish, workflow.DefaultVersion, 1)
+
+	if reviewResult.Passed && len(reviewResult.ReviewTags) > 0 {
+		inkInfo.AiTags = reviewResult.ReviewTags
+	}
+	if reviewResult.Passed && enrichVersion == 2 {
+		inkInfo = enrichInk(ctx, inkInfo)
+	}
+
+	var err error
+	if revisionVersion == 1 {
+		// 无论是否通过都保存版本, 便于作者查看历史和回滚
+		err = workflow.ExecuteActivity(ctx, activities.SaveRevision, inkInfo, reviewResult).Get(ctx, nil)
+		if err != nil {
+			l.Error("save ink revision error", "error", err, "inkId", inkInfo.Id)
+			return err
+		}
+	}
 
 	if reviewResult.Passed {
 		// 通过
-		inkInfo.AiTags = reviewResult.ReviewTags
+		if enrichVersion == 1 {
+			inkInfo = enrichInk(ctx, inkInfo)
+		}
+
+		if scheduleVersion == 1 && !inkInfo.ScheduledAt.IsZero() {
+			published, er := waitSchedule(ctx, inkInfo)
+			if er != nil {
+				l.Error("wait ink schedule error", "error", er, "inkId", inkInfo.Id)
+				return er
+			}
+			if !published {
+				l.Info("ink schedule canceled", "inkId", inkInfo.Id)
+				return nil
+			}
+		}
+
 		// 更新草稿状态
 		err = workflow.ExecuteActivity(ctx, activities.UpdateToPublished, inkInfo.Id, inkInfo.Author.Id).Get(ctx, nil)
 		if err != nil {
@@ -99,11 +223,17 @@ func InkPublish(ctx workflow.Context, inkId int64, uid int64) error {
 			l.Error("sync ink to feed error", "error", err, "inkId", inkInfo.Id)
 			return err
 		}
+
+		// 记录发布行为, 失败不影响发布
+		err = workflow.ExecuteActivity(ctx, activities.RecordPublishAction, inkInfo).Get(ctx, nil)
+		if err != nil {
+			l.Error("record publish action error", "error", err, "inkId", inkInfo.Id)
+		}
 	} else {
 		// 未通过审核
 
 		// 更新文章状态为已拒绝
-		err = workflow.ExecuteActivity(ctx, activities.UpdateInkToRejected, inkInfo.Id, uid).Get(ctx, nil)
+		err = workflow.ExecuteActivity(ctx, activities.UpdateInkToRejected, inkInfo.Id, inkInfo.Author.Id).Get(ctx, nil)
 		if err != nil {
 			l.Error("update ink status to rejected error", "error", err, "inkId", inkInfo.Id)
 			return err
@@ -119,6 +249,81 @@ func InkPublish(ctx workflow.Context, inkId int64, uid int64) error {
 	return nil
 }
 
-func WorkflowId(inkId int64, pubTime time.Time) string {
-	return fmt.Sprintf("ink-pub-%d-%d", inkId, pubTime.UnixMilli())
+// enrichInk 统计字数并补充摘要, 失败时按原样发布
+func enrichInk(ctx workflow.Context, inkInfo ink.Ink) ink.Ink {
+	var activities *Activities
+	var enriched ink.Ink
+	err := workflow.ExecuteActivity(ctx, activities.EnrichInk, inkInfo).Get(ctx, &enriched)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("enrich ink error", "error", err, "inkId", inkInfo.Id)
+		return inkInfo
+	}
+	return enriched
+}
+
+// waitSchedule 等待到定时发布时间, 期间可以通过 ScheduleSignal 修改时间或取消,
+// 返回 false 表示已取消发布
+func waitSchedule(ctx workflow.Context, inkInfo ink.Ink) (bool, error) {
+	var activities *Activities
+	publishAt := inkInfo.ScheduledAt
+	scheduleCh := workflow.GetSignalChannel(ctx, ScheduleSignal)
+	// 审核期间收到的修改先生效, 避免按审核前的时间发布
+	var pending Schedule
+	for scheduleCh.ReceiveAsync(&pending) {
+		if pending.Cancel {
+			err := workflow.ExecuteActivity(ctx, activities.CancelSchedule, inkInfo.Id, inkInfo.Author.Id).Get(ctx, nil)
+			return false, err
+		}
+		publishAt = pending.PublishAt
+	}
+	err := workflow.ExecuteActivity(ctx, activities.ScheduleInk, inkInfo.Id, inkInfo.Author.Id, publishAt).Get(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	for {
+		d := publishAt.Sub(workflow.Now(ctx))
+		if d <= 0 {
+			return true, nil
+		}
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+		timer := workflow.NewTimer(timerCtx, d)
+
+		fired := false
+		var s Schedule
+		selector := workflow.NewSelector(ctx)
+		selector.AddFuture(timer, func(f workflow.Future) {
+			fired = true
+		})
+		selector.AddReceive(scheduleCh, func(c workflow.ReceiveChannel, more bool) {
+			c.Receive(ctx, &s)
+		})
+		selector.Select(ctx)
+		if fired {
+			return true, nil
+		}
+		cancelTimer()
+
+		if s.Cancel {
+			err = workflow.ExecuteActivity(ctx, activities.CancelSchedule, inkInfo.Id, inkInfo.Author.Id).Get(ctx, nil)
+			return false, err
+		}
+		publishAt = s.PublishAt
+		err = workflow.ExecuteActivity(ctx, activities.ScheduleInk, inkInfo.Id, inkInfo.Author.Id, publishAt).Get(ctx, nil)
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+// Schedule ScheduleSignal 的参数
+type Schedule struct {
+	// PublishAt 新的发布时间, 早于当前时间时立即发布
+	PublishAt time.Time `json:"publishAt"`
+	Cancel    bool      `json:"cancel"`
+}
+
+// WorkflowId 同一篇 ink 同时只会有一个发布流程, 便于定时发布时通过 id 发送信号
+func WorkflowId(inkId int64) string {
+	return fmt.Sprintf("ink-pub-%d", inkId)
 }
@@ -0,0 +1,272 @@
+package inkpub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/internal/review"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+// waitScheduleWorkflow 包装 waitSchedule 以便单独测试
+func waitScheduleWorkflow(ctx workflow.Context, inkInfo ink.Ink) (bool, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+	})
+	return waitSchedule(ctx, inkInfo)
+}
+
+func TestWaitSchedule(t *testing.T) {
+	testCases := []struct {
+		name string
+		// 在 signalAfter 时取消或者改为 reschedule 后发布, 都为空时不发送信号
+		cancel      bool
+		reschedule  time.Duration
+		signalAfter time.Duration
+		wantPublish bool
+		wantElapsed time.Duration
+	}{
+		{
+			name:        "到时间发布",
+			wantPublish: true,
+			wantElapsed: time.Hour,
+		},
+		{
+			name:        "取消发布",
+			cancel:      true,
+			signalAfter: time.Minute,
+			wantPublish: false,
+			wantElapsed: time.Minute,
+		},
+		{
+			name:        "推迟发布",
+			reschedule:  time.Hour * 2,
+			signalAfter: time.Minute,
+			wantPublish: true,
+			wantElapsed: time.Hour * 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			var activities *Activities
+			env.RegisterActivity(activities)
+			env.OnActivity(activities.ScheduleInk, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.CancelSchedule, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			start := env.Now()
+			if tc.cancel || tc.reschedule > 0 {
+				env.RegisterDelayedCallback(func() {
+					env.SignalWorkflow(ScheduleSignal, Schedule{
+						PublishAt: start.Add(tc.reschedule),
+						Cancel:    tc.cancel,
+					})
+				}, tc.signalAfter)
+			}
+
+			env.ExecuteWorkflow(waitScheduleWorkflow, ink.Ink{
+				Id:          1,
+				Author:      ink.Author{Id: 2},
+				ScheduledAt: start.Add(time.Hour),
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var published bool
+			require.NoError(t, env.GetWorkflowResult(&published))
+			assert.Equal(t, tc.wantPublish, published)
+			assert.Equal(t, tc.wantElapsed, env.Now().Sub(start).Round(time.Second))
+		})
+	}
+}
+
+// 审核期间修改的发布时间在审核结束后生效, 即使原定时间已经过去
+func TestWaitScheduleRescheduledDuringReview(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	var activities *Activities
+	env.RegisterActivity(activities)
+	start := env.Now()
+	env.OnActivity(activities.ScheduleInk, mock.Anything, int64(1), int64(2), mock.MatchedBy(func(publishAt time.Time) bool {
+		return publishAt.Equal(start.Add(time.Hour * 3))
+	})).Return(nil).Once()
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(ScheduleSignal, Schedule{
+			PublishAt: start.Add(time.Hour * 3),
+		})
+	}, time.Minute*30)
+
+	env.ExecuteWorkflow(func(ctx workflow.Context, inkInfo ink.Ink) (bool, error) {
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+			StartToCloseTimeout: time.Minute,
+		})
+		// 模拟审核耗时超过原定发布时间
+		if err := workflow.Sleep(ctx, time.Hour*2); err != nil {
+			return false, err
+		}
+		return waitSchedule(ctx, inkInfo)
+	}, ink.Ink{
+		Id:          1,
+		Author:      ink.Author{Id: 2},
+		ScheduledAt: start.Add(time.Hour),
+	})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var published bool
+	require.NoError(t, env.GetWorkflowResult(&published))
+	assert.True(t, published)
+	assert.Equal(t, time.Hour*3, env.Now().Sub(start).Round(time.Second))
+	env.AssertExpectations(t)
+}
+
+func TestInkPublishNeedsHuman(t *testing.T) {
+	testCases := []struct {
+		name string
+		// 审核员的结论
+		passed       bool
+		wantApproved bool
+	}{
+		{
+			name:         "人工审核通过",
+			passed:       true,
+			wantApproved: true,
+		},
+		{
+			name:   "人工审核拒绝",
+			passed: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			var activities *Activities
+			env.RegisterActivity(activities)
+			inkInfo := ink.Ink{Id: 1, Author: ink.Author{Id: 2}}
+			env.OnActivity(activities.FindInkInfo, mock.Anything, mock.Anything, mock.Anything).Return(inkInfo, nil)
+			env.OnActivity(activities.CheckSensitive, mock.Anything, mock.Anything).Return("", nil)
+			env.OnActivity(activities.SubmitReview, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.AuditReview, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.SubmitModeration, mock.Anything, mock.Anything, mock.Anything, "").Return(nil).Once()
+			env.OnActivity(activities.SaveRevision, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.EnrichInk, mock.Anything, mock.Anything).Return(inkInfo, nil)
+			for _, fn := range []any{activities.UpdateToPublished, activities.UpdateInkToRejected, activities.CreateIntr} {
+				env.OnActivity(fn, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			}
+			for _, fn := range []any{activities.SyncToLive, activities.SyncToSearch, activities.SyncToRecommend,
+				activities.SyncToFeed, activities.RecordPublishAction} {
+				env.OnActivity(fn, mock.Anything, mock.Anything).Return(nil)
+			}
+			env.OnActivity(activities.NotifyRejected, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflow(ReviewSignal, review.Result{NeedsHuman: true, Confidence: 30})
+			}, time.Second)
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflow(ReviewSignal, review.Result{Passed: tc.passed, Reason: "moderator"})
+			}, time.Hour)
+
+			env.ExecuteWorkflow(InkPublish, int64(1), int64(2))
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			env.AssertCalled(t, "SubmitModeration", mock.Anything, mock.Anything, mock.Anything, "")
+			if tc.wantApproved {
+				env.AssertCalled(t, "UpdateToPublished", mock.Anything, int64(1), int64(2))
+				env.AssertNotCalled(t, "UpdateInkToRejected", mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				env.AssertCalled(t, "UpdateInkToRejected", mock.Anything, int64(1), int64(2))
+				env.AssertNotCalled(t, "UpdateToPublished", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestInkPublishSensitive(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	var activities *Activities
+	env.RegisterActivity(activities)
+	inkInfo := ink.Ink{Id: 1, Author: ink.Author{Id: 2}}
+	env.OnActivity(activities.FindInkInfo, mock.Anything, mock.Anything, mock.Anything).Return(inkInfo, nil)
+	env.OnActivity(activities.CheckSensitive, mock.Anything, mock.Anything).Return("包含敏感词: 赌博", nil)
+	env.OnActivity(activities.SaveRevision, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(activities.UpdateInkToRejected, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(activities.NotifyRejected, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	env.ExecuteWorkflow(InkPublish, int64(1), int64(2))
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	env.AssertCalled(t, "UpdateInkToRejected", mock.Anything, int64(1), int64(2))
+	env.AssertCalled(t, "NotifyRejected", mock.Anything, mock.Anything, "包含敏感词: 赌博")
+	env.AssertNotCalled(t, "SubmitReview", mock.Anything, mock.Anything)
+}
+
+func TestInkPublishDefaultVersion(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	var activities *Activities
+	env.RegisterActivity(activities)
+	// 升级前启动的流程回放时不执行后来加入的步骤
+	env.OnGetVersion(mock.Anything, workflow.DefaultVersion, 1).Return(workflow.DefaultVersion)
+	env.OnGetVersion(versionEnrichInk, workflow.DefaultVersion, 2).Return(workflow.DefaultVersion)
+	inkInfo := ink.Ink{Id: 1, Author: ink.Author{Id: 2}, ScheduledAt: time.Now().Add(time.Hour)}
+	env.OnActivity(activities.FindInkInfo, mock.Anything, mock.Anything, mock.Anything).Return(inkInfo, nil)
+	env.OnActivity(activities.SubmitReview, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(activities.UpdateToPublished, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(activities.CreateIntr, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	for _, fn := range []any{activities.SyncToLive, activities.SyncToSearch, activities.SyncToRecommend,
+		activities.SyncToFeed, activities.RecordPublishAction} {
+		env.OnActivity(fn, mock.Anything, mock.Anything).Return(nil)
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(ReviewSignal, review.Result{Passed: true, NeedsHuman: true})
+	}, time.Second)
+
+	env.ExecuteWorkflow(InkPublish, int64(1), int64(2))
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	env.AssertCalled(t, "UpdateToPublished", mock.Anything, int64(1), int64(2))
+	env.AssertExpectations(t)
+}
+
+func TestInkPublishSaveEnrichedRevision(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	var activities *Activities
+	env.RegisterActivity(activities)
+	inkInfo := ink.Ink{Id: 1, Author: ink.Author{Id: 2}}
+	enriched := inkInfo
+	enriched.Summary = "摘要"
+	enriched.WordCount = 100
+	env.OnActivity(activities.FindInkInfo, mock.Anything, mock.Anything, mock.Anything).Return(inkInfo, nil)
+	env.OnActivity(activities.CheckSensitive, mock.Anything, mock.Anything).Return("", nil)
+	env.OnActivity(activities.SubmitReview, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(activities.AuditReview, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(activities.EnrichInk, mock.Anything, mock.Anything).Return(enriched, nil).Once()
+	// 保存的版本包含补充后的摘要
+	env.OnActivity(activities.SaveRevision, mock.Anything, mock.MatchedBy(func(i ink.Ink) bool {
+		return i.Summary == "摘要" && i.WordCount == 100
+	}), mock.Anything).Return(nil).Once()
+	env.OnActivity(activities.UpdateToPublished, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(activities.CreateIntr, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	for _, fn := range []any{activities.SyncToLive, activities.SyncToSearch, activities.SyncToRecommend,
+		activities.SyncToFeed, activities.RecordPublishAction} {
+		env.OnActivity(fn, mock.Anything, mock.Anything).Return(nil)
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(ReviewSignal, review.Result{Passed: true})
+	}, time.Second)
+
+	env.ExecuteWorkflow(InkPublish, int64(1), int64(2))
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	env.AssertExpectations(t)
+}
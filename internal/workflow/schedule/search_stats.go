@@ -0,0 +1,31 @@
+package schedule
+
+import (
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"time"
+)
+
+// AggregateSearchLog 汇总当前小时和上一个小时的搜索记录,
+// 上一个小时末尾的搜索和之后的点击在本次执行时才会被统计到
+func AggregateSearchLog(ctx workflow.Context) error {
+	ao := workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute * 10,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: time.Second * 5,
+			MaximumAttempts: 3,
+		},
+	}
+	ctx = workflow.WithActivityOptions(ctx, ao)
+	var activities *SearchStatsActivity
+	l := workflow.GetLogger(ctx)
+	now := workflow.Now(ctx).Truncate(time.Hour)
+	for _, hour := range []time.Time{now.Add(-time.Hour), now} {
+		err := workflow.ExecuteActivity(ctx, activities.Aggregate, hour).Get(ctx, nil)
+		if err != nil {
+			l.Error("aggregate search log error", "hour", hour, "error", err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package schedule
+
+import (
+	"context"
+	"github.com/KNICEX/InkFlow/internal/search"
+	"time"
+)
+
+type SearchStatsActivity struct {
+	svc search.AnalyticsService
+}
+
+func NewSearchStatsActivity(svc search.AnalyticsService) *SearchStatsActivity {
+	return &SearchStatsActivity{
+		svc: svc,
+	}
+}
+
+func (a *SearchStatsActivity) Aggregate(ctx context.Context, hour time.Time) error {
+	return a.svc.Aggregate(ctx, hour)
+}
@@ -0,0 +1,457 @@
+package searchindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/KNICEX/InkFlow/internal/comment"
+	"github.com/KNICEX/InkFlow/internal/ink"
+	"github.com/KNICEX/InkFlow/internal/interactive"
+	"github.com/KNICEX/InkFlow/internal/search"
+	"github.com/KNICEX/InkFlow/internal/user"
+	"github.com/KNICEX/InkFlow/pkg/snowflakex"
+	"github.com/samber/lo"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"math/rand/v2"
+	"time"
+)
+
+// loadBatchSize 重建索引时每批写入的数量
+const loadBatchSize = 500
+
+const bizInk = "ink"
+
+// waitHeartbeatInterval 需要小于 WaitIndex 的心跳超时
+const waitHeartbeatInterval = time.Second * 30
+
+type Activities struct {
+	inkSvc     ink.Service
+	intrSvc    interactive.Service
+	userSvc    user.Service
+	commentSvc comment.Service
+	indexSvc   search.IndexService
+}
+
+func NewActivities(inkSvc ink.Service, intrSvc interactive.Service, userSvc user.Service, commentSvc comment.Service,
+	indexSvc search.IndexService) *Activities {
+	return &Activities{
+		inkSvc:     inkSvc,
+		intrSvc:    intrSvc,
+		userSvc:    userSvc,
+		commentSvc: commentSvc,
+		indexSvc:   indexSvc,
+	}
+}
+
+func (a *Activities) BeginRebuild(ctx context.Context, typ search.SearchType) (string, error) {
+	return a.indexSvc.BeginRebuild(ctx, typ)
+}
+
+// loadProgress 通过心跳记录, 活动重试时从上次写入的位置继续
+type loadProgress struct {
+	MaxId int64
+	Total int
+}
+
+// LoadIndex 按 id 倒序把数据库中的数据全部写入新索引, 返回写入的数量
+func (a *Activities) LoadIndex(ctx context.Context, typ search.SearchType, index string) (int, error) {
+	syncSvc, err := a.indexSvc.RebuildSync(typ, index)
+	if err != nil {
+		return 0, err
+	}
+	var progress loadProgress
+	if activity.HasHeartbeatDetails(ctx) {
+		if err = activity.GetHeartbeatDetails(ctx, &progress); err != nil {
+			return 0, err
+		}
+	}
+	for {
+		n, lastId, err := a.syncPage(ctx, syncSvc, typ, progress.MaxId)
+		if err != nil {
+			return progress.Total, err
+		}
+		if n == 0 {
+			return progress.Total, nil
+		}
+		progress.MaxId = lastId
+		progress.Total += n
+		activity.RecordHeartbeat(ctx, progress)
+		if n < loadBatchSize {
+			return progress.Total, nil
+		}
+	}
+}
+
+// syncPage 写入 maxId 之前的一页数据, 返回数量和这一页最小的 id
+func (a *Activities) syncPage(ctx context.Context, syncSvc search.SyncService, typ search.SearchType, maxId int64) (int, int64, error) {
+	switch typ {
+	case search.SearchTypeInk:
+		inks, err := a.listInks(ctx, maxId, loadBatchSize)
+		if err != nil || len(inks) == 0 {
+			return 0, 0, err
+		}
+		return len(inks), inks[len(inks)-1].Id, syncSvc.InputInk(ctx, inks)
+	case search.SearchTypeUser:
+		users, err := a.listUsers(ctx, maxId, loadBatchSize)
+		if err != nil || len(users) == 0 {
+			return 0, 0, err
+		}
+		return len(users), users[len(users)-1].Id, syncSvc.InputUser(ctx, users)
+	case search.SearchTypeComment:
+		comments, err := a.listComments(ctx, maxId, loadBatchSize)
+		if err != nil || len(comments) == 0 {
+			return 0, 0, err
+		}
+		return len(comments), comments[len(comments)-1].Id, syncSvc.InputComment(ctx, comments)
+	default:
+		return 0, 0, fmt.Errorf("unknown search type %s", typ)
+	}
+}
+
+// CatchUp 全量写入可能用读到的旧数据覆盖重建期间的增量同步, 按数据库重新写入这期间被修改过的数据,
+// 数据库中已经不存在或不可见的从新索引中删除, 返回处理的数量
+func (a *Activities) CatchUp(ctx context.Context, typ search.SearchType, index string) (int, error) {
+	syncSvc, err := a.indexSvc.RebuildSync(typ, index)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := a.indexSvc.RebuildTouched(ctx, typ)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, batch := range lo.Chunk(ids, loadBatchSize) {
+		if err = a.syncIds(ctx, syncSvc, typ, batch); err != nil {
+			return total, err
+		}
+		if err = a.indexSvc.ClearTouched(ctx, typ, batch); err != nil {
+			return total, err
+		}
+		total += len(batch)
+	}
+	return total, nil
+}
+
+// syncIds 按数据库重新写入 ids, 不存在或不可见的删除
+func (a *Activities) syncIds(ctx context.Context, syncSvc search.SyncService, typ search.SearchType, ids []int64) error {
+	var present []int64
+	switch typ {
+	case search.SearchTypeInk:
+		inks, err := a.findInks(ctx, ids)
+		if err != nil {
+			return err
+		}
+		if len(inks) > 0 {
+			if err = syncSvc.InputInk(ctx, inks); err != nil {
+				return err
+			}
+		}
+		present = lo.Map(inks, func(item search.Ink, index int) int64 {
+			return item.Id
+		})
+	case search.SearchTypeUser:
+		users, err := a.findUsers(ctx, ids)
+		if err != nil {
+			return err
+		}
+		if len(users) > 0 {
+			if err = syncSvc.InputUser(ctx, users); err != nil {
+				return err
+			}
+		}
+		present = lo.Map(users, func(item search.User, index int) int64 {
+			return item.Id
+		})
+	case search.SearchTypeComment:
+		comments, err := a.findComments(ctx, ids)
+		if err != nil {
+			return err
+		}
+		if len(comments) > 0 {
+			if err = syncSvc.InputComment(ctx, comments); err != nil {
+				return err
+			}
+		}
+		present = lo.Map(comments, func(item search.Comment, index int) int64 {
+			return item.Id
+		})
+	default:
+		return fmt.Errorf("unknown search type %s", typ)
+	}
+	for _, id := range lo.Without(ids, present...) {
+		if err := deleteDoc(ctx, syncSvc, typ, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteDoc(ctx context.Context, syncSvc search.SyncService, typ search.SearchType, id int64) error {
+	switch typ {
+	case search.SearchTypeInk:
+		return syncSvc.DeleteInk(ctx, id)
+	case search.SearchTypeUser:
+		return syncSvc.DeleteUser(ctx, id)
+	case search.SearchTypeComment:
+		return syncSvc.DeleteComment(ctx, id)
+	default:
+		return fmt.Errorf("unknown search type %s", typ)
+	}
+}
+
+// WaitIndex 写入新索引是异步执行的, 提交前等待全部生效, 等待期间通过心跳表明活动存活.
+// 写入失败时重试等待也不会成功, 直接放弃这次重建
+func (a *Activities) WaitIndex(ctx context.Context, index string) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(waitHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				activity.RecordHeartbeat(ctx)
+			}
+		}
+	}()
+	err := a.indexSvc.WaitRebuild(ctx, index)
+	if errors.Is(err, search.ErrIndexTaskFailed) {
+		return temporal.NewNonRetryableApplicationError(err.Error(), "IndexTaskFailed", err)
+	}
+	return err
+}
+
+// CommitRebuild 返回不再使用的旧索引, 为空时不需要删除
+func (a *Activities) CommitRebuild(ctx context.Context, typ search.SearchType, index string) (string, error) {
+	return a.indexSvc.CommitRebuild(ctx, typ, index)
+}
+
+func (a *Activities) AbortRebuild(ctx context.Context, typ search.SearchType) error {
+	return a.indexSvc.AbortRebuild(ctx, typ)
+}
+
+func (a *Activities) DropIndex(ctx context.Context, index string) error {
+	return a.indexSvc.DropIndex(ctx, index)
+}
+
+// Check 从数据库中随机取一段连续的数据检查索引, id 是按时间生成的,
+// 在上线以来的时间里随机取一个时刻, 检查这个时刻之前的 sampleSize 条数据.
+// 同时抽查索引中同一段的文档, 数据库中已经删除或不可见的是需要删除的 orphans
+func (a *Activities) Check(ctx context.Context, typ search.SearchType, sampleSize int, repair bool) (search.IndexCheckResult, error) {
+	start := snowflakex.DefaultStartTime
+	at := start.Add(rand.N(time.Since(start)))
+	maxId := snowflakex.MinIdAt(start, at)
+	ids, err := a.indexSvc.SampleIds(ctx, typ, maxId, sampleSize)
+	if err != nil {
+		return search.IndexCheckResult{}, err
+	}
+	switch typ {
+	case search.SearchTypeInk:
+		inks, orphans, err := checkSample(ctx, maxId, sampleSize, ids, a.listInks, a.findInks, func(item search.Ink) int64 {
+			return item.Id
+		})
+		if err != nil {
+			return search.IndexCheckResult{}, err
+		}
+		return a.indexSvc.CheckInks(ctx, inks, orphans, repair)
+	case search.SearchTypeUser:
+		users, orphans, err := checkSample(ctx, maxId, sampleSize, ids, a.listUsers, a.findUsers, func(item search.User) int64 {
+			return item.Id
+		})
+		if err != nil {
+			return search.IndexCheckResult{}, err
+		}
+		return a.indexSvc.CheckUsers(ctx, users, orphans, repair)
+	case search.SearchTypeComment:
+		comments, orphans, err := checkSample(ctx, maxId, sampleSize, ids, a.listComments, a.findComments, func(item search.Comment) int64 {
+			return item.Id
+		})
+		if err != nil {
+			return search.IndexCheckResult{}, err
+		}
+		return a.indexSvc.CheckComments(ctx, comments, orphans, repair)
+	default:
+		return search.IndexCheckResult{}, fmt.Errorf("unknown search type %s", typ)
+	}
+}
+
+// checkSample 返回要检查的数据库数据, 以及 ids 中数据库里查不到的 orphans.
+// 按 ids 查到的数据也一起检查
+func checkSample[T any](ctx context.Context, maxId int64, size int, ids []int64,
+	list func(ctx context.Context, maxId int64, limit int) ([]T, error),
+	find func(ctx context.Context, ids []int64) ([]T, error), id func(T) int64) ([]T, []int64, error) {
+	items, err := sample(ctx, maxId, size, list)
+	if err != nil {
+		return nil, nil, err
+	}
+	found, err := find(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	present := lo.Map(found, func(item T, index int) int64 {
+		return id(item)
+	})
+	sampled := lo.Map(items, func(item T, index int) int64 {
+		return id(item)
+	})
+	for _, item := range found {
+		if !lo.Contains(sampled, id(item)) {
+			items = append(items, item)
+		}
+	}
+	return items, lo.Without(ids, present...), nil
+}
+
+// sample 随机的时刻之前没有数据时改为检查最新的数据
+func sample[T any](ctx context.Context, maxId int64, size int,
+	list func(ctx context.Context, maxId int64, limit int) ([]T, error)) ([]T, error) {
+	items, err := list(ctx, maxId, size)
+	if err != nil || len(items) > 0 {
+		return items, err
+	}
+	return list(ctx, 0, size)
+}
+
+func (a *Activities) listInks(ctx context.Context, maxId int64, limit int) ([]search.Ink, error) {
+	inks, err := a.inkSvc.ListAllLive(ctx, maxId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return a.searchInks(ctx, inks)
+}
+
+// findInks 只返回已发布的 ink
+func (a *Activities) findInks(ctx context.Context, ids []int64) ([]search.Ink, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	inks, err := a.inkSvc.FindByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return a.searchInks(ctx, lo.Filter(lo.Values(inks), func(item ink.Ink, index int) bool {
+		return item.Status == ink.StatusPublished
+	}))
+}
+
+func (a *Activities) searchInks(ctx context.Context, inks []ink.Ink) ([]search.Ink, error) {
+	if len(inks) == 0 {
+		return nil, nil
+	}
+	intrs, err := a.intrSvc.GetMulti(ctx, bizInk, lo.Map(inks, func(item ink.Ink, index int) int64 {
+		return item.Id
+	}), 0)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(inks, func(item ink.Ink, index int) search.Ink {
+		return SearchInk(item, intrs[item.Id])
+	}), nil
+}
+
+func (a *Activities) listUsers(ctx context.Context, maxId int64, limit int) ([]search.User, error) {
+	users, err := a.userSvc.ListAll(ctx, maxId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(users, func(item user.User, index int) search.User {
+		return searchUser(item)
+	}), nil
+}
+
+func (a *Activities) findUsers(ctx context.Context, ids []int64) ([]search.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	users, err := a.userSvc.FindByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return lo.MapToSlice(users, func(id int64, item user.User) search.User {
+		return searchUser(item)
+	}), nil
+}
+
+func (a *Activities) listComments(ctx context.Context, maxId int64, limit int) ([]search.Comment, error) {
+	comments, err := a.commentSvc.ListAllVisible(ctx, maxId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(comments, func(item comment.Comment, index int) search.Comment {
+		return searchComment(item)
+	}), nil
+}
+
+// findComments 只返回可见的评论
+func (a *Activities) findComments(ctx context.Context, ids []int64) ([]search.Comment, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	// uid 为 0 时不会返回评论者自己能看到的隐藏评论
+	comments, err := a.commentSvc.FindByIds(ctx, ids, 0)
+	if err != nil {
+		return nil, err
+	}
+	return lo.MapToSlice(comments, func(id int64, item comment.Comment) search.Comment {
+		return searchComment(item)
+	}), nil
+}
+
+func searchUser(item user.User) search.User {
+	return search.User{
+		Id:        item.Id,
+		Avatar:    item.Avatar,
+		Account:   item.Account,
+		Username:  item.Username,
+		AboutMe:   item.AboutMe,
+		CreatedAt: item.CreatedAt,
+		UpdatedAt: item.UpdatedAt,
+	}
+}
+
+func searchComment(item comment.Comment) search.Comment {
+	c := search.Comment{
+		Id:          item.Id,
+		Biz:         item.Biz,
+		BizId:       item.BizId,
+		Content:     item.Payload.Content,
+		Images:      item.Payload.Images,
+		Commentator: search.User{Id: item.Commentator.Id},
+		CreatedAt:   item.CreatedAt,
+	}
+	if item.Root != nil {
+		c.RootId = item.Root.Id
+	}
+	if item.Parent != nil {
+		c.ParentId = item.Parent.Id
+	}
+	return c
+}
+
+// SearchInk 发布时同步和重建索引使用同样的转换, 一致性检查才不会误报.
+// 写入索引会替换整个文档, 需要带上当前的互动计数, 否则按点赞数排序时会被清零
+func SearchInk(item ink.Ink, intr interactive.Interactive) search.Ink {
+	return search.Ink{
+		Id:    item.Id,
+		Title: item.Title,
+		Author: search.User{
+			Id: item.Author.Id,
+		},
+		Summary:     item.Summary,
+		Content:     item.ContentHtml,
+		Cover:       item.Cover,
+		Tags:        item.Tags,
+		AiTags:      item.AiTags,
+		ContentType: int(item.ContentType),
+		CategoryId:  item.Category.Id,
+		WordCount:   item.WordCount,
+		ReadingTime: item.ReadingTime,
+		ViewCnt:     intr.ViewCnt,
+		LikeCnt:     intr.LikeCnt,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+	}
+}
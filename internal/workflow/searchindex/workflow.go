@@ -0,0 +1,130 @@
+package searchindex
+
+import (
+	"github.com/KNICEX/InkFlow/internal/search"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"time"
+)
+
+// RebuildWorkflowId 同一时间只允许一个重建流程
+const RebuildWorkflowId = "search-index-rebuild"
+
+var allTypes = []search.SearchType{search.SearchTypeUser, search.SearchTypeInk, search.SearchTypeComment}
+
+// retireDelay 停止双写后等待进行中的写入完成, 再删除不再使用的索引
+const retireDelay = time.Second * 10
+
+// RebuildSearchIndex 从数据库全量重建索引, types 为空时重建全部索引.
+// 每个索引先写入新建的索引, 写完后原子替换, 重建期间搜索照常使用旧索引,
+// 增量同步同时写入新旧索引, 替换前按数据库重新写入这期间被修改过的数据
+func RebuildSearchIndex(ctx workflow.Context, types []search.SearchType) error {
+	if len(types) == 0 {
+		types = allTypes
+	}
+	for _, typ := range types {
+		if err := rebuild(ctx, typ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func rebuild(ctx workflow.Context, typ search.SearchType) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: time.Second * 5,
+			MaximumAttempts: 5,
+		},
+	})
+	var activities *Activities
+	l := workflow.GetLogger(ctx)
+
+	var index string
+	if err := workflow.ExecuteActivity(ctx, activities.BeginRebuild, typ).Get(ctx, &index); err != nil {
+		return err
+	}
+
+	// 全量写入耗时较长, 通过心跳判断活动是否存活, 重试时从心跳记录的位置继续
+	loadCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Hour * 12,
+		HeartbeatTimeout:    time.Minute * 2,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: time.Second * 10,
+			MaximumAttempts: 5,
+		},
+	})
+	var total, caught int
+	err := workflow.ExecuteActivity(loadCtx, activities.LoadIndex, typ, index).Get(ctx, &total)
+	if err == nil {
+		err = workflow.ExecuteActivity(loadCtx, activities.CatchUp, typ, index).Get(ctx, &caught)
+	}
+	if err == nil {
+		// 等写入全部生效后再交换, 交换不用排在写入任务之后, 写入失败时也不会换上不完整的索引
+		err = workflow.ExecuteActivity(loadCtx, activities.WaitIndex, index).Get(ctx, nil)
+	}
+	var retired string
+	if err == nil {
+		// meilisearch 的交换不是幂等的, 重试可能把索引换回去, 失败后直接放弃
+		commitCtx := workflow.WithRetryPolicy(ctx, temporal.RetryPolicy{MaximumAttempts: 1})
+		err = workflow.ExecuteActivity(commitCtx, activities.CommitRebuild, typ, index).Get(ctx, &retired)
+	}
+	// 失败和取消时也要停止双写并删除索引
+	dctx, _ := workflow.NewDisconnectedContext(ctx)
+	if err != nil {
+		l.Error("rebuild search index error", "type", typ, "index", index, "error", err)
+		if er := workflow.ExecuteActivity(dctx, activities.AbortRebuild, typ).Get(dctx, nil); er != nil {
+			l.Error("abort search index rebuild error", "type", typ, "error", er)
+		}
+		// 交换成功但没有收到结果时 index 是正在使用的索引, DropIndex 不会删除它
+		retired = index
+	}
+	if retired != "" {
+		if er := workflow.Sleep(dctx, retireDelay); er != nil {
+			l.Error("wait search index retire error", "index", retired, "error", er)
+		}
+		if er := workflow.ExecuteActivity(dctx, activities.DropIndex, retired).Get(dctx, nil); er != nil {
+			l.Error("drop search index error", "index", retired, "error", er)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	l.Info("search index rebuilt", "type", typ, "index", index, "total", total, "caught", caught)
+	return nil
+}
+
+// CheckOptions SampleSize 为每种索引抽查的数量, Repair 为 true 时重新写入缺失和过期的文档, 并删除多余的文档
+type CheckOptions struct {
+	SampleSize int
+	Repair     bool
+}
+
+// CheckSearchIndex 抽查索引和数据库是否一致, 不一致时记录日志
+func CheckSearchIndex(ctx workflow.Context, opts CheckOptions) ([]search.IndexCheckResult, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute * 5,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: time.Second * 5,
+			MaximumAttempts: 3,
+		},
+	})
+	var activities *Activities
+	l := workflow.GetLogger(ctx)
+	results := make([]search.IndexCheckResult, 0, len(allTypes))
+	for _, typ := range allTypes {
+		var res search.IndexCheckResult
+		err := workflow.ExecuteActivity(ctx, activities.Check, typ, opts.SampleSize, opts.Repair).Get(ctx, &res)
+		if err != nil {
+			l.Error("check search index error", "type", typ, "error", err)
+			return results, err
+		}
+		if !res.Consistent() {
+			l.Warn("search index inconsistent", "type", typ, "checked", res.Checked,
+				"missing", res.Missing, "stale", res.Stale, "orphan", res.Orphan, "repaired", res.Repaired)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
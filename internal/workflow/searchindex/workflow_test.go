@@ -0,0 +1,67 @@
+package searchindex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/KNICEX/InkFlow/internal/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestRebuildSearchIndex(t *testing.T) {
+	testCases := []struct {
+		name       string
+		loadErr    error
+		waitErr    error
+		wantCommit bool
+		wantAbort  bool
+	}{
+		{
+			name:       "写入完成后替换",
+			wantCommit: true,
+		},
+		{
+			name:      "写入失败时删除新索引",
+			loadErr:   temporal.NewNonRetryableApplicationError("load failed", "", errors.New("load failed")),
+			wantAbort: true,
+		},
+		{
+			name:      "写入任务失败时不替换",
+			waitErr:   temporal.NewNonRetryableApplicationError("task failed", "IndexTaskFailed", errors.New("task failed")),
+			wantAbort: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			var activities *Activities
+			env.RegisterActivity(activities)
+
+			env.OnActivity(activities.BeginRebuild, mock.Anything, search.SearchTypeInk).Return("ink_index_1", nil)
+			env.OnActivity(activities.LoadIndex, mock.Anything, search.SearchTypeInk, "ink_index_1").Return(10, tc.loadErr)
+			env.OnActivity(activities.CatchUp, mock.Anything, search.SearchTypeInk, "ink_index_1").Return(2, nil)
+			env.OnActivity(activities.WaitIndex, mock.Anything, "ink_index_1").Return(tc.waitErr)
+			committed, aborted := false, false
+			// 交换后 ink_index_1 中是旧数据
+			env.OnActivity(activities.CommitRebuild, mock.Anything, search.SearchTypeInk, "ink_index_1").
+				Return("ink_index_1", nil).Run(func(args mock.Arguments) { committed = true })
+			env.OnActivity(activities.AbortRebuild, mock.Anything, search.SearchTypeInk).
+				Return(nil).Run(func(args mock.Arguments) { aborted = true })
+			dropped := ""
+			env.OnActivity(activities.DropIndex, mock.Anything, mock.Anything).
+				Return(nil).Run(func(args mock.Arguments) { dropped = args.String(1) })
+
+			env.ExecuteWorkflow(RebuildSearchIndex, []search.SearchType{search.SearchTypeInk})
+			require.True(t, env.IsWorkflowCompleted())
+			assert.Equal(t, tc.loadErr != nil || tc.waitErr != nil, env.GetWorkflowError() != nil)
+			assert.Equal(t, tc.wantCommit, committed)
+			assert.Equal(t, tc.wantAbort, aborted)
+			assert.Equal(t, "ink_index_1", dropped)
+		})
+	}
+}
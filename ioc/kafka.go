@@ -2,13 +2,18 @@ package ioc
 
 import (
 	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/internal/action"
+	"github.com/KNICEX/InkFlow/internal/comment"
 	"github.com/KNICEX/InkFlow/internal/interactive"
 	"github.com/KNICEX/InkFlow/internal/notification"
 	"github.com/KNICEX/InkFlow/internal/recommend"
 	"github.com/KNICEX/InkFlow/internal/review"
 	"github.com/KNICEX/InkFlow/internal/search"
+	"github.com/KNICEX/InkFlow/pkg/backoff"
+	"github.com/KNICEX/InkFlow/pkg/logx"
 	"github.com/KNICEX/InkFlow/pkg/saramax"
 	"github.com/spf13/viper"
+	"time"
 )
 
 func InitKafka() sarama.Client {
@@ -39,14 +44,48 @@ func InitSyncProducer(client sarama.Client) sarama.SyncProducer {
 	return producer
 }
 
+func InitRetryHandler(producer sarama.SyncProducer, l logx.Logger) *saramax.RetryHandler {
+	type Config struct {
+		MaxRetries    int           `mapstructure:"max_retries"`
+		MaxRedelivery int           `mapstructure:"max_redelivery"`
+		RetryDelay    time.Duration `mapstructure:"retry_delay"`
+	}
+	var cfg Config
+	if err := viper.UnmarshalKey("kafka.retry", &cfg); err != nil {
+		panic(err)
+	}
+	var opts []saramax.RetryOption
+	if cfg.MaxRetries > 0 {
+		opts = append(opts, saramax.WithRetryPolicy(backoff.Policy{
+			MaxRetries:       cfg.MaxRetries,
+			InitialInterval:  100 * time.Millisecond,
+			MaxRetryInterval: time.Second,
+		}))
+	}
+	if cfg.MaxRedelivery > 0 {
+		opts = append(opts, saramax.WithMaxRedelivery(cfg.MaxRedelivery))
+	}
+	if cfg.RetryDelay > 0 {
+		opts = append(opts, saramax.WithRetryDelay(cfg.RetryDelay))
+	}
+	return saramax.NewRetryHandler(producer, l, opts...)
+}
+
 func InitConsumers(inkRead *interactive.InkViewConsumer, review *review.Consumer,
 	search *search.SyncConsumer, notification *notification.SyncConsumer,
-	recommend *recommend.SyncConsumer) []saramax.Consumer {
+	recommend *recommend.SyncConsumer, action *action.Consumer,
+	commentReview *review.CommentConsumer, commentReviewed *comment.ReviewedConsumer,
+	searchLog *search.LogConsumer, searchInkStats *search.InkStatsConsumer) []saramax.Consumer {
 	return []saramax.Consumer{
 		inkRead,
 		review,
 		search,
+		searchLog,
+		searchInkStats,
 		notification,
 		recommend,
+		action,
+		commentReview,
+		commentReviewed,
 	}
 }
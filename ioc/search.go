@@ -0,0 +1,18 @@
+package ioc
+
+import (
+	"github.com/KNICEX/InkFlow/internal/search"
+	"github.com/spf13/viper"
+)
+
+// InitSearchBackend 根据 search.backend 选择搜索引擎, 只初始化用到的客户端, 默认 meilisearch
+func InitSearchBackend() search.Backend {
+	switch backend := viper.GetString("search.backend"); backend {
+	case "", "meilisearch":
+		return search.InitMeiliBackend(InitMeiliSearch())
+	case "elasticsearch":
+		return search.InitEsBackend(InitEs())
+	default:
+		panic("unknown search backend: " + backend)
+	}
+}
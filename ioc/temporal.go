@@ -2,8 +2,11 @@ package ioc
 
 import (
 	"context"
+	"github.com/KNICEX/InkFlow/internal/prompt"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
 	"github.com/KNICEX/InkFlow/internal/workflow/inkpub"
 	"github.com/KNICEX/InkFlow/internal/workflow/schedule"
+	"github.com/KNICEX/InkFlow/internal/workflow/searchindex"
 	"github.com/KNICEX/InkFlow/pkg/schedulex"
 	"github.com/KNICEX/InkFlow/pkg/temporalx"
 	"github.com/spf13/viper"
@@ -16,6 +19,8 @@ const (
 	rankInkQueue     = "rank-ink-queue"
 	rankTagQueue     = "rank-tag-queue"
 	retryReviewQueue = "retry-review-queue"
+	searchStatsQueue = "search-stats-queue"
+	searchIndexQueue = "search-index-queue"
 )
 
 func InitTemporalClient() client.Client {
@@ -42,6 +47,7 @@ type InkPubWorker struct {
 func InitInkPubWorker(cli client.Client, activities *inkpub.Activities) *InkPubWorker {
 	w := worker.New(cli, inkPubQueue, worker.Options{})
 	w.RegisterWorkflow(inkpub.InkPublish)
+	w.RegisterWorkflow(inkpub.InkAppeal)
 	w.RegisterActivity(activities)
 	return &InkPubWorker{
 		Worker: w,
@@ -60,6 +66,14 @@ type RetryReviewWorker struct {
 	worker.Worker
 }
 
+type SearchStatsWorker struct {
+	worker.Worker
+}
+
+type SearchIndexWorker struct {
+	worker.Worker
+}
+
 func InitRankInkWorker(cli client.Client, activities *schedule.RankActivities) *RankInkWorker {
 	w := worker.New(cli, rankInkQueue, worker.Options{})
 	w.RegisterWorkflow(schedule.RankHotInk)
@@ -87,12 +101,35 @@ func InitRetryReviewWorker(cli client.Client, activities *schedule.ReviewFailove
 	}
 }
 
-func InitWorkers(inkPub *InkPubWorker, rankTag *RankTagWorker, rankInk *RankInkWorker, retryReview *RetryReviewWorker) []worker.Worker {
+func InitSearchStatsWorker(cli client.Client, activity *schedule.SearchStatsActivity) *SearchStatsWorker {
+	w := worker.New(cli, searchStatsQueue, worker.Options{})
+	w.RegisterWorkflow(schedule.AggregateSearchLog)
+	w.RegisterActivity(activity)
+	return &SearchStatsWorker{
+		Worker: w,
+	}
+}
+
+// InitSearchIndexWorker 重建索引由管理员触发, 一致性检查定时执行
+func InitSearchIndexWorker(cli client.Client, activities *searchindex.Activities) *SearchIndexWorker {
+	w := worker.New(cli, searchIndexQueue, worker.Options{})
+	w.RegisterWorkflow(searchindex.RebuildSearchIndex)
+	w.RegisterWorkflow(searchindex.CheckSearchIndex)
+	w.RegisterActivity(activities)
+	return &SearchIndexWorker{
+		Worker: w,
+	}
+}
+
+func InitWorkers(inkPub *InkPubWorker, rankTag *RankTagWorker, rankInk *RankInkWorker, retryReview *RetryReviewWorker,
+	searchStats *SearchStatsWorker, searchIndex *SearchIndexWorker) []worker.Worker {
 	return []worker.Worker{
 		inkPub.Worker,
 		rankTag.Worker,
 		rankInk.Worker,
 		retryReview.Worker,
+		searchStats.Worker,
+		searchIndex.Worker,
 	}
 }
 
@@ -162,10 +199,78 @@ func InitReviewRetryScheduler(cli client.Client) ReviewFailRetryScheduler {
 	}
 }
 
-func InitSchedulers(rankInk RankInkScheduler, rankTag RankTagScheduler, reviewRetry ReviewFailRetryScheduler) []schedulex.Scheduler {
+type SearchStatsScheduler func() error
+
+func (r SearchStatsScheduler) Start() error {
+	return r()
+}
+
+// InitSearchStatsScheduler 每 10 分钟重新汇总一次, 热搜在小时内也能及时更新
+func InitSearchStatsScheduler(cli client.Client) SearchStatsScheduler {
+	return func() error {
+		return temporalx.UpsertSchedule(context.Background(), cli, client.ScheduleOptions{
+			ID: "search-stats-scheduler",
+			Spec: client.ScheduleSpec{
+				CronExpressions: []string{"@every 10m"},
+			},
+			Action: &client.ScheduleWorkflowAction{
+				ID:        "search-stats-scheduler-action",
+				Workflow:  schedule.AggregateSearchLog,
+				TaskQueue: searchStatsQueue,
+			},
+		})
+	}
+}
+
+type SearchIndexCheckScheduler func() error
+
+func (r SearchIndexCheckScheduler) Start() error {
+	return r()
+}
+
+// InitSearchIndexCheckScheduler 抽查数量和是否自动修复通过 search.check 配置
+func InitSearchIndexCheckScheduler(cli client.Client) SearchIndexCheckScheduler {
+	type Config struct {
+		Interval   string `mapstructure:"interval"`
+		SampleSize int    `mapstructure:"sample_size"`
+		Repair     bool   `mapstructure:"repair"`
+	}
+	cfg := Config{
+		Interval:   "1h",
+		SampleSize: 200,
+	}
+	if err := viper.UnmarshalKey("search.check", &cfg); err != nil {
+		panic(err)
+	}
+	return func() error {
+		return temporalx.UpsertSchedule(context.Background(), cli, client.ScheduleOptions{
+			ID: "search-index-check-scheduler",
+			Spec: client.ScheduleSpec{
+				CronExpressions: []string{"@every " + cfg.Interval},
+			},
+			Action: &client.ScheduleWorkflowAction{
+				ID:        "search-index-check-scheduler-action",
+				Workflow:  searchindex.CheckSearchIndex,
+				TaskQueue: searchIndexQueue,
+				Args: []any{searchindex.CheckOptions{
+					SampleSize: cfg.SampleSize,
+					Repair:     cfg.Repair,
+				}},
+			},
+		})
+	}
+}
+
+func InitSchedulers(rankInk RankInkScheduler, rankTag RankTagScheduler, reviewRetry ReviewFailRetryScheduler,
+	searchStats SearchStatsScheduler, searchIndexCheck SearchIndexCheckScheduler, sensitiveReload sensitive.ReloadScheduler,
+	promptReload prompt.ReloadScheduler) []schedulex.Scheduler {
 	return []schedulex.Scheduler{
 		rankInk,
 		rankTag,
 		reviewRetry,
+		searchStats,
+		searchIndexCheck,
+		sensitiveReload,
+		promptReload,
 	}
 }
@@ -5,6 +5,7 @@ package ioc
 import (
 	"github.com/KNICEX/InkFlow/internal/action"
 	"github.com/KNICEX/InkFlow/internal/ai"
+	"github.com/KNICEX/InkFlow/internal/assistant"
 	"github.com/KNICEX/InkFlow/internal/bff"
 	"github.com/KNICEX/InkFlow/internal/code"
 	"github.com/KNICEX/InkFlow/internal/comment"
@@ -13,22 +14,27 @@ import (
 	"github.com/KNICEX/InkFlow/internal/ink"
 	"github.com/KNICEX/InkFlow/internal/interactive"
 	"github.com/KNICEX/InkFlow/internal/notification"
+	"github.com/KNICEX/InkFlow/internal/poll"
+	"github.com/KNICEX/InkFlow/internal/prompt"
 	"github.com/KNICEX/InkFlow/internal/recommend"
 	"github.com/KNICEX/InkFlow/internal/relation"
 	"github.com/KNICEX/InkFlow/internal/review"
 	"github.com/KNICEX/InkFlow/internal/search"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
 	"github.com/KNICEX/InkFlow/internal/user"
 	"github.com/KNICEX/InkFlow/internal/workflow/inkpub"
 	"github.com/KNICEX/InkFlow/internal/workflow/schedule"
+	"github.com/KNICEX/InkFlow/internal/workflow/searchindex"
 	"github.com/google/wire"
 )
 
 var thirdPartSet = wire.NewSet(
 	InitLogger,
 	InitDB,
-	InitMeiliSearch,
+	InitSearchBackend,
 	InitKafka,
 	InitSyncProducer,
+	InitRetryHandler,
 	InitRedisUniversalClient,
 	InitRedisCmdable,
 	InitGeminiClient,
@@ -57,6 +63,7 @@ func InitApp() *App {
 
 		ink.InitInkService,
 		ink.InitRankingService,
+		ink.InitRevisionService,
 
 		notification.InitNotificationService,
 		notification.InitNotificationConsumer,
@@ -64,34 +71,55 @@ func InitApp() *App {
 		search.InitSyncService,
 		search.InitSearchService,
 		search.InitSyncConsumer,
+		search.InitAnalyticsService,
+		search.InitLogConsumer,
+		search.InitInkStatsConsumer,
+		search.InitIndexService,
 
 		recommend.InitSyncService,
 		recommend.InitSyncConsumer,
 		recommend.InitService,
 
+		sensitive.InitService,
+		sensitive.InitReloadScheduler,
+
 		comment.InitCommentService,
+		comment.InitReviewedConsumer,
+		poll.InitPollService,
 
 		ai.InitLLMService,
+		prompt.InitService,
+		prompt.InitReloadScheduler,
 		review.InitService,
 		review.InitAsyncService,
 		review.InitReviewConsumer,
+		review.InitCommentReviewConsumer,
 		review.InitFailoverService,
+		review.InitModerationService,
+		assistant.InitService,
 
 		action.InitService,
+		action.InitActionConsumer,
 		feed.InitService,
 
 		inkpub.NewActivities,
 		schedule.NewRankActivities,
 		schedule.NewReviewFailoverActivity,
+		schedule.NewSearchStatsActivity,
+		searchindex.NewActivities,
 
 		InitRankTagWorker,
 		InitRankInkWorker,
 		InitInkPubWorker,
 		InitRetryReviewWorker,
+		InitSearchStatsWorker,
+		InitSearchIndexWorker,
 
 		InitRankInkScheduler,
 		InitRankTagScheduler,
 		InitReviewRetryScheduler,
+		InitSearchStatsScheduler,
+		InitSearchIndexCheckScheduler,
 		InitSchedulers,
 
 		bff.InitBff,
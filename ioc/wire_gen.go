@@ -9,6 +9,7 @@ package ioc
 import (
 	"github.com/KNICEX/InkFlow/internal/action"
 	"github.com/KNICEX/InkFlow/internal/ai"
+	"github.com/KNICEX/InkFlow/internal/assistant"
 	"github.com/KNICEX/InkFlow/internal/bff"
 	"github.com/KNICEX/InkFlow/internal/code"
 	"github.com/KNICEX/InkFlow/internal/comment"
@@ -17,13 +18,17 @@ import (
 	"github.com/KNICEX/InkFlow/internal/ink"
 	"github.com/KNICEX/InkFlow/internal/interactive"
 	"github.com/KNICEX/InkFlow/internal/notification"
+	"github.com/KNICEX/InkFlow/internal/poll"
+	"github.com/KNICEX/InkFlow/internal/prompt"
 	"github.com/KNICEX/InkFlow/internal/recommend"
 	"github.com/KNICEX/InkFlow/internal/relation"
 	"github.com/KNICEX/InkFlow/internal/review"
 	"github.com/KNICEX/InkFlow/internal/search"
+	"github.com/KNICEX/InkFlow/internal/sensitive"
 	"github.com/KNICEX/InkFlow/internal/user"
 	"github.com/KNICEX/InkFlow/internal/workflow/inkpub"
 	"github.com/KNICEX/InkFlow/internal/workflow/schedule"
+	"github.com/KNICEX/InkFlow/internal/workflow/searchindex"
 	"github.com/google/wire"
 )
 
@@ -42,45 +47,67 @@ func InitApp() *App {
 	inkService := ink.InitInkService(cmdable, db, logger)
 	interactiveService := interactive.InitInteractiveService(cmdable, syncProducer, db, logger)
 	rankingService := ink.InitRankingService(cmdable, db, logger, interactiveService)
+	revisionService := ink.InitRevisionService(db)
+	clientClient := InitTemporalClient()
+	moderationService := review.InitModerationService(clientClient, db, logger)
 	followService := relation.InitFollowService(cmdable, db, syncProducer, logger)
-	commentService := comment.InitCommentService(db, cmdable, inkService, syncProducer, logger)
+	actionService := action.InitService(db)
+	asyncService := review.InitAsyncService(syncProducer, logger)
+	sensitiveService := sensitive.InitService(db, cmdable, logger)
+	commentService := comment.InitCommentService(db, cmdable, inkService, asyncService, sensitiveService, syncProducer, logger)
+	pollService := poll.InitPollService(db, cmdable, inkService, commentService, logger)
 	notificationService := notification.InitNotificationService(db)
 	gorsexClient := InitGorseCli()
 	recommendService := recommend.InitService(gorsexClient, followService, interactiveService, logger)
-	actionService := action.InitService()
 	feedService := feed.InitService(db, followService, actionService, logger)
-	serviceManager := InitMeiliSearch()
-	searchService := search.InitSearchService(serviceManager)
-	clientClient := InitTemporalClient()
+	backend := InitSearchBackend()
+	searchService := search.InitSearchService(backend, cmdable, rankingService, logger)
+	analyticsService := search.InitAnalyticsService(db, cmdable, syncProducer, logger)
+	promptService := prompt.InitService(db, logger)
+	v2 := InitGeminiClient()
+	llmService := ai.InitLLMService(v2, cmdable, logger)
+	assistantService := assistant.InitService(db, inkService, llmService, logger)
 	handler := InitJwtHandler(cmdable)
 	authentication := InitAuthMiddleware(handler, logger)
-	v := bff.InitBff(userService, serviceService, inkService, rankingService, followService, interactiveService, commentService, notificationService, recommendService, feedService, searchService, clientClient, handler, authentication, logger)
+	v := bff.InitBff(userService, serviceService, inkService, rankingService, revisionService, moderationService, followService, actionService, interactiveService, commentService, pollService, notificationService, recommendService, feedService, searchService, analyticsService, sensitiveService, promptService, assistantService, clientClient, cmdable, handler, authentication, logger)
 	engine := InitGin(v, logger)
-	inkViewConsumer := interactive.InitInteractiveInkReadConsumer(client, logger)
-	v2 := InitGeminiClient()
-	llmService := ai.InitLLMService(v2)
-	service2 := review.InitService(llmService)
+	retryHandler := InitRetryHandler(syncProducer, logger)
+	inkViewConsumer := interactive.InitInteractiveInkReadConsumer(client, retryHandler, logger)
+	service2 := review.InitService(llmService, promptService, logger)
 	failoverService := review.InitFailoverService(clientClient, service2, db, logger)
 	reviewConsumer := review.InitReviewConsumer(clientClient, client, service2, failoverService, logger)
-	syncService := search.InitSyncService(serviceManager)
-	syncConsumer := search.InitSyncConsumer(syncService, client, logger)
-	notificationConsumer := notification.InitNotificationConsumer(client, notificationService, inkService, commentService, logger)
+	syncService := search.InitSyncService(backend, cmdable)
+	syncConsumer := search.InitSyncConsumer(syncService, client, retryHandler, logger)
+	notificationConsumer := notification.InitNotificationConsumer(client, notificationService, inkService, commentService, retryHandler, logger)
 	recommendSyncService := recommend.InitSyncService(gorsexClient)
-	eventSyncConsumer := recommend.InitSyncConsumer(client, recommendSyncService, logger)
-	v3 := InitConsumers(inkViewConsumer, reviewConsumer, syncConsumer, notificationConsumer, eventSyncConsumer)
-	asyncService := review.InitAsyncService(syncProducer, logger)
-	activities := inkpub.NewActivities(inkService, interactiveService, asyncService, syncService, recommendSyncService, notificationService, feedService)
+	eventSyncConsumer := recommend.InitSyncConsumer(client, recommendSyncService, retryHandler, logger)
+	actionConsumer := action.InitActionConsumer(client, actionService, retryHandler, logger)
+	commentReviewConsumer := review.InitCommentReviewConsumer(client, syncProducer, service2, moderationService, retryHandler, logger)
+	reviewedConsumer := comment.InitReviewedConsumer(client, db, cmdable, syncProducer, retryHandler, logger)
+	logConsumer := search.InitLogConsumer(db, client, retryHandler, logger)
+	inkStatsConsumer := search.InitInkStatsConsumer(syncService, interactiveService, client, retryHandler, logger)
+	v3 := InitConsumers(inkViewConsumer, reviewConsumer, syncConsumer, notificationConsumer, eventSyncConsumer, actionConsumer, commentReviewConsumer, reviewedConsumer, logConsumer, inkStatsConsumer)
+	activities := inkpub.NewActivities(inkService, interactiveService, asyncService, syncService, recommendSyncService, notificationService, feedService, actionService, revisionService, moderationService, sensitiveService, llmService, promptService)
 	inkPubWorker := InitInkPubWorker(clientClient, activities)
 	rankActivities := schedule.NewRankActivities(rankingService)
 	rankTagWorker := InitRankTagWorker(clientClient, rankActivities)
 	rankInkWorker := InitRankInkWorker(clientClient, rankActivities)
 	reviewFailoverActivity := schedule.NewReviewFailoverActivity(failoverService)
 	retryReviewWorker := InitRetryReviewWorker(clientClient, reviewFailoverActivity)
-	v4 := InitWorkers(inkPubWorker, rankTagWorker, rankInkWorker, retryReviewWorker)
+	searchStatsActivity := schedule.NewSearchStatsActivity(analyticsService)
+	searchStatsWorker := InitSearchStatsWorker(clientClient, searchStatsActivity)
+	indexService := search.InitIndexService(backend, cmdable)
+	searchindexActivities := searchindex.NewActivities(inkService, interactiveService, userService, commentService, indexService)
+	searchIndexWorker := InitSearchIndexWorker(clientClient, searchindexActivities)
+	v4 := InitWorkers(inkPubWorker, rankTagWorker, rankInkWorker, retryReviewWorker, searchStatsWorker, searchIndexWorker)
 	rankInkScheduler := InitRankInkScheduler(clientClient)
 	rankTagScheduler := InitRankTagScheduler(clientClient)
 	reviewFailRetryScheduler := InitReviewRetryScheduler(clientClient)
-	v5 := InitSchedulers(rankInkScheduler, rankTagScheduler, reviewFailRetryScheduler)
+	searchStatsScheduler := InitSearchStatsScheduler(clientClient)
+	searchIndexCheckScheduler := InitSearchIndexCheckScheduler(clientClient)
+	reloadScheduler := sensitive.InitReloadScheduler(sensitiveService, logger)
+	promptReloadScheduler := prompt.InitReloadScheduler(promptService, logger)
+	v5 := InitSchedulers(rankInkScheduler, rankTagScheduler, reviewFailRetryScheduler, searchStatsScheduler, searchIndexCheckScheduler, reloadScheduler, promptReloadScheduler)
 	app := &App{
 		Server:     engine,
 		Consumers:  v3,
@@ -95,9 +122,10 @@ func InitApp() *App {
 var thirdPartSet = wire.NewSet(
 	InitLogger,
 	InitDB,
-	InitMeiliSearch,
+	InitSearchBackend,
 	InitKafka,
 	InitSyncProducer,
+	InitRetryHandler,
 	InitRedisUniversalClient,
 	InitRedisCmdable,
 	InitGeminiClient,
@@ -0,0 +1,175 @@
+package ahocorasick
+
+import (
+	"unicode"
+)
+
+// Pattern 待匹配的词, Category 为词的分类, 比如 政治、色情、广告
+type Pattern struct {
+	Word     string
+	Category string
+}
+
+// Match 一次命中, Start 和 End 为 rune 下标, 左闭右开
+type Match struct {
+	Word     string `json:"word"`
+	Category string `json:"category"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+type node struct {
+	next map[rune]int32
+	fail int32
+	// out 以该节点结尾的所有模式, 包括 fail 链上的
+	out []int32
+}
+
+// Matcher 构建完成后只读, 可以并发使用
+type Matcher struct {
+	nodes    []node
+	patterns []Pattern
+	// lens 模式的 rune 长度
+	lens []int
+}
+
+// New 构建自动机, 匹配时忽略大小写, 空的词会被忽略
+func New(patterns []Pattern) *Matcher {
+	m := &Matcher{
+		nodes: []node{{next: map[rune]int32{}}},
+	}
+	seen := make(map[Pattern]struct{}, len(patterns))
+	for _, p := range patterns {
+		if p.Word == "" {
+			continue
+		}
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		m.insert(p)
+	}
+	m.build()
+	return m
+}
+
+func (m *Matcher) insert(p Pattern) {
+	cur := int32(0)
+	n := 0
+	for _, r := range p.Word {
+		r = unicode.ToLower(r)
+		nxt, ok := m.nodes[cur].next[r]
+		if !ok {
+			nxt = int32(len(m.nodes))
+			m.nodes = append(m.nodes, node{next: map[rune]int32{}})
+			m.nodes[cur].next[r] = nxt
+		}
+		cur = nxt
+		n++
+	}
+	m.nodes[cur].out = append(m.nodes[cur].out, int32(len(m.patterns)))
+	m.patterns = append(m.patterns, p)
+	m.lens = append(m.lens, n)
+}
+
+// build 按层序计算 fail 指针, 并合并 fail 链上的输出
+func (m *Matcher) build() {
+	queue := make([]int32, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[cur].next {
+			f := m.nodes[cur].fail
+			for {
+				if nxt, ok := m.nodes[f].next[r]; ok && nxt != child {
+					m.nodes[child].fail = nxt
+					break
+				}
+				if f == 0 {
+					break
+				}
+				f = m.nodes[f].fail
+			}
+			fail := m.nodes[child].fail
+			m.nodes[child].out = append(m.nodes[child].out, m.nodes[fail].out...)
+			queue = append(queue, child)
+		}
+	}
+}
+
+// Len 模式数量
+func (m *Matcher) Len() int {
+	return len(m.patterns)
+}
+
+// FindAll 返回所有命中, 包括相互重叠的, 按结束位置排序
+func (m *Matcher) FindAll(text string) []Match {
+	var matches []Match
+	m.scan(text, func(end int, idx int32) bool {
+		p := m.patterns[idx]
+		matches = append(matches, Match{
+			Word:     p.Word,
+			Category: p.Category,
+			Start:    end - m.lens[idx],
+			End:      end,
+		})
+		return true
+	})
+	return matches
+}
+
+// Contains 是否命中任意模式, 命中后立即返回
+func (m *Matcher) Contains(text string) bool {
+	found := false
+	m.scan(text, func(end int, idx int32) bool {
+		found = true
+		return false
+	})
+	return found
+}
+
+// Mask 将命中的字符替换为 mask
+func (m *Matcher) Mask(text string, mask rune) string {
+	matches := m.FindAll(text)
+	if len(matches) == 0 {
+		return text
+	}
+	runes := []rune(text)
+	for _, match := range matches {
+		for i := match.Start; i < match.End; i++ {
+			runes[i] = mask
+		}
+	}
+	return string(runes)
+}
+
+// scan 遍历文本, 每次命中时调用 fn, fn 返回 false 时停止
+func (m *Matcher) scan(text string, fn func(end int, idx int32) bool) {
+	if len(m.patterns) == 0 {
+		return
+	}
+	cur := int32(0)
+	pos := 0
+	for _, r := range text {
+		pos++
+		r = unicode.ToLower(r)
+		for {
+			if nxt, ok := m.nodes[cur].next[r]; ok {
+				cur = nxt
+				break
+			}
+			if cur == 0 {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+		for _, idx := range m.nodes[cur].out {
+			if !fn(pos, idx) {
+				return
+			}
+		}
+	}
+}
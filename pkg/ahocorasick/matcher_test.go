@@ -0,0 +1,63 @@
+package ahocorasick
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher(t *testing.T) {
+	m := New([]Pattern{
+		{Word: "he", Category: "a"},
+		{Word: "she", Category: "a"},
+		{Word: "his", Category: "b"},
+		{Word: "hers", Category: "b"},
+		{Word: "赌博", Category: "c"},
+		{Word: "", Category: "c"},
+		{Word: "he", Category: "a"},
+	})
+	assert.Equal(t, 5, m.Len())
+
+	testCases := []struct {
+		name string
+		text string
+		want []Match
+	}{
+		{
+			name: "重叠命中",
+			text: "ushers",
+			want: []Match{
+				{Word: "she", Category: "a", Start: 1, End: 4},
+				{Word: "he", Category: "a", Start: 2, End: 4},
+				{Word: "hers", Category: "b", Start: 2, End: 6},
+			},
+		},
+		{
+			name: "忽略大小写",
+			text: "HIS",
+			want: []Match{{Word: "his", Category: "b", Start: 0, End: 3}},
+		},
+		{
+			name: "中文按 rune 计算位置",
+			text: "网络赌博平台",
+			want: []Match{{Word: "赌博", Category: "c", Start: 2, End: 4}},
+		},
+		{
+			name: "未命中",
+			text: "world",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, m.FindAll(tc.text))
+			assert.Equal(t, len(tc.want) > 0, m.Contains(tc.text))
+		})
+	}
+}
+
+func TestMatcherMask(t *testing.T) {
+	m := New([]Pattern{{Word: "赌博"}, {Word: "bad"}})
+	assert.Equal(t, "网络**平台, ***", m.Mask("网络赌博平台, BAD", '*'))
+	assert.Equal(t, "ok", m.Mask("ok", '*'))
+	assert.False(t, New(nil).Contains("anything"))
+}
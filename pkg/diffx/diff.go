@@ -0,0 +1,118 @@
+package diffx
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrTooLarge 去掉相同的前后缀后仍然太大, 不进行比较
+var ErrTooLarge = errors.New("too large to diff")
+
+// maxCells lcs 的 dp 表最多的格子数, 时间和内存都与它成正比
+const maxCells = 1 << 21
+
+type Op int
+
+const (
+	OpEqual Op = iota
+	OpInsert
+	OpDelete
+)
+
+func (o Op) String() string {
+	switch o {
+	case OpInsert:
+		return "insert"
+	case OpDelete:
+		return "delete"
+	default:
+		return "equal"
+	}
+}
+
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines 按行比较 a 和 b, 返回把 a 变为 b 的编辑序列
+func Lines(a, b string) ([]Line, error) {
+	return Strings(splitLines(a), splitLines(b))
+}
+
+// Strings 基于最长公共子序列比较两个字符串切片, 不同的部分太大时返回 ErrTooLarge
+func Strings(a, b []string) ([]Line, error) {
+	// 去掉相同的前缀和后缀, 减少 dp 的规模
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	if (len(a)-prefix-suffix)*(len(b)-prefix-suffix) > maxCells {
+		return nil, ErrTooLarge
+	}
+
+	res := make([]Line, 0, len(a)+len(b))
+	for _, s := range a[:prefix] {
+		res = append(res, Line{Op: OpEqual, Text: s})
+	}
+	res = append(res, lcs(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, s := range a[len(a)-suffix:] {
+		res = append(res, Line{Op: OpEqual, Text: s})
+	}
+	return res, nil
+}
+
+func lcs(a, b []string) []Line {
+	n, m := len(a), len(b)
+	// dp[i][j] 为 a[i:] 和 b[j:] 的最长公共子序列长度
+	dp := make([][]int, n+1)
+	for i := range dp {
+		dp[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+
+	res := make([]Line, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			res = append(res, Line{Op: OpEqual, Text: a[i]})
+			i++
+			j++
+		case dp[i+1][j] >= dp[i][j+1]:
+			res = append(res, Line{Op: OpDelete, Text: a[i]})
+			i++
+		default:
+			res = append(res, Line{Op: OpInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		res = append(res, Line{Op: OpDelete, Text: a[i]})
+	}
+	for ; j < m; j++ {
+		res = append(res, Line{Op: OpInsert, Text: b[j]})
+	}
+	return res
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
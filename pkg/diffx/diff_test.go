@@ -0,0 +1,62 @@
+package diffx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLines(t *testing.T) {
+	testCases := []struct {
+		name string
+		a    string
+		b    string
+		want []Line
+	}{
+		{
+			name: "相同",
+			a:    "a\nb",
+			b:    "a\nb\n",
+			want: []Line{{OpEqual, "a"}, {OpEqual, "b"}},
+		},
+		{
+			name: "修改中间行",
+			a:    "a\nb\nc",
+			b:    "a\nx\nc",
+			want: []Line{{OpEqual, "a"}, {OpDelete, "b"}, {OpInsert, "x"}, {OpEqual, "c"}},
+		},
+		{
+			name: "插入和删除",
+			a:    "a\nb\nc\nd",
+			b:    "b\nc\ne\nd",
+			want: []Line{{OpDelete, "a"}, {OpEqual, "b"}, {OpEqual, "c"}, {OpInsert, "e"}, {OpEqual, "d"}},
+		},
+		{
+			name: "从空内容",
+			a:    "",
+			b:    "a",
+			want: []Line{{OpInsert, "a"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Lines(tc.a, tc.b)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, res)
+		})
+	}
+}
+
+func TestLines_TooLarge(t *testing.T) {
+	a := strings.Repeat("a\n", 2000)
+	b := strings.Repeat("b\n", 2000)
+	_, err := Lines(a, b)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	// 相同的前后缀不计入比较规模
+	res, err := Lines("x\n"+a, "y\n"+a)
+	require.NoError(t, err)
+	assert.Len(t, res, 2002)
+}
@@ -63,6 +63,15 @@ func BizError(msg string) Result {
 	}
 }
 
+// BizErrorWithData data 用于说明错误的具体原因
+func BizErrorWithData(msg string, data any) Result {
+	return Result{
+		Code: 1,
+		Msg:  msg,
+		Data: data,
+	}
+}
+
 func InternalError() Result {
 	return Result{
 		Code: 500,
@@ -84,6 +93,15 @@ func NotFound() Result {
 	}
 }
 
+// QuotaExceeded 超出使用配额, data 用于说明具体原因
+func QuotaExceeded(data any) Result {
+	return Result{
+		Code: 429,
+		Msg:  "超出使用配额",
+		Data: data,
+	}
+}
+
 // i18n
 var langCodeMsgMap = map[string]map[int]string{
 	"zh": {
@@ -93,6 +111,7 @@ var langCodeMsgMap = map[string]map[int]string{
 		4:   "参数错误",
 		500: "系统错误",
 		404: "资源不存在",
+		429: "超出使用配额",
 	},
 	"en": {
 		0:   "Request Success",
@@ -101,5 +120,6 @@ var langCodeMsgMap = map[string]map[int]string{
 		4:   "Invalid Param",
 		500: "Internal Error",
 		404: "Resource Not Found",
+		429: "Quota Exceeded",
 	},
 }
@@ -0,0 +1,58 @@
+package ginx
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/gin-gonic/gin"
+)
+
+// Sender 发送一个 SSE 事件, 客户端断开后返回错误
+type Sender func(event string, data any) error
+
+// WrapStream 以 SSE 返回结果. 第一次调用 send 前出错时与 WrapBody 一样返回 json,
+// 开始推送后最终结果作为 result 事件发送
+func WrapStream[T any](l logx.Logger, bizFn func(ctx *gin.Context, req T, send Sender) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req T
+		if err := ctx.ShouldBind(&req); err != nil {
+			res := InvalidParam()
+			vector.WithLabelValues(strconv.Itoa(res.Code)).Inc()
+			ctx.JSON(http.StatusOK, res)
+			return
+		}
+
+		streaming := false
+		send := func(event string, data any) error {
+			if !streaming {
+				streaming = true
+				ctx.Header("Content-Type", "text/event-stream")
+				ctx.Header("Cache-Control", "no-cache")
+				ctx.Header("Connection", "keep-alive")
+				// 关闭 nginx 的缓冲, 否则会攒够一批才返回
+				ctx.Header("X-Accel-Buffering", "no")
+			}
+			ctx.SSEvent(event, data)
+			ctx.Writer.Flush()
+			return ctx.Request.Context().Err()
+		}
+
+		res, err := bizFn(ctx, req, send)
+		vector.WithLabelValues(strconv.Itoa(res.Code)).Inc()
+		if err != nil {
+			l.WithCtx(ctx).Error("handle http error: ",
+				logx.Error(err),
+				logx.String("path", ctx.Request.URL.Path),
+				logx.String("route", fmt.Sprintf("%s %s", ctx.Request.Method, ctx.FullPath())),
+			)
+		}
+		if !streaming {
+			ctx.JSON(http.StatusOK, res)
+			return
+		}
+		ctx.SSEvent("result", res)
+		ctx.Writer.Flush()
+	}
+}
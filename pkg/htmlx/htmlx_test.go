@@ -0,0 +1,72 @@
+package htmlx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitize(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "保留白名单标签",
+			input: `<h2 id="t">标题</h2><p>正文<strong>加粗</strong></p>`,
+			want:  `<h2 id="t">标题</h2><p>正文<strong>加粗</strong></p>`,
+		},
+		{
+			name:  "去掉脚本及内容",
+			input: `<p>a</p><script>alert(1)</script><style>p{}</style>b`,
+			want:  `<p>a</p>b`,
+		},
+		{
+			name:  "去掉事件属性",
+			input: `<img src="https://a.com/1.jpg" onerror="alert(1)">`,
+			want:  `<img src="https://a.com/1.jpg" />`,
+		},
+		{
+			name:  "去掉危险链接",
+			input: `<a href="java&#x09;script:alert(1)">x</a><a href="/ink/1" target="_blank">y</a>`,
+			want:  `<a rel="nofollow noopener noreferrer">x</a><a href="/ink/1" rel="nofollow noopener noreferrer">y</a>`,
+		},
+		{
+			name:  "未知标签只保留文本",
+			input: `<custom>文本</custom><iframe src="https://a.com"></iframe>`,
+			want:  `文本`,
+		},
+		{
+			name:  "补全未闭合标签",
+			input: `<blockquote><p>引用`,
+			want:  `<blockquote><p>引用</p></blockquote>`,
+		},
+		{
+			name:  "转义文本",
+			input: `1 &lt; 2 &amp;&amp; <b>3 > 2</b>`,
+			want:  `1 &lt; 2 &amp;&amp; <b>3 &gt; 2</b>`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Sanitize(tc.input))
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	input := `<h1>标题</h1><p>第一段&amp;<em>强调</em></p><script>x()</script><ul><li>一</li><li>二</li></ul>`
+	assert.Equal(t, "标题 第一段&强调 一 二", Text(input))
+}
+
+func TestAbstract(t *testing.T) {
+	input := `<p>墨水流是一个内容社区</p>`
+	assert.Equal(t, "墨水流", Abstract(input, 3))
+	assert.Equal(t, "墨水流是一个内容社区", Abstract(input, 50))
+}
+
+func TestImages(t *testing.T) {
+	input := `<p><img src="https://a.com/1.png"/>文字<img src="https://a.com/2.png"></p><img src="https://a.com/1.png"><img alt="x">`
+	assert.Equal(t, []string{"https://a.com/1.png", "https://a.com/2.png"}, Images(input))
+}
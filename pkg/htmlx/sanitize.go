@@ -0,0 +1,184 @@
+package htmlx
+
+import (
+	"html"
+	"net/url"
+	"slices"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// 允许的标签及其属性, 其余标签只保留文本内容
+var allowedTags = map[atom.Atom][]string{
+	atom.P: nil, atom.Br: nil, atom.Hr: nil, atom.Div: {"class"}, atom.Span: {"class"},
+	atom.H1: {"id"}, atom.H2: {"id"}, atom.H3: {"id"}, atom.H4: {"id"}, atom.H5: {"id"}, atom.H6: {"id"},
+	atom.Strong: nil, atom.B: nil, atom.Em: nil, atom.I: nil, atom.U: nil, atom.S: nil, atom.Del: nil,
+	atom.Sup: nil, atom.Sub: nil, atom.Mark: nil,
+	atom.Blockquote: nil, atom.Pre: {"class"}, atom.Code: {"class"},
+	atom.Ul: nil, atom.Ol: {"start"}, atom.Li: nil,
+	atom.A:     {"href", "title"},
+	atom.Img:   {"src", "alt", "title", "width", "height"},
+	atom.Table: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tr: nil,
+	atom.Th: {"align"}, atom.Td: {"align"},
+	// markdown 任务列表
+	atom.Input: {"type", "checked", "disabled"},
+}
+
+// 连同内容一起丢弃的标签
+var droppedTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true, atom.Embed: true,
+	atom.Noscript: true, atom.Template: true, atom.Textarea: true, atom.Select: true, atom.Svg: true,
+	atom.Math: true, atom.Title: true, atom.Head: true,
+}
+
+var voidTags = map[atom.Atom]bool{
+	atom.Br: true, atom.Hr: true, atom.Img: true, atom.Input: true,
+}
+
+// 值为链接的属性
+var urlAttrs = map[string]bool{
+	"href": true,
+	"src":  true,
+}
+
+var allowedSchemes = map[string]bool{
+	"":       true,
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// Sanitize 按白名单过滤 html, 去掉脚本、事件属性和危险链接, 并补全未闭合的标签
+func Sanitize(s string) string {
+	var sb strings.Builder
+	z := nethtml.NewTokenizer(strings.NewReader(s))
+	// 已输出的未闭合标签
+	var stack []atom.Atom
+	// 被丢弃标签的嵌套深度, 大于 0 时跳过所有内容
+	dropping := 0
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			// io.EOF 或者输入异常, 已处理的部分照常输出
+			break
+		}
+		tok := z.Token()
+		switch tt {
+		case nethtml.TextToken:
+			if dropping == 0 {
+				sb.WriteString(html.EscapeString(tok.Data))
+			}
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			if droppedTags[tok.DataAtom] {
+				if tt == nethtml.StartTagToken {
+					dropping++
+				}
+				continue
+			}
+			if dropping > 0 {
+				continue
+			}
+			attrs, ok := allowedTags[tok.DataAtom]
+			if !ok {
+				continue
+			}
+			if tok.DataAtom == atom.Input && !isCheckbox(tok) {
+				continue
+			}
+			writeStartTag(&sb, tok, attrs)
+			if !voidTags[tok.DataAtom] {
+				if tt == nethtml.SelfClosingTagToken {
+					writeEndTag(&sb, tok.DataAtom)
+				} else {
+					stack = append(stack, tok.DataAtom)
+				}
+			}
+		case nethtml.EndTagToken:
+			if droppedTags[tok.DataAtom] {
+				if dropping > 0 {
+					dropping--
+				}
+				continue
+			}
+			if dropping > 0 || voidTags[tok.DataAtom] {
+				continue
+			}
+			// 只闭合已打开的标签, 中间未闭合的标签一并闭合
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i] != tok.DataAtom {
+					continue
+				}
+				for j := len(stack) - 1; j >= i; j-- {
+					writeEndTag(&sb, stack[j])
+				}
+				stack = stack[:i]
+				break
+			}
+		}
+		// 注释、doctype 直接丢弃
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		writeEndTag(&sb, stack[i])
+	}
+	return sb.String()
+}
+
+func writeStartTag(sb *strings.Builder, tok nethtml.Token, allowed []string) {
+	sb.WriteByte('<')
+	sb.WriteString(tok.DataAtom.String())
+	for _, attr := range tok.Attr {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || !slices.Contains(allowed, key) {
+			continue
+		}
+		val := strings.TrimSpace(attr.Val)
+		if urlAttrs[key] && !safeURL(val) {
+			continue
+		}
+		sb.WriteByte(' ')
+		sb.WriteString(key)
+		sb.WriteString(`="`)
+		sb.WriteString(html.EscapeString(val))
+		sb.WriteByte('"')
+	}
+	if tok.DataAtom == atom.A {
+		sb.WriteString(` rel="nofollow noopener noreferrer"`)
+	}
+	if voidTags[tok.DataAtom] {
+		sb.WriteString(" /")
+	}
+	sb.WriteByte('>')
+}
+
+func writeEndTag(sb *strings.Builder, a atom.Atom) {
+	sb.WriteString("</")
+	sb.WriteString(a.String())
+	sb.WriteByte('>')
+}
+
+func isCheckbox(tok nethtml.Token) bool {
+	for _, attr := range tok.Attr {
+		if strings.ToLower(attr.Key) == "type" {
+			return strings.ToLower(attr.Val) == "checkbox"
+		}
+	}
+	return false
+}
+
+// safeURL 只允许 http(s)、mailto 及相对链接
+func safeURL(s string) bool {
+	// 去掉浏览器会忽略的控制字符, 防止 java\tscript: 之类的绕过
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	return allowedSchemes[strings.ToLower(u.Scheme)]
+}
@@ -0,0 +1,87 @@
+package htmlx
+
+import (
+	"strings"
+
+	"github.com/KNICEX/InkFlow/pkg/stringx"
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// 块级标签, 提取文本时与相邻内容用空白分隔
+var blockTags = map[atom.Atom]bool{
+	atom.P: true, atom.Br: true, atom.Hr: true, atom.Div: true, atom.Li: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Tr: true, atom.Td: true, atom.Th: true,
+	atom.Ul: true, atom.Ol: true, atom.Table: true,
+}
+
+// Text 提取 html 中的纯文本, 连续空白合并为一个空格
+func Text(s string) string {
+	var sb strings.Builder
+	z := nethtml.NewTokenizer(strings.NewReader(s))
+	dropping := 0
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			break
+		}
+		tok := z.Token()
+		switch tt {
+		case nethtml.TextToken:
+			if dropping == 0 {
+				sb.WriteString(tok.Data)
+			}
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			if droppedTags[tok.DataAtom] && tt == nethtml.StartTagToken {
+				dropping++
+			}
+			if blockTags[tok.DataAtom] {
+				sb.WriteByte(' ')
+			}
+		case nethtml.EndTagToken:
+			if droppedTags[tok.DataAtom] && dropping > 0 {
+				dropping--
+			}
+			if blockTags[tok.DataAtom] {
+				sb.WriteByte(' ')
+			}
+		}
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// Abstract 提取 html 中前 n 个字符的纯文本作为摘要
+func Abstract(s string, n int) string {
+	return stringx.Truncate(Text(s), n)
+}
+
+// Images 按出现顺序提取 html 中的图片链接, 重复的链接只保留一个
+func Images(s string) []string {
+	var res []string
+	seen := make(map[string]struct{})
+	z := nethtml.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			break
+		}
+		if tt != nethtml.StartTagToken && tt != nethtml.SelfClosingTagToken {
+			continue
+		}
+		tok := z.Token()
+		if tok.DataAtom != atom.Img {
+			continue
+		}
+		for _, attr := range tok.Attr {
+			if attr.Key != "src" || attr.Val == "" {
+				continue
+			}
+			if _, ok := seen[attr.Val]; !ok {
+				seen[attr.Val] = struct{}{}
+				res = append(res, attr.Val)
+			}
+		}
+	}
+	return res
+}
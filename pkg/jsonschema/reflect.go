@@ -0,0 +1,121 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType      = reflect.TypeFor[time.Time]()
+	rawJSONType   = reflect.TypeFor[json.RawMessage]()
+	byteSliceType = reflect.TypeFor[[]byte]()
+)
+
+// For 反射 T 的 schema, 见 Reflect
+func For[T any]() *Schema {
+	return Reflect(reflect.TypeFor[T]())
+}
+
+// Reflect 根据 go 类型生成 schema, 字段名取 json tag, 没有 omitempty 的字段为必填.
+// 额外支持以下 tag:
+//
+//	description:"字段说明"
+//	enum:"a,b,c"         仅用于 string
+//	minimum:"0" maximum:"100"
+//
+// 指针字段可以为 null, 递归引用的类型展开一层后不再继续展开
+func Reflect(t reflect.Type) *Schema {
+	return reflectType(t, map[reflect.Type]bool{})
+}
+
+func reflectType(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	if t.Kind() == reflect.Pointer {
+		s := reflectType(t.Elem(), visiting)
+		s.Nullable = true
+		return s
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: TypeString, Format: "date-time"}
+	case rawJSONType:
+		// 任意 json, 只能约束为对象
+		return &Schema{Type: TypeObject}
+	case byteSliceType:
+		return &Schema{Type: TypeString, Format: "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: TypeBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: TypeInteger}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: TypeNumber}
+	case reflect.String:
+		return &Schema{Type: TypeString}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: TypeArray, Items: reflectType(t.Elem(), visiting)}
+	case reflect.Map:
+		return &Schema{Type: TypeObject}
+	case reflect.Struct:
+		if visiting[t] {
+			return &Schema{Type: TypeObject}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		s := &Schema{Type: TypeObject, Properties: map[string]*Schema{}}
+		reflectFields(s, t, visiting)
+		return s
+	default:
+		// chan、func 等无法序列化的类型
+		return &Schema{Type: TypeObject}
+	}
+}
+
+func reflectFields(s *Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			// 匿名嵌入的结构体与 encoding/json 一样展开
+			reflectFields(s, f.Type, visiting)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := reflectType(f.Type, visiting)
+		prop.Description = f.Tag.Get("description")
+		if enum := f.Tag.Get("enum"); enum != "" {
+			prop.Enum = strings.Split(enum, ",")
+		}
+		prop.Minimum = parseFloat(f.Tag.Get("minimum"))
+		prop.Maximum = parseFloat(f.Tag.Get("maximum"))
+		s.Properties[name] = prop
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func parseFloat(s string) *float64 {
+	if s == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		panic("jsonschema: invalid number tag " + strconv.Quote(s))
+	}
+	return &v
+}
@@ -0,0 +1,148 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+type Type string
+
+const (
+	TypeObject  Type = "object"
+	TypeArray   Type = "array"
+	TypeString  Type = "string"
+	TypeInteger Type = "integer"
+	TypeNumber  Type = "number"
+	TypeBoolean Type = "boolean"
+)
+
+// Schema json schema 的子集, 各家模型的结构化输出和函数调用都支持这些关键字
+type Schema struct {
+	Type        Type               `json:"type"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Nullable    bool               `json:"nullable,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+}
+
+// ValidationError 第一个不符合 schema 的位置, Path 形如 $.tags[1]
+type ValidationError struct {
+	Path string
+	Msg  string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+}
+
+// Validate 校验 json 数据是否符合 schema
+func (s *Schema) Validate(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return &ValidationError{Path: "$", Msg: "invalid json: " + err.Error()}
+	}
+	return s.validate("$", v)
+}
+
+func (s *Schema) validate(path string, v any) error {
+	if v == nil {
+		if s.Nullable {
+			return nil
+		}
+		return &ValidationError{Path: path, Msg: "must not be null"}
+	}
+	switch s.Type {
+	case TypeObject:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return s.typeErr(path, v)
+		}
+		for _, name := range s.Required {
+			if _, ok = obj[name]; !ok {
+				return &ValidationError{Path: path, Msg: fmt.Sprintf("missing required property %q", name)}
+			}
+		}
+		// 按属性名排序, 保证每次返回的错误一致
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			val, ok := obj[name]
+			if !ok {
+				continue
+			}
+			if err := s.Properties[name].validate(path+"."+name, val); err != nil {
+				return err
+			}
+		}
+	case TypeArray:
+		arr, ok := v.([]any)
+		if !ok {
+			return s.typeErr(path, v)
+		}
+		if s.Items == nil {
+			return nil
+		}
+		for i, item := range arr {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	case TypeString:
+		str, ok := v.(string)
+		if !ok {
+			return s.typeErr(path, v)
+		}
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, str) {
+			return &ValidationError{Path: path, Msg: fmt.Sprintf("must be one of [%s]", strings.Join(s.Enum, ", "))}
+		}
+	case TypeInteger, TypeNumber:
+		num, ok := v.(float64)
+		if !ok {
+			return s.typeErr(path, v)
+		}
+		if s.Type == TypeInteger && num != math.Trunc(num) {
+			return &ValidationError{Path: path, Msg: "must be an integer"}
+		}
+		if s.Minimum != nil && num < *s.Minimum {
+			return &ValidationError{Path: path, Msg: fmt.Sprintf("must be >= %v", *s.Minimum)}
+		}
+		if s.Maximum != nil && num > *s.Maximum {
+			return &ValidationError{Path: path, Msg: fmt.Sprintf("must be <= %v", *s.Maximum)}
+		}
+	case TypeBoolean:
+		if _, ok := v.(bool); !ok {
+			return s.typeErr(path, v)
+		}
+	}
+	return nil
+}
+
+func (s *Schema) typeErr(path string, v any) error {
+	var got string
+	switch v.(type) {
+	case map[string]any:
+		got = "object"
+	case []any:
+		got = "array"
+	case string:
+		got = "string"
+	case float64:
+		got = "number"
+	case bool:
+		got = "boolean"
+	default:
+		got = fmt.Sprintf("%T", v)
+	}
+	return &ValidationError{Path: path, Msg: fmt.Sprintf("expected %s, got %s", s.Type, got)}
+}
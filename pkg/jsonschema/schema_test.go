@@ -0,0 +1,95 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTag struct {
+	Name string `json:"name"`
+}
+
+type testResult struct {
+	Passed     bool      `json:"passed" description:"是否通过"`
+	Level      string    `json:"level" enum:"low,high"`
+	Confidence int64     `json:"confidence" minimum:"0" maximum:"100"`
+	Tags       []testTag `json:"tags,omitempty"`
+	Note       *string   `json:"note,omitempty"`
+	ignored    int
+}
+
+func TestReflect(t *testing.T) {
+	s := For[testResult]()
+	assert.Equal(t, TypeObject, s.Type)
+	assert.Equal(t, []string{"passed", "level", "confidence"}, s.Required)
+	assert.Equal(t, "是否通过", s.Properties["passed"].Description)
+	assert.Equal(t, []string{"low", "high"}, s.Properties["level"].Enum)
+	assert.Equal(t, TypeInteger, s.Properties["confidence"].Type)
+	assert.Equal(t, float64(100), *s.Properties["confidence"].Maximum)
+	assert.Equal(t, TypeArray, s.Properties["tags"].Type)
+	assert.Equal(t, TypeString, s.Properties["tags"].Items.Properties["name"].Type)
+	assert.True(t, s.Properties["note"].Nullable)
+	assert.NotContains(t, s.Properties, "ignored")
+}
+
+func TestValidate(t *testing.T) {
+	s := For[testResult]()
+	testCases := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "合法",
+			data: `{"passed": true, "level": "low", "confidence": 80, "tags": [{"name": "a"}], "note": null}`,
+		},
+		{
+			name:    "不是 json",
+			data:    "```json\n{}\n```",
+			wantErr: "$: invalid json",
+		},
+		{
+			name:    "缺少必填字段",
+			data:    `{"passed": true, "level": "low"}`,
+			wantErr: `$: missing required property "confidence"`,
+		},
+		{
+			name:    "类型错误",
+			data:    `{"passed": "yes", "level": "low", "confidence": 80}`,
+			wantErr: "$.passed: expected boolean, got string",
+		},
+		{
+			name:    "不在枚举中",
+			data:    `{"passed": true, "level": "mid", "confidence": 80}`,
+			wantErr: "$.level: must be one of [low, high]",
+		},
+		{
+			name:    "超出范围",
+			data:    `{"passed": true, "level": "low", "confidence": 120}`,
+			wantErr: "$.confidence: must be <= 100",
+		},
+		{
+			name:    "不是整数",
+			data:    `{"passed": true, "level": "low", "confidence": 8.5}`,
+			wantErr: "$.confidence: must be an integer",
+		},
+		{
+			name:    "数组元素错误",
+			data:    `{"passed": true, "level": "low", "confidence": 80, "tags": [{"name": "a"}, {}]}`,
+			wantErr: `$.tags[1]: missing required property "name"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := s.Validate([]byte(tc.data))
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
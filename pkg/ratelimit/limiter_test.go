@@ -1,10 +1,15 @@
 package ratelimit
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestKeyWindowSliceLimiter_Limited(t *testing.T) {
@@ -29,3 +34,49 @@ func TestKeyWindowSliceLimiter_Limited(t *testing.T) {
 	wg.Wait()
 	time.Sleep(time.Second * 2)
 }
+
+func newTestRedis(t *testing.T) redis.Cmdable {
+	cmd := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	if err := cmd.Ping(context.Background()).Err(); err != nil {
+		t.Skip("redis not available:", err)
+	}
+	return cmd
+}
+
+func TestRedisFixedWindowKeyLimiter_LimitedN(t *testing.T) {
+	cmd := newTestRedis(t)
+	ctx := context.Background()
+	key := fmt.Sprintf("test:fixed_window:%d", time.Now().UnixNano())
+	defer cmd.Del(ctx, key)
+	lim := NewRedisFixedWindowKeyLimiter(cmd, time.Minute, 10)
+
+	limited, err := lim.LimitedN(ctx, key, 6)
+	require.NoError(t, err)
+	assert.False(t, limited)
+
+	// 超出限制的请求不计数
+	limited, err = lim.LimitedN(ctx, key, 5)
+	require.NoError(t, err)
+	assert.True(t, limited)
+	cnt, err := cmd.Get(ctx, key).Int64()
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), cnt)
+
+	// 归还后可以继续计数
+	require.NoError(t, lim.ReleaseN(ctx, key, 3))
+	limited, err = lim.LimitedN(ctx, key, 7)
+	require.NoError(t, err)
+	assert.False(t, limited)
+
+	// 归还超过已有计数时删除 key, 不会出现负数
+	require.NoError(t, lim.ReleaseN(ctx, key, 100))
+	exists, err := cmd.Exists(ctx, key).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), exists)
+	require.NoError(t, lim.ReleaseN(ctx, key, 1))
+	exists, err = cmd.Exists(ctx, key).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), exists)
+}
@@ -0,0 +1,20 @@
+-- 计数的key
+local key = KEYS[1]
+-- 本次计数的权重
+local n = tonumber(ARGV[1])
+-- 窗口内允许的最大计数
+local limit = tonumber(ARGV[2])
+-- 窗口大小, 毫秒
+local window = tonumber(ARGV[3])
+
+local cur = tonumber(redis.call("get", key) or "0")
+if cur + n > limit then
+    -- 被限流的请求不计入窗口
+    return 1
+end
+redis.call("incrby", key, n)
+if redis.call("pttl", key) < 0 then
+    -- 第一次计数, 开启窗口
+    redis.call("pexpire", key, window)
+end
+return 0
@@ -0,0 +1,16 @@
+-- 计数的key
+local key = KEYS[1]
+-- 归还的计数
+local n = tonumber(ARGV[1])
+
+local cur = tonumber(redis.call("get", key) or "0")
+if cur <= 0 then
+    -- 窗口已经过期, 无需归还
+    return 0
+end
+if cur <= n then
+    redis.call("del", key)
+    return 0
+end
+redis.call("decrby", key, n)
+return 0
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	_ "embed"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	//go:embed lua/fixed_window.lua
+	luaFixedWindow string
+	//go:embed lua/fixed_window_release.lua
+	luaFixedWindowRelease string
+)
+
+// WeightedKeyLimiter 支持按权重计数的限流器, 比如按字节数限流
+type WeightedKeyLimiter interface {
+	KeyLimiter
+	// LimitedN 本次请求计数 n, 超出限制时返回 true 且不计数
+	LimitedN(ctx context.Context, key string, n int64) (bool, error)
+	// ReleaseN 归还 n 个计数, 用于已计数的请求最终被拒绝时回滚
+	ReleaseN(ctx context.Context, key string, n int64) error
+}
+
+// RedisFixedWindowKeyLimiter 基于 redis 的固定窗口限流, 窗口从 key 第一次计数开始
+type RedisFixedWindowKeyLimiter struct {
+	cmd    redis.Cmdable
+	window time.Duration
+	limit  int64
+}
+
+func NewRedisFixedWindowKeyLimiter(cmd redis.Cmdable, window time.Duration, limit int64) WeightedKeyLimiter {
+	return &RedisFixedWindowKeyLimiter{
+		cmd:    cmd,
+		window: window,
+		limit:  limit,
+	}
+}
+
+func (l *RedisFixedWindowKeyLimiter) Limited(ctx context.Context, key string) (bool, error) {
+	return l.LimitedN(ctx, key, 1)
+}
+
+func (l *RedisFixedWindowKeyLimiter) LimitedN(ctx context.Context, key string, n int64) (bool, error) {
+	res, err := l.cmd.Eval(ctx, luaFixedWindow, []string{key}, n, l.limit, l.window.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (l *RedisFixedWindowKeyLimiter) ReleaseN(ctx context.Context, key string, n int64) error {
+	return l.cmd.Eval(ctx, luaFixedWindowRelease, []string{key}, n).Err()
+}
@@ -17,6 +17,7 @@ type BatchHandler[T any] struct {
 	batchSize int
 	maxWait   time.Duration
 	consumer  BatchConsumable[T]
+	opts      handlerOptions
 }
 
 func NewBatchHandler[T any](l logx.Logger, consumer BatchConsumable[T], opts ...BatchHandlerOption[T]) *BatchHandler[T] {
@@ -46,6 +47,13 @@ func WithMaxWait[T any](maxWait time.Duration) BatchHandlerOption[T] {
 	}
 }
 
+// WithHandlerOptions 为 BatchHandler 设置与其他 Handler 共用的选项
+func WithHandlerOptions[T any](opts ...HandlerOption) BatchHandlerOption[T] {
+	return func(bh *BatchHandler[T]) {
+		bh.opts = newHandlerOptions(opts)
+	}
+}
+
 func (h *BatchHandler[T]) Setup(session sarama.ConsumerGroupSession) error {
 	return nil
 }
@@ -61,6 +69,7 @@ func (h *BatchHandler[T]) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 		ts := make([]T, 0, h.batchSize)
 		ctx, cancel := context.WithTimeout(context.Background(), h.maxWait)
 		batchDone := false
+		// last 为本批最后一条原消息, 业务方拿到的是还原 topic 后的副本, 提交位移使用原消息
 		var last *sarama.ConsumerMessage
 		for _ = range h.batchSize {
 			if batchDone {
@@ -77,37 +86,48 @@ func (h *BatchHandler[T]) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 					return nil
 				}
 
+				evt, wait, ok := h.opts.prepare(msg)
+				if !ok {
+					last = msg
+					continue
+				}
+				if wait > 0 {
+					// 先处理已经收集的消息, 不让它们跟着等待
+					h.consume(session, batch, ts, last)
+					batch = make([]*sarama.ConsumerMessage, 0, h.batchSize)
+					ts = make([]T, 0, h.batchSize)
+					last = nil
+					if !waitRetry(session, wait) {
+						cancel()
+						return nil
+					}
+				}
 				last = msg
 				var t T
-				err := json.Unmarshal(msg.Value, &t)
+				err := json.Unmarshal(evt.Value, &t)
 				if err != nil {
-					h.l.WithCtx(ctx).Error("batch handler: failed to unmarshal message",
-						logx.Error(err),
-						logx.String("topic", msg.Topic),
-						logx.Int32("partition", msg.Partition),
-						logx.Int64("offset", msg.Offset))
-					// TODO 考虑丢死信队列
+					// 反序列化失败重试也无用, 直接进入死信队列
+					h.opts.deadLetter(h.l, evt, err)
 					continue
 				}
-				batch = append(batch, msg)
+				batch = append(batch, evt)
 				ts = append(ts, t)
 			}
 		}
 		cancel()
-		if len(batch) == 0 {
-			continue
-		}
+		h.consume(session, batch, ts, last)
+	}
+}
 
-		err := h.consumer.Consume(batch, ts)
-		if err != nil {
-			h.l.Error("batch handler: failed to handle message",
-				logx.String("topic", last.Topic),
-				logx.Error(err),
-			)
-			// TODO 重试 还是丢到死信队列
-		}
-		if last != nil {
-			session.MarkMessage(last, "")
-		}
+// consume 处理一批消息并提交到 last
+func (h *BatchHandler[T]) consume(session sarama.ConsumerGroupSession, batch []*sarama.ConsumerMessage, ts []T,
+	last *sarama.ConsumerMessage) {
+	if len(batch) > 0 {
+		h.opts.handleBatch(h.l, batch, func() error {
+			return h.consumer.Consume(batch, ts)
+		})
+	}
+	if last != nil {
+		session.MarkMessage(last, "")
 	}
 }
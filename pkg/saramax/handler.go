@@ -9,16 +9,18 @@ import (
 type Handler[T any] struct {
 	l        logx.Logger
 	consumer Consumable[T]
+	opts     handlerOptions
 }
 
 type Consumable[T any] interface {
 	Consume(msg *sarama.ConsumerMessage, event T) error
 }
 
-func NewHandler[T any](consumer Consumable[T], l logx.Logger) *Handler[T] {
+func NewHandler[T any](consumer Consumable[T], l logx.Logger, opts ...HandlerOption) *Handler[T] {
 	return &Handler[T]{
 		l:        l,
 		consumer: consumer,
+		opts:     newHandlerOptions(opts),
 	}
 }
 
@@ -33,23 +35,26 @@ func (h Handler[T]) Cleanup(session sarama.ConsumerGroupSession) error {
 func (h Handler[T]) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	msgs := claim.Messages()
 	for msg := range msgs {
-		var t T
-		err := json.Unmarshal(msg.Value, &t)
-		if err != nil {
-			h.l.Error("failed to unmarshal message",
-				logx.Error(err),
-				logx.String("topic", msg.Topic),
-				logx.Int32("partition", msg.Partition),
-				logx.Int64("offset", msg.Offset))
+		// 业务方使用还原 topic 后的 evt, 提交位移使用原消息
+		evt, wait, ok := h.opts.prepare(msg)
+		if !ok {
+			session.MarkMessage(msg, "")
+			continue
 		}
-		err = h.consumer.Consume(msg, t)
+		if !waitRetry(session, wait) {
+			return nil
+		}
+		var t T
+		err := json.Unmarshal(evt.Value, &t)
 		if err != nil {
-			h.l.Error("failed to handle message",
-				logx.Error(err),
-				logx.String("topic", msg.Topic),
-				logx.Int32("partition", msg.Partition),
-				logx.Int64("offset", msg.Offset))
+			// 反序列化失败重试也无用, 直接进入死信队列
+			h.opts.deadLetter(h.l, evt, err)
+			session.MarkMessage(msg, "")
+			continue
 		}
+		h.opts.handle(h.l, evt, func() error {
+			return h.consumer.Consume(evt, t)
+		})
 		session.MarkMessage(msg, "")
 	}
 	return nil
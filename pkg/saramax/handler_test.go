@@ -0,0 +1,137 @@
+package saramax
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	ctx    context.Context
+	marked []*sarama.ConsumerMessage
+}
+
+func (s *fakeSession) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.marked = append(s.marked, msg)
+}
+
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+	msgs chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return c.msgs
+}
+
+func newFakeClaim(msgs ...*sarama.ConsumerMessage) *fakeClaim {
+	c := &fakeClaim{msgs: make(chan *sarama.ConsumerMessage, len(msgs))}
+	for _, msg := range msgs {
+		c.msgs <- msg
+	}
+	close(c.msgs)
+	return c
+}
+
+type testEvt struct {
+	Id int64 `json:"id"`
+}
+
+type fakeConsumer struct {
+	topics []string
+}
+
+func (c *fakeConsumer) Consume(msg *sarama.ConsumerMessage, evt testEvt) error {
+	c.topics = append(c.topics, msg.Topic)
+	return nil
+}
+
+func retryMessage(offset int64, retryAt time.Time) *sarama.ConsumerMessage {
+	return &sarama.ConsumerMessage{
+		Topic:     "ink-like.retry",
+		Partition: 2,
+		Offset:    offset,
+		Value:     []byte(`{"id":1}`),
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte(HeaderOriginTopic), Value: []byte("ink-like")},
+			{Key: []byte(HeaderGroup), Value: []byte("test-group")},
+			{Key: []byte(HeaderRetryAt), Value: []byte(strconv.FormatInt(retryAt.UnixMilli(), 10))},
+		},
+	}
+}
+
+func TestHandler_MarkRetryMessage(t *testing.T) {
+	consumer := &fakeConsumer{}
+	h := NewHandler[testEvt](consumer, &logx.NopLogger{}, WithRetryHandler(newTestRetryHandler(&fakeProducer{})))
+	session := &fakeSession{ctx: context.Background()}
+
+	err := h.ConsumeClaim(session, newFakeClaim(retryMessage(7, time.Now().Add(10*time.Millisecond))))
+	require.NoError(t, err)
+	// 业务方看到原始 topic, 提交的是重试 topic 上的位移
+	assert.Equal(t, []string{"ink-like"}, consumer.topics)
+	require.Len(t, session.marked, 1)
+	assert.Equal(t, "ink-like.retry", session.marked[0].Topic)
+	assert.Equal(t, int32(2), session.marked[0].Partition)
+	assert.Equal(t, int64(7), session.marked[0].Offset)
+}
+
+func TestHandler_SessionEndBeforeRetryAt(t *testing.T) {
+	consumer := &fakeConsumer{}
+	h := NewHandler[testEvt](consumer, &logx.NopLogger{}, WithRetryHandler(newTestRetryHandler(&fakeProducer{})))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	session := &fakeSession{ctx: ctx}
+
+	err := h.ConsumeClaim(session, newFakeClaim(retryMessage(7, time.Now().Add(time.Hour))))
+	require.NoError(t, err)
+	// 会话结束时未到重投时间的消息不处理也不提交
+	assert.Empty(t, consumer.topics)
+	assert.Empty(t, session.marked)
+}
+
+type fakeBatchConsumer struct {
+	topics []string
+}
+
+func (c *fakeBatchConsumer) Consume(msgs []*sarama.ConsumerMessage, ts []testEvt) error {
+	for _, msg := range msgs {
+		c.topics = append(c.topics, msg.Topic)
+	}
+	return nil
+}
+
+func TestBatchHandler_MarkRetryMessage(t *testing.T) {
+	consumer := &fakeBatchConsumer{}
+	h := NewBatchHandler[testEvt](&logx.NopLogger{}, consumer, WithBatchSize[testEvt](2),
+		WithMaxWait[testEvt](10*time.Millisecond),
+		WithHandlerOptions[testEvt](WithRetryHandler(newTestRetryHandler(&fakeProducer{}))))
+	session := &fakeSession{ctx: context.Background()}
+	now := time.Now()
+	claim := &fakeClaim{msgs: make(chan *sarama.ConsumerMessage, 2)}
+	claim.msgs <- retryMessage(7, now)
+	claim.msgs <- retryMessage(8, now.Add(10*time.Millisecond))
+	done := make(chan error)
+	go func() {
+		done <- h.ConsumeClaim(session, claim)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(claim.msgs)
+	require.NoError(t, <-done)
+
+	assert.Equal(t, []string{"ink-like", "ink-like"}, consumer.topics)
+	require.NotEmpty(t, session.marked)
+	last := session.marked[len(session.marked)-1]
+	assert.Equal(t, "ink-like.retry", last.Topic)
+	assert.Equal(t, int64(8), last.Offset)
+}
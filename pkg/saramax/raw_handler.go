@@ -10,30 +10,35 @@ type RawHandler struct {
 	l        logx.Logger
 	consumer RawConsumable
 	se       semaphore.Weighted
+	opts     handlerOptions
 }
 type RawConsumable interface {
 	Consume(msg *sarama.ConsumerMessage) error
 }
 
-func NewRawHandler(l logx.Logger, consumer RawConsumable) *RawHandler {
+func NewRawHandler(l logx.Logger, consumer RawConsumable, opts ...HandlerOption) *RawHandler {
 	return &RawHandler{
 		l:        l,
 		consumer: consumer,
+		opts:     newHandlerOptions(opts),
 	}
 }
 
 func (h *RawHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	msgs := claim.Messages()
 	for msg := range msgs {
-
-		err := h.consumer.Consume(msg)
-		if err != nil {
-			h.l.Error("failed to handle message",
-				logx.Error(err),
-				logx.String("topic", msg.Topic),
-				logx.Int32("partition", msg.Partition),
-				logx.Int64("offset", msg.Offset))
+		// 业务方使用还原 topic 后的 evt, 提交位移使用原消息
+		evt, wait, ok := h.opts.prepare(msg)
+		if !ok {
+			session.MarkMessage(msg, "")
+			continue
+		}
+		if !waitRetry(session, wait) {
+			return nil
 		}
+		h.opts.handle(h.l, evt, func() error {
+			return h.consumer.Consume(evt)
+		})
 		session.MarkMessage(msg, "")
 	}
 	return nil
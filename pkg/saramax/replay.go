@@ -0,0 +1,147 @@
+package saramax
+
+import (
+	"context"
+	"errors"
+
+	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+)
+
+const replayGroup = "dlq-replay-group"
+
+// Replayer 将死信队列中的消息重新投递回原始 topic,
+// 记录了失败消费组的消息投递到原始 topic 的重试层, 只由该消费组重新消费, 避免其他消费组重复处理.
+// 重放进度以 replayGroup 提交, 重复执行不会重复投递
+type Replayer struct {
+	cli      sarama.Client
+	producer sarama.SyncProducer
+	l        logx.Logger
+}
+
+func NewReplayer(cli sarama.Client, producer sarama.SyncProducer, l logx.Logger) *Replayer {
+	return &Replayer{
+		cli:      cli,
+		producer: producer,
+		l:        l,
+	}
+}
+
+// Replay 重放 topic 对应死信队列中截至当前的全部消息, 返回重放的消息数
+func (r *Replayer) Replay(ctx context.Context, topic string) (int, error) {
+	dlq := DLQTopic(topic)
+	partitions, err := r.cli.Partitions(dlq)
+	if err != nil {
+		return 0, err
+	}
+	om, err := sarama.NewOffsetManagerFromClient(replayGroup, r.cli)
+	if err != nil {
+		return 0, err
+	}
+	defer om.Close()
+	consumer, err := sarama.NewConsumerFromClient(r.cli)
+	if err != nil {
+		return 0, err
+	}
+	defer consumer.Close()
+
+	total := 0
+	for _, p := range partitions {
+		cnt, er := r.replayPartition(ctx, consumer, om, dlq, p)
+		total += cnt
+		if er != nil {
+			return total, er
+		}
+	}
+	om.Commit()
+	return total, nil
+}
+
+func (r *Replayer) replayPartition(ctx context.Context, consumer sarama.Consumer, om sarama.OffsetManager, dlq string, partition int32) (int, error) {
+	pom, err := om.ManagePartition(dlq, partition)
+	if err != nil {
+		return 0, err
+	}
+	defer pom.Close()
+
+	oldest, err := r.cli.GetOffset(dlq, partition, sarama.OffsetOldest)
+	if err != nil {
+		return 0, err
+	}
+	// 只重放启动时已存在的消息, 避免重放过程中新进入的死信导致循环
+	newest, err := r.cli.GetOffset(dlq, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, err
+	}
+	start, _ := pom.NextOffset()
+	if start < oldest {
+		start = oldest
+	}
+	if start >= newest {
+		return 0, nil
+	}
+
+	pc, err := consumer.ConsumePartition(dlq, partition, start)
+	if err != nil {
+		return 0, err
+	}
+	defer pc.Close()
+
+	cnt := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return cnt, ctx.Err()
+		case msg, ok := <-pc.Messages():
+			if !ok {
+				return cnt, errors.New("partition consumer closed")
+			}
+			if err = r.republish(msg); err != nil {
+				return cnt, err
+			}
+			pom.MarkOffset(msg.Offset+1, "")
+			cnt++
+			if msg.Offset+1 >= newest {
+				return cnt, nil
+			}
+		}
+	}
+}
+
+func (r *Replayer) republish(msg *sarama.ConsumerMessage) error {
+	origin := OriginTopic(msg)
+	target := origin
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers))
+	for _, h := range msg.Headers {
+		// 重置重试信息, 回到原始 topic 后重新计数
+		if h == nil || isRetryHeader(string(h.Key)) {
+			continue
+		}
+		headers = append(headers, *h)
+	}
+	if group := header(msg, HeaderGroup); group != "" {
+		target = RetryTopic(origin)
+		headers = append(headers,
+			sarama.RecordHeader{Key: []byte(HeaderOriginTopic), Value: []byte(origin)},
+			sarama.RecordHeader{Key: []byte(HeaderGroup), Value: []byte(group)},
+		)
+	}
+	pm := &sarama.ProducerMessage{
+		Topic:   target,
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	}
+	if msg.Key != nil {
+		pm.Key = sarama.ByteEncoder(msg.Key)
+	}
+	_, _, err := r.producer.SendMessage(pm)
+	if err != nil {
+		return err
+	}
+	r.l.Info("dlq message replayed",
+		logx.String("topic", target),
+		logx.Int32("partition", msg.Partition),
+		logx.Int64("offset", msg.Offset),
+		logx.String("error", header(msg, HeaderError)))
+	return nil
+}
@@ -0,0 +1,222 @@
+package saramax
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/pkg/backoff"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+)
+
+const (
+	HeaderOriginTopic = "x-origin-topic"
+	HeaderError       = "x-error"
+	HeaderRedelivery  = "x-redelivery"
+	HeaderRetryAt     = "x-retry-at"
+	HeaderGroup       = "x-consumer-group"
+
+	retrySuffix = ".retry"
+	dlqSuffix   = ".dlq"
+)
+
+func RetryTopic(topic string) string {
+	return topic + retrySuffix
+}
+
+func DLQTopic(topic string) string {
+	return topic + dlqSuffix
+}
+
+// WithRetryTopics 返回原始 topic 及其重试 topic, 供消费者订阅
+func WithRetryTopics(topics ...string) []string {
+	res := make([]string, 0, len(topics)*2)
+	for _, topic := range topics {
+		res = append(res, topic, RetryTopic(topic))
+	}
+	return res
+}
+
+// RetryHandler 消费失败时先按 backoff.Policy 原地重试,
+// 仍失败则携带错误信息投递到 <topic>.retry, 重投次数耗尽后投递到 <topic>.dlq.
+// 同一 topic 可能被多个消费组订阅, 重试消息会带上失败的消费组, 只由该组再次消费
+type RetryHandler struct {
+	group         string
+	producer      sarama.SyncProducer
+	policy        backoff.Policy
+	maxRedelivery int
+	retryDelay    time.Duration
+	l             logx.Logger
+}
+
+type RetryOption func(rh *RetryHandler)
+
+func WithRetryPolicy(policy backoff.Policy) RetryOption {
+	return func(rh *RetryHandler) {
+		rh.policy = policy
+	}
+}
+
+// WithMaxRedelivery 消息最多经过重试 topic 的次数, 超过后进入死信队列
+func WithMaxRedelivery(n int) RetryOption {
+	return func(rh *RetryHandler) {
+		rh.maxRedelivery = n
+	}
+}
+
+// WithRetryDelay 重试 topic 中的消息至少延迟多久后再次消费
+func WithRetryDelay(d time.Duration) RetryOption {
+	return func(rh *RetryHandler) {
+		rh.retryDelay = d
+	}
+}
+
+func NewRetryHandler(producer sarama.SyncProducer, l logx.Logger, opts ...RetryOption) *RetryHandler {
+	rh := &RetryHandler{
+		producer: producer,
+		policy: backoff.Policy{
+			MaxRetries:         3,
+			InitialInterval:    100 * time.Millisecond,
+			MaxRetryInterval:   time.Second,
+			BackoffCoefficient: 2.0,
+		},
+		maxRedelivery: 3,
+		retryDelay:    30 * time.Second,
+		l:             l,
+	}
+	for _, opt := range opts {
+		opt(rh)
+	}
+	return rh
+}
+
+// WithGroup 返回绑定到消费组的 RetryHandler, 每个消费者应使用自己的消费组
+func (rh *RetryHandler) WithGroup(group string) *RetryHandler {
+	res := *rh
+	res.group = group
+	return &res
+}
+
+// Prepare 处理来自重试 topic 的消息: 返回 Topic 还原为原始 topic 的副本交给业务方,
+// 使业务方无需关心消息来自哪一层, 提交位移时仍使用原消息, 否则会提交到原始 topic 的同一位移.
+// wait 为距离重投时间还需等待的时长, skip 为 true 表示消息属于其他消费组, 应直接跳过
+func (rh *RetryHandler) Prepare(msg *sarama.ConsumerMessage) (res *sarama.ConsumerMessage, wait time.Duration, skip bool) {
+	if !strings.HasSuffix(msg.Topic, retrySuffix) {
+		return msg, 0, false
+	}
+	if group := header(msg, HeaderGroup); group != "" && group != rh.group {
+		return msg, 0, true
+	}
+	cp := *msg
+	cp.Topic = OriginTopic(msg)
+	retryAt, err := strconv.ParseInt(header(msg, HeaderRetryAt), 10, 64)
+	if err != nil {
+		return &cp, 0, false
+	}
+	return &cp, time.Until(time.UnixMilli(retryAt)), false
+}
+
+// Handle 执行 fn, 失败时重试, 重试仍失败则转投重试或死信 topic
+func (rh *RetryHandler) Handle(msg *sarama.ConsumerMessage, fn func() error) {
+	if err := backoff.Wrap(fn, rh.policy)(); err != nil {
+		rh.Fail(msg, err)
+	}
+}
+
+// Fail 将已经确定处理失败的消息转投重试 topic, 重投次数耗尽后进入死信队列
+func (rh *RetryHandler) Fail(msg *sarama.ConsumerMessage, err error) {
+	redelivery, _ := strconv.Atoi(header(msg, HeaderRedelivery))
+	if redelivery >= rh.maxRedelivery {
+		rh.DeadLetter(msg, err)
+		return
+	}
+	origin := OriginTopic(msg)
+	retryAt := time.Now().Add(rh.retryDelay).UnixMilli()
+	if er := rh.publish(msg, RetryTopic(origin), err, redelivery+1, retryAt); er != nil {
+		rh.l.Error("publish message to retry topic failed, fallback to dlq",
+			logx.Error(er), logx.String("topic", origin))
+		rh.DeadLetter(msg, err)
+	}
+}
+
+// DeadLetter 直接投递到死信 topic, 用于无法通过重试恢复的错误, 如反序列化失败
+func (rh *RetryHandler) DeadLetter(msg *sarama.ConsumerMessage, err error) {
+	origin := OriginTopic(msg)
+	redelivery, _ := strconv.Atoi(header(msg, HeaderRedelivery))
+	if er := rh.publish(msg, DLQTopic(origin), err, redelivery, 0); er != nil {
+		// 死信也投递失败, 只能记录日志
+		logFailure(rh.l, msg, er)
+		return
+	}
+	rh.l.Warn("message sent to dlq",
+		logx.Error(err),
+		logx.String("topic", origin),
+		logx.Int32("partition", msg.Partition),
+		logx.Int64("offset", msg.Offset))
+}
+
+func (rh *RetryHandler) publish(msg *sarama.ConsumerMessage, topic string, cause error, redelivery int, retryAt int64) error {
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+4)
+	for _, h := range msg.Headers {
+		if h == nil || isRetryHeader(string(h.Key)) {
+			continue
+		}
+		headers = append(headers, *h)
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(HeaderOriginTopic), Value: []byte(OriginTopic(msg))},
+		sarama.RecordHeader{Key: []byte(HeaderError), Value: []byte(cause.Error())},
+		sarama.RecordHeader{Key: []byte(HeaderRedelivery), Value: []byte(strconv.Itoa(redelivery))},
+	)
+	if rh.group != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(HeaderGroup), Value: []byte(rh.group)})
+	}
+	if retryAt > 0 {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(HeaderRetryAt), Value: []byte(strconv.FormatInt(retryAt, 10))})
+	}
+	pm := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	}
+	if msg.Key != nil {
+		pm.Key = sarama.ByteEncoder(msg.Key)
+	}
+	_, _, err := rh.producer.SendMessage(pm)
+	return err
+}
+
+// OriginTopic 返回消息最初所在的 topic
+func OriginTopic(msg *sarama.ConsumerMessage) string {
+	if origin := header(msg, HeaderOriginTopic); origin != "" {
+		return origin
+	}
+	topic := strings.TrimSuffix(msg.Topic, retrySuffix)
+	return strings.TrimSuffix(topic, dlqSuffix)
+}
+
+func header(msg *sarama.ConsumerMessage, key string) string {
+	for _, h := range msg.Headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func isRetryHeader(key string) bool {
+	switch key {
+	case HeaderOriginTopic, HeaderError, HeaderRedelivery, HeaderRetryAt, HeaderGroup:
+		return true
+	}
+	return false
+}
+
+func logFailure(l logx.Logger, msg *sarama.ConsumerMessage, err error) {
+	l.Error("failed to handle message",
+		logx.Error(err),
+		logx.String("topic", msg.Topic),
+		logx.Int32("partition", msg.Partition),
+		logx.Int64("offset", msg.Offset))
+}
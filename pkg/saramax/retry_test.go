@@ -0,0 +1,88 @@
+package saramax
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/pkg/backoff"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProducer struct {
+	sarama.SyncProducer
+	msgs []*sarama.ProducerMessage
+}
+
+func (p *fakeProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	p.msgs = append(p.msgs, msg)
+	return 0, int64(len(p.msgs)), nil
+}
+
+func newTestRetryHandler(p sarama.SyncProducer) *RetryHandler {
+	return NewRetryHandler(p, &logx.NopLogger{},
+		WithRetryPolicy(backoff.Policy{MaxRetries: 2, InitialInterval: time.Millisecond, MaxRetryInterval: time.Millisecond}),
+		WithMaxRedelivery(1),
+		WithRetryDelay(time.Millisecond),
+	).WithGroup("test-group")
+}
+
+func TestRetryHandler_Tiers(t *testing.T) {
+	p := &fakeProducer{}
+	rh := newTestRetryHandler(p)
+	bizErr := errors.New("biz error")
+
+	calls := 0
+	msg := &sarama.ConsumerMessage{Topic: "ink-like", Value: []byte(`{}`)}
+	rh.Handle(msg, func() error {
+		calls++
+		return bizErr
+	})
+	assert.Equal(t, 2, calls)
+	assert.Len(t, p.msgs, 1)
+	retryMsg := p.msgs[0]
+	assert.Equal(t, "ink-like.retry", retryMsg.Topic)
+
+	// 模拟从重试 topic 消费到该消息
+	consumed := &sarama.ConsumerMessage{Topic: retryMsg.Topic, Value: []byte(`{}`)}
+	for i := range retryMsg.Headers {
+		consumed.Headers = append(consumed.Headers, &retryMsg.Headers[i])
+	}
+	assert.Equal(t, "1", header(consumed, HeaderRedelivery))
+	assert.Equal(t, bizErr.Error(), header(consumed, HeaderError))
+
+	// 其他消费组跳过
+	other := rh.WithGroup("other-group")
+	_, _, skip := other.Prepare(consumed)
+	assert.True(t, skip)
+
+	evt, _, skip := rh.Prepare(consumed)
+	assert.False(t, skip)
+	assert.Equal(t, "ink-like", evt.Topic)
+	// 原消息不变, 提交位移时使用
+	assert.Equal(t, "ink-like.retry", consumed.Topic)
+
+	// 重投次数耗尽, 进入死信队列
+	rh.Handle(evt, func() error {
+		return bizErr
+	})
+	assert.Len(t, p.msgs, 2)
+	assert.Equal(t, "ink-like.dlq", p.msgs[1].Topic)
+}
+
+func TestRetryHandler_Success(t *testing.T) {
+	p := &fakeProducer{}
+	rh := newTestRetryHandler(p)
+	calls := 0
+	rh.Handle(&sarama.ConsumerMessage{Topic: "ink-like"}, func() error {
+		calls++
+		if calls == 1 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, p.msgs)
+}
@@ -1,5 +1,98 @@
 package saramax
 
+import (
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/KNICEX/InkFlow/pkg/backoff"
+	"github.com/KNICEX/InkFlow/pkg/logx"
+)
+
 type Consumer interface {
 	Start() error
 }
+
+// HandlerOption Handler, RawHandler, BatchHandler 共用的选项
+type HandlerOption func(opts *handlerOptions)
+
+type handlerOptions struct {
+	retry *RetryHandler
+}
+
+// WithRetryHandler 处理失败的消息交由 RetryHandler 重试或投递死信队列,
+// 不设置时失败消息仅记录日志
+func WithRetryHandler(rh *RetryHandler) HandlerOption {
+	return func(opts *handlerOptions) {
+		opts.retry = rh
+	}
+}
+
+func newHandlerOptions(opts []HandlerOption) handlerOptions {
+	var o handlerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// prepare 返回交给业务方的消息, ok 为 false 时消息应直接提交并跳过
+func (o handlerOptions) prepare(msg *sarama.ConsumerMessage) (*sarama.ConsumerMessage, time.Duration, bool) {
+	if o.retry == nil {
+		return msg, 0, true
+	}
+	res, wait, skip := o.retry.Prepare(msg)
+	return res, wait, !skip
+}
+
+// waitRetry 重试 topic 中的消息按重投时间顺序写入, 未到重投时间时暂停消费当前分区,
+// 每个分区由单独的 ConsumeClaim 处理, 其他分区不受影响.
+// 会话结束时不再等待, 返回 false, 消息不处理也不提交, 由重新分配后的消费者处理
+func waitRetry(session sarama.ConsumerGroupSession, wait time.Duration) bool {
+	if wait <= 0 {
+		return true
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-session.Context().Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (o handlerOptions) handle(l logx.Logger, msg *sarama.ConsumerMessage, fn func() error) {
+	if o.retry != nil {
+		o.retry.Handle(msg, fn)
+		return
+	}
+	if err := fn(); err != nil {
+		logFailure(l, msg, err)
+	}
+}
+
+// handleBatch 整批重试, 仍失败则逐条转投重试或死信 topic
+func (o handlerOptions) handleBatch(l logx.Logger, msgs []*sarama.ConsumerMessage, fn func() error) {
+	if o.retry != nil {
+		if err := backoff.Wrap(fn, o.retry.policy)(); err != nil {
+			for _, msg := range msgs {
+				o.retry.Fail(msg, err)
+			}
+		}
+		return
+	}
+	if err := fn(); err != nil {
+		l.Error("batch handler: failed to handle message",
+			logx.String("topic", msgs[len(msgs)-1].Topic),
+			logx.Error(err),
+		)
+	}
+}
+
+func (o handlerOptions) deadLetter(l logx.Logger, msg *sarama.ConsumerMessage, err error) {
+	if o.retry != nil {
+		o.retry.DeadLetter(msg, err)
+		return
+	}
+	logFailure(l, msg, err)
+}
@@ -0,0 +1,48 @@
+package schedulex
+
+import (
+	"context"
+	"time"
+
+	"github.com/KNICEX/InkFlow/pkg/logx"
+)
+
+// IntervalScheduler 每隔 interval 执行一次任务, 比如定时重新加载配置
+type IntervalScheduler struct {
+	name     string
+	interval time.Duration
+	timeout  time.Duration
+	fn       func(ctx context.Context) error
+	l        logx.Logger
+}
+
+func NewIntervalScheduler(name string, interval time.Duration, fn func(ctx context.Context) error, l logx.Logger) *IntervalScheduler {
+	return &IntervalScheduler{
+		name:     name,
+		interval: interval,
+		timeout:  time.Second * 10,
+		fn:       fn,
+		l:        l,
+	}
+}
+
+// Start 先同步执行一次, 失败时只记录日志, 等待下次执行
+func (s *IntervalScheduler) Start() error {
+	s.run()
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.run()
+		}
+	}()
+	return nil
+}
+
+func (s *IntervalScheduler) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	if err := s.fn(ctx); err != nil {
+		s.l.Error("interval task error", logx.String("name", s.name), logx.Error(err))
+	}
+}
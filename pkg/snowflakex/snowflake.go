@@ -35,3 +35,9 @@ func (node *SonyFlake) NextID() int64 {
 	id, _ := node.flake.NextID()
 	return int64(id)
 }
+
+// MinIdAt t 时刻生成的 id 都不小于返回值, 用于按时间定位 id
+func MinIdAt(startTime, t time.Time) int64 {
+	elapsed := t.Sub(startTime) / (10 * time.Millisecond)
+	return int64(elapsed) << (sonyflake.BitLenSequence + sonyflake.BitLenMachineID)
+}
@@ -1,6 +1,9 @@
 package stringx
 
-import "strings"
+import (
+	"strings"
+	"unicode"
+)
 
 func Split(s, sep string) []string {
 	if s == "" {
@@ -8,3 +11,44 @@ func Split(s, sep string) []string {
 	}
 	return strings.Split(s, sep)
 }
+
+// Truncate 按字符截取前 n 个字符, 避免截断多字节字符
+func Truncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if len(s) <= n {
+		// 字节数不超过 n, 字符数一定不超过 n
+		return s
+	}
+	cnt := 0
+	for i := range s {
+		if cnt == n {
+			return s[:i]
+		}
+		cnt++
+	}
+	return s
+}
+
+// WordCount 统计字数, 中日韩文字每个字算一个, 其他语言按连续的字母数字算一个词
+func WordCount(s string) int {
+	cnt := 0
+	inWord := false
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			cnt++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				cnt++
+				inWord = true
+			}
+		default:
+			inWord = false
+		}
+	}
+	return cnt
+}
@@ -0,0 +1,14 @@
+package stringx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordCount(t *testing.T) {
+	assert.Equal(t, 0, WordCount(""))
+	assert.Equal(t, 5, WordCount("墨水流社区"))
+	assert.Equal(t, 3, WordCount("hello, world 2024"))
+	assert.Equal(t, 5, WordCount("使用 Go 开发"))
+}